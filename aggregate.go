@@ -0,0 +1,250 @@
+package cpanic
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Trend is how the frequency of a crash changed over the window of a `Report`.
+type Trend int
+
+// The trends, computed by comparing the occurrences in the first and second halves of
+// the window.
+const (
+	// TrendSteady is a crash that occurred as often in both halves of the window.
+	TrendSteady Trend = iota
+	// TrendRising is a crash that occurred more often in the second half of the window.
+	TrendRising
+	// TrendFalling is a crash that occurred less often in the second half of the window.
+	TrendFalling
+	// TrendNew is a crash first seen in the second half of the window.
+	TrendNew
+)
+
+// String returns the lowercase name of the trend, such as "rising".
+func (t Trend) String() string {
+	switch t {
+	case TrendSteady:
+		return "steady"
+	case TrendRising:
+		return "rising"
+	case TrendFalling:
+		return "falling"
+	case TrendNew:
+		return "new"
+	default:
+		return fmt.Sprintf("trend(%d)", int(t))
+	}
+}
+
+// MarshalText implements `encoding.TextMarshaler`.
+func (t Trend) MarshalText() ([]byte, error) {
+	return []byte(t.String()), nil
+}
+
+// Crasher is the panics of a `Report` that share a fingerprint.
+type Crasher struct {
+	Fingerprint string `json:"fingerprint"`
+	// Type and Message are those of the most recent panic.
+	Type    string `json:"type"`
+	Message string `json:"message"`
+	Culprit string `json:"culprit,omitempty"`
+	Count   int    `json:"count"`
+	// FirstSeen and LastSeen are the times of the oldest and most recent panics. They
+	// are zero if no panic has a time, such as parsed panics.
+	FirstSeen time.Time `json:"first_seen"`
+	LastSeen  time.Time `json:"last_seen"`
+	// BuildIDs are the builds affected by the crash, in the order they were first seen.
+	BuildIDs []string `json:"build_ids,omitempty"`
+	Trend    Trend    `json:"trend"`
+}
+
+// Report summarizes a set of panics by fingerprint, such as for a weekly "top crashers"
+// post. Render it with `Text` or `Markdown`, or as JSON with `encoding/json`.
+type Report struct {
+	// Start and End are the times of the oldest and most recent panics.
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+	Total int       `json:"total"`
+	// Crashers are the distinct crashes, the most frequent first.
+	Crashers []Crasher `json:"crashers"`
+}
+
+// Aggregate summarizes the panics, such as those returned by `Query` for a time range,
+// into a report of the distinct crashes with their counts, first and last seen times,
+// affected builds, and trends over the window spanned by the panics.
+func Aggregate(panics []*Panic) Report {
+	var r Report
+	for _, p := range panics {
+		if p.Time.IsZero() {
+			continue
+		}
+		if r.Start.IsZero() || p.Time.Before(r.Start) {
+			r.Start = p.Time
+		}
+		if p.Time.After(r.End) {
+			r.End = p.Time
+		}
+	}
+	mid := r.Start.Add(r.End.Sub(r.Start) / 2)
+
+	type tally struct {
+		crasher  Crasher
+		latest   *Panic
+		halves   [2]int
+		buildIDs map[string]bool
+	}
+	byFingerprint := make(map[string]*tally)
+	var order []*tally
+	for _, p := range panics {
+		fp := p.Fingerprint()
+		t, ok := byFingerprint[fp]
+		if !ok {
+			t = &tally{crasher: Crasher{Fingerprint: fp}, buildIDs: make(map[string]bool)}
+			byFingerprint[fp] = t
+			order = append(order, t)
+		}
+		c := &t.crasher
+		c.Count++
+		if t.latest == nil || !p.Time.Before(t.latest.Time) {
+			t.latest = p
+		}
+		if p.BuildID != "" && !t.buildIDs[p.BuildID] {
+			t.buildIDs[p.BuildID] = true
+			c.BuildIDs = append(c.BuildIDs, p.BuildID)
+		}
+		if p.Time.IsZero() {
+			continue
+		}
+		if c.FirstSeen.IsZero() || p.Time.Before(c.FirstSeen) {
+			c.FirstSeen = p.Time
+		}
+		if p.Time.After(c.LastSeen) {
+			c.LastSeen = p.Time
+		}
+		if p.Time.Before(mid) {
+			t.halves[0]++
+		} else {
+			t.halves[1]++
+		}
+	}
+
+	r.Total = len(panics)
+	r.Crashers = make([]Crasher, len(order))
+	for i, t := range order {
+		c := t.crasher
+		c.Type = t.latest.Type()
+		c.Message = t.latest.Message()
+		if f, ok := t.latest.Culprit(); ok {
+			c.Culprit = f.Function
+		}
+		switch {
+		case r.End.After(r.Start) && !c.FirstSeen.IsZero() && !c.FirstSeen.Before(mid):
+			c.Trend = TrendNew
+		case t.halves[1] > t.halves[0]:
+			c.Trend = TrendRising
+		case t.halves[1] < t.halves[0]:
+			c.Trend = TrendFalling
+		}
+		r.Crashers[i] = c
+	}
+	sort.SliceStable(r.Crashers, func(i, j int) bool {
+		a, b := r.Crashers[i], r.Crashers[j]
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		return a.LastSeen.After(b.LastSeen)
+	})
+	return r
+}
+
+// Top returns a copy of the report with only the n most frequent crashes.
+func (r Report) Top(n int) Report {
+	if n >= 0 && n < len(r.Crashers) {
+		r.Crashers = r.Crashers[:n:n]
+	}
+	return r
+}
+
+// String returns the report rendered by `Text`.
+func (r Report) String() string {
+	return r.Text()
+}
+
+// Text renders the report as plain text, one numbered entry per crash.
+func (r Report) Text() string {
+	var b strings.Builder
+	b.WriteString(r.summary())
+	b.WriteString("\n")
+	for i, c := range r.Crashers {
+		fmt.Fprintf(&b, "\n%2d. %dx %s (%s)\n", i+1, c.Count, firstLine(c.Message), c.Trend)
+		fmt.Fprintf(&b, "    %s", c.Type)
+		if c.Culprit != "" {
+			fmt.Fprintf(&b, " in %s", c.Culprit)
+		}
+		fmt.Fprintf(&b, " [%s]\n", c.Fingerprint)
+		if !c.FirstSeen.IsZero() {
+			fmt.Fprintf(&b, "    first seen %s, last seen %s\n", formatReportTime(c.FirstSeen), formatReportTime(c.LastSeen))
+		}
+		if len(c.BuildIDs) > 0 {
+			fmt.Fprintf(&b, "    builds: %s\n", strings.Join(c.BuildIDs, ", "))
+		}
+	}
+	return b.String()
+}
+
+// Markdown renders the report as a GitHub-flavored Markdown table, suitable for an
+// issue or a chat message.
+func (r Report) Markdown() string {
+	var b strings.Builder
+	b.WriteString(markdownInline(r.summary()))
+	b.WriteString("\n")
+	if len(r.Crashers) == 0 {
+		return b.String()
+	}
+
+	b.WriteString("\n| # | Count | Panic | Culprit | First seen | Last seen | Builds | Trend |\n")
+	b.WriteString("| ---: | ---: | --- | --- | --- | --- | --- | --- |\n")
+	for i, c := range r.Crashers {
+		culprit := ""
+		if c.Culprit != "" {
+			culprit = markdownCode(c.Culprit)
+		}
+		builds := make([]string, len(c.BuildIDs))
+		for j, id := range c.BuildIDs {
+			builds[j] = markdownCode(id)
+		}
+		fmt.Fprintf(&b, "| %d | %d | %s<br>%s | %s | %s | %s | %s | %s |\n",
+			i+1, c.Count,
+			markdownCell(markdownInline(firstLine(c.Message))), markdownCell(markdownCode(c.Type)),
+			markdownCell(culprit),
+			formatReportTime(c.FirstSeen), formatReportTime(c.LastSeen),
+			markdownCell(strings.Join(builds, " ")), c.Trend)
+	}
+	return b.String()
+}
+
+// summary returns the first line of the rendered report.
+func (r Report) summary() string {
+	s := fmt.Sprintf("%d panics, %d distinct", r.Total, len(r.Crashers))
+	if !r.Start.IsZero() {
+		s += fmt.Sprintf(", from %s to %s", formatReportTime(r.Start), formatReportTime(r.End))
+	}
+	return s
+}
+
+func formatReportTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+func firstLine(s string) string {
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		return s[:i]
+	}
+	return s
+}
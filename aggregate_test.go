@@ -0,0 +1,83 @@
+package cpanic_test
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/demosdemon/cpanic"
+)
+
+func TestAggregate(t *testing.T) {
+	base := time.Date(2021, 4, 1, 0, 0, 0, 0, time.UTC)
+	at := func(p *cpanic.Panic, hours int, buildID string) *cpanic.Panic {
+		p.Time = base.Add(time.Duration(hours) * time.Hour)
+		p.BuildID = buildID
+		return p
+	}
+	// Each crash is created by its own function, so they have distinct fingerprints.
+	rising := func(hours int, buildID string) *cpanic.Panic { return at(cpanic.New("rising"), hours, buildID) }
+	falling := func(hours int, buildID string) *cpanic.Panic { return at(cpanic.New("falling"), hours, buildID) }
+
+	// The window spans 100 hours, so its second half starts at hour 50.
+	panics := []*cpanic.Panic{
+		rising(0, "v1"),
+		rising(60, "v2"),
+		rising(70, "v2"),
+		falling(10, "v1"),
+		falling(20, "v1"),
+		falling(90, "v1"),
+		at(cpanic.New(errors.New("new")), 100, "v2"),
+	}
+	r := cpanic.Aggregate(panics)
+
+	assert.Equal(t, base, r.Start)
+	assert.Equal(t, base.Add(100*time.Hour), r.End)
+	assert.Equal(t, 7, r.Total)
+	require.Len(t, r.Crashers, 3)
+
+	up, down, fresh := r.Crashers[1], r.Crashers[0], r.Crashers[2]
+	assert.Equal(t, "falling", down.Message, "ties are broken by last seen")
+	assert.Equal(t, "rising", up.Message)
+	assert.Equal(t, 3, up.Count)
+	assert.Equal(t, panics[0].Fingerprint(), up.Fingerprint)
+	assert.Equal(t, "string", up.Type)
+	assert.Equal(t, base, up.FirstSeen)
+	assert.Equal(t, base.Add(70*time.Hour), up.LastSeen)
+	assert.Equal(t, []string{"v1", "v2"}, up.BuildIDs)
+	assert.Equal(t, cpanic.TrendRising, up.Trend)
+	assert.Equal(t, cpanic.TrendFalling, down.Trend)
+	assert.Equal(t, []string{"v1"}, down.BuildIDs)
+	assert.Equal(t, "*errors.errorString", fresh.Type)
+	assert.Equal(t, cpanic.TrendNew, fresh.Trend)
+
+	assert.Len(t, r.Top(1).Crashers, 1)
+	assert.Len(t, r.Crashers, 3, "Top does not change the report")
+
+	text := r.Text()
+	assert.Contains(t, text, "7 panics, 3 distinct, from 2021-04-01T00:00:00Z to 2021-04-05T04:00:00Z\n")
+	assert.Contains(t, text, " 1. 3x falling (falling)\n")
+	assert.Contains(t, text, " 2. 3x rising (rising)\n")
+	assert.Contains(t, text, "    builds: v1, v2\n")
+
+	md := r.Markdown()
+	assert.Contains(t, md, "| # | Count | Panic |")
+	assert.Contains(t, md, "| 3 | 1 | new<br>`*errors.errorString` |")
+
+	b, err := json.Marshal(r)
+	require.NoError(t, err)
+	var decoded map[string]interface{}
+	require.NoError(t, json.Unmarshal(b, &decoded))
+	crashers := decoded["crashers"].([]interface{})
+	assert.Equal(t, "falling", crashers[0].(map[string]interface{})["trend"])
+}
+
+func TestAggregateEmpty(t *testing.T) {
+	r := cpanic.Aggregate(nil)
+	assert.Equal(t, "0 panics, 0 distinct\n", r.Text())
+	assert.Equal(t, "0 panics, 0 distinct\n", r.Markdown())
+}
@@ -0,0 +1,82 @@
+package cpanic
+
+import (
+	"sync"
+	"time"
+)
+
+// BatchHandler is a function that handles a batch of panics, in the order they were
+// reported.
+type BatchHandler func(ps []*Panic)
+
+// Flusher is implemented by handlers that buffer panics, such as a `*Batcher`, so that
+// `WithFlush` can deliver them before the process exits.
+type Flusher interface {
+	Flush()
+}
+
+// Batcher accumulates panics and delivers them to a `BatchHandler` in batches, for
+// reporters with a per-request overhead, such as webhooks. Use its `Handle` method as
+// the `Handler`.
+type Batcher struct {
+	h        BatchHandler
+	maxSize  int
+	maxDelay time.Duration
+
+	send    sync.Mutex
+	mu      sync.Mutex
+	pending []*Panic
+	timer   *time.Timer
+}
+
+// Batch returns a batcher delivering panics to h once maxSize panics are pending or
+// maxDelay has passed since the first of them was reported, whichever comes first. A
+// maxSize of zero or less only delivers on the delay, and a maxDelay of zero or less
+// only delivers when the batch is full. Either way, pending panics are delivered by
+// `Flush`, which should be called before the process exits, such as with `WithFlush`.
+func Batch(h BatchHandler, maxSize int, maxDelay time.Duration) *Batcher {
+	return &Batcher{h: h, maxSize: maxSize, maxDelay: maxDelay}
+}
+
+// Handle adds the panic to the pending batch, delivering the batch if it is full.
+func (b *Batcher) Handle(p *Panic) {
+	b.mu.Lock()
+	b.pending = append(b.pending, p)
+	countQueued(1)
+	full := b.maxSize > 0 && len(b.pending) >= b.maxSize
+	if !full && b.timer == nil && b.maxDelay > 0 {
+		b.timer = time.AfterFunc(b.maxDelay, b.Flush)
+	}
+	b.mu.Unlock()
+
+	if full {
+		b.Flush()
+	}
+}
+
+// Flush delivers the pending panics, if any, and returns once they have been handled.
+// Batches are delivered one at a time, in order.
+func (b *Batcher) Flush() {
+	b.send.Lock()
+	defer b.send.Unlock()
+
+	b.mu.Lock()
+	batch := b.pending
+	b.pending = nil
+	if b.timer != nil {
+		b.timer.Stop()
+		b.timer = nil
+	}
+	b.mu.Unlock()
+
+	if len(batch) == 0 {
+		return
+	}
+	countQueued(-len(batch))
+	if b.h != nil {
+		start := time.Now()
+		b.h(batch)
+		countFlush(time.Since(start))
+	}
+	flushed()
+}
@@ -0,0 +1,76 @@
+package cpanic_test
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/demosdemon/cpanic"
+)
+
+// batches is a batch handler that records the batches it is called with.
+type batches struct {
+	mu      sync.Mutex
+	batches [][]*cpanic.Panic
+}
+
+func (b *batches) handle(ps []*cpanic.Panic) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.batches = append(b.batches, ps)
+}
+
+func (b *batches) sizes() []int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	sizes := make([]int, len(b.batches))
+	for i, batch := range b.batches {
+		sizes[i] = len(batch)
+	}
+	return sizes
+}
+
+func TestBatchMaxSize(t *testing.T) {
+	var got batches
+	b := cpanic.Batch(got.handle, 2, 0)
+
+	p1, p2, p3 := cpanic.New(1), cpanic.New(2), cpanic.New(3)
+	b.Handle(p1)
+	assert.Empty(t, got.sizes())
+	b.Handle(p2)
+	b.Handle(p3)
+	assert.Equal(t, []int{2}, got.sizes())
+
+	b.Flush()
+	assert.Equal(t, [][]*cpanic.Panic{{p1, p2}, {p3}}, got.batches)
+
+	b.Flush()
+	assert.Equal(t, []int{2, 1}, got.sizes())
+}
+
+func TestBatchMaxDelay(t *testing.T) {
+	var got batches
+	b := cpanic.Batch(got.handle, 0, 10*time.Millisecond)
+
+	b.Handle(cpanic.New(1))
+	b.Handle(cpanic.New(2))
+	require.Eventually(t, func() bool {
+		return len(got.sizes()) == 1
+	}, time.Second, time.Millisecond)
+	assert.Equal(t, []int{2}, got.sizes())
+}
+
+func TestRunFlush(t *testing.T) {
+	var got batches
+	b := cpanic.Batch(got.handle, 0, 0)
+
+	code := cpanic.Run(func() error {
+		b.Handle(cpanic.New("not at a disco"))
+		return nil
+	}, cpanic.WithFlush(b))
+	assert.Equal(t, 0, code)
+	assert.Equal(t, []int{1}, got.sizes())
+}
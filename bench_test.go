@@ -0,0 +1,62 @@
+package cpanic_test
+
+import (
+	"testing"
+
+	"github.com/demosdemon/cpanic"
+)
+
+//go:noinline
+func forwardNoPanic() (err error) {
+	defer cpanic.Forward(&err)
+	return nil
+}
+
+//go:noinline
+func recoverNoPanic(h cpanic.Handler) {
+	defer cpanic.Recover(h)
+}
+
+//go:noinline
+func deferNoPanic() (err error) {
+	defer func() {}()
+	return nil
+}
+
+func noop() error { return nil }
+
+func BenchmarkBaselineDefer(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_ = deferNoPanic()
+	}
+}
+
+func BenchmarkForward(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_ = forwardNoPanic()
+	}
+}
+
+func BenchmarkRecover(b *testing.B) {
+	h := func(*cpanic.Panic) {}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		recoverNoPanic(h)
+	}
+}
+
+func BenchmarkGo(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_ = cpanic.Go(noop)
+	}
+}
+
+func BenchmarkGoPanic(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_ = cpanic.Go(func() error { panic("not at a disco") })
+	}
+}
@@ -0,0 +1,301 @@
+package cpanic
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"math"
+	"sort"
+	"time"
+)
+
+// MarshalCBOR encodes the panic as a CBOR (RFC 8949) map with the fields written by
+// `NDJSONHandler`, for binary telemetry pipelines. The time is encoded as a tag 0
+// date/time string. It implements the `cbor.Marshaler` interface of
+// `github.com/fxamacker/cbor`, so a `*Panic` can be embedded in larger messages.
+func (p *Panic) MarshalCBOR() ([]byte, error) {
+	return marshalBinary(p, &cborEncoder{})
+}
+
+// MarshalMsgpack encodes the panic as a MessagePack map with the fields written by
+// `NDJSONHandler`, for binary telemetry pipelines. The time is encoded with the
+// timestamp extension type. It implements the `msgpack.Marshaler` interface of
+// `github.com/vmihailenco/msgpack`, so a `*Panic` can be embedded in larger messages.
+func (p *Panic) MarshalMsgpack() ([]byte, error) {
+	return marshalBinary(p, &msgpackEncoder{})
+}
+
+// binaryEncoder writes the values of the report schema in a binary format.
+type binaryEncoder interface {
+	null()
+	bool(v bool)
+	int(v int64)
+	float(v float64)
+	string(v string)
+	time(v time.Time)
+	arrayLen(n int)
+	mapLen(n int)
+	bytes() []byte
+}
+
+// marshalBinary encodes the report of the panic with enc. Empty fields are omitted, as
+// are those with `omitempty` in the JSON report. Attributes are converted to plain
+// values through their JSON encoding, so they encode the same way in every format.
+func marshalBinary(p *Panic, enc binaryEncoder) ([]byte, error) {
+	r := newReport(p)
+	var attrs interface{}
+	if len(r.Attrs) > 0 {
+		b, err := json.Marshal(r.Attrs)
+		if err != nil {
+			return nil, err
+		}
+		dec := json.NewDecoder(bytes.NewReader(b))
+		dec.UseNumber()
+		if err := dec.Decode(&attrs); err != nil {
+			return nil, err
+		}
+	}
+
+	type field struct {
+		key   string
+		value interface{}
+		omit  bool
+	}
+	fields := []field{
+		{"id", r.ID, r.ID == ""},
+		{"time", r.Time, false},
+		{"value", r.Value, false},
+		{"type", r.Type, false},
+		{"fingerprint", r.Fingerprint, false},
+		{"build_id", r.BuildID, r.BuildID == ""},
+		{"severity", r.Severity.String(), false},
+		{"culprit", r.Culprit, r.Culprit == ""},
+		{"attrs", attrs, attrs == nil},
+		{"trace", r.Trace, r.Trace == ""},
+	}
+
+	n := 0
+	for _, f := range fields {
+		if !f.omit {
+			n++
+		}
+	}
+	enc.mapLen(n)
+	for _, f := range fields {
+		if !f.omit {
+			enc.string(f.key)
+			encodeBinary(enc, f.value)
+		}
+	}
+	return enc.bytes(), nil
+}
+
+// encodeBinary encodes a value of the report, or a value decoded from JSON.
+func encodeBinary(enc binaryEncoder, v interface{}) {
+	switch v := v.(type) {
+	case nil:
+		enc.null()
+	case bool:
+		enc.bool(v)
+	case string:
+		enc.string(v)
+	case time.Time:
+		enc.time(v)
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			enc.int(i)
+		} else if f, err := v.Float64(); err == nil {
+			enc.float(f)
+		} else {
+			enc.string(v.String())
+		}
+	case []interface{}:
+		enc.arrayLen(len(v))
+		for _, elem := range v {
+			encodeBinary(enc, elem)
+		}
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		enc.mapLen(len(keys))
+		for _, k := range keys {
+			enc.string(k)
+			encodeBinary(enc, v[k])
+		}
+	}
+}
+
+// cborEncoder encodes values as CBOR.
+type cborEncoder struct {
+	buf []byte
+}
+
+// head writes the initial bytes of a data item with the major type and argument.
+func (e *cborEncoder) head(major byte, n uint64) {
+	major <<= 5
+	switch {
+	case n < 24:
+		e.buf = append(e.buf, major|byte(n))
+	case n <= math.MaxUint8:
+		e.buf = append(e.buf, major|24, byte(n))
+	case n <= math.MaxUint16:
+		e.buf = append(e.buf, major|25)
+		e.buf = appendUint16(e.buf, uint16(n))
+	case n <= math.MaxUint32:
+		e.buf = append(e.buf, major|26)
+		e.buf = appendUint32(e.buf, uint32(n))
+	default:
+		e.buf = append(e.buf, major|27)
+		e.buf = appendUint64(e.buf, n)
+	}
+}
+
+func (e *cborEncoder) null() { e.buf = append(e.buf, 0xf6) }
+
+func (e *cborEncoder) bool(v bool) {
+	if v {
+		e.buf = append(e.buf, 0xf5)
+	} else {
+		e.buf = append(e.buf, 0xf4)
+	}
+}
+
+func (e *cborEncoder) int(v int64) {
+	if v >= 0 {
+		e.head(0, uint64(v))
+	} else {
+		e.head(1, uint64(-1-v))
+	}
+}
+
+func (e *cborEncoder) float(v float64) {
+	e.buf = append(e.buf, 0xfb)
+	e.buf = appendUint64(e.buf, math.Float64bits(v))
+}
+
+func (e *cborEncoder) string(v string) {
+	e.head(3, uint64(len(v)))
+	e.buf = append(e.buf, v...)
+}
+
+func (e *cborEncoder) time(v time.Time) {
+	e.head(6, 0)
+	e.string(v.Format(time.RFC3339Nano))
+}
+
+func (e *cborEncoder) arrayLen(n int) { e.head(4, uint64(n)) }
+func (e *cborEncoder) mapLen(n int)   { e.head(5, uint64(n)) }
+func (e *cborEncoder) bytes() []byte  { return e.buf }
+
+// msgpackEncoder encodes values as MessagePack.
+type msgpackEncoder struct {
+	buf []byte
+}
+
+func (e *msgpackEncoder) null() { e.buf = append(e.buf, 0xc0) }
+
+func (e *msgpackEncoder) bool(v bool) {
+	if v {
+		e.buf = append(e.buf, 0xc3)
+	} else {
+		e.buf = append(e.buf, 0xc2)
+	}
+}
+
+func (e *msgpackEncoder) int(v int64) {
+	switch {
+	case v >= 0 && v <= math.MaxInt8, v < 0 && v >= -32:
+		e.buf = append(e.buf, byte(v))
+	case v >= 0 && v <= math.MaxUint8:
+		e.buf = append(e.buf, 0xcc, byte(v))
+	case v >= 0 && v <= math.MaxUint16:
+		e.buf = append(e.buf, 0xcd)
+		e.buf = appendUint16(e.buf, uint16(v))
+	case v >= 0 && v <= math.MaxUint32:
+		e.buf = append(e.buf, 0xce)
+		e.buf = appendUint32(e.buf, uint32(v))
+	case v >= 0:
+		e.buf = append(e.buf, 0xcf)
+		e.buf = appendUint64(e.buf, uint64(v))
+	case v >= math.MinInt8:
+		e.buf = append(e.buf, 0xd0, byte(v))
+	case v >= math.MinInt16:
+		e.buf = append(e.buf, 0xd1)
+		e.buf = appendUint16(e.buf, uint16(v))
+	case v >= math.MinInt32:
+		e.buf = append(e.buf, 0xd2)
+		e.buf = appendUint32(e.buf, uint32(v))
+	default:
+		e.buf = append(e.buf, 0xd3)
+		e.buf = appendUint64(e.buf, uint64(v))
+	}
+}
+
+func (e *msgpackEncoder) float(v float64) {
+	e.buf = append(e.buf, 0xcb)
+	e.buf = appendUint64(e.buf, math.Float64bits(v))
+}
+
+func (e *msgpackEncoder) string(v string) {
+	n := len(v)
+	switch {
+	case n < 32:
+		e.buf = append(e.buf, 0xa0|byte(n))
+	case n <= math.MaxUint8:
+		e.buf = append(e.buf, 0xd9, byte(n))
+	case n <= math.MaxUint16:
+		e.buf = append(e.buf, 0xda)
+		e.buf = appendUint16(e.buf, uint16(n))
+	default:
+		e.buf = append(e.buf, 0xdb)
+		e.buf = appendUint32(e.buf, uint32(n))
+	}
+	e.buf = append(e.buf, v...)
+}
+
+// time writes the 96-bit form of the timestamp extension type, -1.
+func (e *msgpackEncoder) time(v time.Time) {
+	e.buf = append(e.buf, 0xc7, 12, 0xff)
+	e.buf = appendUint32(e.buf, uint32(v.Nanosecond()))
+	e.buf = appendUint64(e.buf, uint64(v.Unix()))
+}
+
+func (e *msgpackEncoder) arrayLen(n int) { e.collection(0x90, 0xdc, n) }
+func (e *msgpackEncoder) mapLen(n int)   { e.collection(0x80, 0xde, n) }
+func (e *msgpackEncoder) bytes() []byte  { return e.buf }
+
+// collection writes the header of an array or map, given the fix and 16-bit formats.
+// The 32-bit format directly follows the 16-bit one.
+func (e *msgpackEncoder) collection(fix, format16 byte, n int) {
+	switch {
+	case n < 16:
+		e.buf = append(e.buf, fix|byte(n))
+	case n <= math.MaxUint16:
+		e.buf = append(e.buf, format16)
+		e.buf = appendUint16(e.buf, uint16(n))
+	default:
+		e.buf = append(e.buf, format16+1)
+		e.buf = appendUint32(e.buf, uint32(n))
+	}
+}
+
+func appendUint16(b []byte, v uint16) []byte {
+	var tmp [2]byte
+	binary.BigEndian.PutUint16(tmp[:], v)
+	return append(b, tmp[:]...)
+}
+
+func appendUint32(b []byte, v uint32) []byte {
+	var tmp [4]byte
+	binary.BigEndian.PutUint32(tmp[:], v)
+	return append(b, tmp[:]...)
+}
+
+func appendUint64(b []byte, v uint64) []byte {
+	var tmp [8]byte
+	binary.BigEndian.PutUint64(tmp[:], v)
+	return append(b, tmp[:]...)
+}
@@ -0,0 +1,93 @@
+package cpanic_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/demosdemon/cpanic"
+)
+
+func binaryPanic() *cpanic.Panic {
+	return &cpanic.Panic{Time: time.Unix(1, 2).UTC(), Value: "boom"}
+}
+
+// fixstr encodes a short string in either format, which differ only in the major type
+// bits of the first byte.
+func fixstr(major byte, s string) []byte {
+	return append([]byte{major | byte(len(s))}, s...)
+}
+
+// expected concatenates the head with the fields, encoding strings with fixstr.
+func expected(head []byte, major byte, fields ...interface{}) []byte {
+	b := append([]byte(nil), head...)
+	for _, f := range fields {
+		switch f := f.(type) {
+		case string:
+			b = append(b, fixstr(major, f)...)
+		case []byte:
+			b = append(b, f...)
+		}
+	}
+	return b
+}
+
+func TestMarshalMsgpack(t *testing.T) {
+	p := binaryPanic()
+	b, err := p.MarshalMsgpack()
+	assert.NoError(t, err)
+
+	timestamp := []byte{0xc7, 12, 0xff, 0, 0, 0, 2, 0, 0, 0, 0, 0, 0, 0, 1}
+	assert.Equal(t, expected([]byte{0x85}, 0xa0,
+		"time", timestamp,
+		"value", "boom",
+		"type", "string",
+		"fingerprint", p.Fingerprint(),
+		"severity", "error",
+	), b)
+}
+
+func TestMarshalCBOR(t *testing.T) {
+	p := binaryPanic()
+	b, err := p.MarshalCBOR()
+	assert.NoError(t, err)
+
+	timestamp := append([]byte{0xc0, 0x78, 30}, "1970-01-01T00:00:01.000000002Z"...)
+	assert.Equal(t, expected([]byte{0xa5}, 0x60,
+		"time", timestamp,
+		"value", "boom",
+		"type", "string",
+		"fingerprint", p.Fingerprint(),
+		"severity", "error",
+	), b)
+}
+
+func TestMarshalBinaryAttrs(t *testing.T) {
+	p := binaryPanic()
+	p.SetAttr("attempt", 300)
+	p.SetAttr("delta", []int{-1, -200})
+	p.SetAttr("ratio", 1.5)
+	p.SetAttr("retry", true)
+	p.SetAttr("user", nil)
+
+	b, err := p.MarshalMsgpack()
+	assert.NoError(t, err)
+	assert.Contains(t, string(b), string(expected(nil, 0xa0, "attrs", []byte{0x85},
+		"attempt", []byte{0xcd, 0x01, 0x2c},
+		"delta", []byte{0x92, 0xff, 0xd1, 0xff, 0x38},
+		"ratio", []byte{0xcb, 0x3f, 0xf8, 0, 0, 0, 0, 0, 0},
+		"retry", []byte{0xc3},
+		"user", []byte{0xc0},
+	)))
+
+	b, err = p.MarshalCBOR()
+	assert.NoError(t, err)
+	assert.Contains(t, string(b), string(expected(nil, 0x60, "attrs", []byte{0xa5},
+		"attempt", []byte{0x19, 0x01, 0x2c},
+		"delta", []byte{0x82, 0x20, 0x38, 0xc7},
+		"ratio", []byte{0xfb, 0x3f, 0xf8, 0, 0, 0, 0, 0, 0},
+		"retry", []byte{0xf5},
+		"user", []byte{0xf6},
+	)))
+}
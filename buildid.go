@@ -0,0 +1,23 @@
+package cpanic
+
+import "sync"
+
+var buildID struct {
+	once sync.Once
+	id   string
+}
+
+// BuildID identifies the running binary, so crash reports can be matched to the exact
+// build, such as for `Symbolize`. It is the VCS revision stamped by the Go toolchain,
+// with a "-dirty" suffix for modified working trees, when built from a repository with
+// Go 1.18 or later; otherwise, it is the GNU or Go build ID from the notes of an ELF
+// executable, or empty. It is stamped on every panic by `New`.
+func BuildID() string {
+	buildID.once.Do(func() {
+		buildID.id = vcsRevision()
+		if buildID.id == "" {
+			buildID.id = executableBuildID()
+		}
+	})
+	return buildID.id
+}
@@ -0,0 +1,31 @@
+//go:build !tinygo && !cpanic_minimal
+// +build !tinygo,!cpanic_minimal
+
+package cpanic_test
+
+import (
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/demosdemon/cpanic"
+)
+
+func TestBuildID(t *testing.T) {
+	p := cpanic.New("not at a disco")
+	assert.Equal(t, cpanic.BuildID(), p.BuildID)
+	if runtime.GOOS == "linux" {
+		// Test binaries are not stamped with VCS info, so the ID is read from the ELF
+		// notes.
+		assert.NotEmpty(t, p.BuildID)
+	}
+
+	if p.BuildID != "" {
+		assert.Contains(t, p.Markdown(), "| Build ID | `"+p.BuildID+"` |")
+		b, err := cpanic.Logfmt.Format(p)
+		assert.NoError(t, err)
+		assert.True(t, strings.Contains(string(b), " build_id="), string(b))
+	}
+}
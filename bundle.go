@@ -0,0 +1,146 @@
+//go:build !tinygo && !cpanic_minimal
+// +build !tinygo,!cpanic_minimal
+
+package cpanic
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"runtime/pprof"
+)
+
+// bundleProfiles are the profiles captured in a bundle when it is written.
+var bundleProfiles = []string{"goroutine", "heap"}
+
+// Bundle writes a crash report bundle for the panic to a new zip file in dir, and
+// returns its path, so CLI users can attach a single file to a bug report. The bundle
+// contains:
+//
+//   - report.json: the panic's time, value, fingerprint, culprit, attributes, and trace
+//   - trace.txt: the raw trace
+//   - buildinfo.txt: the Go version, main module, and dependencies of the binary
+//   - goroutine.pprof and heap.pprof: profiles captured when the bundle is written
+//
+// The file is named after the fingerprint and time of the panic. If writing fails, the
+// partial file is removed.
+func (p *Panic) Bundle(dir string) (path string, err error) {
+	path = filepath.Join(dir, fmt.Sprintf("crash-%s-%d.zip", p.Fingerprint(), p.Time.Unix()))
+	f, err := os.Create(path)
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			_ = os.Remove(path)
+			path = ""
+		}
+	}()
+
+	zw := zip.NewWriter(f)
+	if err := p.writeBundle(zw); err != nil {
+		return path, err
+	}
+	return path, zw.Close()
+}
+
+// SealedBundle writes the crash report bundle of `Bundle` to a new file in dir,
+// encrypted with the keyring, and returns its path. The file is named as by `Bundle`
+// with an additional ".enc" extension; `(*Keyring).Open` decrypts it to the zip file.
+func (p *Panic) SealedBundle(dir string, k *Keyring) (string, error) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	if err := p.writeBundle(zw); err != nil {
+		return "", err
+	}
+	if err := zw.Close(); err != nil {
+		return "", err
+	}
+	data, err := k.Seal(buf.Bytes())
+	if err != nil {
+		return "", err
+	}
+
+	path := filepath.Join(dir, fmt.Sprintf("crash-%s-%d.zip.enc", p.Fingerprint(), p.Time.Unix()))
+	if err := ioutil.WriteFile(path, data, 0o600); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+type bundleFile struct {
+	name  string
+	write func(io.Writer) error
+}
+
+func (p *Panic) writeBundle(zw *zip.Writer) error {
+	files := []bundleFile{
+		{"report.json", func(w io.Writer) error {
+			enc := json.NewEncoder(w)
+			enc.SetIndent("", "  ")
+			return enc.Encode(newReport(p))
+		}},
+		{"trace.txt", func(w io.Writer) error {
+			_, err := io.WriteString(w, p.Trace)
+			return err
+		}},
+		{"buildinfo.txt", writeBuildInfo},
+	}
+	for _, name := range bundleProfiles {
+		profile := pprof.Lookup(name)
+		files = append(files, bundleFile{name + ".pprof", func(w io.Writer) error {
+			return profile.WriteTo(w, 0)
+		}})
+	}
+
+	for _, file := range files {
+		w, err := zw.Create(file.name)
+		if err != nil {
+			return err
+		}
+		if err := file.write(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeBuildInfo(w io.Writer) error {
+	if _, err := fmt.Fprintf(w, "go\t%s\n", runtime.Version()); err != nil {
+		return err
+	}
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return nil
+	}
+
+	if _, err := fmt.Fprintf(w, "path\t%s\n", info.Path); err != nil {
+		return err
+	}
+	modules := append([]*debug.Module{&info.Main}, info.Deps...)
+	for i, m := range modules {
+		kind := "dep"
+		if i == 0 {
+			kind = "mod"
+		}
+		if _, err := fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", kind, m.Path, m.Version, m.Sum); err != nil {
+			return err
+		}
+		if m.Replace != nil {
+			if _, err := fmt.Fprintf(w, "=>\t%s\t%s\t%s\n", m.Replace.Path, m.Replace.Version, m.Replace.Sum); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+//go:build !tinygo && !cpanic_minimal
+// +build !tinygo,!cpanic_minimal
+
+package cpanic_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/demosdemon/cpanic"
+)
+
+func TestBundle(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cpanic")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	p := cpanic.New("not at a disco")
+	p.SetAttr("user", "brendon")
+	path, err := p.Bundle(dir)
+	require.NoError(t, err)
+	assert.Equal(t, dir, filepath.Dir(path))
+	assert.True(t, strings.HasPrefix(filepath.Base(path), "crash-"+p.Fingerprint()+"-"), path)
+
+	zr, err := zip.OpenReader(path)
+	require.NoError(t, err)
+	defer zr.Close()
+
+	files := make(map[string][]byte)
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		require.NoError(t, err)
+		files[f.Name], err = ioutil.ReadAll(rc)
+		rc.Close()
+		require.NoError(t, err)
+	}
+
+	assert.ElementsMatch(t, []string{
+		"report.json", "trace.txt", "buildinfo.txt", "goroutine.pprof", "heap.pprof",
+	}, keys(files))
+
+	var report map[string]interface{}
+	require.NoError(t, json.Unmarshal(files["report.json"], &report))
+	assert.Equal(t, "not at a disco", report["value"])
+	assert.Equal(t, p.Fingerprint(), report["fingerprint"])
+	assert.Equal(t, map[string]interface{}{"user": "brendon"}, report["attrs"])
+
+	assert.Equal(t, p.Trace, string(files["trace.txt"]))
+	assert.True(t, strings.HasPrefix(string(files["buildinfo.txt"]), "go\tgo"))
+	assert.NotEmpty(t, files["goroutine.pprof"])
+	assert.NotEmpty(t, files["heap.pprof"])
+}
+
+func TestBundleError(t *testing.T) {
+	path, err := cpanic.New("not at a disco").Bundle(filepath.Join("does", "not", "exist"))
+	assert.Error(t, err)
+	assert.Empty(t, path)
+}
+
+func TestRunBundleDir(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cpanic")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	_, stderr := runCrash(cpanic.WithBundleDir(dir))
+	matches, err := filepath.Glob(filepath.Join(dir, "crash-*.zip"))
+	require.NoError(t, err)
+	require.Len(t, matches, 1)
+	assert.True(t, strings.HasSuffix(stderr, "\ncrash report written to "+matches[0]+"\n"), stderr)
+}
+
+func TestSealedBundle(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cpanic")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	k, err := cpanic.NewKeyring(bytes.Repeat([]byte{1}, 32))
+	require.NoError(t, err)
+	p := cpanic.New("not at a disco")
+	path, err := p.SealedBundle(dir, k)
+	require.NoError(t, err)
+	assert.True(t, strings.HasSuffix(path, ".zip.enc"), path)
+
+	data, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	_, err = zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	assert.Error(t, err, "the bundle is not readable without the key")
+
+	data, err = k.Open(data)
+	require.NoError(t, err)
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	require.NoError(t, err)
+	assert.Len(t, zr.File, 5)
+}
+
+func TestRunBundleDirEncrypted(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cpanic")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	k, err := cpanic.NewKeyring(bytes.Repeat([]byte{1}, 16))
+	require.NoError(t, err)
+	_, stderr := runCrash(cpanic.WithBundleDir(dir), cpanic.WithEncryption(k))
+	matches, err := filepath.Glob(filepath.Join(dir, "crash-*.zip.enc"))
+	require.NoError(t, err)
+	require.Len(t, matches, 1)
+	assert.True(t, strings.HasSuffix(stderr, "\ncrash report written to "+matches[0]+"\n"), stderr)
+}
+
+func keys(m map[string][]byte) []string {
+	var names []string
+	for k := range m {
+		names = append(names, k)
+	}
+	return names
+}
@@ -0,0 +1,12 @@
+package cpanic
+
+import "reflect"
+
+// Call calls the function fn with the input arguments, like `reflect.Value.Call`, and
+// recovers any panics. This includes both panics raised by the function itself and
+// those raised by `reflect` when fn is not a function or the arguments do not match
+// its signature. If a panic is recovered, the error returned will be a `*Panic`.
+func Call(fn reflect.Value, args ...reflect.Value) (results []reflect.Value, err error) {
+	defer Forward(&err)
+	return fn.Call(args), nil
+}
@@ -0,0 +1,67 @@
+package cpanic_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/demosdemon/cpanic"
+)
+
+func TestCall(t *testing.T) {
+	tests := []struct {
+		name    string
+		fn      interface{}
+		args    []interface{}
+		results []interface{}
+		errMsg  string
+	}{
+		{
+			name:    "calls function",
+			fn:      strings.Repeat,
+			args:    []interface{}{"a", 3},
+			results: []interface{}{"aaa"},
+		},
+		{
+			name:   "returns panic",
+			fn:     func() { panic("not at a disco") },
+			errMsg: "panic: not at a disco",
+		},
+		{
+			name:   "not a function",
+			fn:     42,
+			errMsg: "panic: reflect: call of reflect.Value.Call on int Value",
+		},
+		{
+			name:   "wrong arguments",
+			fn:     strings.Repeat,
+			args:   []interface{}{"a"},
+			errMsg: "panic: reflect: Call with too few input arguments",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			args := make([]reflect.Value, len(tt.args))
+			for i, arg := range tt.args {
+				args[i] = reflect.ValueOf(arg)
+			}
+
+			out, err := cpanic.Call(reflect.ValueOf(tt.fn), args...)
+			if tt.errMsg == "" {
+				assert.NoError(t, err)
+				results := make([]interface{}, len(out))
+				for i, v := range out {
+					results[i] = v.Interface()
+				}
+				assert.Equal(t, tt.results, results)
+			} else {
+				assert.EqualError(t, err, tt.errMsg)
+				assert.Nil(t, out)
+			}
+		})
+	}
+}
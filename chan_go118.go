@@ -0,0 +1,55 @@
+//go:build go1.18
+// +build go1.18
+
+package cpanic
+
+import (
+	"errors"
+	"runtime"
+)
+
+var (
+	// ErrSendOnClosedChannel is returned by `Send` when the channel is closed.
+	ErrSendOnClosedChannel = errors.New("send on closed channel")
+	// ErrCloseOfClosedChannel is returned by `SafeClose` when the channel is already
+	// closed.
+	ErrCloseOfClosedChannel = errors.New("close of closed channel")
+	// ErrCloseOfNilChannel is returned by `SafeClose` when the channel is nil.
+	ErrCloseOfNilChannel = errors.New("close of nil channel")
+)
+
+// Send sends the value on the channel, blocking like a regular send. If the channel is
+// closed, `ErrSendOnClosedChannel` is returned instead of panicking. This is meant for
+// shutdown paths where a race with the closer is hard to eliminate.
+func Send[T any](ch chan<- T, v T) (err error) {
+	defer recoverChannel(&err, ErrSendOnClosedChannel)
+	ch <- v
+	return nil
+}
+
+// SafeClose closes the channel. If the channel is already closed or nil,
+// `ErrCloseOfClosedChannel` or `ErrCloseOfNilChannel` is returned instead of
+// panicking.
+func SafeClose[T any](ch chan<- T) (err error) {
+	defer recoverChannel(&err, ErrCloseOfClosedChannel, ErrCloseOfNilChannel)
+	close(ch)
+	return nil
+}
+
+// recoverChannel is a defer function that converts the runtime panics matching the
+// provided errors into those errors. Any other panic is converted into a `*Panic`.
+func recoverChannel(errPtr *error, expected ...error) {
+	if value := recover(); value != nil {
+		if rerr, ok := value.(runtime.Error); ok {
+			for _, err := range expected {
+				if rerr.Error() == err.Error() {
+					*errPtr = err
+					return
+				}
+			}
+		}
+		p := New(value)
+		settle(p)
+		*errPtr = p
+	}
+}
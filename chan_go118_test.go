@@ -0,0 +1,30 @@
+//go:build go1.18
+// +build go1.18
+
+package cpanic_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/demosdemon/cpanic"
+)
+
+func TestSend(t *testing.T) {
+	ch := make(chan int, 1)
+	assert.NoError(t, cpanic.Send(ch, 42))
+	assert.Equal(t, 42, <-ch)
+
+	close(ch)
+	assert.Equal(t, cpanic.ErrSendOnClosedChannel, cpanic.Send(ch, 42))
+}
+
+func TestSafeClose(t *testing.T) {
+	ch := make(chan int)
+	assert.NoError(t, cpanic.SafeClose(ch))
+	assert.Equal(t, cpanic.ErrCloseOfClosedChannel, cpanic.SafeClose(ch))
+
+	var nilCh chan int
+	assert.Equal(t, cpanic.ErrCloseOfNilChannel, cpanic.SafeClose(nilCh))
+}
@@ -0,0 +1,159 @@
+// chaos provides panic injection for verifying that panic handling, reporting, and
+// alerting actually work. Nothing is ever injected unless chaos is enabled, either by
+// setting the `CPANIC_CHAOS` environment variable or by calling `Set`, so trigger
+// points can be left in production code and switched on in staging.
+//
+// The environment variable is a comma-separated list of `point=probability` pairs,
+// where the point `*` matches every trigger point:
+//
+//	CPANIC_CHAOS='cpanic.Go=0.01,checkout=0.5'
+//
+// The cpanic wrappers are trigger points named after themselves, such as "cpanic.Go"
+// and "cpanichttp.RoundTripper", so the whole pipeline from recovery to alerting can be
+// exercised without changing application code.
+package chaos
+
+import (
+	"math/rand"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// EnvVar is the environment variable that enables chaos and configures the
+// probability of each trigger point.
+const EnvVar = "CPANIC_CHAOS"
+
+// Wildcard is the trigger point name that matches every trigger point.
+const Wildcard = "*"
+
+// Injected is the value of every synthetic panic, so handlers can tell injected
+// panics from real ones.
+type Injected struct {
+	// Point is the name of the trigger point, if any, that injected the panic.
+	Point string
+}
+
+// Error implements the `error` interface.
+func (e *Injected) Error() string {
+	if e.Point == "" {
+		return "chaos: injected panic"
+	}
+	return "chaos: injected panic at " + e.Point
+}
+
+// The states of chaos, checked atomically so that disabled trigger points cost a
+// single load.
+const (
+	unloaded int32 = iota
+	disabled
+	enabled
+)
+
+var (
+	state  int32
+	mu     sync.RWMutex
+	points map[string]float64
+)
+
+// load parses the environment variable the first time chaos is consulted.
+func load() {
+	mu.Lock()
+	defer mu.Unlock()
+	if atomic.LoadInt32(&state) != unloaded {
+		return
+	}
+
+	env := os.Getenv(EnvVar)
+	points = make(map[string]float64)
+	for _, entry := range strings.Split(env, ",") {
+		idx := strings.IndexByte(entry, '=')
+		if idx < 0 {
+			continue
+		}
+		prob, err := strconv.ParseFloat(strings.TrimSpace(entry[idx+1:]), 64)
+		if err != nil {
+			continue
+		}
+		points[strings.TrimSpace(entry[:idx])] = prob
+	}
+
+	if env == "" {
+		atomic.StoreInt32(&state, disabled)
+	} else {
+		atomic.StoreInt32(&state, enabled)
+	}
+}
+
+// Enabled reports whether chaos is enabled.
+func Enabled() bool {
+	switch atomic.LoadInt32(&state) {
+	case enabled:
+		return true
+	case disabled:
+		return false
+	}
+
+	load()
+	return atomic.LoadInt32(&state) == enabled
+}
+
+// Set enables chaos and sets the probability that the named trigger point panics.
+func Set(point string, prob float64) {
+	if atomic.LoadInt32(&state) == unloaded {
+		load()
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	points[point] = prob
+	atomic.StoreInt32(&state, enabled)
+}
+
+// Reset disables chaos and discards all configuration. The environment variable is
+// parsed again the next time chaos is consulted.
+func Reset() {
+	mu.Lock()
+	defer mu.Unlock()
+	points = nil
+	atomic.StoreInt32(&state, unloaded)
+}
+
+// Maybe panics with the provided probability, between 0 and 1, if chaos is enabled.
+func Maybe(prob float64) {
+	if Enabled() && rand.Float64() < prob {
+		panic(&Injected{})
+	}
+}
+
+// Point is a named trigger point. If chaos is enabled, it panics with the probability
+// configured for the name, or for `Wildcard` if the name is not configured.
+func Point(name string) {
+	if !Enabled() {
+		return
+	}
+
+	mu.RLock()
+	prob, ok := points[name]
+	if !ok {
+		prob = points[Wildcard]
+	}
+	mu.RUnlock()
+
+	if prob > 0 && rand.Float64() < prob {
+		panic(&Injected{Point: name})
+	}
+}
+
+// PanicAfter returns a function that panics on every call after the first n calls, if
+// chaos is enabled.
+func PanicAfter(n int) func() {
+	var calls int64
+	return func() {
+		if atomic.AddInt64(&calls, 1) > int64(n) && Enabled() {
+			panic(&Injected{})
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package chaos_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/demosdemon/cpanic/chaos"
+)
+
+func TestDisabled(t *testing.T) {
+	chaos.Reset()
+	defer chaos.Reset()
+
+	assert.False(t, chaos.Enabled())
+	assert.NotPanics(t, func() {
+		chaos.Maybe(1)
+		chaos.Point("test")
+		chaos.PanicAfter(0)()
+	})
+}
+
+func TestEnvVar(t *testing.T) {
+	chaos.Reset()
+	defer chaos.Reset()
+
+	assert.NoError(t, os.Setenv(chaos.EnvVar, "always=1, never=0"))
+	defer os.Unsetenv(chaos.EnvVar)
+
+	assert.True(t, chaos.Enabled())
+	assert.PanicsWithError(t, "chaos: injected panic at always", func() { chaos.Point("always") })
+	assert.NotPanics(t, func() { chaos.Point("never") })
+	assert.NotPanics(t, func() { chaos.Point("unknown") })
+}
+
+func TestSet(t *testing.T) {
+	chaos.Reset()
+	defer chaos.Reset()
+
+	chaos.Set(chaos.Wildcard, 1)
+	chaos.Set("never", 0)
+	assert.PanicsWithError(t, "chaos: injected panic at anything", func() { chaos.Point("anything") })
+	assert.NotPanics(t, func() { chaos.Point("never") })
+
+	assert.PanicsWithError(t, "chaos: injected panic", func() { chaos.Maybe(1) })
+	assert.NotPanics(t, func() { chaos.Maybe(0) })
+}
+
+func TestPanicAfter(t *testing.T) {
+	chaos.Reset()
+	defer chaos.Reset()
+	chaos.Set("enabled", 0)
+
+	fn := chaos.PanicAfter(2)
+	assert.NotPanics(t, fn)
+	assert.NotPanics(t, fn)
+	assert.Panics(t, fn)
+	assert.Panics(t, fn)
+}
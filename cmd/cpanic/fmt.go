@@ -0,0 +1,154 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/demosdemon/cpanic"
+)
+
+// ANSI escape sequences used by `fmt`.
+const (
+	ansiReset  = "\x1b[0m"
+	ansiBold   = "\x1b[1m"
+	ansiDim    = "\x1b[2m"
+	ansiRed    = "\x1b[31m"
+	ansiYellow = "\x1b[33m"
+	ansiCyan   = "\x1b[36m"
+)
+
+type painter bool
+
+func (c painter) paint(s string, codes ...string) string {
+	if !c || s == "" {
+		return s
+	}
+	return strings.Join(codes, "") + s + ansiReset
+}
+
+func runFmt(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("fmt", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	color := flags.String("color", "auto", "colorize the output: auto, always, or never")
+	fold := flags.Bool("fold", true, "fold consecutive goroutines with the same stack")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	var c painter
+	switch *color {
+	case "always":
+		c = true
+	case "never":
+	case "auto":
+		c = painter(isTerminal(stdout) && os.Getenv("NO_COLOR") == "")
+	default:
+		fmt.Fprintf(stderr, "cpanic: invalid -color %q\n", *color)
+		return 2
+	}
+
+	p, err := parse(flags.Args(), stdin)
+	if err != nil {
+		fmt.Fprintln(stderr, "cpanic:", err)
+		return 1
+	}
+
+	if msg, ok := p.Value.(string); ok && msg != "" {
+		fmt.Fprintln(stdout, c.paint("panic: "+msg, ansiBold, ansiRed))
+		fmt.Fprintln(stdout)
+	}
+
+	goroutines := p.Goroutines()
+	for i := 0; i < len(goroutines); i++ {
+		g := goroutines[i]
+		var same []int
+		for *fold && i+1 < len(goroutines) && sameStack(g, goroutines[i+1]) {
+			i++
+			same = append(same, goroutines[i].ID)
+		}
+		writeGoroutine(stdout, c, g, same)
+	}
+	return 0
+}
+
+func writeGoroutine(w io.Writer, c painter, g cpanic.Goroutine, same []int) {
+	header := fmt.Sprintf("goroutine %d [%s]", g.ID, g.State)
+	if len(g.Labels) > 0 {
+		header += " " + formatLabels(g.Labels)
+	}
+	fmt.Fprintln(w, c.paint(header+":", ansiBold, ansiYellow))
+
+	for _, f := range g.Frames {
+		writeFrame(w, c, f.Function+"()", f)
+	}
+	if g.CreatedBy.Function != "" {
+		writeFrame(w, c, "created by "+g.CreatedBy.Function, g.CreatedBy)
+	}
+	if len(same) > 0 {
+		ids := make([]string, len(same))
+		for i, id := range same {
+			ids[i] = fmt.Sprint(id)
+		}
+		fmt.Fprintln(w, c.paint(fmt.Sprintf("... %d more goroutines with the same stack: %s",
+			len(same), strings.Join(ids, ", ")), ansiDim))
+	}
+	fmt.Fprintln(w)
+}
+
+func writeFrame(w io.Writer, c painter, call string, f cpanic.Frame) {
+	if isRuntime(f.Function) {
+		fmt.Fprintln(w, c.paint(call, ansiDim))
+	} else {
+		fmt.Fprintln(w, c.paint(call, ansiBold))
+	}
+	if f.File != "" {
+		fmt.Fprintf(w, "\t%s\n", c.paint(fmt.Sprintf("%s:%d", f.File, f.Line), ansiCyan))
+	}
+}
+
+func formatLabels(labels map[string]string) string {
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	pairs := make([]string, len(keys))
+	for i, k := range keys {
+		pairs[i] = k + ": " + labels[k]
+	}
+	return "{" + strings.Join(pairs, ", ") + "}"
+}
+
+// isRuntime reports whether the function belongs to the runtime or standard library
+// internals that are rarely the cause of a panic.
+func isRuntime(fn string) bool {
+	return fn == "panic" || strings.HasPrefix(fn, "runtime.") || strings.HasPrefix(fn, "runtime/") ||
+		strings.HasPrefix(fn, "internal/")
+}
+
+// sameStack reports whether two goroutines are in the same state with the same stack.
+func sameStack(a, b cpanic.Goroutine) bool {
+	if a.State != b.State || a.CreatedBy.Function != b.CreatedBy.Function || len(a.Frames) != len(b.Frames) {
+		return false
+	}
+	for i := range a.Frames {
+		if a.Frames[i] != b.Frames[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// isTerminal reports whether w is a character device, such as a terminal.
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
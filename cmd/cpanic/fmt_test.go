@@ -0,0 +1,54 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunFmt(t *testing.T) {
+	code, stdout, _ := runCommand("fmt", crashLog)
+	assert.Equal(t, 0, code)
+	assert.Equal(t, `panic: assignment to entry in nil map
+
+goroutine 1 [running]:
+main.main()
+	/src/main.go:6
+
+goroutine 18 [chan receive]:
+main.worker()
+	/src/main.go:12
+created by main.main
+	/src/main.go:5
+... 2 more goroutines with the same stack: 19, 20
+
+goroutine 21 [select]:
+runtime.gopark()
+	/usr/local/go/src/runtime/proc.go:402
+main.idle()
+	/src/main.go:20
+created by main.main
+	/src/main.go:7
+
+`, stdout)
+}
+
+func TestRunFmtNoFold(t *testing.T) {
+	code, stdout, _ := runCommand("fmt", "-fold=false", crashLog)
+	assert.Equal(t, 0, code)
+	assert.Equal(t, 5, strings.Count(stdout, "goroutine "))
+	assert.NotContains(t, stdout, "more goroutines")
+}
+
+func TestRunFmtColor(t *testing.T) {
+	code, stdout, _ := runCommand("fmt", "-color", "always", crashLog)
+	assert.Equal(t, 0, code)
+	assert.True(t, strings.HasPrefix(stdout, ansiBold+ansiRed+"panic: assignment to entry in nil map"+ansiReset+"\n"), stdout)
+	assert.Contains(t, stdout, ansiDim+"runtime.gopark()"+ansiReset)
+	assert.Contains(t, stdout, ansiBold+"main.idle()"+ansiReset)
+
+	code, _, stderr := runCommand("fmt", "-color", "rainbow", crashLog)
+	assert.Equal(t, 2, code)
+	assert.Equal(t, "cpanic: invalid -color \"rainbow\"\n", stderr)
+}
@@ -0,0 +1,92 @@
+// cpanic triages production crash logs using the cpanic parser.
+//
+//	cpanic fmt [-color auto|always|never] [-fold=false] [file]
+//	cpanic top [file]
+//	cpanic fingerprint [file]
+//	cpanic watch [-f] [-journal unit] [-prefix regexp] [-demux] [-otlp url] [-statsd addr] [files...]
+//
+// `fmt` prints the panic message and goroutines of a raw crash dump, colorized, with
+// consecutive goroutines sharing a stack folded into one. `top` summarizes the
+// goroutines by state. `fingerprint` prints the hash used to group panics. Each
+// command reads the crash log from the file, or standard input if none is given.
+//
+// `watch` tails the mixed output of a program, from standard input, files, or
+// journald, and reports every panic it finds as a line of JSON on standard output, to
+// an OpenTelemetry collector, or to StatsD, bolting cpanic telemetry onto binaries
+// that do not use it. With `-demux`, traces are followed across the log lines of
+// other goroutines or processes interleaved with them.
+package main
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"github.com/demosdemon/cpanic"
+)
+
+const usage = `usage: cpanic <command> [flags] [file]
+
+commands:
+  fmt          pretty-print a crash dump
+  top          summarize goroutines by state
+  fingerprint  print the grouping hash of the panic
+  watch        report the panics found in a log stream
+`
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// command runs a subcommand with its arguments, returning the exit code.
+type command func(args []string, stdin io.Reader, stdout, stderr io.Writer) int
+
+var commands = map[string]command{
+	"fmt":         runFmt,
+	"top":         runTop,
+	"fingerprint": runFingerprint,
+	"watch":       runWatch,
+}
+
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, usage)
+		return 2
+	}
+	cmd, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprintf(stderr, "cpanic: unknown command %q\n\n%s", args[0], usage)
+		return 2
+	}
+	return cmd(args[1:], stdin, stdout, stderr)
+}
+
+// parse reads the crash log from the file named by args, or stdin, and parses it.
+func parse(args []string, stdin io.Reader) (*cpanic.Panic, error) {
+	r := stdin
+	if len(args) > 0 && args[0] != "-" {
+		f, err := os.Open(args[0])
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return cpanic.Parse(string(data))
+}
+
+func runFingerprint(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	p, err := parse(args, stdin)
+	if err != nil {
+		fmt.Fprintln(stderr, "cpanic:", err)
+		return 1
+	}
+	fmt.Fprintln(stdout, p.Fingerprint())
+	return 0
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/demosdemon/cpanic"
+)
+
+var crashLog = filepath.Join("testdata", "crash.log")
+
+func runCommand(args ...string) (code int, stdout, stderr string) {
+	var out, errOut bytes.Buffer
+	code = run(args, strings.NewReader(""), &out, &errOut)
+	return code, out.String(), errOut.String()
+}
+
+func TestRunUsage(t *testing.T) {
+	code, _, stderr := runCommand()
+	assert.Equal(t, 2, code)
+	assert.True(t, strings.HasPrefix(stderr, "usage: cpanic"), stderr)
+
+	code, _, stderr = runCommand("nope")
+	assert.Equal(t, 2, code)
+	assert.True(t, strings.HasPrefix(stderr, `cpanic: unknown command "nope"`), stderr)
+}
+
+func TestRunFingerprint(t *testing.T) {
+	p, err := cpanic.Parse(`panic: assignment to entry in nil map
+
+goroutine 1 [running]:
+main.main()
+	/src/main.go:6 +0x34
+`)
+	assert.NoError(t, err)
+
+	code, stdout, _ := runCommand("fingerprint", crashLog)
+	assert.Equal(t, 0, code)
+	assert.Equal(t, p.Fingerprint()+"\n", stdout)
+}
+
+func TestRunStdin(t *testing.T) {
+	var out, errOut bytes.Buffer
+	code := run([]string{"fingerprint"}, strings.NewReader("not a crash"), &out, &errOut)
+	assert.Equal(t, 1, code)
+	assert.Equal(t, "cpanic: "+cpanic.ErrNoTrace.Error()+"\n", errOut.String())
+}
+
+func TestRunMissingFile(t *testing.T) {
+	code, _, stderr := runCommand("top", filepath.Join("testdata", "missing.log"))
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stderr, "missing.log")
+}
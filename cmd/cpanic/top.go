@@ -0,0 +1,42 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+)
+
+func runTop(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	p, err := parse(args, stdin)
+	if err != nil {
+		fmt.Fprintln(stderr, "cpanic:", err)
+		return 1
+	}
+
+	goroutines := p.Goroutines()
+	counts := make(map[string]int)
+	for _, g := range goroutines {
+		counts[g.State]++
+	}
+
+	states := make([]string, 0, len(counts))
+	for state := range counts {
+		states = append(states, state)
+	}
+	sort.Slice(states, func(i, j int) bool {
+		if counts[states[i]] != counts[states[j]] {
+			return counts[states[i]] > counts[states[j]]
+		}
+		return states[i] < states[j]
+	})
+
+	tw := tabwriter.NewWriter(stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "COUNT\tSTATE")
+	for _, state := range states {
+		fmt.Fprintf(tw, "%d\t%s\n", counts[state], state)
+	}
+	fmt.Fprintf(tw, "%d\ttotal\n", len(goroutines))
+	_ = tw.Flush()
+	return 0
+}
@@ -0,0 +1,18 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunTop(t *testing.T) {
+	code, stdout, _ := runCommand("top", crashLog)
+	assert.Equal(t, 0, code)
+	assert.Equal(t, `COUNT  STATE
+3      chan receive
+1      running
+1      select
+5      total
+`, stdout)
+}
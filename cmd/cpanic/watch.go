@@ -0,0 +1,158 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/demosdemon/cpanic"
+	"github.com/demosdemon/cpanic/cpanicotlp"
+	"github.com/demosdemon/cpanic/cpanicstatsd"
+)
+
+// pollInterval is how often a followed file is checked for new data.
+const pollInterval = 250 * time.Millisecond
+
+func runWatch(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("watch", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	follow := flags.Bool("f", false, "keep reading files as they grow, like tail -f")
+	unit := flags.String("journal", "", "follow the journald logs of the systemd unit")
+	prefix := flags.String("prefix", "", "regular expression stripped from the start of each line")
+	demux := flags.Bool("demux", false, "follow traces across interleaved log lines, such as merged stdout and stderr")
+	ndjson := flags.Bool("ndjson", true, "write each panic to standard output as a line of JSON")
+	otlp := flags.String("otlp", "", "export panics to the OTLP/HTTP collector at the URL")
+	statsd := flags.String("statsd", "", "count panics with the StatsD agent at the address")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	var re *regexp.Regexp
+	if *prefix != "" {
+		var err error
+		if re, err = regexp.Compile(*prefix); err != nil {
+			fmt.Fprintln(stderr, "cpanic: invalid -prefix:", err)
+			return 2
+		}
+	}
+
+	var handlers []cpanic.Handler
+	if *ndjson {
+		handlers = append(handlers, cpanic.NDJSONHandler(stdout))
+	}
+	if *otlp != "" {
+		handlers = append(handlers, cpanicotlp.New(*otlp).Handler())
+	}
+	if *statsd != "" {
+		h, err := cpanicstatsd.Dial(*statsd)
+		if err != nil {
+			fmt.Fprintln(stderr, "cpanic:", err)
+			return 1
+		}
+		handlers = append(handlers, h)
+	}
+
+	var sources []io.Reader
+	switch {
+	case *unit != "":
+		cmd := exec.Command("journalctl", "--follow", "--output=cat", "--unit", *unit)
+		cmd.Stderr = stderr
+		out, err := cmd.StdoutPipe()
+		if err == nil {
+			err = cmd.Start()
+		}
+		if err != nil {
+			fmt.Fprintln(stderr, "cpanic:", err)
+			return 1
+		}
+		defer func() { _ = cmd.Wait() }()
+		sources = append(sources, out)
+
+	case flags.NArg() == 0:
+		sources = append(sources, stdin)
+
+	default:
+		for _, name := range flags.Args() {
+			f, err := os.Open(name)
+			if err != nil {
+				fmt.Fprintln(stderr, "cpanic:", err)
+				return 1
+			}
+			defer f.Close()
+			if *follow {
+				sources = append(sources, &followReader{f: f})
+			} else {
+				sources = append(sources, f)
+			}
+		}
+	}
+
+	code := 0
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, r := range sources {
+		wg.Add(1)
+		go func(r io.Reader) {
+			defer wg.Done()
+			if err := watch(r, re, *demux, handlers); err != nil {
+				mu.Lock()
+				defer mu.Unlock()
+				fmt.Fprintln(stderr, "cpanic:", err)
+				code = 1
+			}
+		}(r)
+	}
+	wg.Wait()
+	return code
+}
+
+// scanner is implemented by `cpanic.Scanner` and `cpanic.Demux`.
+type scanner interface {
+	SetPrefix(re *regexp.Regexp)
+	Scan() bool
+	Panic() *cpanic.Panic
+	Err() error
+}
+
+// watch reports every panic found in r to the handlers. With demux, traces are
+// followed across interleaved log lines.
+func watch(r io.Reader, prefix *regexp.Regexp, demux bool, handlers []cpanic.Handler) error {
+	var s scanner = cpanic.NewScanner(r)
+	if demux {
+		s = cpanic.NewDemux(r)
+	}
+	if prefix != nil {
+		s.SetPrefix(prefix)
+	}
+	for s.Scan() {
+		p := s.Panic()
+		if p == nil {
+			continue
+		}
+		p.Stamp(time.Now())
+		for _, h := range handlers {
+			h.Handle(p)
+		}
+	}
+	return s.Err()
+}
+
+// followReader reads a file forever, waiting for it to grow at the end.
+type followReader struct {
+	f *os.File
+}
+
+func (r *followReader) Read(p []byte) (int, error) {
+	for {
+		n, err := r.f.Read(p)
+		if n > 0 || err != io.EOF {
+			return n, err
+		}
+		time.Sleep(pollInterval)
+	}
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const mixedLog = `starting
+[app] panic: boom
+[app] 
+[app] goroutine 1 [running]:
+[app] main.main()
+[app] 	/src/main.go:6 +0x34
+[app] exit status 2
+`
+
+func TestRunWatch(t *testing.T) {
+	var out, errOut bytes.Buffer
+	code := run([]string{"watch", "-prefix", `^\[app\] `}, strings.NewReader(mixedLog), &out, &errOut)
+	assert.Equal(t, 0, code, errOut.String())
+
+	var report map[string]interface{}
+	require.NoError(t, json.Unmarshal(out.Bytes(), &report))
+	assert.Equal(t, "boom", report["value"])
+	assert.Equal(t, "main.main (/src/main.go:6)", report["culprit"])
+	assert.Len(t, report["id"], 26)
+}
+
+func TestRunWatchFiles(t *testing.T) {
+	code, stdout, stderr := runCommand("watch", crashLog, crashLog)
+	assert.Equal(t, 0, code, stderr)
+	assert.Equal(t, 2, strings.Count(stdout, "\n"))
+
+	code, _, stderr = runCommand("watch", "-prefix", "(", crashLog)
+	assert.Equal(t, 2, code)
+	assert.Contains(t, stderr, "invalid -prefix")
+}
+
+func TestFollowReader(t *testing.T) {
+	f, err := ioutil.TempFile("", "cpanic")
+	require.NoError(t, err)
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	r, err := os.Open(f.Name())
+	require.NoError(t, err)
+	defer r.Close()
+
+	go func() {
+		time.Sleep(2 * pollInterval)
+		_, _ = f.WriteString("hello")
+	}()
+
+	buf := make([]byte, 5)
+	n, err := (&followReader{f: r}).Read(buf)
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(buf[:n]))
+}
+
+func TestRunWatchDemux(t *testing.T) {
+	log := "panic: boom\n\ngoroutine 1 [running]:\nmain.main()\nGET /healthz 200\n\t/src/main.go:6 +0x34\n"
+
+	var out, errOut bytes.Buffer
+	code := run([]string{"watch", "-demux"}, strings.NewReader(log), &out, &errOut)
+	assert.Equal(t, 0, code, errOut.String())
+
+	var report map[string]interface{}
+	require.NoError(t, json.Unmarshal(out.Bytes(), &report))
+	assert.Equal(t, "main.main (/src/main.go:6)", report["culprit"])
+}
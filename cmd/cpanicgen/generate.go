@@ -0,0 +1,336 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// cpanicImport is the import path of the cpanic package used by generated code.
+const cpanicImport = "github.com/demosdemon/cpanic"
+
+// methodAttr is the attribute key holding the "Interface.Method" that panicked.
+const methodAttr = "cpanic.method"
+
+// versionSuffix matches the major version suffix of an import path, such as "/v2".
+var versionSuffix = regexp.MustCompile(`/v\d+$`)
+
+type method struct {
+	name    string
+	params  []param
+	results []string
+}
+
+type param struct {
+	name     string
+	typ      string
+	variadic bool
+}
+
+// pkg is the parsed, non-test source of a package.
+type pkg struct {
+	fset  *token.FileSet
+	name  string
+	files []*ast.File
+}
+
+// generate returns the source of a decorator for the named interface declared in the
+// package in dir.
+func generate(dir, typeName string) ([]byte, error) {
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, dir, func(fi os.FileInfo) bool {
+		return !strings.HasSuffix(fi.Name(), "_test.go") && !strings.HasSuffix(fi.Name(), "_cpanic.go")
+	}, 0)
+	if err != nil {
+		return nil, err
+	}
+	if len(pkgs) != 1 {
+		return nil, fmt.Errorf("expected one package in %s, found %d", dir, len(pkgs))
+	}
+
+	p := &pkg{fset: fset}
+	for name, astPkg := range pkgs {
+		p.name = name
+		for _, f := range astPkg.Files {
+			p.files = append(p.files, f)
+		}
+	}
+	sort.Slice(p.files, func(i, j int) bool {
+		return fset.File(p.files[i].Pos()).Name() < fset.File(p.files[j].Pos()).Name()
+	})
+
+	imports := make(map[string]string)
+	methods, err := p.methods(typeName, imports, make(map[string]bool))
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(methods, func(i, j int) bool { return methods[i].name < methods[j].name })
+
+	return render(p.name, typeName, imports, methods)
+}
+
+// lookup finds the interface type with the name and the file declaring it.
+func (p *pkg) lookup(name string) (*ast.InterfaceType, *ast.File, error) {
+	for _, f := range p.files {
+		for _, decl := range f.Decls {
+			gen, ok := decl.(*ast.GenDecl)
+			if !ok || gen.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range gen.Specs {
+				ts := spec.(*ast.TypeSpec)
+				if ts.Name.Name != name {
+					continue
+				}
+				iface, ok := ts.Type.(*ast.InterfaceType)
+				if !ok {
+					return nil, nil, fmt.Errorf("%s is not an interface", name)
+				}
+				if typeParams(ts) {
+					return nil, nil, fmt.Errorf("generic interface %s is not supported", name)
+				}
+				return iface, f, nil
+			}
+		}
+	}
+	return nil, nil, fmt.Errorf("interface %s not found in package %s", name, p.name)
+}
+
+// typeParams reports whether the type spec declares type parameters, without
+// depending on the go1.18 AST field.
+func typeParams(ts *ast.TypeSpec) bool {
+	var buf bytes.Buffer
+	_ = printer.Fprint(&buf, token.NewFileSet(), ts)
+	return strings.HasPrefix(buf.String(), ts.Name.Name+"[")
+}
+
+// methods returns the method set of the named interface, expanding interfaces embedded
+// from the same package, and records the imports used by the method signatures.
+func (p *pkg) methods(name string, imports map[string]string, seen map[string]bool) ([]method, error) {
+	if seen[name] {
+		return nil, nil
+	}
+	seen[name] = true
+
+	iface, file, err := p.lookup(name)
+	if err != nil {
+		return nil, err
+	}
+
+	var methods []method
+	for _, field := range iface.Methods.List {
+		switch typ := field.Type.(type) {
+		case *ast.FuncType:
+			for _, n := range field.Names {
+				m, err := p.method(n.Name, typ, file, imports)
+				if err != nil {
+					return nil, err
+				}
+				methods = append(methods, m)
+			}
+		case *ast.Ident:
+			if typ.Name == "error" {
+				methods = append(methods, method{name: "Error", results: []string{"string"}})
+				continue
+			}
+			embedded, err := p.methods(typ.Name, imports, seen)
+			if err != nil {
+				return nil, err
+			}
+			methods = append(methods, embedded...)
+		default:
+			return nil, fmt.Errorf("%s: cannot expand embedded interface %s", name, p.expr(field.Type))
+		}
+	}
+	return methods, nil
+}
+
+func (p *pkg) method(name string, typ *ast.FuncType, file *ast.File, imports map[string]string) (method, error) {
+	m := method{name: name}
+	ast.Inspect(typ, func(n ast.Node) bool {
+		if sel, ok := n.(*ast.SelectorExpr); ok {
+			if x, ok := sel.X.(*ast.Ident); ok {
+				if path, ok := importPath(file, x.Name); ok {
+					imports[x.Name] = path
+				}
+			}
+		}
+		return true
+	})
+
+	for _, field := range typ.Params.List {
+		t := field.Type
+		variadic := false
+		if ell, ok := t.(*ast.Ellipsis); ok {
+			t, variadic = ell.Elt, true
+		}
+		n := len(field.Names)
+		if n == 0 {
+			n = 1
+		}
+		for i := 0; i < n; i++ {
+			m.params = append(m.params, param{
+				name:     "a" + strconv.Itoa(len(m.params)),
+				typ:      p.expr(t),
+				variadic: variadic,
+			})
+		}
+	}
+
+	if typ.Results != nil {
+		for _, field := range typ.Results.List {
+			n := len(field.Names)
+			if n == 0 {
+				n = 1
+			}
+			for i := 0; i < n; i++ {
+				m.results = append(m.results, p.expr(field.Type))
+			}
+		}
+	}
+	return m, nil
+}
+
+func (p *pkg) expr(e ast.Expr) string {
+	var buf bytes.Buffer
+	_ = printer.Fprint(&buf, p.fset, e)
+	return buf.String()
+}
+
+// importPath returns the path of the import in file referred to by name.
+func importPath(file *ast.File, name string) (string, bool) {
+	for _, spec := range file.Imports {
+		path, err := strconv.Unquote(spec.Path.Value)
+		if err != nil {
+			continue
+		}
+		if spec.Name != nil {
+			if spec.Name.Name == name {
+				return path, true
+			}
+			continue
+		}
+		if defaultName(path) == name {
+			return path, true
+		}
+	}
+	return "", false
+}
+
+// defaultName guesses the package name of an import path from its last element.
+func defaultName(importPath string) string {
+	name := path.Base(versionSuffix.ReplaceAllString(importPath, ""))
+	name = strings.TrimPrefix(name, "go-")
+	if i := strings.IndexAny(name, ".-"); i >= 0 {
+		name = name[:i]
+	}
+	return name
+}
+
+func render(pkgName, typeName string, imports map[string]string, methods []method) ([]byte, error) {
+	var b bytes.Buffer
+	safe := "Safe" + typeName
+
+	fmt.Fprintf(&b, "// Code generated by cpanicgen; DO NOT EDIT.\n\npackage %s\n\n", pkgName)
+
+	b.WriteString("import (\n")
+	names := make([]string, 0, len(imports))
+	for name := range imports {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if name == defaultName(imports[name]) {
+			fmt.Fprintf(&b, "\t%q\n", imports[name])
+		} else {
+			fmt.Fprintf(&b, "\t%s %q\n", name, imports[name])
+		}
+	}
+	fmt.Fprintf(&b, "\n\t%q\n)\n\n", cpanicImport)
+
+	fmt.Fprintf(&b, `// %[1]s is a `+"`%[2]s`"+` whose methods recover panics raised by the delegate.
+// Each recovered panic is tagged with the method name and reported to the handler.
+type %[1]s struct {
+	next    %[2]s
+	handler cpanic.Handler
+}
+
+// New%[1]s returns a `+"`%[2]s`"+` that delegates to next, reporting panics to h.
+func New%[1]s(next %[2]s, h cpanic.Handler) *%[1]s {
+	return &%[1]s{next: next, handler: h}
+}
+
+var _ %[2]s = (*%[1]s)(nil)
+`, safe, typeName)
+
+	for _, m := range methods {
+		renderMethod(&b, safe, typeName, m)
+	}
+
+	src, err := format.Source(b.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("formatting generated code: %v", err)
+	}
+	return src, nil
+}
+
+func renderMethod(b *bytes.Buffer, safe, typeName string, m method) {
+	params := make([]string, len(m.params))
+	args := make([]string, len(m.params))
+	for i, p := range m.params {
+		if p.variadic {
+			params[i] = p.name + " ..." + p.typ
+			args[i] = p.name + "..."
+		} else {
+			params[i] = p.name + " " + p.typ
+			args[i] = p.name
+		}
+	}
+
+	returnsError := len(m.results) > 0 && m.results[len(m.results)-1] == "error"
+	results := strings.Join(m.results, ", ")
+	if returnsError {
+		named := make([]string, len(m.results))
+		for i, r := range m.results[:len(m.results)-1] {
+			named[i] = "_ " + r
+		}
+		named[len(named)-1] = "err error"
+		results = "(" + strings.Join(named, ", ") + ")"
+	} else if len(m.results) > 1 {
+		results = "(" + results + ")"
+	}
+
+	if returnsError {
+		fmt.Fprintf(b, "\n// %s calls the delegate, returning any panic as the error.\n", m.name)
+	} else {
+		fmt.Fprintf(b, "\n// %s calls the delegate, reporting any panic before re-panicking.\n", m.name)
+	}
+	fmt.Fprintf(b, "func (s *%s) %s(%s) %s {\n", safe, m.name, strings.Join(params, ", "), results)
+	b.WriteString("\tdefer func() {\n\t\tif value := recover(); value != nil {\n")
+	b.WriteString("\t\t\tp := cpanic.New(value)\n")
+	fmt.Fprintf(b, "\t\t\tp.SetAttr(%q, %q)\n", methodAttr, typeName+"."+m.name)
+	b.WriteString("\t\t\tcpanic.Deliver(p, s.handler)\n")
+	if returnsError {
+		b.WriteString("\t\t\terr = p\n")
+	} else {
+		b.WriteString("\t\t\tpanic(value)\n")
+	}
+	b.WriteString("\t\t}\n\t}()\n")
+
+	call := fmt.Sprintf("s.next.%s(%s)", m.name, strings.Join(args, ", "))
+	if len(m.results) == 0 {
+		fmt.Fprintf(b, "\t%s\n}\n", call)
+	} else {
+		fmt.Fprintf(b, "\treturn %s\n}\n", call)
+	}
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerate(t *testing.T) {
+	dir := filepath.Join("internal", "example")
+	want, err := ioutil.ReadFile(filepath.Join(dir, "store_cpanic.go"))
+	require.NoError(t, err)
+
+	got, err := generate(dir, "Store")
+	require.NoError(t, err)
+	assert.Equal(t, string(want), string(got), "run go generate ./... to update the example")
+}
+
+func TestGenerateErrors(t *testing.T) {
+	dir := filepath.Join("testdata", "bad")
+	cases := []struct {
+		typeName string
+		want     string
+	}{
+		{"Missing", "interface Missing not found in package bad"},
+		{"NotInterface", "NotInterface is not an interface"},
+		{"Embeds", "Embeds: cannot expand embedded interface io.Reader"},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.typeName, func(t *testing.T) {
+			_, err := generate(dir, tc.typeName)
+			assert.EqualError(t, err, tc.want)
+		})
+	}
+}
+
+func TestDefaultName(t *testing.T) {
+	cases := map[string]string{
+		"context":                      "context",
+		"net/http":                     "http",
+		"github.com/go-redis/redis/v8": "redis",
+		"gopkg.in/yaml.v3":             "yaml",
+		"github.com/mattn/go-sqlite3":  "sqlite3",
+	}
+	for path, want := range cases {
+		assert.Equal(t, want, defaultName(path), path)
+	}
+}
@@ -0,0 +1,26 @@
+// example declares an interface decorated by cpanicgen, used to test the generator.
+package example
+
+import (
+	"context"
+	"io"
+)
+
+//go:generate go run github.com/demosdemon/cpanic/cmd/cpanicgen -type Store
+
+// Closer is embedded in `Store` to test the expansion of embedded interfaces.
+type Closer interface {
+	Close() error
+}
+
+// Store is a key/value store.
+type Store interface {
+	Closer
+
+	Get(ctx context.Context, key string) ([]byte, error)
+	Put(ctx context.Context, key string, value []byte) error
+	Keys(prefix string, limit int) []string
+	Delete(ctx context.Context, keys ...string) (n int, err error)
+	Copy(w io.Writer, key string) (int64, error)
+	Reset()
+}
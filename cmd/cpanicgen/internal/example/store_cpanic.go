@@ -0,0 +1,115 @@
+// Code generated by cpanicgen; DO NOT EDIT.
+
+package example
+
+import (
+	"context"
+	"io"
+
+	"github.com/demosdemon/cpanic"
+)
+
+// SafeStore is a `Store` whose methods recover panics raised by the delegate.
+// Each recovered panic is tagged with the method name and reported to the handler.
+type SafeStore struct {
+	next    Store
+	handler cpanic.Handler
+}
+
+// NewSafeStore returns a `Store` that delegates to next, reporting panics to h.
+func NewSafeStore(next Store, h cpanic.Handler) *SafeStore {
+	return &SafeStore{next: next, handler: h}
+}
+
+var _ Store = (*SafeStore)(nil)
+
+// Close calls the delegate, returning any panic as the error.
+func (s *SafeStore) Close() (err error) {
+	defer func() {
+		if value := recover(); value != nil {
+			p := cpanic.New(value)
+			p.SetAttr("cpanic.method", "Store.Close")
+			cpanic.Deliver(p, s.handler)
+			err = p
+		}
+	}()
+	return s.next.Close()
+}
+
+// Copy calls the delegate, returning any panic as the error.
+func (s *SafeStore) Copy(a0 io.Writer, a1 string) (_ int64, err error) {
+	defer func() {
+		if value := recover(); value != nil {
+			p := cpanic.New(value)
+			p.SetAttr("cpanic.method", "Store.Copy")
+			cpanic.Deliver(p, s.handler)
+			err = p
+		}
+	}()
+	return s.next.Copy(a0, a1)
+}
+
+// Delete calls the delegate, returning any panic as the error.
+func (s *SafeStore) Delete(a0 context.Context, a1 ...string) (_ int, err error) {
+	defer func() {
+		if value := recover(); value != nil {
+			p := cpanic.New(value)
+			p.SetAttr("cpanic.method", "Store.Delete")
+			cpanic.Deliver(p, s.handler)
+			err = p
+		}
+	}()
+	return s.next.Delete(a0, a1...)
+}
+
+// Get calls the delegate, returning any panic as the error.
+func (s *SafeStore) Get(a0 context.Context, a1 string) (_ []byte, err error) {
+	defer func() {
+		if value := recover(); value != nil {
+			p := cpanic.New(value)
+			p.SetAttr("cpanic.method", "Store.Get")
+			cpanic.Deliver(p, s.handler)
+			err = p
+		}
+	}()
+	return s.next.Get(a0, a1)
+}
+
+// Keys calls the delegate, reporting any panic before re-panicking.
+func (s *SafeStore) Keys(a0 string, a1 int) []string {
+	defer func() {
+		if value := recover(); value != nil {
+			p := cpanic.New(value)
+			p.SetAttr("cpanic.method", "Store.Keys")
+			cpanic.Deliver(p, s.handler)
+			panic(value)
+		}
+	}()
+	return s.next.Keys(a0, a1)
+}
+
+// Put calls the delegate, returning any panic as the error.
+func (s *SafeStore) Put(a0 context.Context, a1 string, a2 []byte) (err error) {
+	defer func() {
+		if value := recover(); value != nil {
+			p := cpanic.New(value)
+			p.SetAttr("cpanic.method", "Store.Put")
+			cpanic.Deliver(p, s.handler)
+			err = p
+		}
+	}()
+	return s.next.Put(a0, a1, a2)
+}
+
+// Reset calls the delegate, reporting any panic before re-panicking.
+func (s *SafeStore) Reset() {
+	defer func() {
+		if value := recover(); value != nil {
+			p := cpanic.New(value)
+			p.SetAttr("cpanic.method", "Store.Reset")
+			cpanic.Deliver(p, s.handler)
+			panic(value)
+		}
+	}()
+	s.next.Reset()
+}
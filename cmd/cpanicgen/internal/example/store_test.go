@@ -0,0 +1,55 @@
+package example_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/demosdemon/cpanic"
+	"github.com/demosdemon/cpanic/cmd/cpanicgen/internal/example"
+)
+
+type panickyStore struct{}
+
+func (panickyStore) Close() error { return nil }
+func (panickyStore) Get(ctx context.Context, key string) ([]byte, error) {
+	panic("not at a disco")
+}
+func (panickyStore) Put(ctx context.Context, key string, value []byte) error { return nil }
+func (panickyStore) Keys(prefix string, limit int) []string                  { panic("keys") }
+func (panickyStore) Delete(ctx context.Context, keys ...string) (int, error) {
+	return len(keys), nil
+}
+func (panickyStore) Copy(w io.Writer, key string) (int64, error) { return 0, io.EOF }
+func (panickyStore) Reset()                                      {}
+
+func TestSafeStore(t *testing.T) {
+	var handled []*cpanic.Panic
+	s := example.NewSafeStore(panickyStore{}, func(p *cpanic.Panic) { handled = append(handled, p) })
+
+	v, err := s.Get(context.Background(), "key")
+	assert.Nil(t, v)
+	assert.EqualError(t, err, "panic: not at a disco")
+	if assert.Len(t, handled, 1) {
+		assert.Equal(t, "Store.Get", handled[0].Attrs["cpanic.method"])
+		assert.True(t, errors.Is(err, cpanic.ErrPanic))
+	}
+
+	n, err := s.Delete(context.Background(), "a", "b")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, n)
+
+	_, err = s.Copy(nil, "key")
+	assert.Equal(t, io.EOF, err)
+
+	assert.PanicsWithValue(t, "keys", func() { s.Keys("", 0) })
+	if assert.Len(t, handled, 2) {
+		assert.Equal(t, "Store.Keys", handled[1].Attrs["cpanic.method"])
+	}
+
+	assert.NotPanics(t, s.Reset)
+	assert.NoError(t, s.Close())
+}
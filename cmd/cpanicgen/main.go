@@ -0,0 +1,46 @@
+// cpanicgen generates panic-safe decorators for interfaces, so entire service
+// interfaces can be guarded at their boundaries. Run it with `go generate`:
+//
+//	//go:generate go run github.com/demosdemon/cpanic/cmd/cpanicgen -type Store
+//
+// For an interface `Store`, it writes `store_cpanic.go` declaring a `SafeStore` type
+// and a `NewSafeStore(next Store, h cpanic.Handler) *SafeStore` constructor. Every
+// method of `SafeStore` calls the delegate, recovering any panic; the recovered
+// `*cpanic.Panic` is tagged with the method name and reported to the handler. Methods
+// whose last result is an error return the panic as that error; other methods re-panic
+// with the original value once it has been reported.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+func main() {
+	typeName := flag.String("type", "", "name of the interface to decorate (required)")
+	output := flag.String("output", "", "output file name; default <type>_cpanic.go")
+	dir := flag.String("dir", ".", "directory of the package declaring the interface")
+	flag.Parse()
+
+	if *typeName == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if *output == "" {
+		*output = strings.ToLower(*typeName) + "_cpanic.go"
+	}
+
+	src, err := generate(*dir, *typeName)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "cpanicgen:", err)
+		os.Exit(1)
+	}
+	if err := ioutil.WriteFile(filepath.Join(*dir, *output), src, 0o644); err != nil {
+		fmt.Fprintln(os.Stderr, "cpanicgen:", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,9 @@
+package bad
+
+import "io"
+
+type NotInterface struct{}
+
+type Embeds interface {
+	io.Reader
+}
@@ -0,0 +1,163 @@
+//go:build !tinygo && !cpanic_minimal
+// +build !tinygo,!cpanic_minimal
+
+package cpanic
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os/exec"
+	"sync"
+	"time"
+)
+
+// ExitCodeAttr is the attribute holding the exit code of a child process that crashed.
+const ExitCodeAttr = "exit_code"
+
+// maxCapturedStderr is the number of trailing bytes of a child's stderr kept to look
+// for a crash dump.
+const maxCapturedStderr = 4 << 20
+
+// Cmd is an `exec.Cmd` that turns the crash of a Go child process into a `*Panic`, so a
+// parent process gets structured crash info about its workers. Use `Start`, `Wait`,
+// `Run`, `Output`, and `CombinedOutput` on the `Cmd` itself rather than on the embedded
+// `exec.Cmd`, which would bypass the capture. The stderr of the child is still written
+// to `Stderr`, if set.
+type Cmd struct {
+	*exec.Cmd
+	stderr tailBuffer
+}
+
+// Command is like `exec.CommandContext`, returning a `*Cmd`.
+func Command(ctx context.Context, name string, args ...string) *Cmd {
+	return &Cmd{Cmd: exec.CommandContext(ctx, name, args...)}
+}
+
+// Start starts the command, capturing its stderr.
+func (c *Cmd) Start() error {
+	c.stderr.max = maxCapturedStderr
+	if c.Stderr == nil {
+		c.Cmd.Stderr = &c.stderr
+	} else {
+		c.Cmd.Stderr = io.MultiWriter(c.Stderr, &c.stderr)
+	}
+	return c.Cmd.Start()
+}
+
+// Wait waits for the command to exit. If the child exited with an error and its stderr
+// ends with a Go crash dump, the parsed `*Panic` is returned, with the
+// `ExitCodeAttr` attribute set to the exit code of the child; otherwise, the error from
+// `exec.Cmd.Wait` is returned.
+func (c *Cmd) Wait() error {
+	err := c.Cmd.Wait()
+	var exitErr *exec.ExitError
+	if err == nil || !errors.As(err, &exitErr) {
+		return err
+	}
+
+	var p *Panic
+	s := NewScanner(bytes.NewReader(c.stderr.Bytes()))
+	for s.Scan() {
+		p = s.Panic()
+	}
+	if p == nil {
+		return err
+	}
+	p.Stamp(time.Now())
+	p.SetAttr(ExitCodeAttr, exitErr.ExitCode())
+	return p
+}
+
+// Run starts the command and waits for it to exit.
+func (c *Cmd) Run() error {
+	if err := c.Start(); err != nil {
+		return err
+	}
+	return c.Wait()
+}
+
+// Output runs the command and returns its standard output.
+func (c *Cmd) Output() ([]byte, error) {
+	if c.Stdout != nil {
+		return nil, errors.New("exec: Stdout already set")
+	}
+	var stdout bytes.Buffer
+	c.Stdout = &stdout
+	err := c.Run()
+	return stdout.Bytes(), err
+}
+
+// CombinedOutput runs the command and returns its combined standard output and
+// standard error.
+func (c *Cmd) CombinedOutput() ([]byte, error) {
+	if c.Stdout != nil {
+		return nil, errors.New("exec: Stdout already set")
+	}
+	if c.Stderr != nil {
+		return nil, errors.New("exec: Stderr already set")
+	}
+	var b syncBuffer
+	c.Stdout = &b
+	c.Stderr = &b
+	err := c.Run()
+	return b.Bytes(), err
+}
+
+// tailBuffer keeps the last max bytes written to it, in a ring so that a chatty child
+// does not cost a copy of the whole buffer on every write.
+type tailBuffer struct {
+	mu  sync.Mutex
+	buf []byte
+	off int // start of the oldest byte once buf is full
+	max int
+}
+
+func (b *tailBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	n := len(p)
+	if len(p) > b.max {
+		p = p[len(p)-b.max:]
+	}
+	if room := b.max - len(b.buf); room > 0 {
+		if room > len(p) {
+			room = len(p)
+		}
+		b.buf = append(b.buf, p[:room]...)
+		p = p[room:]
+	}
+	for len(p) > 0 {
+		k := copy(b.buf[b.off:], p)
+		p = p[k:]
+		b.off = (b.off + k) % b.max
+	}
+	return n, nil
+}
+
+func (b *tailBuffer) Bytes() []byte {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	out := make([]byte, 0, len(b.buf))
+	out = append(out, b.buf[b.off:]...)
+	return append(out, b.buf[:b.off]...)
+}
+
+// syncBuffer is a `bytes.Buffer` safe for the concurrent writes of stdout and stderr.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) Bytes() []byte {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Bytes()
+}
@@ -0,0 +1,119 @@
+//go:build !tinygo && !cpanic_minimal
+// +build !tinygo,!cpanic_minimal
+
+package cpanic_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/demosdemon/cpanic"
+)
+
+// helperCommand runs the test binary as a child process executing TestHelperProcess
+// with the given behavior.
+func helperCommand(behavior string) *cpanic.Cmd {
+	cmd := cpanic.Command(context.Background(), os.Args[0], "-test.run=^TestHelperProcess$")
+	cmd.Env = append(os.Environ(), "CPANIC_HELPER_PROCESS="+behavior)
+	return cmd
+}
+
+func TestHelperProcess(t *testing.T) {
+	switch os.Getenv("CPANIC_HELPER_PROCESS") {
+	case "":
+		return
+	case "panic":
+		fmt.Fprintln(os.Stderr, "starting worker")
+		go func() {
+			var m map[string]int
+			m["a"] = 1
+		}()
+		select {}
+	case "exit":
+		fmt.Fprintln(os.Stderr, "giving up")
+		os.Exit(3)
+	case "ok":
+		fmt.Println("hello")
+		os.Exit(0)
+	case "harness":
+		cpanic.Harness(func() {
+			if os.Getenv(cpanic.HarnessEnv) != "2" {
+				panic("not at a disco")
+			}
+			os.Exit(0)
+		}, harnessOptions(5)...)
+	case "noisy-panic":
+		line := strings.Repeat("x", 999) + "\n"
+		for i := 0; i < 5<<10; i++ {
+			fmt.Fprint(os.Stderr, line)
+		}
+		go panic("not at a disco")
+		select {}
+	case "harness-killed":
+		cpanic.Harness(func() {
+			if os.Getenv(cpanic.HarnessEnv) == "0" {
+				self, _ := os.FindProcess(os.Getpid())
+				_ = self.Kill()
+				select {}
+			}
+			os.Exit(0)
+		}, harnessOptions(5)...)
+	case "harness-give-up":
+		cpanic.Harness(func() { panic("not at a disco") }, harnessOptions(1)...)
+	}
+}
+
+func TestCommandPanic(t *testing.T) {
+	var stderr bytes.Buffer
+	cmd := helperCommand("panic")
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+
+	var p *cpanic.Panic
+	require.True(t, errors.As(err, &p), "%v", err)
+	assert.Equal(t, "assignment to entry in nil map", p.Value)
+	assert.Equal(t, 2, p.Attrs[cpanic.ExitCodeAttr])
+	assert.NotEmpty(t, p.ID)
+	assert.False(t, p.Time.IsZero())
+	if f, ok := p.Culprit(); assert.True(t, ok) {
+		assert.Equal(t, "github.com/demosdemon/cpanic_test.TestHelperProcess.func1", f.Function)
+	}
+	assert.Contains(t, stderr.String(), "starting worker\n")
+}
+
+func TestCommandNoisyPanic(t *testing.T) {
+	cmd := helperCommand("noisy-panic")
+	cmd.Stderr = ioutil.Discard
+	err := cmd.Run()
+
+	var p *cpanic.Panic
+	require.True(t, errors.As(err, &p), "%v", err)
+	assert.Equal(t, "not at a disco", p.Value)
+}
+
+func TestCommandExit(t *testing.T) {
+	err := helperCommand("exit").Run()
+	var exitErr *exec.ExitError
+	require.True(t, errors.As(err, &exitErr), "%v", err)
+	assert.Equal(t, 3, exitErr.ExitCode())
+}
+
+func TestCommandOutput(t *testing.T) {
+	out, err := helperCommand("ok").Output()
+	assert.NoError(t, err)
+	assert.Equal(t, "hello\n", string(out))
+
+	out, err = helperCommand("panic").CombinedOutput()
+	assert.True(t, errors.Is(err, cpanic.ErrPanic), "%v", err)
+	assert.Contains(t, string(out), "starting worker\npanic: assignment to entry in nil map")
+}
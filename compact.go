@@ -0,0 +1,47 @@
+package cpanic
+
+import (
+	"sync"
+	"time"
+)
+
+// Compactor is a `Store` that evicts the panics its retention policy no longer keeps,
+// such as a `*History` with `WithMaxAge`, when compacted.
+type Compactor interface {
+	Store
+	// Compact applies the retention policy of the store.
+	Compact() error
+}
+
+// CompactEvery compacts the store in the background at every interval, so a
+// long-running service does not hold on to expired panics between writes. Compaction
+// errors are passed to onError, if provided. The returned function stops the
+// compaction and waits for a running one to finish.
+func CompactEvery(store Compactor, interval time.Duration, onError func(error)) (stop func()) {
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if err := store.Compact(); err != nil && onError != nil {
+					onError(err)
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			close(done)
+			wg.Wait()
+		})
+	}
+}
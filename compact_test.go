@@ -0,0 +1,45 @@
+package cpanic_test
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/demosdemon/cpanic"
+)
+
+type compactor struct {
+	*cpanic.History
+	mu    sync.Mutex
+	calls int
+}
+
+func (c *compactor) Compact() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.calls++
+	return errors.New("disk full")
+}
+
+func (c *compactor) count() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.calls
+}
+
+func TestCompactEvery(t *testing.T) {
+	c := &compactor{History: cpanic.NewHistory(0)}
+	errs := make(chan error, 100)
+	stop := cpanic.CompactEvery(c, time.Millisecond, func(err error) { errs <- err })
+
+	assert.Eventually(t, func() bool { return c.count() >= 2 }, time.Second, time.Millisecond)
+	stop()
+	stop()
+	n := c.count()
+	time.Sleep(5 * time.Millisecond)
+	assert.Equal(t, n, c.count(), "no compaction after stop")
+	assert.EqualError(t, <-errs, "disk full")
+}
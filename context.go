@@ -0,0 +1,116 @@
+package cpanic
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/demosdemon/cpanic/chaos"
+)
+
+type taskKey struct{}
+
+// NewContext returns a copy of ctx whose `Task` also reports panics to the handler and
+// attaches the attributes, given as alternating key/value pairs, to them. Keys that are
+// not strings are formatted with `fmt.Sprint`, and a final key without a value is given
+// a nil value. Panics recovered with `RecoverCtx` or `ForwardCtx` are reported with the
+// handlers and attributes of every enclosing `NewContext`, so reporting can be
+// configured per request through existing context plumbing.
+func NewContext(ctx context.Context, handler Handler, keyvals ...interface{}) context.Context {
+	t := TaskFromContext(ctx).WithHandler(handler)
+	for i := 0; i < len(keyvals); i += 2 {
+		key, ok := keyvals[i].(string)
+		if !ok {
+			key = fmt.Sprint(keyvals[i])
+		}
+		var value interface{}
+		if i+1 < len(keyvals) {
+			value = keyvals[i+1]
+		}
+		t = t.WithAttr(key, value)
+	}
+	return ContextWithTask(ctx, t)
+}
+
+// ContextWithTask returns a copy of ctx carrying the task.
+func ContextWithTask(ctx context.Context, t *Task) context.Context {
+	return context.WithValue(ctx, taskKey{}, t)
+}
+
+// TaskFromContext returns the task carried by ctx, or nil if it has none.
+func TaskFromContext(ctx context.Context) *Task {
+	t, _ := ctx.Value(taskKey{}).(*Task)
+	return t
+}
+
+// RecoverCtx is a defer function that recovers from a panic and reports it with the
+// task carried by ctx, applying the severity set by `WithSeverity` and the attributes
+// of the extractors registered with `RegisterAttrExtractor`. If ctx has no task,
+// `recover` is never called and the panic is allowed to continue, as with `Recover`.
+func RecoverCtx(ctx context.Context) {
+	t := TaskFromContext(ctx)
+	if t == nil {
+		return
+	}
+
+	if value := recover(); value != nil {
+		handleCtx(ctx, t, value)
+	}
+}
+
+//go:noinline
+func handleCtx(ctx context.Context, t *Task, value interface{}) {
+	p := New(value)
+	_ = withContextSeverity(ctx, p)
+	extractAttrs(ctx, t, p)
+	reportTo(p, t.Handle)
+	settle(p)
+}
+
+// ForwardCtx is a defer function that recovers from a panic, reports it with the task
+// carried by ctx, if any, and sets the provided error pointer to the `*Panic` as
+// `Forward` does. The severity set by `WithSeverity` and the attributes of the
+// extractors registered with `RegisterAttrExtractor` are applied. If the error pointer
+// is nil, `recover` is never called and the panic is allowed to continue.
+func ForwardCtx(ctx context.Context, errPtr *error) {
+	if errPtr == nil {
+		return
+	}
+
+	if value := recover(); value != nil {
+		forwardCtx(ctx, errPtr, value)
+	}
+}
+
+//go:noinline
+func forwardCtx(ctx context.Context, errPtr *error, value interface{}) {
+	p := New(value)
+	_ = withContextSeverity(ctx, p)
+	t := TaskFromContext(ctx)
+	extractAttrs(ctx, t, p)
+	reportTo(p, t.Handle)
+	settle(p)
+	if *errPtr == nil {
+		*errPtr = p
+	}
+}
+
+// goContext is like `Go` but applies the severity set by `WithSeverity` on ctx to a
+// recovered panic before the `OnRecovered` and `OnFatal` hooks are called.
+func goContext(ctx context.Context, fn func() error) (err error) {
+	defer forwardSeverity(ctx, &err)
+	chaos.Point("cpanic.Go")
+	return fn()
+}
+
+// forwardSeverity is a defer function like `Forward` that applies the severity carried
+// by ctx.
+func forwardSeverity(ctx context.Context, errPtr *error) {
+	if value := recover(); value != nil {
+		p := New(value)
+		_ = withContextSeverity(ctx, p)
+		settle(p)
+		if *errPtr == nil {
+			*errPtr = p
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package cpanic_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/demosdemon/cpanic"
+)
+
+func TestRecoverCtx(t *testing.T) {
+	var outer, inner collector
+	ctx := cpanic.NewContext(context.Background(), outer.handle, "request_id", "abc", "user")
+	ctx = cpanic.NewContext(ctx, inner.handle, 1, "one")
+	ctx = cpanic.WithSeverity(ctx, cpanic.SeverityWarning)
+
+	func() {
+		defer cpanic.RecoverCtx(ctx)
+		panic("not at a disco")
+	}()
+
+	for _, c := range []*collector{&outer, &inner} {
+		panics := c.get()
+		require.Len(t, panics, 1)
+		p := panics[0]
+		assert.Equal(t, "not at a disco", p.Value)
+		assert.Equal(t, cpanic.SeverityWarning, p.Severity)
+		assert.Equal(t, map[string]interface{}{"request_id": "abc", "user": nil, "1": "one"}, p.Attrs)
+	}
+}
+
+func TestRecoverCtxWithoutTask(t *testing.T) {
+	assert.PanicsWithValue(t, "not at a disco", func() {
+		defer cpanic.RecoverCtx(context.Background())
+		panic("not at a disco")
+	})
+}
+
+func TestForwardCtx(t *testing.T) {
+	var c collector
+	ctx := cpanic.NewContext(context.Background(), c.handle, "request_id", "abc")
+
+	err := func() (err error) {
+		defer cpanic.ForwardCtx(ctx, &err)
+		panic("not at a disco")
+	}()
+
+	var p *cpanic.Panic
+	require.True(t, errors.As(err, &p))
+	assert.Equal(t, "abc", p.Attrs["request_id"])
+	assert.Equal(t, []*cpanic.Panic{p}, c.get())
+
+	err = func() (err error) {
+		defer cpanic.ForwardCtx(context.Background(), &err)
+		panic("not at a disco")
+	}()
+	assert.Error(t, err)
+}
+
+func TestTaskFromContext(t *testing.T) {
+	assert.Nil(t, cpanic.TaskFromContext(context.Background()))
+
+	task := cpanic.NewTask(nil)
+	ctx := cpanic.ContextWithTask(context.Background(), task)
+	assert.Same(t, task, cpanic.TaskFromContext(ctx))
+}
+
+func TestContextWithStateCapture(t *testing.T) {
+	var got *cpanic.Panic
+	ctx := cpanic.NewContext(context.Background(), func(p *cpanic.Panic) { got = p })
+	ctx = cpanic.ContextWithStateCapture(ctx, func() interface{} { return "draining" })
+
+	func() {
+		defer cpanic.RecoverCtx(ctx)
+		panic("not at a disco")
+	}()
+	if assert.NotNil(t, got) {
+		assert.Equal(t, "draining", got.Attrs[cpanic.StateAttr])
+	}
+}
@@ -9,70 +9,213 @@
 package cpanic
 
 import (
+	"errors"
 	"fmt"
-	"runtime"
+	"reflect"
 	"time"
+
+	"github.com/demosdemon/cpanic/chaos"
 )
 
 // Handler is a function that handles a panic.
 type Handler func(p *Panic)
 
+// Handle calls the handler with the panic unless the handler is nil. Suppressions and
+// `PipelineStats` are applied once per panic, where it is recovered, not by `Handle`, so
+// handlers wrapping other handlers do not count a panic more than once.
+func (h Handler) Handle(p *Panic) {
+	if h == nil {
+		return
+	}
+	h(p)
+}
+
+// Deliver reports a panic recovered outside of this package, such as by the deferred
+// function of an integration, the way `Recover` does: the handler is called unless the
+// panic is `Suppressed`, the `OnRecovered` and `OnFatal` hooks are called, and the
+// panic is raised again if it is `Unrecoverable`. The panic should be created by `New`
+// where it was recovered, and its attributes and severity set before it is delivered.
+func Deliver(p *Panic, h Handler) {
+	reportTo(p, h)
+	settle(p)
+}
+
+// reportTo calls the handler with a panic recovered by this package unless the panic is
+// `Suppressed` and has not been escalated by `EscalateSuppressed`.
+func reportTo(p *Panic, h Handler) {
+	if h == nil || !deliver(p) {
+		return
+	}
+	countInvoked()
+	h(p)
+}
+
+// deliver reports whether the panic should be delivered to handlers, tagging it with
+// `SuppressedAttr` if it is a suppressed panic that has been escalated.
+func deliver(p *Panic) bool {
+	if Suppressed(p) {
+		n, ok := escalate(p)
+		if !ok {
+			countDropped()
+			return false
+		}
+		p.SetAttr(SuppressedAttr, n)
+	}
+	return true
+}
+
 // Recover is a defer function that recovers from a panic and calls the handler. If no
 // handler is provided, `recover` is never called and the panic is allowed to continue.
+// When no panic occurs, `Recover` does not allocate.
 func Recover(handler Handler) {
 	if handler == nil {
 		return
 	}
 
 	if value := recover(); value != nil {
-		handler(New(value))
+		handle(handler, value)
+	}
+}
+
+// handle is the slow path of `Recover`, kept out of line so the common path stays small.
+//
+//go:noinline
+func handle(handler Handler, value interface{}) {
+	p := New(value)
+	reportTo(p, handler)
+	settle(p)
+}
+
+// RecoverIf is a defer function like `Recover` that only recovers the panics whose value
+// is accepted by the predicate, and panics again with the value otherwise, so a
+// recovery boundary can catch expected panics, such as validation bailouts, while
+// programmer errors still crash loudly. A nil predicate accepts every value.
+func RecoverIf(pred func(value interface{}) bool, handler Handler) {
+	if handler == nil {
+		return
+	}
+
+	if value := recover(); value != nil {
+		handleIf(pred, handler, value)
+	}
+}
+
+//go:noinline
+func handleIf(pred func(value interface{}) bool, handler Handler, value interface{}) {
+	if pred != nil && !pred(value) {
+		panic(value)
 	}
+	p := New(value)
+	reportTo(p, handler)
+	settle(p)
 }
 
 // Go calls the provided function and recovers from any panics. If the function panics,
 // the error returned will be a `*Panic` type otherwise the error returned, if any, will
-// be from the function.
+// be from the function. `Go` is the `chaos` trigger point named "cpanic.Go".
 func Go(fn func() error) (err error) {
 	defer Forward(&err)
+	chaos.Point("cpanic.Go")
 	return fn()
 }
 
 // Forward is a defer function that recovers from a panic and sets the provided error
 // pointer to a `*Panic` type. If the error pointer is nil, `recover` is never called
-// and the panic is allowed to continue.
+// and the panic is allowed to continue. When no panic occurs, `Forward` does not
+// allocate and costs only a few nanoseconds more than an empty deferred function.
 func Forward(errPtr *error) {
 	if errPtr == nil {
 		return
 	}
 
 	if value := recover(); value != nil {
-		if *errPtr == nil {
-			*errPtr = New(value)
-		}
+		forward(errPtr, value)
+	}
+}
+
+// forward is the slow path of `Forward`, kept out of line so the common path stays
+// small.
+//
+//go:noinline
+func forward(errPtr *error, value interface{}) {
+	p := New(value)
+	settle(p)
+	if *errPtr == nil {
+		*errPtr = p
 	}
 }
 
+// ErrPanic is a sentinel error matched by every `*Panic`, so that
+// `errors.Is(err, cpanic.ErrPanic)` reports whether an error came from a recovered
+// panic regardless of the panic value.
+var ErrPanic = errors.New("recovered panic")
+
 // Panic is an error type that is returned when a panic is recovered.
 type Panic struct {
+	// ID uniquely identifies the panic, so an error id reported by a user can be matched
+	// to the exact crash. `New` generates a ULID, which sorts by time.
+	ID string `json:"id" yaml:"id"`
 	// Time is the time the panic occurred.
 	Time time.Time `json:"time" yaml:"time"`
 	// Value is the value of the panic. This is usually a `string` or an `error` but can
 	// be any type.
 	Value interface{} `json:"value" yaml:"value"`
-	// Trace is the stack trace of all goroutines at the time of the panic.
+	// Trace is the stack trace of all goroutines at the time of the panic, or only the
+	// panicking goroutine in minimal builds.
 	Trace string `json:"trace" yaml:"trace"`
+	// Attrs are additional key/value pairs attached to the panic after it was recovered,
+	// such as the remote address of the connection being served.
+	Attrs map[string]interface{} `json:"attrs,omitempty" yaml:"attrs,omitempty"`
+	// Stack holds the parsed frames of the panicking goroutine once the raw trace has
+	// been discarded, such as by a `History` retention policy.
+	Stack []Frame `json:"stack,omitempty" yaml:"stack,omitempty"`
+	// Severity is how serious the panic is. It is set by the first matching `Classify`
+	// rule, or by `WithSeverity`, and defaults to `SeverityError`.
+	Severity Severity `json:"severity" yaml:"severity"`
+	// PublicMessage is a message that is safe to show to clients in place of the panic
+	// value. It is set from values wrapped with `WithPublicMessage`.
+	PublicMessage string `json:"public_message,omitempty" yaml:"public_message,omitempty"`
+	// ValueSnapshot is the rendered value captured when the panic was created, if
+	// enabled by `SetValueSnapshot`.
+	ValueSnapshot *ValueSnapshot `json:"value_snapshot,omitempty" yaml:"value_snapshot,omitempty"`
+	// PCs are the program counters of the stack that created the panic, relative to the
+	// address of `New`, if enabled by `SetProgramCounters`. See `Symbolize`.
+	PCs []uintptr `json:"pcs,omitempty" yaml:"pcs,omitempty"`
+	// BuildID identifies the binary that created the panic. `New` sets it to `BuildID`.
+	BuildID string `json:"build_id,omitempty" yaml:"build_id,omitempty"`
+
+	// dropped holds the value dropped by `SetValueSnapshot` until the panic is settled,
+	// so an `Unrecoverable` panic is raised again with its original value. It is shared
+	// by copies of the panic, so releasing it once releases it for every copy.
+	dropped *droppedValue
 }
 
 // Error implements the `error` interface and returns a string representation of the
-// panic value. This does not include the stack traces.
+// panic value, as rendered by `Message`, after the prefix set by `SetErrorPrefix`, or
+// as returned by the function set by `SetMessageFunc`. This does not include the stack
+// traces.
 func (p *Panic) Error() string {
-	return fmt.Sprintf("panic: %v", p.Value)
+	m := loadErrorMessage()
+	if m.fn != nil {
+		return m.fn(p)
+	}
+	return m.prefix + p.Message()
 }
 
 // String implements the `fmt.Stringer` interface and returns a string representation
 // of the panic with all of the collected stack traces from when the panic occurred.
+// Frames of functions registered with `RegisterHelper` are elided.
 func (p *Panic) String() string {
-	return fmt.Sprintf("%s\n\n%s", p.Error(), p.Trace)
+	return fmt.Sprintf("%s\n\n%s", p.Error(), elideHelpers(p.Trace))
+}
+
+// SetAttr attaches the key/value pair to the panic, replacing any previous value for
+// the key.
+func (p *Panic) SetAttr(key string, value interface{}) {
+	if p.Attrs == nil {
+		p.Attrs = make(map[string]interface{})
+	}
+	p.Attrs[key] = value
 }
 
 // Unwrap implements the `errors.Unwrap` interface and returns the panic value as an
@@ -82,15 +225,72 @@ func (p *Panic) Unwrap() error {
 	return err
 }
 
+// Is implements the interface used by `errors.Is` and reports whether the target is
+// `ErrPanic`. The panic value, if it is an error, is compared through `Unwrap`.
+func (p *Panic) Is(target error) bool {
+	return target == ErrPanic
+}
+
+// As implements the interface used by `errors.As` and assigns the panic value to the
+// target if the value's type is assignable to it, so values that are not errors, such
+// as strings or custom payload types, can be extracted too. If the panic value is an
+// error, the target is also matched against its chain. The target must be a non-nil
+// pointer.
+func (p *Panic) As(target interface{}) bool {
+	val := reflect.ValueOf(target)
+	if val.Kind() != reflect.Ptr || val.IsNil() || p.Value == nil {
+		return false
+	}
+
+	elem := val.Type().Elem()
+	if reflect.TypeOf(p.Value).AssignableTo(elem) {
+		val.Elem().Set(reflect.ValueOf(p.Value))
+		return true
+	}
+
+	if err, ok := p.Value.(error); ok && (elem.Kind() == reflect.Interface || elem.Implements(errorType)) {
+		return errors.As(err, target)
+	}
+	return false
+}
+
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
 // New creates a new `*Panic` from the provided value. Stack traces for all goroutines
 // are collected during construction. This is expected to be used during panic recovery.
+// Every panic created is counted by `PanicCount` and remembered by `LastPanic`.
+//
+//go:noinline
 func New(v interface{}) *Panic {
-	var trace [1 << 16]byte
-	n := runtime.Stack(trace[:], true)
+	p := newPanic(v)
+	record(p)
+	return p
+}
+
+// NewEvent creates a `*Panic` like `New` for a report that is not a recovered panic,
+// such as a stalled goroutine or a goroutine dump, so it can be passed to handlers.
+// Unlike `New`, it is not counted by `PanicCount` nor remembered by `LastPanic`.
+//
+//go:noinline
+func NewEvent(v interface{}) *Panic {
+	return newPanic(v)
+}
+
+// newPanic creates a panic for `New` and `NewEvent`, which must call it directly.
+func newPanic(v interface{}) *Panic {
+	now := time.Now()
 	p := &Panic{
-		Time:  time.Now(),
-		Value: v,
-		Trace: string(trace[:n]),
+		ID:            newID(now),
+		Time:          now,
+		Value:         v,
+		Trace:         captureTrace(),
+		PublicMessage: publicMessage(v),
+		PCs:           programCounters(1),
+		BuildID:       BuildID(),
+	}
+	if s, ok := classify(p); ok {
+		p.Severity = s
 	}
+	snapshotValue(p)
 	return p
 }
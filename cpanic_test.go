@@ -2,11 +2,13 @@ package cpanic_test
 
 import (
 	"errors"
+	"fmt"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 
 	"github.com/demosdemon/cpanic"
+	"github.com/demosdemon/cpanic/chaos"
 )
 
 func TestForward(t *testing.T) {
@@ -56,3 +58,107 @@ func TestForward(t *testing.T) {
 		})
 	}
 }
+
+type bailout string
+
+func TestRecoverIf(t *testing.T) {
+	ours := func(v interface{}) bool {
+		_, ok := v.(bailout)
+		return ok
+	}
+	var got *cpanic.Panic
+	handler := func(p *cpanic.Panic) { got = p }
+
+	func() {
+		defer cpanic.RecoverIf(ours, handler)
+		panic(bailout("invalid name"))
+	}()
+	if assert.NotNil(t, got) {
+		assert.Equal(t, bailout("invalid name"), got.Value)
+	}
+
+	got = nil
+	assert.PanicsWithValue(t, "index out of range", func() {
+		defer cpanic.RecoverIf(ours, handler)
+		panic("index out of range")
+	})
+	assert.Nil(t, got)
+
+	func() {
+		defer cpanic.RecoverIf(nil, handler)
+		panic("anything")
+	}()
+	assert.NotNil(t, got)
+}
+
+func TestSetAttr(t *testing.T) {
+	p := cpanic.New("not at a disco")
+	assert.Nil(t, p.Attrs)
+
+	p.SetAttr("key", "value")
+	p.SetAttr("key", 42)
+	assert.Equal(t, map[string]interface{}{"key": 42}, p.Attrs)
+}
+
+func TestGoChaos(t *testing.T) {
+	chaos.Reset()
+	defer chaos.Reset()
+	chaos.Set("cpanic.Go", 1)
+
+	err := cpanic.Go(func() error { return nil })
+	var p *cpanic.Panic
+	if assert.True(t, errors.As(err, &p)) {
+		assert.IsType(t, &chaos.Injected{}, p.Value)
+	}
+}
+
+func TestErrPanic(t *testing.T) {
+	sentinel := errors.New("sentinel")
+	err := cpanic.Go(func() error { panic(sentinel) })
+
+	assert.True(t, errors.Is(err, cpanic.ErrPanic))
+	assert.True(t, errors.Is(err, sentinel))
+	assert.True(t, errors.Is(fmt.Errorf("wrapped: %w", err), cpanic.ErrPanic))
+	assert.False(t, errors.Is(sentinel, cpanic.ErrPanic))
+	assert.False(t, errors.Is(cpanic.Go(func() error { return sentinel }), cpanic.ErrPanic))
+}
+
+type codeError struct {
+	code int
+}
+
+func (e codeError) Error() string {
+	return fmt.Sprintf("code %d", e.code)
+}
+
+type named string
+
+func (n named) Name() string {
+	return string(n)
+}
+
+func TestPanicAs(t *testing.T) {
+	err := cpanic.Go(func() error { panic(fmt.Errorf("wrapped: %w", codeError{42})) })
+	var ce codeError
+	if assert.True(t, errors.As(err, &ce)) {
+		assert.Equal(t, 42, ce.code)
+	}
+
+	err = cpanic.Go(func() error { panic(named("not at a disco")) })
+	var s interface{ Name() string }
+	if assert.True(t, errors.As(err, &s)) {
+		assert.Equal(t, "not at a disco", s.Name())
+	}
+	assert.False(t, errors.As(err, &ce))
+
+	var p *cpanic.Panic
+	if assert.True(t, errors.As(err, &p)) {
+		var n named
+		assert.True(t, p.As(&n))
+		assert.Equal(t, named("not at a disco"), n)
+
+		var i int
+		assert.False(t, p.As(&i))
+		assert.False(t, p.As(nil))
+	}
+}
@@ -0,0 +1,214 @@
+// cpanicairbrake sends panics to Airbrake as notices using the Airbrake Notifier API
+// v3, without depending on the Airbrake Go notifier.
+package cpanicairbrake
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/demosdemon/cpanic"
+)
+
+// DefaultHost is the Airbrake API endpoint.
+const DefaultHost = "https://api.airbrake.io"
+
+// DefaultTimeout is the default time allowed for each notice sent by `Handler`.
+const DefaultTimeout = 10 * time.Second
+
+// Option configures a `Notifier`.
+type Option func(*Notifier)
+
+// WithHost sets the API endpoint, such as that of a self-hosted Errbit.
+func WithHost(host string) Option {
+	return func(n *Notifier) {
+		n.host = strings.TrimSuffix(host, "/")
+	}
+}
+
+// WithHTTPClient sets the client used to send notices.
+func WithHTTPClient(c *http.Client) Option {
+	return func(n *Notifier) {
+		n.client = c
+	}
+}
+
+// WithEnvironment sets the environment of the application, such as "production".
+func WithEnvironment(env string) Option {
+	return func(n *Notifier) {
+		n.environment = env
+	}
+}
+
+// WithTimeout sets the time allowed for each notice sent by `Handler`.
+func WithTimeout(d time.Duration) Option {
+	return func(n *Notifier) {
+		n.timeout = d
+	}
+}
+
+// Notifier sends panics to an Airbrake project.
+type Notifier struct {
+	host        string
+	projectID   int64
+	projectKey  string
+	environment string
+	client      *http.Client
+	timeout     time.Duration
+}
+
+// New creates a notifier for the project with the ID and key.
+func New(projectID int64, projectKey string, opts ...Option) *Notifier {
+	n := &Notifier{
+		host:       DefaultHost,
+		projectID:  projectID,
+		projectKey: projectKey,
+		client:     http.DefaultClient,
+		timeout:    DefaultTimeout,
+	}
+	for _, opt := range opts {
+		opt(n)
+	}
+	return n
+}
+
+// Handler returns a `cpanic.Handler` that sends each panic, discarding errors.
+func (n *Notifier) Handler() cpanic.Handler {
+	return func(p *cpanic.Panic) {
+		ctx, cancel := context.WithTimeout(context.Background(), n.timeout)
+		defer cancel()
+		_ = n.Notify(ctx, p)
+	}
+}
+
+// Notify sends the panic as a notice. The frames of the panicking goroutine become the
+// backtrace, the attributes become the params, and the runtime of the process becomes
+// the environment.
+func (n *Notifier) Notify(ctx context.Context, p *cpanic.Panic) error {
+	body, err := json.Marshal(n.notice(p))
+	if err != nil {
+		return err
+	}
+
+	url := fmt.Sprintf("%s/api/v3/projects/%d/notices", n.host, n.projectID)
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+n.projectKey)
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(ioutil.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("cpanicairbrake: notify failed: %s", resp.Status)
+	}
+	return nil
+}
+
+// The types below mirror the notice of the Notifier API.
+
+type notice struct {
+	Errors      []noticeError          `json:"errors"`
+	Context     noticeContext          `json:"context"`
+	Environment map[string]interface{} `json:"environment"`
+	Params      map[string]interface{} `json:"params,omitempty"`
+}
+
+type noticeError struct {
+	Type      string          `json:"type"`
+	Message   string          `json:"message"`
+	Backtrace []backtraceLine `json:"backtrace"`
+}
+
+type backtraceLine struct {
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+	Function string `json:"function"`
+}
+
+type noticeContext struct {
+	Notifier    notifierInfo `json:"notifier"`
+	Severity    string       `json:"severity"`
+	Environment string       `json:"environment,omitempty"`
+	Hostname    string       `json:"hostname,omitempty"`
+	OS          string       `json:"os"`
+	Language    string       `json:"language"`
+	Version     string       `json:"version,omitempty"`
+}
+
+type notifierInfo struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	URL     string `json:"url"`
+}
+
+func (n *Notifier) notice(p *cpanic.Panic) notice {
+	e := noticeError{Type: p.Type(), Message: p.Message(), Backtrace: []backtraceLine{}}
+	for _, f := range p.Frames() {
+		e.Backtrace = append(e.Backtrace, backtraceLine{File: f.File, Line: f.Line, Function: f.Function})
+	}
+
+	hostname, _ := os.Hostname()
+	env := map[string]interface{}{
+		"goos":        runtime.GOOS,
+		"goarch":      runtime.GOARCH,
+		"goroutines":  runtime.NumGoroutine(),
+		"fingerprint": p.Fingerprint(),
+	}
+	if p.ID != "" {
+		env["id"] = p.ID
+	}
+
+	var params map[string]interface{}
+	if len(p.Attrs) > 0 {
+		params = make(map[string]interface{}, len(p.Attrs))
+		for k, v := range p.Attrs {
+			if _, err := json.Marshal(v); err != nil {
+				v = fmt.Sprint(v)
+			}
+			params[k] = v
+		}
+	}
+
+	return notice{
+		Errors: []noticeError{e},
+		Context: noticeContext{
+			Notifier:    notifierInfo{Name: "cpanic", Version: "1", URL: "https://github.com/demosdemon/cpanic"},
+			Severity:    severity(p.Severity),
+			Environment: n.environment,
+			Hostname:    hostname,
+			OS:          runtime.GOOS + "/" + runtime.GOARCH,
+			Language:    "go/" + runtime.Version(),
+			Version:     p.BuildID,
+		},
+		Environment: env,
+		Params:      params,
+	}
+}
+
+// severity returns the Airbrake severity of the panic.
+func severity(s cpanic.Severity) string {
+	switch {
+	case s >= cpanic.SeverityFatal:
+		return "critical"
+	case s <= cpanic.SeverityWarning:
+		return "warning"
+	default:
+		return "error"
+	}
+}
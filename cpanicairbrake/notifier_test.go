@@ -0,0 +1,70 @@
+package cpanicairbrake_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/demosdemon/cpanic"
+	"github.com/demosdemon/cpanic/cpanicairbrake"
+)
+
+func TestNotifier(t *testing.T) {
+	var (
+		path   string
+		auth   string
+		notice map[string]interface{}
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path, auth = r.URL.Path, r.Header.Get("Authorization")
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&notice))
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer srv.Close()
+
+	n := cpanicairbrake.New(42, "key", cpanicairbrake.WithHost(srv.URL+"/"), cpanicairbrake.WithEnvironment("production"))
+	p := &cpanic.Panic{
+		ID:       "01F2",
+		Value:    "not at a disco",
+		Trace:    "goroutine 1 [running]:\nmain.main()\n\t/src/main.go:3 +0x1d\n",
+		Severity: cpanic.SeverityFatal,
+	}
+	p.SetAttr("route", "/")
+	require.NoError(t, n.Notify(context.Background(), p))
+
+	assert.Equal(t, "/api/v3/projects/42/notices", path)
+	assert.Equal(t, "Bearer key", auth)
+	assert.Equal(t, []interface{}{map[string]interface{}{
+		"type":    "string",
+		"message": "not at a disco",
+		"backtrace": []interface{}{map[string]interface{}{
+			"file":     "/src/main.go",
+			"line":     float64(3),
+			"function": "main.main",
+		}},
+	}}, notice["errors"])
+
+	ctx := notice["context"].(map[string]interface{})
+	assert.Equal(t, "critical", ctx["severity"])
+	assert.Equal(t, "production", ctx["environment"])
+	assert.Equal(t, map[string]interface{}{"route": "/"}, notice["params"])
+
+	env := notice["environment"].(map[string]interface{})
+	assert.Equal(t, p.Fingerprint(), env["fingerprint"])
+	assert.Equal(t, "01F2", env["id"])
+}
+
+func TestNotifierError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer srv.Close()
+
+	err := cpanicairbrake.New(42, "key", cpanicairbrake.WithHost(srv.URL)).Notify(context.Background(), cpanic.New("not at a disco"))
+	assert.EqualError(t, err, "cpanicairbrake: notify failed: 401 Unauthorized")
+}
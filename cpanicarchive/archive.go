@@ -0,0 +1,183 @@
+// cpanicarchive moves crash history between machines as tar archives, so it can be
+// taken off a machine before it is recycled, or merged into a central store. It is
+// separate from cpanic so that programs that never move crash history do not link
+// "archive/tar".
+package cpanicarchive
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+	"time"
+
+	"github.com/demosdemon/cpanic"
+)
+
+// Version is the version of the archive format written by `Export`.
+const Version = 1
+
+// The entries of an archive.
+const (
+	archiveManifest = "manifest.json"
+	archivePanics   = "panics.ndjson"
+	archiveProfiles = "profiles/"
+	profileSuffix   = ".cpu.pprof"
+)
+
+// ErrVersion is returned by `Import` for an archive written by a newer version of the
+// format.
+var ErrVersion = errors.New("cpanicarchive: unsupported archive version")
+
+// archiveManifestData describes an archive.
+type archiveManifestData struct {
+	Version int       `json:"version"`
+	Created time.Time `json:"created"`
+	Panics  int       `json:"panics"`
+}
+
+// Export writes the panics of the store to w as a tar archive, so crash history can be
+// moved off a machine before it is recycled, or merged into a central store with
+// `Import`. The archive holds:
+//
+//   - manifest.json: the format version, the time of the export, and the number of panics
+//   - panics.ndjson: the reports of the panics, oldest first, as written by
+//     `cpanic.NDJSON`
+//   - profiles/ID.cpu.pprof: the CPU profile attached as `cpanic.CPUProfileAttr` to the
+//     panic with the ID, such as by `cpanic.ProfileRepeated`, which is left out of its
+//     report
+//
+// Wrap w in a `gzip.Writer` to compress the archive.
+func Export(store cpanic.Store, w io.Writer) error {
+	panics, err := store.Load()
+	if err != nil {
+		return err
+	}
+
+	var reports bytes.Buffer
+	profiles := make(map[string][]byte)
+	for _, p := range panics {
+		if profile, ok := p.Attrs[cpanic.CPUProfileAttr].([]byte); ok {
+			profiles[p.ID] = profile
+			cp := *p
+			cp.Attrs = make(map[string]interface{}, len(p.Attrs)-1)
+			for k, v := range p.Attrs {
+				if k != cpanic.CPUProfileAttr {
+					cp.Attrs[k] = v
+				}
+			}
+			p = &cp
+		}
+		b, err := cpanic.NDJSON.Format(p)
+		if err != nil {
+			return err
+		}
+		reports.Write(b)
+	}
+	now := time.Now()
+	manifest, err := json.MarshalIndent(archiveManifestData{
+		Version: Version,
+		Created: now,
+		Panics:  len(panics),
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tw := tar.NewWriter(w)
+	add := func(name string, data []byte) error {
+		hdr := &tar.Header{Name: name, Mode: 0o644, Size: int64(len(data)), ModTime: now}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		_, err := tw.Write(data)
+		return err
+	}
+	if err := add(archiveManifest, append(manifest, '\n')); err != nil {
+		return err
+	}
+	if err := add(archivePanics, reports.Bytes()); err != nil {
+		return err
+	}
+	for _, p := range panics {
+		if profile, ok := profiles[p.ID]; ok {
+			if err := add(archiveProfiles+p.ID+profileSuffix, profile); err != nil {
+				return err
+			}
+		}
+	}
+	return tw.Close()
+}
+
+// Import appends the panics of an archive written by `Export` to the store, oldest
+// first, and returns the number appended. The panics are decoded with
+// `cpanic.ParseReport`, and their CPU profiles attached again as `cpanic.CPUProfileAttr`.
+// Other entries are skipped.
+func Import(r io.Reader, store cpanic.Appender) (int, error) {
+	var (
+		manifest *archiveManifestData
+		reports  []byte
+		profiles = make(map[string][]byte)
+	)
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return 0, err
+		}
+
+		switch hdr.Name {
+		case archiveManifest:
+			manifest = new(archiveManifestData)
+			if err := json.NewDecoder(tr).Decode(manifest); err != nil {
+				return 0, fmt.Errorf("cpanicarchive: invalid archive manifest: %w", err)
+			}
+			if manifest.Version < 1 || manifest.Version > Version {
+				return 0, fmt.Errorf("%w %d", ErrVersion, manifest.Version)
+			}
+		case archivePanics:
+			if reports, err = ioutil.ReadAll(tr); err != nil {
+				return 0, err
+			}
+		default:
+			if strings.HasPrefix(hdr.Name, archiveProfiles) && strings.HasSuffix(hdr.Name, profileSuffix) {
+				id := strings.TrimSuffix(strings.TrimPrefix(hdr.Name, archiveProfiles), profileSuffix)
+				if profiles[id], err = ioutil.ReadAll(tr); err != nil {
+					return 0, err
+				}
+			}
+		}
+	}
+	if manifest == nil {
+		return 0, errors.New("cpanicarchive: archive has no manifest")
+	}
+
+	n := 0
+	sc := bufio.NewScanner(bytes.NewReader(reports))
+	sc.Buffer(nil, len(reports)+1)
+	for sc.Scan() {
+		if len(bytes.TrimSpace(sc.Bytes())) == 0 {
+			continue
+		}
+		p, err := cpanic.ParseReport(sc.Bytes())
+		if err != nil {
+			return n, fmt.Errorf("cpanicarchive: report %d: %w", n+1, err)
+		}
+		if profile, ok := profiles[p.ID]; ok {
+			p.SetAttr(cpanic.CPUProfileAttr, profile)
+		}
+		if err := store.Append(p); err != nil {
+			return n, err
+		}
+		n++
+	}
+	return n, sc.Err()
+}
@@ -0,0 +1,149 @@
+package cpanicarchive_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/demosdemon/cpanic"
+	"github.com/demosdemon/cpanic/cpanicarchive"
+)
+
+func TestExportImport(t *testing.T) {
+	src := cpanic.NewHistory(0)
+	var one *cpanic.Panic
+	func() {
+		defer cpanic.RecoverTo(&one)
+		var m map[string]*int
+		_ = *m["missing"]
+	}()
+	one.SetAttr("tenant", "acme")
+	two := cpanic.New("not at a disco")
+	two.SetAttr(cpanic.CPUProfileAttr, []byte("profile"))
+	src.Handle(one)
+	src.Handle(two)
+
+	var buf bytes.Buffer
+	require.NoError(t, cpanicarchive.Export(src, &buf))
+
+	var names []string
+	tr := tar.NewReader(bytes.NewReader(buf.Bytes()))
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		names = append(names, hdr.Name)
+	}
+	assert.Equal(t, []string{"manifest.json", "panics.ndjson", "profiles/" + two.ID + ".cpu.pprof"}, names)
+	assert.NotContains(t, buf.String(), `"profile.cpu"`, "the profile is not in the report")
+
+	dst := cpanic.NewHistory(0)
+	n, err := cpanicarchive.Import(&buf, dst)
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+
+	panics := dst.Panics()
+	require.Len(t, panics, 2)
+	for i, want := range []*cpanic.Panic{one, two} {
+		got := panics[i]
+		assert.Equal(t, want.ID, got.ID)
+		assert.Equal(t, want.Message(), got.Value)
+		assert.Equal(t, want.Fingerprint(), got.Fingerprint())
+	}
+	assert.Equal(t, "acme", panics[0].Attrs["tenant"])
+	assert.NotContains(t, panics[0].Attrs, cpanic.CPUProfileAttr)
+	assert.Equal(t, []byte("profile"), panics[1].Attrs[cpanic.CPUProfileAttr])
+	assert.Equal(t, []byte("profile"), two.Attrs[cpanic.CPUProfileAttr], "the store is unchanged")
+}
+
+func TestExportImportRetention(t *testing.T) {
+	src := cpanic.NewHistory(0, cpanic.WithRetention(cpanic.KeepFrames, cpanic.DropRawTrace))
+	var one, two *cpanic.Panic
+	func() {
+		defer cpanic.RecoverTo(&one)
+		panic(cpanic.WithPublicMessage(errors.New("boom"), "try again"))
+	}()
+	func() {
+		defer cpanic.RecoverTo(&two)
+		func() {
+			panic(errors.New("boom"))
+		}()
+	}()
+	require.NotEqual(t, one.Fingerprint(), two.Fingerprint())
+	src.Handle(one)
+	src.Handle(two)
+
+	var buf bytes.Buffer
+	require.NoError(t, cpanicarchive.Export(src, &buf))
+	dst := cpanic.NewHistory(0)
+	_, err := cpanicarchive.Import(&buf, dst)
+	require.NoError(t, err)
+
+	panics := dst.Panics()
+	require.Len(t, panics, 2)
+	for i, want := range []*cpanic.Panic{one, two} {
+		got := panics[i]
+		assert.Empty(t, got.Trace)
+		assert.Equal(t, want.Frames(), got.Stack)
+		assert.Equal(t, want.Fingerprint(), got.Fingerprint())
+		assert.Equal(t, want.PublicMessage, got.PublicMessage)
+		assert.Equal(t, want.PCs, got.PCs)
+	}
+	assert.Equal(t, "try again", panics[0].PublicMessage)
+}
+
+func TestImportErrors(t *testing.T) {
+	archive := func(files ...string) io.Reader {
+		var buf bytes.Buffer
+		tw := tar.NewWriter(&buf)
+		for i := 0; i < len(files); i += 2 {
+			require.NoError(t, tw.WriteHeader(&tar.Header{Name: files[i], Mode: 0o644, Size: int64(len(files[i+1]))}))
+			_, err := tw.Write([]byte(files[i+1]))
+			require.NoError(t, err)
+		}
+		require.NoError(t, tw.Close())
+		return &buf
+	}
+
+	_, err := cpanicarchive.Import(archive("manifest.json", `{"version": 2}`), cpanic.NewHistory(0))
+	assert.True(t, errors.Is(err, cpanicarchive.ErrVersion))
+	assert.EqualError(t, err, "cpanicarchive: unsupported archive version 2")
+
+	_, err = cpanicarchive.Import(archive("panics.ndjson", ""), cpanic.NewHistory(0))
+	assert.EqualError(t, err, "cpanicarchive: archive has no manifest")
+
+	store := cpanic.NewHistory(0)
+	n, err := cpanicarchive.Import(archive("manifest.json", `{"version": 1}`, "panics.ndjson", `{"type": "string", "value": "one"}`+"\n"+`{"value": "two"}`+"\n"), store)
+	assert.True(t, errors.Is(err, cpanic.ErrInvalidReport))
+	assert.Equal(t, 1, n)
+	assert.Equal(t, 1, store.Len())
+}
+
+func TestExportImportEncrypted(t *testing.T) {
+	k, err := cpanic.NewKeyring(bytes.Repeat([]byte{1}, 32))
+	require.NoError(t, err)
+
+	src := cpanic.NewHistory(10)
+	src.Handle(cpanic.New("card 4242"))
+
+	var buf bytes.Buffer
+	w := k.NewWriter(&buf)
+	require.NoError(t, cpanicarchive.Export(src, w))
+	require.NoError(t, w.Close())
+	assert.NotContains(t, buf.String(), "4242")
+
+	r, err := k.NewReader(&buf)
+	require.NoError(t, err)
+	dst := cpanic.NewHistory(10)
+	n, err := cpanicarchive.Import(r, dst)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.Equal(t, "card 4242", dst.Panics()[0].Message())
+}
@@ -0,0 +1,208 @@
+// cpanicaws publishes panic reports to Amazon SQS queues and SNS topics, signing
+// requests with Signature Version 4, without depending on the AWS SDK. Each message
+// carries the fingerprint, severity, and type of the panic as message attributes, so
+// SNS subscription filter policies and queue consumers can route panics without
+// decoding the report.
+package cpanicaws
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/demosdemon/cpanic"
+)
+
+// The message attributes set on every message.
+const (
+	FingerprintAttr = "fingerprint"
+	SeverityAttr    = "severity"
+	TypeAttr        = "type"
+	IDAttr          = "id"
+)
+
+// DefaultTimeout is the default time allowed for each publish request made by
+// `Handler`.
+const DefaultTimeout = 10 * time.Second
+
+// Option configures a `Publisher`.
+type Option func(*Publisher)
+
+// WithHTTPClient sets the client used to send requests.
+func WithHTTPClient(c *http.Client) Option {
+	return func(p *Publisher) {
+		p.client = c
+	}
+}
+
+// WithEndpoint sends requests to url instead of the regional endpoint of the service,
+// such as for a VPC endpoint or a local emulator.
+func WithEndpoint(url string) Option {
+	return func(p *Publisher) {
+		p.endpoint = url
+	}
+}
+
+// WithFormatter sets how the panic is serialized in the message body. It defaults to
+// `cpanic.NDJSON`.
+func WithFormatter(f cpanic.Formatter) Option {
+	return func(p *Publisher) {
+		p.formatter = f
+	}
+}
+
+// WithTimeout sets the time allowed for each publish request made by `Handler`.
+func WithTimeout(d time.Duration) Option {
+	return func(p *Publisher) {
+		p.timeout = d
+	}
+}
+
+// Publisher publishes panics to an SQS queue or an SNS topic.
+type Publisher struct {
+	service   string
+	region    string
+	endpoint  string
+	params    url.Values
+	bodyKey   string
+	attrKey   string
+	creds     Credentials
+	client    *http.Client
+	formatter cpanic.Formatter
+	timeout   time.Duration
+}
+
+// SQS creates a publisher sending messages to the queue, such as
+// "https://sqs.us-east-1.amazonaws.com/123456789012/panics". The region is taken from
+// the queue URL.
+func SQS(queueURL string, creds Credentials, opts ...Option) (*Publisher, error) {
+	u, err := url.Parse(queueURL)
+	if err != nil {
+		return nil, err
+	}
+	parts := strings.Split(u.Host, ".")
+	if len(parts) < 3 || parts[0] != "sqs" {
+		return nil, fmt.Errorf("cpanicaws: cannot find the region of queue %q", queueURL)
+	}
+	params := url.Values{
+		"Action":   {"SendMessage"},
+		"Version":  {"2012-11-05"},
+		"QueueUrl": {queueURL},
+	}
+	return newPublisher("sqs", parts[1], queueURL, params, "MessageAttribute", "MessageBody", creds, opts), nil
+}
+
+// SNS creates a publisher publishing messages to the topic, such as
+// "arn:aws:sns:us-east-1:123456789012:panics". The region is taken from the ARN.
+func SNS(topicARN string, creds Credentials, opts ...Option) (*Publisher, error) {
+	parts := strings.Split(topicARN, ":")
+	if len(parts) != 6 || parts[0] != "arn" || parts[2] != "sns" {
+		return nil, fmt.Errorf("cpanicaws: invalid topic ARN %q", topicARN)
+	}
+	region := parts[3]
+	params := url.Values{
+		"Action":   {"Publish"},
+		"Version":  {"2010-03-31"},
+		"TopicArn": {topicARN},
+	}
+	endpoint := "https://sns." + region + ".amazonaws.com/"
+	return newPublisher("sns", region, endpoint, params, "MessageAttributes.entry", "Message", creds, opts), nil
+}
+
+func newPublisher(service, region, endpoint string, params url.Values, attrKey, bodyKey string, creds Credentials, opts []Option) *Publisher {
+	p := &Publisher{
+		service:   service,
+		region:    region,
+		endpoint:  endpoint,
+		params:    params,
+		bodyKey:   bodyKey,
+		attrKey:   attrKey,
+		creds:     creds,
+		client:    http.DefaultClient,
+		formatter: cpanic.NDJSON,
+		timeout:   DefaultTimeout,
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// Handler returns a `cpanic.Handler` that publishes each panic, discarding errors.
+func (p *Publisher) Handler() cpanic.Handler {
+	return func(pn *cpanic.Panic) {
+		ctx, cancel := context.WithTimeout(context.Background(), p.timeout)
+		defer cancel()
+		_ = p.Publish(ctx, pn)
+	}
+}
+
+// Publish sends the panic as a single message.
+func (p *Publisher) Publish(ctx context.Context, pn *cpanic.Panic) error {
+	report, err := p.formatter.Format(pn)
+	if err != nil {
+		return err
+	}
+
+	form := url.Values{}
+	for k, v := range p.params {
+		form[k] = v
+	}
+	form.Set(p.bodyKey, strings.TrimSuffix(string(report), "\n"))
+	for i, attr := range messageAttributes(pn) {
+		prefix := p.attrKey + "." + strconv.Itoa(i+1) + "."
+		form.Set(prefix+"Name", attr[0])
+		form.Set(prefix+"Value.DataType", "String")
+		form.Set(prefix+"Value.StringValue", attr[1])
+	}
+	body := []byte(form.Encode())
+
+	req, err := http.NewRequest(http.MethodPost, p.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=utf-8")
+	sign(req, body, p.creds, p.service, p.region, time.Now())
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		var e struct {
+			Code    string `xml:"Error>Code"`
+			Message string `xml:"Error>Message"`
+		}
+		b, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 64<<10))
+		if xml.Unmarshal(b, &e) == nil && e.Code != "" {
+			return fmt.Errorf("cpanicaws: publish failed: %s: %s", e.Code, e.Message)
+		}
+		return fmt.Errorf("cpanicaws: publish failed: %s", resp.Status)
+	}
+	_, _ = io.Copy(ioutil.Discard, resp.Body)
+	return nil
+}
+
+// messageAttributes returns the routing attributes of the panic.
+func messageAttributes(p *cpanic.Panic) [][2]string {
+	attrs := [][2]string{
+		{FingerprintAttr, p.Fingerprint()},
+		{SeverityAttr, p.Severity.String()},
+		{TypeAttr, p.Type()},
+	}
+	if p.ID != "" {
+		attrs = append(attrs, [2]string{IDAttr, p.ID})
+	}
+	return attrs
+}
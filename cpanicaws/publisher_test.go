@@ -0,0 +1,101 @@
+package cpanicaws_test
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/demosdemon/cpanic"
+	"github.com/demosdemon/cpanic/cpanicaws"
+)
+
+var creds = cpanicaws.Credentials{AccessKeyID: "AKID", SecretAccessKey: "secret", SessionToken: "token"}
+
+// capture starts a server recording the form and headers of the last request.
+func capture(t *testing.T, status int, body string) (*httptest.Server, *url.Values, *http.Header) {
+	var (
+		form    url.Values
+		headers http.Header
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, err := ioutil.ReadAll(r.Body)
+		assert.NoError(t, err)
+		form, err = url.ParseQuery(string(b))
+		assert.NoError(t, err)
+		headers = r.Header
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(body))
+	}))
+	return srv, &form, &headers
+}
+
+func TestSQS(t *testing.T) {
+	srv, form, headers := capture(t, http.StatusOK, "")
+	defer srv.Close()
+
+	queue := "https://sqs.us-east-1.amazonaws.com/123456789012/panics"
+	pub, err := cpanicaws.SQS(queue, creds, cpanicaws.WithEndpoint(srv.URL))
+	require.NoError(t, err)
+
+	p := cpanic.New("not at a disco")
+	require.NoError(t, pub.Publish(context.Background(), p))
+
+	assert.Equal(t, "SendMessage", form.Get("Action"))
+	assert.Equal(t, queue, form.Get("QueueUrl"))
+	assert.True(t, strings.HasPrefix(form.Get("MessageBody"), `{"id":"`+p.ID+`"`), form.Get("MessageBody"))
+	assert.Equal(t, "fingerprint", form.Get("MessageAttribute.1.Name"))
+	assert.Equal(t, p.Fingerprint(), form.Get("MessageAttribute.1.Value.StringValue"))
+	assert.Equal(t, "severity", form.Get("MessageAttribute.2.Name"))
+	assert.Equal(t, "error", form.Get("MessageAttribute.2.Value.StringValue"))
+	assert.Equal(t, "String", form.Get("MessageAttribute.3.Value.DataType"))
+
+	auth := headers.Get("Authorization")
+	assert.Regexp(t, `^AWS4-HMAC-SHA256 Credential=AKID/\d{8}/us-east-1/sqs/aws4_request, `+
+		`SignedHeaders=content-type;host;x-amz-date;x-amz-security-token, Signature=[0-9a-f]{64}$`, auth)
+	assert.Equal(t, "token", headers.Get("X-Amz-Security-Token"))
+}
+
+func TestSNS(t *testing.T) {
+	srv, form, headers := capture(t, http.StatusOK, "")
+	defer srv.Close()
+
+	topic := "arn:aws:sns:eu-west-1:123456789012:panics"
+	pub, err := cpanicaws.SNS(topic, creds, cpanicaws.WithEndpoint(srv.URL), cpanicaws.WithFormatter(cpanic.Logfmt))
+	require.NoError(t, err)
+
+	p := cpanic.New("not at a disco")
+	p.Severity = cpanic.SeverityFatal
+	require.NoError(t, pub.Publish(context.Background(), p))
+
+	assert.Equal(t, "Publish", form.Get("Action"))
+	assert.Equal(t, topic, form.Get("TopicArn"))
+	assert.Contains(t, form.Get("Message"), `panic="not at a disco"`)
+	assert.Equal(t, "severity", form.Get("MessageAttributes.entry.2.Name"))
+	assert.Equal(t, "fatal", form.Get("MessageAttributes.entry.2.Value.StringValue"))
+	assert.Contains(t, headers.Get("Authorization"), "/eu-west-1/sns/aws4_request")
+}
+
+func TestPublishError(t *testing.T) {
+	srv, _, _ := capture(t, http.StatusForbidden,
+		`<ErrorResponse><Error><Code>AccessDenied</Code><Message>no</Message></Error></ErrorResponse>`)
+	defer srv.Close()
+
+	pub, err := cpanicaws.SQS("https://sqs.us-east-1.amazonaws.com/1/q", creds, cpanicaws.WithEndpoint(srv.URL))
+	require.NoError(t, err)
+	err = pub.Publish(context.Background(), cpanic.New("not at a disco"))
+	assert.EqualError(t, err, "cpanicaws: publish failed: AccessDenied: no")
+}
+
+func TestInvalid(t *testing.T) {
+	_, err := cpanicaws.SQS("https://example.com/q", creds)
+	assert.Error(t, err)
+	_, err = cpanicaws.SNS("arn:aws:sqs:us-east-1:1:q", creds)
+	assert.Error(t, err)
+}
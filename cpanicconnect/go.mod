@@ -0,0 +1,18 @@
+module github.com/demosdemon/cpanic/cpanicconnect
+
+go 1.25.0
+
+require (
+	connectrpc.com/connect v1.21.0
+	github.com/demosdemon/cpanic v0.0.0
+	github.com/stretchr/testify v1.8.2
+	google.golang.org/protobuf v1.36.11
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
+
+replace github.com/demosdemon/cpanic => ../
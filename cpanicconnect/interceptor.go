@@ -0,0 +1,48 @@
+// cpanicconnect provides a `connectrpc.com/connect` integration for cpanic.
+package cpanicconnect
+
+import (
+	"context"
+	"errors"
+
+	"connectrpc.com/connect"
+
+	"github.com/demosdemon/cpanic"
+)
+
+const (
+	// ProcedureAttr is the `cpanic.Panic` attribute key holding the procedure, such as
+	// "/acme.foo.v1.FooService/Bar", that panicked.
+	ProcedureAttr = "connect.procedure"
+	// FingerprintMeta is the error metadata key carrying the fingerprint of the
+	// recovered panic so clients can correlate failures with reports.
+	FingerprintMeta = "Cpanic-Fingerprint"
+)
+
+// PublicMessage is the message of the error returned to clients in place of the panic
+// value, unless the panic has its own `cpanic.Panic.PublicMessage`.
+const PublicMessage = "internal error"
+
+// UnaryInterceptor returns a `connect.UnaryInterceptorFunc` that recovers panics
+// raised by unary handlers and returns a `connect.CodeInternal` error carrying the
+// panic fingerprint in its metadata. The recovered panic, tagged with the procedure,
+// is reported to the handler, if provided.
+func UnaryInterceptor(h cpanic.Handler) connect.UnaryInterceptorFunc {
+	return func(next connect.UnaryFunc) connect.UnaryFunc {
+		return func(ctx context.Context, req connect.AnyRequest) (resp connect.AnyResponse, err error) {
+			defer func() {
+				if value := recover(); value != nil {
+					p := cpanic.New(value)
+					p.SetAttr(ProcedureAttr, req.Spec().Procedure)
+					cpanic.Deliver(p, h)
+
+					cerr := connect.NewError(connect.CodeInternal, errors.New(p.PublicMessageOr(PublicMessage)))
+					cerr.Meta().Set(FingerprintMeta, p.Fingerprint())
+					resp, err = nil, cerr
+				}
+			}()
+
+			return next(ctx, req)
+		}
+	}
+}
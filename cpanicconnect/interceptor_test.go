@@ -0,0 +1,73 @@
+package cpanicconnect_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"connectrpc.com/connect"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	"github.com/demosdemon/cpanic"
+	"github.com/demosdemon/cpanic/cpanicconnect"
+)
+
+func TestUnaryInterceptor(t *testing.T) {
+	tests := []struct {
+		name   string
+		next   connect.UnaryFunc
+		errMsg string
+		panics bool
+	}{
+		{
+			name: "passes through response",
+			next: func(context.Context, connect.AnyRequest) (connect.AnyResponse, error) {
+				return connect.NewResponse(&emptypb.Empty{}), nil
+			},
+		},
+		{
+			name: "passes through error",
+			next: func(context.Context, connect.AnyRequest) (connect.AnyResponse, error) {
+				return nil, errors.New("test")
+			},
+			errMsg: "test",
+		},
+		{
+			name: "returns internal error",
+			next: func(context.Context, connect.AnyRequest) (connect.AnyResponse, error) {
+				panic("not at a disco")
+			},
+			errMsg: "internal: " + cpanicconnect.PublicMessage,
+			panics: true,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			var handled *cpanic.Panic
+			next := cpanicconnect.UnaryInterceptor(func(p *cpanic.Panic) { handled = p }).WrapUnary(tt.next)
+
+			resp, err := next(context.Background(), connect.NewRequest(&emptypb.Empty{}))
+			if tt.errMsg == "" {
+				assert.NoError(t, err)
+				assert.NotNil(t, resp)
+			} else {
+				assert.EqualError(t, err, tt.errMsg)
+				assert.Nil(t, resp)
+			}
+
+			if tt.panics {
+				var cerr *connect.Error
+				if assert.True(t, errors.As(err, &cerr)) && assert.NotNil(t, handled) {
+					assert.Equal(t, connect.CodeInternal, cerr.Code())
+					assert.Equal(t, handled.Fingerprint(), cerr.Meta().Get(cpanicconnect.FingerprintMeta))
+					assert.Contains(t, handled.Attrs, cpanicconnect.ProcedureAttr)
+				}
+			} else {
+				assert.Nil(t, handled)
+			}
+		})
+	}
+}
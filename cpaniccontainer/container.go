@@ -0,0 +1,229 @@
+// cpaniccontainer attaches the metadata of the container a process runs in, such as
+// its ID and memory limit, to its panics. A panic near the memory limit is a common
+// sign that the process was about to be killed, so the memory usage at the time of the
+// panic is attached too.
+//
+//	defer cpanic.Recover(cpaniccontainer.Enrich(h))
+//
+// The container is detected from the cgroup and mount tables of the process, for both
+// cgroup v1 and v2, and from the marker files of Docker and Podman. Outside of Linux, or
+// outside of a container, no attributes are attached.
+package cpaniccontainer
+
+import (
+	"bufio"
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/demosdemon/cpanic"
+)
+
+// The attributes attached to panics by `Enrich`.
+const (
+	IDAttr          = "container.id"
+	RuntimeAttr     = "container.runtime"
+	ImageAttr       = "container.image.name"
+	MemoryLimitAttr = "container.memory.limit"
+	MemoryUsageAttr = "container.memory.usage"
+)
+
+// DefaultImageEnv is the environment variable holding the image of the container, which
+// cannot be detected from inside it. Deployments typically set it from the image
+// reference, such as with the Kubernetes downward API.
+const DefaultImageEnv = "CONTAINER_IMAGE"
+
+// Option configures `Detect` and `Enrich`.
+type Option func(*config)
+
+type config struct {
+	root     string
+	imageEnv string
+}
+
+// WithRoot reads the proc and cgroup file systems under dir instead of "/", such as the
+// host paths mounted into a sidecar.
+func WithRoot(dir string) Option {
+	return func(c *config) {
+		c.root = dir
+	}
+}
+
+// WithImageEnv reads the image of the container from the environment variable instead
+// of `DefaultImageEnv`.
+func WithImageEnv(name string) Option {
+	return func(c *config) {
+		c.imageEnv = name
+	}
+}
+
+// Info describes the container of the process.
+type Info struct {
+	// ID is the full ID of the container.
+	ID string
+	// Runtime is the container runtime, such as "docker", "containerd", "cri-o",
+	// "podman", or "kubernetes" if only the pod is known.
+	Runtime string
+	// Image is the image of the container, from the environment.
+	Image string
+	// MemoryLimit is the memory limit of the cgroup in bytes, or zero if unlimited.
+	MemoryLimit int64
+}
+
+var containerID = regexp.MustCompile(`[0-9a-f]{64}`)
+
+// runtimes maps the markers found in cgroup paths to their runtime, in order of
+// precedence.
+var runtimes = []struct{ marker, runtime string }{
+	{"docker", "docker"},
+	{"cri-containerd", "containerd"},
+	{"containerd", "containerd"},
+	{"crio", "cri-o"},
+	{"libpod", "podman"},
+	{"kubepods", "kubernetes"},
+}
+
+// Detect returns the container of the process, or false if it does not appear to run in
+// one.
+func Detect(opts ...Option) (Info, bool) {
+	c := newConfig(opts)
+
+	var info Info
+	cgroup, _ := ioutil.ReadFile(c.path("proc/self/cgroup"))
+	for _, rt := range runtimes {
+		if bytes.Contains(cgroup, []byte(rt.marker)) {
+			info.Runtime = rt.runtime
+			break
+		}
+	}
+	if info.Runtime == "" {
+		switch {
+		case exists(c.path(".dockerenv")):
+			info.Runtime = "docker"
+		case exists(c.path("run/.containerenv")):
+			info.Runtime = "podman"
+		}
+	}
+
+	info.ID = containerID.FindString(string(cgroup))
+	if info.ID == "" {
+		// With cgroup v2 and a private cgroup namespace, the cgroup path is "/", but
+		// the files bind-mounted by the runtime are named after the container.
+		if mounts, err := ioutil.ReadFile(c.path("proc/self/mountinfo")); err == nil {
+			info.ID = mountedContainerID(mounts)
+		}
+	}
+
+	if info.ID == "" && info.Runtime == "" {
+		return Info{}, false
+	}
+	info.Image = os.Getenv(c.imageEnv)
+	info.MemoryLimit, _ = memoryLimit(c)
+	return info, true
+}
+
+// Enrich returns a handler that attaches the container of the process and its current
+// memory usage to each panic before calling h. The container is detected once; if the
+// process does not run in a container, h is returned unchanged.
+func Enrich(h cpanic.Handler, opts ...Option) cpanic.Handler {
+	info, ok := Detect(opts...)
+	if !ok {
+		return h
+	}
+	c := newConfig(opts)
+
+	return func(p *cpanic.Panic) {
+		if info.ID != "" {
+			p.SetAttr(IDAttr, info.ID)
+		}
+		p.SetAttr(RuntimeAttr, info.Runtime)
+		if info.Image != "" {
+			p.SetAttr(ImageAttr, info.Image)
+		}
+		if info.MemoryLimit > 0 {
+			p.SetAttr(MemoryLimitAttr, info.MemoryLimit)
+		}
+		if usage, ok := memoryUsage(c); ok {
+			p.SetAttr(MemoryUsageAttr, usage)
+		}
+		h.Handle(p)
+	}
+}
+
+func newConfig(opts []Option) config {
+	c := config{root: "/", imageEnv: DefaultImageEnv}
+	for _, opt := range opts {
+		opt(&c)
+	}
+	return c
+}
+
+func (c config) path(name string) string {
+	return filepath.Join(c.root, filepath.FromSlash(name))
+}
+
+// mountedContainerID finds the container ID in the mount table, such as in the source
+// of "/etc/hostname" under "/var/lib/docker/containers/<id>/".
+func mountedContainerID(mounts []byte) string {
+	s := bufio.NewScanner(bytes.NewReader(mounts))
+	for s.Scan() {
+		fields := strings.Fields(s.Text())
+		if len(fields) < 5 {
+			continue
+		}
+		root, target := fields[3], fields[4]
+		if target != "/etc/hostname" && target != "/etc/hosts" {
+			continue
+		}
+		if id := containerID.FindString(root); id != "" {
+			return id
+		}
+	}
+	return ""
+}
+
+// memoryLimit returns the memory limit of the cgroup, from cgroup v2 or v1. Limits of
+// "max", or the page-aligned maximum of v1, mean unlimited and are returned as zero.
+func memoryLimit(c config) (int64, bool) {
+	for _, name := range []string{"sys/fs/cgroup/memory.max", "sys/fs/cgroup/memory/memory.limit_in_bytes"} {
+		b, err := ioutil.ReadFile(c.path(name))
+		if err != nil {
+			continue
+		}
+		s := strings.TrimSpace(string(b))
+		if s == "max" {
+			return 0, true
+		}
+		n, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return 0, false
+		}
+		if n >= 1<<62 {
+			n = 0
+		}
+		return n, true
+	}
+	return 0, false
+}
+
+// memoryUsage returns the current memory usage of the cgroup, from cgroup v2 or v1.
+func memoryUsage(c config) (int64, bool) {
+	for _, name := range []string{"sys/fs/cgroup/memory.current", "sys/fs/cgroup/memory/memory.usage_in_bytes"} {
+		b, err := ioutil.ReadFile(c.path(name))
+		if err != nil {
+			continue
+		}
+		n, err := strconv.ParseInt(strings.TrimSpace(string(b)), 10, 64)
+		return n, err == nil
+	}
+	return 0, false
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
@@ -0,0 +1,128 @@
+package cpaniccontainer_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/demosdemon/cpanic"
+	"github.com/demosdemon/cpanic/cpaniccontainer"
+)
+
+const id = "3f4e5d6c7b8a99887766554433221100ffeeddccbbaa00112233445566778899"
+
+// fakeRoot creates a root file system holding the files.
+func fakeRoot(t *testing.T, files map[string]string) string {
+	dir, err := ioutil.TempDir("", "cpaniccontainer")
+	require.NoError(t, err)
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, ioutil.WriteFile(path, []byte(content), 0o644))
+	}
+	return dir
+}
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		want  cpaniccontainer.Info
+		ok    bool
+	}{
+		{
+			name: "cgroup v1",
+			files: map[string]string{
+				"proc/self/cgroup":                           "12:memory:/docker/" + id + "\n1:name=systemd:/docker/" + id + "\n",
+				"sys/fs/cgroup/memory/memory.limit_in_bytes": "268435456\n",
+			},
+			want: cpaniccontainer.Info{ID: id, Runtime: "docker", MemoryLimit: 268435456},
+			ok:   true,
+		},
+		{
+			name: "cgroup v2",
+			files: map[string]string{
+				"proc/self/cgroup":         "0::/\n",
+				"proc/self/mountinfo":      "1 2 8:1 /var/lib/docker/containers/" + id + "/hostname /etc/hostname rw - ext4 /dev/sda1 rw\n",
+				".dockerenv":               "",
+				"sys/fs/cgroup/memory.max": "max\n",
+			},
+			want: cpaniccontainer.Info{ID: id, Runtime: "docker"},
+			ok:   true,
+		},
+		{
+			name: "kubernetes",
+			files: map[string]string{
+				"proc/self/cgroup":         "0::/kubepods/burstable/pod1234/cri-containerd-" + id + ".scope\n",
+				"sys/fs/cgroup/memory.max": "536870912\n",
+			},
+			want: cpaniccontainer.Info{ID: id, Runtime: "containerd", MemoryLimit: 536870912},
+			ok:   true,
+		},
+		{
+			name:  "podman",
+			files: map[string]string{"run/.containerenv": ""},
+			want:  cpaniccontainer.Info{Runtime: "podman"},
+			ok:    true,
+		},
+		{
+			name:  "host",
+			files: map[string]string{"proc/self/cgroup": "0::/user.slice/user-1000.slice/session-1.scope\n"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := fakeRoot(t, tt.files)
+			defer os.RemoveAll(root)
+
+			info, ok := cpaniccontainer.Detect(cpaniccontainer.WithRoot(root), cpaniccontainer.WithImageEnv("CPANIC_TEST_UNSET"))
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.want, info)
+		})
+	}
+}
+
+func TestEnrich(t *testing.T) {
+	root := fakeRoot(t, map[string]string{
+		"proc/self/cgroup":             "0::/docker/" + id + "\n",
+		"sys/fs/cgroup/memory.max":     "268435456\n",
+		"sys/fs/cgroup/memory.current": "260000000\n",
+	})
+	defer os.RemoveAll(root)
+	require.NoError(t, os.Setenv("CPANIC_TEST_IMAGE", "example/app:1.2.3"))
+	defer os.Unsetenv("CPANIC_TEST_IMAGE")
+
+	var got *cpanic.Panic
+	h := cpaniccontainer.Enrich(func(p *cpanic.Panic) { got = p },
+		cpaniccontainer.WithRoot(root), cpaniccontainer.WithImageEnv("CPANIC_TEST_IMAGE"))
+	func() {
+		defer cpanic.Recover(h)
+		panic("not at a disco")
+	}()
+
+	require.NotNil(t, got)
+	assert.Equal(t, map[string]interface{}{
+		cpaniccontainer.IDAttr:          id,
+		cpaniccontainer.RuntimeAttr:     "docker",
+		cpaniccontainer.ImageAttr:       "example/app:1.2.3",
+		cpaniccontainer.MemoryLimitAttr: int64(268435456),
+		cpaniccontainer.MemoryUsageAttr: int64(260000000),
+	}, got.Attrs)
+}
+
+func TestEnrichHost(t *testing.T) {
+	root := fakeRoot(t, nil)
+	defer os.RemoveAll(root)
+
+	called := false
+	h := cpaniccontainer.Enrich(func(p *cpanic.Panic) {
+		called = true
+		assert.Empty(t, p.Attrs)
+	}, cpaniccontainer.WithRoot(root))
+	h.Handle(cpanic.New("not at a disco"))
+	assert.True(t, called)
+}
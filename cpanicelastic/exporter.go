@@ -0,0 +1,279 @@
+// cpanicelastic reports panics as errors to Elastic APM using the intake protocol of
+// the APM Server, without depending on the Elastic APM Go agent. Errors reported
+// through `(*Exporter).HandlerFunc` are correlated with the transaction carried by the
+// context, as returned by `WithTraceContext`.
+package cpanicelastic
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"path"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/demosdemon/cpanic"
+)
+
+// DefaultTimeout is the default time allowed for each intake request made by `Handler`.
+const DefaultTimeout = 10 * time.Second
+
+// intakePath is the path of the events intake endpoint of the APM Server.
+const intakePath = "/intake/v2/events"
+
+// agentName is the name of the agent reported in the metadata of every event.
+const agentName = "cpanic"
+
+// TraceContext identifies the transaction or span that was active when a panic was
+// recovered, in the hexadecimal W3C form used by Elastic APM.
+type TraceContext struct {
+	TraceID       string
+	TransactionID string
+	// ParentID is the ID of the span active at the time, or the transaction.
+	ParentID string
+}
+
+// Option configures an `Exporter`.
+type Option func(*Exporter)
+
+// WithHTTPClient sets the client used to send intake requests.
+func WithHTTPClient(c *http.Client) Option {
+	return func(e *Exporter) {
+		e.client = c
+	}
+}
+
+// WithSecretToken authenticates with the secret token of the APM Server.
+func WithSecretToken(token string) Option {
+	return func(e *Exporter) {
+		e.auth = "Bearer " + token
+	}
+}
+
+// WithAPIKey authenticates with an API key, the base64 "id:key" form.
+func WithAPIKey(key string) Option {
+	return func(e *Exporter) {
+		e.auth = "ApiKey " + key
+	}
+}
+
+// WithEnvironment sets the environment of the service, such as "production".
+func WithEnvironment(env string) Option {
+	return func(e *Exporter) {
+		e.environment = env
+	}
+}
+
+// WithTraceContext sets the function returning the trace context carried by a context,
+// such as one reading the transaction of the Elastic APM agent, so errors are shown
+// with the transaction that panicked.
+func WithTraceContext(fn func(ctx context.Context) (TraceContext, bool)) Option {
+	return func(e *Exporter) {
+		e.traceContext = fn
+	}
+}
+
+// WithTimeout sets the time allowed for each intake request made by `Handler`.
+func WithTimeout(d time.Duration) Option {
+	return func(e *Exporter) {
+		e.timeout = d
+	}
+}
+
+// Exporter sends panics to an APM Server.
+type Exporter struct {
+	url          string
+	service      string
+	environment  string
+	auth         string
+	client       *http.Client
+	timeout      time.Duration
+	traceContext func(ctx context.Context) (TraceContext, bool)
+}
+
+// New creates an exporter sending to the APM Server at serverURL, such as
+// "http://localhost:8200", on behalf of the named service.
+func New(serverURL, service string, opts ...Option) *Exporter {
+	e := &Exporter{
+		url:     strings.TrimSuffix(serverURL, "/") + intakePath,
+		service: service,
+		client:  http.DefaultClient,
+		timeout: DefaultTimeout,
+	}
+	for _, opt := range opts {
+		opt(e)
+	}
+	return e
+}
+
+// Handler returns a `cpanic.Handler` that exports each panic, discarding export errors.
+func (e *Exporter) Handler() cpanic.Handler {
+	return func(p *cpanic.Panic) {
+		ctx, cancel := context.WithTimeout(context.Background(), e.timeout)
+		defer cancel()
+		_ = e.Export(ctx, p)
+	}
+}
+
+// HandlerFunc returns a `cpanic.HandlerFunc` that exports each panic with the trace
+// context of ctx, returning the export error.
+func (e *Exporter) HandlerFunc() cpanic.HandlerFunc {
+	return e.Export
+}
+
+// Export sends the panic as an unhandled error with the culprit and frames of the
+// panicking goroutine.
+func (e *Exporter) Export(ctx context.Context, p *cpanic.Panic) error {
+	var tc TraceContext
+	if e.traceContext != nil {
+		tc, _ = e.traceContext(ctx)
+	}
+
+	var body bytes.Buffer
+	enc := json.NewEncoder(&body)
+	if err := enc.Encode(e.metadata()); err != nil {
+		return err
+	}
+	if err := enc.Encode(map[string]interface{}{"error": newError(p, tc)}); err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, e.url, &body)
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	if e.auth != "" {
+		req.Header.Set("Authorization", e.auth)
+	}
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(ioutil.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("cpanicelastic: intake failed: %s", resp.Status)
+	}
+	return nil
+}
+
+func (e *Exporter) metadata() map[string]interface{} {
+	service := map[string]interface{}{
+		"name":     e.service,
+		"agent":    map[string]string{"name": agentName, "version": "1"},
+		"language": map[string]string{"name": "go", "version": runtime.Version()},
+	}
+	if e.environment != "" {
+		service["environment"] = e.environment
+	}
+	return map[string]interface{}{"metadata": map[string]interface{}{"service": service}}
+}
+
+// The types below mirror the error event of the intake protocol.
+
+type apmError struct {
+	ID            string         `json:"id"`
+	Timestamp     int64          `json:"timestamp"`
+	TraceID       string         `json:"trace_id,omitempty"`
+	TransactionID string         `json:"transaction_id,omitempty"`
+	ParentID      string         `json:"parent_id,omitempty"`
+	Culprit       string         `json:"culprit,omitempty"`
+	Exception     apmException   `json:"exception"`
+	Context       *apmErrContext `json:"context,omitempty"`
+}
+
+type apmException struct {
+	Message    string          `json:"message"`
+	Type       string          `json:"type"`
+	Handled    bool            `json:"handled"`
+	Stacktrace []apmStackFrame `json:"stacktrace,omitempty"`
+}
+
+type apmStackFrame struct {
+	Function string `json:"function"`
+	Module   string `json:"module,omitempty"`
+	Filename string `json:"filename"`
+	AbsPath  string `json:"abs_path"`
+	Lineno   int    `json:"lineno"`
+}
+
+type apmErrContext struct {
+	Labels map[string]interface{} `json:"labels"`
+}
+
+func newError(p *cpanic.Panic, tc TraceContext) apmError {
+	t := p.Time
+	if t.IsZero() {
+		t = time.Now()
+	}
+	e := apmError{
+		ID:            p.ID,
+		Timestamp:     t.UnixNano() / int64(time.Microsecond),
+		TraceID:       tc.TraceID,
+		TransactionID: tc.TransactionID,
+		ParentID:      tc.ParentID,
+		Exception: apmException{
+			Message: p.Message(),
+			Type:    p.Type(),
+			// The panic was recovered, but not handled by the code that raised it.
+			Handled: false,
+		},
+	}
+	if f, ok := p.Culprit(); ok {
+		e.Culprit = f.Function
+	}
+	for _, f := range p.Frames() {
+		module, function := splitFunction(f.Function)
+		e.Exception.Stacktrace = append(e.Exception.Stacktrace, apmStackFrame{
+			Function: function,
+			Module:   module,
+			Filename: path.Base(f.File),
+			AbsPath:  f.File,
+			Lineno:   f.Line,
+		})
+	}
+	if len(p.Attrs) > 0 {
+		labels := make(map[string]interface{}, len(p.Attrs))
+		for k, v := range p.Attrs {
+			labels[labelKey(k)] = labelValue(v)
+		}
+		e.Context = &apmErrContext{Labels: labels}
+	}
+	return e
+}
+
+// splitFunction splits a qualified function name, such as "github.com/a/b.(*T).M", into
+// its package path and the function name within it.
+func splitFunction(fn string) (module, function string) {
+	slash := strings.LastIndex(fn, "/")
+	dot := strings.Index(fn[slash+1:], ".")
+	if dot < 0 {
+		return "", fn
+	}
+	return fn[:slash+1+dot], fn[slash+2+dot:]
+}
+
+// labelKey replaces the characters not allowed in label keys.
+func labelKey(k string) string {
+	return strings.NewReplacer(".", "_", "*", "_", `"`, "_").Replace(k)
+}
+
+// labelValue returns the value as a label value, which must be a string, number, or
+// boolean.
+func labelValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case string, bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		return v
+	default:
+		return fmt.Sprint(v)
+	}
+}
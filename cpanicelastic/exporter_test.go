@@ -0,0 +1,94 @@
+package cpanicelastic_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/demosdemon/cpanic"
+	"github.com/demosdemon/cpanic/cpanicelastic"
+)
+
+type traceKey struct{}
+
+func TestExporter(t *testing.T) {
+	var (
+		path    string
+		headers http.Header
+		events  []map[string]map[string]interface{}
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path, headers = r.URL.Path, r.Header
+		s := bufio.NewScanner(r.Body)
+		for s.Scan() {
+			var event map[string]map[string]interface{}
+			assert.NoError(t, json.Unmarshal(s.Bytes(), &event))
+			events = append(events, event)
+		}
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer srv.Close()
+
+	e := cpanicelastic.New(srv.URL+"/", "checkout",
+		cpanicelastic.WithSecretToken("secret"),
+		cpanicelastic.WithEnvironment("production"),
+		cpanicelastic.WithTraceContext(func(ctx context.Context) (cpanicelastic.TraceContext, bool) {
+			tc, ok := ctx.Value(traceKey{}).(cpanicelastic.TraceContext)
+			return tc, ok
+		}),
+	)
+
+	p := &cpanic.Panic{
+		ID:    "01F2",
+		Value: "not at a disco",
+		Trace: "goroutine 1 [running]:\ngithub.com/a/b.(*T).M()\n\t/src/b/t.go:3 +0x1d\n",
+	}
+	p.SetAttr("http.route", "/cart")
+	tc := cpanicelastic.TraceContext{TraceID: "0af7651916cd43dd8448eb211c80319c", TransactionID: "b7ad6b7169203331", ParentID: "b7ad6b7169203331"}
+	ctx := context.WithValue(context.Background(), traceKey{}, tc)
+	require.NoError(t, e.HandlerFunc()(ctx, p))
+
+	assert.Equal(t, "/intake/v2/events", path)
+	assert.Equal(t, "Bearer secret", headers.Get("Authorization"))
+	assert.Equal(t, "application/x-ndjson", headers.Get("Content-Type"))
+	require.Len(t, events, 2)
+
+	service := events[0]["metadata"]["service"].(map[string]interface{})
+	assert.Equal(t, "checkout", service["name"])
+	assert.Equal(t, "production", service["environment"])
+
+	apmErr := events[1]["error"]
+	assert.Equal(t, "01F2", apmErr["id"])
+	assert.Equal(t, tc.TraceID, apmErr["trace_id"])
+	assert.Equal(t, tc.TransactionID, apmErr["transaction_id"])
+	assert.Equal(t, "github.com/a/b.(*T).M", apmErr["culprit"])
+	assert.Equal(t, map[string]interface{}{
+		"message": "not at a disco",
+		"type":    "string",
+		"handled": false,
+		"stacktrace": []interface{}{map[string]interface{}{
+			"function": "(*T).M",
+			"module":   "github.com/a/b",
+			"filename": "t.go",
+			"abs_path": "/src/b/t.go",
+			"lineno":   float64(3),
+		}},
+	}, apmErr["exception"])
+	assert.Equal(t, map[string]interface{}{"labels": map[string]interface{}{"http_route": "/cart"}}, apmErr["context"])
+}
+
+func TestExporterError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer srv.Close()
+
+	err := cpanicelastic.New(srv.URL, "checkout").Export(context.Background(), cpanic.New("not at a disco"))
+	assert.EqualError(t, err, "cpanicelastic: intake failed: 403 Forbidden")
+}
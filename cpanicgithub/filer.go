@@ -0,0 +1,284 @@
+// cpanicgithub files panics as GitHub issues, a lightweight crash tracker for open
+// source tools.
+package cpanicgithub
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/demosdemon/cpanic"
+)
+
+// DefaultBaseURL is the GitHub REST API endpoint.
+const DefaultBaseURL = "https://api.github.com"
+
+// DefaultTimeout is the default time allowed for filing each panic by `Handler`.
+const DefaultTimeout = 30 * time.Second
+
+// DefaultCommentInterval is the default minimum time between two occurrence comments
+// for the same fingerprint.
+const DefaultCommentInterval = time.Hour
+
+// maxTitleMessage is the maximum length of the panic message in an issue title.
+const maxTitleMessage = 120
+
+// maxBody is the maximum length of an issue or comment body, in bytes. GitHub rejects
+// bodies over 65,536 characters, and a character is at least one byte.
+const maxBody = 65000
+
+// truncatedBody ends a body cut to `maxBody`.
+const truncatedBody = "\n\n…(truncated)\n"
+
+// Option configures a `Filer`.
+type Option func(*Filer)
+
+// WithBaseURL sets the REST API endpoint, such as "https://github.example.com/api/v3"
+// for GitHub Enterprise Server.
+func WithBaseURL(u string) Option {
+	return func(f *Filer) {
+		f.baseURL = strings.TrimSuffix(u, "/")
+	}
+}
+
+// WithHTTPClient sets the client used to call the API.
+func WithHTTPClient(c *http.Client) Option {
+	return func(f *Filer) {
+		f.client = c
+	}
+}
+
+// WithLabels sets the labels applied to new issues.
+func WithLabels(labels ...string) Option {
+	return func(f *Filer) {
+		f.labels = labels
+	}
+}
+
+// WithCommentInterval sets the minimum time between two occurrence comments for the
+// same fingerprint. Occurrences within the interval are dropped, so a panic storm
+// leaves a single comment.
+func WithCommentInterval(d time.Duration) Option {
+	return func(f *Filer) {
+		f.interval = d
+	}
+}
+
+// WithTimeout sets the time allowed for filing each panic by `Handler`.
+func WithTimeout(d time.Duration) Option {
+	return func(f *Filer) {
+		f.timeout = d
+	}
+}
+
+// WithFormatter renders issue and comment bodies with fm. It defaults to
+// `cpanic.Markdown`.
+func WithFormatter(fm cpanic.Formatter) Option {
+	return func(f *Filer) {
+		f.format = fm
+	}
+}
+
+// Filer files panics as issues in a GitHub repository. Each fingerprint gets a single
+// open issue, with its fingerprint in the title; later occurrences are added as
+// comments.
+type Filer struct {
+	token    string
+	repo     string
+	baseURL  string
+	client   *http.Client
+	labels   []string
+	interval time.Duration
+	timeout  time.Duration
+	format   cpanic.Formatter
+
+	mu   sync.Mutex
+	seen map[string]time.Time
+}
+
+// New creates a filer for the repository, given as "owner/name", authenticating with
+// token. The token needs permission to read and write issues.
+func New(token, repo string, opts ...Option) *Filer {
+	f := &Filer{
+		token:    token,
+		repo:     repo,
+		baseURL:  DefaultBaseURL,
+		client:   http.DefaultClient,
+		interval: DefaultCommentInterval,
+		timeout:  DefaultTimeout,
+		format:   cpanic.Markdown,
+		seen:     make(map[string]time.Time),
+	}
+	for _, opt := range opts {
+		opt(f)
+	}
+	return f
+}
+
+// Handler returns a `cpanic.Handler` that files each panic, discarding errors.
+func (f *Filer) Handler() cpanic.Handler {
+	return func(p *cpanic.Panic) {
+		ctx, cancel := context.WithTimeout(context.Background(), f.timeout)
+		defer cancel()
+		_ = f.File(ctx, p)
+	}
+}
+
+// File comments on the open issue for the panic's fingerprint, or opens one if there is
+// none. Both bodies are rendered by the formatter, `cpanic.Markdown` by default, and cut
+// short to fit GitHub's limit. Calls are serialized so that concurrent occurrences of a
+// new panic open a single issue.
+func (f *Filer) File(ctx context.Context, p *cpanic.Panic) error {
+	fingerprint := p.Fingerprint()
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if last, ok := f.seen[fingerprint]; ok && time.Since(last) < f.interval {
+		return nil
+	}
+
+	body, err := f.format.Format(p)
+	if err != nil {
+		return err
+	}
+
+	number, err := f.search(ctx, fingerprint)
+	if err != nil {
+		return err
+	}
+
+	if number == 0 {
+		err = f.post(ctx, "/repos/"+f.repo+"/issues", issue{
+			Title:  title(p, fingerprint),
+			Body:   truncate(string(body), maxBody),
+			Labels: f.labels,
+		})
+	} else {
+		err = f.post(ctx, fmt.Sprintf("/repos/%s/issues/%d/comments", f.repo, number), comment{
+			Body: truncate("Occurred again.\n\n"+string(body), maxBody),
+		})
+	}
+	if err != nil {
+		return err
+	}
+
+	f.seen[fingerprint] = time.Now()
+	return nil
+}
+
+type issue struct {
+	Title  string   `json:"title"`
+	Body   string   `json:"body"`
+	Labels []string `json:"labels,omitempty"`
+}
+
+type comment struct {
+	Body string `json:"body"`
+}
+
+type searchResult struct {
+	Items []struct {
+		Number int    `json:"number"`
+		Title  string `json:"title"`
+	} `json:"items"`
+}
+
+// title formats the issue title, ending with the fingerprint used to find it again.
+func title(p *cpanic.Panic, fingerprint string) string {
+	msg := strings.Join(strings.Fields(p.Error()), " ")
+	if len(msg) > maxTitleMessage {
+		msg = msg[:runeBoundary(msg, maxTitleMessage)] + "…"
+	}
+	return fmt.Sprintf("%s [%s]", msg, fingerprint)
+}
+
+// truncate cuts s to at most n bytes, marking the cut with `truncatedBody`.
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:runeBoundary(s, n-len(truncatedBody))] + truncatedBody
+}
+
+// runeBoundary returns the largest index no greater than n that does not split a rune of
+// s.
+func runeBoundary(s string, n int) int {
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return n
+}
+
+// search returns the number of the open issue with fingerprint in its title, or 0.
+func (f *Filer) search(ctx context.Context, fingerprint string) (int, error) {
+	q := fmt.Sprintf("repo:%s is:issue is:open in:title %q", f.repo, fingerprint)
+	req, err := f.request(ctx, http.MethodGet, "/search/issues?q="+url.QueryEscape(q), nil)
+	if err != nil {
+		return 0, err
+	}
+
+	var result searchResult
+	if err := f.do(req, &result); err != nil {
+		return 0, err
+	}
+	for _, item := range result.Items {
+		// The search is full-text, so check for an exact match.
+		if strings.Contains(item.Title, "["+fingerprint+"]") {
+			return item.Number, nil
+		}
+	}
+	return 0, nil
+}
+
+func (f *Filer) post(ctx context.Context, path string, v interface{}) error {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	req, err := f.request(ctx, http.MethodPost, path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	return f.do(req, nil)
+}
+
+func (f *Filer) request(ctx context.Context, method, path string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequest(method, f.baseURL+path, body)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("Authorization", "Bearer "+f.token)
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	return req, nil
+}
+
+func (f *Filer) do(req *http.Request, v interface{}) error {
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		_, _ = io.Copy(ioutil.Discard, resp.Body)
+		return fmt.Errorf("cpanicgithub: %s %s: %s", req.Method, req.URL.Path, resp.Status)
+	}
+	if v == nil {
+		_, _ = io.Copy(ioutil.Discard, resp.Body)
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
@@ -0,0 +1,168 @@
+package cpanicgithub_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/demosdemon/cpanic"
+	"github.com/demosdemon/cpanic/cpanicgithub"
+)
+
+// fakeGitHub implements just enough of the issues API for the filer.
+type fakeGitHub struct {
+	mu       sync.Mutex
+	issues   []map[string]interface{}
+	comments map[int][]string
+	searches []string
+}
+
+func (g *fakeGitHub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if r.Header.Get("Authorization") != "Bearer token" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == "/search/issues":
+		q := r.URL.Query().Get("q")
+		g.searches = append(g.searches, q)
+		var items []map[string]interface{}
+		for _, issue := range g.issues {
+			fp := q[strings.LastIndex(q, " ")+2 : len(q)-1]
+			if strings.Contains(issue["title"].(string), fp) {
+				items = append(items, issue)
+			}
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"items": items})
+
+	case r.Method == http.MethodPost && r.URL.Path == "/repos/owner/repo/issues":
+		var issue map[string]interface{}
+		_ = json.NewDecoder(r.Body).Decode(&issue)
+		issue["number"] = len(g.issues) + 1
+		g.issues = append(g.issues, issue)
+		w.WriteHeader(http.StatusCreated)
+
+	case r.Method == http.MethodPost && strings.HasPrefix(r.URL.Path, "/repos/owner/repo/issues/"):
+		var number int
+		_, _ = fmt.Sscanf(r.URL.Path, "/repos/owner/repo/issues/%d/comments", &number)
+		var comment map[string]string
+		_ = json.NewDecoder(r.Body).Decode(&comment)
+		g.comments[number] = append(g.comments[number], comment["body"])
+		w.WriteHeader(http.StatusCreated)
+
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func TestFiler(t *testing.T) {
+	gh := &fakeGitHub{comments: make(map[int][]string)}
+	srv := httptest.NewServer(gh)
+	defer srv.Close()
+
+	f := cpanicgithub.New("token", "owner/repo",
+		cpanicgithub.WithBaseURL(srv.URL),
+		cpanicgithub.WithLabels("crash"),
+		cpanicgithub.WithCommentInterval(0),
+	)
+
+	p := cpanic.New("not at a disco")
+	require.NoError(t, f.File(context.Background(), p))
+	require.Len(t, gh.issues, 1)
+	assert.Equal(t, "panic: not at a disco ["+p.Fingerprint()+"]", gh.issues[0]["title"])
+	assert.Equal(t, p.Markdown(), gh.issues[0]["body"])
+	assert.Equal(t, []interface{}{"crash"}, gh.issues[0]["labels"])
+	assert.Equal(t, `repo:owner/repo is:issue is:open in:title "`+p.Fingerprint()+`"`, gh.searches[0])
+
+	require.NoError(t, f.File(context.Background(), p))
+	require.Len(t, gh.issues, 1)
+	require.Len(t, gh.comments[1], 1)
+	assert.Equal(t, "Occurred again.\n\n"+p.Markdown(), gh.comments[1][0])
+
+	other := cpanic.New(fmt.Errorf("boom"))
+	require.NoError(t, f.File(context.Background(), other))
+	assert.Len(t, gh.issues, 2)
+}
+
+func TestFilerDedupe(t *testing.T) {
+	gh := &fakeGitHub{comments: make(map[int][]string)}
+	srv := httptest.NewServer(gh)
+	defer srv.Close()
+
+	h := cpanicgithub.New("token", "owner/repo",
+		cpanicgithub.WithBaseURL(srv.URL),
+		cpanicgithub.WithCommentInterval(time.Hour),
+	).Handler()
+
+	p := cpanic.New("not at a disco")
+	for i := 0; i < 3; i++ {
+		h(p)
+	}
+	assert.Len(t, gh.issues, 1)
+	assert.Empty(t, gh.comments)
+	assert.Len(t, gh.searches, 1)
+}
+
+func TestFilerError(t *testing.T) {
+	gh := &fakeGitHub{comments: make(map[int][]string)}
+	srv := httptest.NewServer(gh)
+	defer srv.Close()
+
+	f := cpanicgithub.New("bad", "owner/repo", cpanicgithub.WithBaseURL(srv.URL))
+	err := f.File(context.Background(), cpanic.New("not at a disco"))
+	assert.EqualError(t, err, "cpanicgithub: GET /search/issues: 401 Unauthorized")
+}
+
+func TestFilerFormatter(t *testing.T) {
+	gh := &fakeGitHub{comments: make(map[int][]string)}
+	srv := httptest.NewServer(gh)
+	defer srv.Close()
+
+	f := cpanicgithub.New("token", "owner/repo",
+		cpanicgithub.WithBaseURL(srv.URL),
+		cpanicgithub.WithFormatter(cpanic.Text),
+	)
+
+	p := cpanic.New("not at a disco")
+	require.NoError(t, f.File(context.Background(), p))
+	require.Len(t, gh.issues, 1)
+	assert.Equal(t, strings.TrimRight(p.String(), "\n")+"\n", gh.issues[0]["body"])
+}
+
+func TestFilerTruncate(t *testing.T) {
+	gh := &fakeGitHub{comments: make(map[int][]string)}
+	srv := httptest.NewServer(gh)
+	defer srv.Close()
+
+	f := cpanicgithub.New("token", "owner/repo",
+		cpanicgithub.WithBaseURL(srv.URL),
+		cpanicgithub.WithFormatter(cpanic.Text),
+	)
+
+	p := cpanic.New(strings.Repeat("ü", 1<<16))
+	require.NoError(t, f.File(context.Background(), p))
+	require.Len(t, gh.issues, 1)
+
+	title := gh.issues[0]["title"].(string)
+	assert.True(t, utf8.ValidString(title), title)
+	assert.True(t, strings.HasSuffix(title, "… ["+p.Fingerprint()+"]"), title)
+
+	body := gh.issues[0]["body"].(string)
+	assert.True(t, utf8.ValidString(body))
+	assert.Less(t, utf8.RuneCountInString(body), 65536)
+	assert.True(t, strings.HasSuffix(body, "…(truncated)\n"))
+}
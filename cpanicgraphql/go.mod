@@ -0,0 +1,19 @@
+module github.com/demosdemon/cpanic/cpanicgraphql
+
+go 1.26
+
+require (
+	github.com/99designs/gqlgen v0.17.95
+	github.com/demosdemon/cpanic v0.0.0
+	github.com/stretchr/testify v1.12.1
+	github.com/vektah/gqlparser/v2 v2.5.37
+)
+
+require (
+	github.com/google/uuid v1.6.0 // indirect
+	github.com/sosodev/duration v1.4.0 // indirect
+	go.yaml.in/yaml/v3 v3.0.5 // indirect
+	golang.org/x/sync v0.22.0 // indirect
+)
+
+replace github.com/demosdemon/cpanic => ../
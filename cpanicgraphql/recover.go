@@ -0,0 +1,49 @@
+// cpanicgraphql provides a `github.com/99designs/gqlgen` integration for cpanic.
+package cpanicgraphql
+
+import (
+	"context"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+
+	"github.com/demosdemon/cpanic"
+)
+
+const (
+	// OperationAttr is the `cpanic.Panic` attribute key holding the name of the GraphQL
+	// operation that was executing when the panic occurred.
+	OperationAttr = "graphql.operation"
+	// PathAttr is the `cpanic.Panic` attribute key holding the path of the field whose
+	// resolver panicked.
+	PathAttr = "graphql.path"
+)
+
+// PublicMessage is the message returned to GraphQL clients in place of the panic
+// value. It matches the message used by `graphql.DefaultRecover`.
+const PublicMessage = "internal system error"
+
+// RecoverFunc returns a `graphql.RecoverFunc` that converts resolver panics into a
+// GraphQL error carrying only the panic's `PublicMessage`, or `PublicMessage` if it has
+// none. The full `*cpanic.Panic`, tagged with the operation name and field path when
+// available, is reported to the handler, if provided, so internal details never reach
+// the client.
+//
+//	srv.SetRecoverFunc(cpanicgraphql.RecoverFunc(handler))
+func RecoverFunc(h cpanic.Handler) graphql.RecoverFunc {
+	return func(ctx context.Context, value interface{}) error {
+		p := cpanic.New(value)
+		if graphql.HasOperationContext(ctx) {
+			if name := graphql.GetOperationContext(ctx).OperationName; name != "" {
+				p.SetAttr(OperationAttr, name)
+			}
+		}
+		if path := graphql.GetPath(ctx); len(path) > 0 {
+			p.SetAttr(PathAttr, path.String())
+		}
+
+		cpanic.Deliver(p, h)
+
+		return gqlerror.Errorf("%s", p.PublicMessageOr(PublicMessage))
+	}
+}
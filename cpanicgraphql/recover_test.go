@@ -0,0 +1,56 @@
+package cpanicgraphql_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/vektah/gqlparser/v2/ast"
+
+	"github.com/demosdemon/cpanic"
+	"github.com/demosdemon/cpanic/cpanicgraphql"
+)
+
+func TestRecoverFunc(t *testing.T) {
+	tests := []struct {
+		name  string
+		ctx   func() context.Context
+		attrs map[string]interface{}
+	}{
+		{
+			name: "without context",
+			ctx:  context.Background,
+		},
+		{
+			name: "with operation and path",
+			ctx: func() context.Context {
+				ctx := graphql.WithOperationContext(context.Background(), &graphql.OperationContext{
+					OperationName: "GetUser",
+				})
+				return graphql.WithFieldContext(ctx, &graphql.FieldContext{
+					Field: graphql.CollectedField{Field: &ast.Field{Alias: "user"}},
+				})
+			},
+			attrs: map[string]interface{}{
+				cpanicgraphql.OperationAttr: "GetUser",
+				cpanicgraphql.PathAttr:      "user",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			var handled *cpanic.Panic
+			fn := cpanicgraphql.RecoverFunc(func(p *cpanic.Panic) { handled = p })
+
+			err := fn(tt.ctx(), "not at a disco")
+			assert.EqualError(t, err, "input: "+cpanicgraphql.PublicMessage)
+			if assert.NotNil(t, handled) {
+				assert.Equal(t, "not at a disco", handled.Value)
+				assert.Equal(t, tt.attrs, handled.Attrs)
+			}
+		})
+	}
+}
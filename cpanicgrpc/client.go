@@ -0,0 +1,121 @@
+package cpanicgrpc
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+
+	"github.com/demosdemon/cpanic"
+	"github.com/demosdemon/cpanic/cpanicgrpc/collectorpb"
+)
+
+// DefaultTimeout is the default time allowed for each report sent by `Client.Send` on
+// behalf of `Client.Handler`.
+const DefaultTimeout = 10 * time.Second
+
+// Option configures a `Client`.
+type Option func(*Client)
+
+// WithTimeout sets the time allowed for each report sent by `Client.Send` on behalf of
+// `Client.Handler`.
+func WithTimeout(d time.Duration) Option {
+	return func(c *Client) {
+		c.timeout = d
+	}
+}
+
+// Client sends panics to a collector.
+type Client struct {
+	client  collectorpb.CollectorServiceClient
+	timeout time.Duration
+
+	mu     sync.Mutex
+	stream collectorpb.CollectorService_StreamReportsClient
+	cancel context.CancelFunc
+}
+
+// NewClient creates a client sending to the collector on conn, such as a
+// `*grpc.ClientConn`.
+func NewClient(conn grpc.ClientConnInterface, opts ...Option) *Client {
+	c := &Client{
+		client:  collectorpb.NewCollectorServiceClient(conn),
+		timeout: DefaultTimeout,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Send sends the panic with a single `Report` call, returning once the collector has
+// stored it.
+func (c *Client) Send(ctx context.Context, p *cpanic.Panic) error {
+	report, err := ToProto(p)
+	if err != nil {
+		return err
+	}
+	_, err = c.client.Report(ctx, &collectorpb.ReportRequest{Report: report})
+	return err
+}
+
+// Handler returns a `cpanic.Handler` that streams each panic to the collector over a
+// single `StreamReports` call, opened by the first panic. If the stream breaks, the
+// panic is sent with `Send` instead and a new stream is opened by the next panic.
+// Errors are discarded. `Close` ends the stream.
+func (c *Client) Handler() cpanic.Handler {
+	return func(p *cpanic.Panic) {
+		report, err := ToProto(p)
+		if err != nil {
+			return
+		}
+		if c.sendStream(report) {
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+		defer cancel()
+		_, _ = c.client.Report(ctx, &collectorpb.ReportRequest{Report: report})
+	}
+}
+
+// sendStream sends the report on the stream, opening it if needed, and reports whether it
+// was sent.
+func (c *Client) sendStream(report *collectorpb.PanicReport) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.stream == nil {
+		ctx, cancel := context.WithCancel(context.Background())
+		stream, err := c.client.StreamReports(ctx)
+		if err != nil {
+			cancel()
+			return false
+		}
+		c.stream, c.cancel = stream, cancel
+	}
+	if err := c.stream.Send(&collectorpb.ReportRequest{Report: report}); err != nil {
+		c.cancel()
+		c.stream, c.cancel = nil, nil
+		return false
+	}
+	return true
+}
+
+// Close ends the stream opened by `Handler`, if any, and returns the number of reports
+// the collector stored from it.
+func (c *Client) Close() (int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.stream == nil {
+		return 0, nil
+	}
+	defer c.cancel()
+	resp, err := c.stream.CloseAndRecv()
+	c.stream, c.cancel = nil, nil
+	if err != nil {
+		return 0, err
+	}
+	return resp.GetAccepted(), nil
+}
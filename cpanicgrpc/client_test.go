@@ -0,0 +1,85 @@
+package cpanicgrpc_test
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/demosdemon/cpanic"
+	"github.com/demosdemon/cpanic/cpanicgrpc"
+	"github.com/demosdemon/cpanic/cpanicgrpc/collectorpb"
+)
+
+// collector starts a server storing into a history and returns a connection to it.
+func collector(t *testing.T) (*cpanic.History, *grpc.ClientConn, func()) {
+	store := cpanic.NewHistory(0)
+	lis := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer()
+	collectorpb.RegisterCollectorServiceServer(srv, cpanicgrpc.NewServer(store))
+	go func() { _ = srv.Serve(lis) }()
+
+	conn, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	return store, conn, func() {
+		conn.Close()
+		srv.Stop()
+	}
+}
+
+func TestClientSend(t *testing.T) {
+	store, conn, stop := collector(t)
+	defer stop()
+
+	p := cpanic.New(testError{})
+	p.SetAttr("request_id", "abc")
+	p.SetAttr("retries", 3)
+	require.NoError(t, cpanicgrpc.NewClient(conn).Send(context.Background(), p))
+
+	panics := store.Panics()
+	require.Len(t, panics, 1)
+	got := panics[0]
+	assert.Equal(t, p.ID, got.ID)
+	assert.True(t, p.Time.Equal(got.Time))
+	assert.Equal(t, p.Message(), got.Value)
+	assert.Equal(t, p.Type(), got.Type())
+	assert.Equal(t, p.Fingerprint(), got.Fingerprint())
+	assert.Equal(t, p.Trace, got.Trace)
+	assert.Equal(t, map[string]interface{}{"request_id": "abc", "retries": float64(3)}, got.Attrs)
+}
+
+func TestClientHandler(t *testing.T) {
+	store, conn, stop := collector(t)
+	defer stop()
+
+	c := cpanicgrpc.NewClient(conn)
+	h := c.Handler()
+	h(cpanic.New("one"))
+	h(cpanic.New("two"))
+	accepted, err := c.Close()
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), accepted)
+
+	panics := store.Panics()
+	require.Len(t, panics, 2)
+	assert.Equal(t, "one", panics[0].Value)
+	assert.Equal(t, "two", panics[1].Value)
+
+	accepted, err = c.Close()
+	assert.NoError(t, err)
+	assert.Zero(t, accepted)
+}
+
+type testError struct{}
+
+func (testError) Error() string { return "not at a disco" }
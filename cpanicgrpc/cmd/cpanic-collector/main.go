@@ -0,0 +1,47 @@
+// cpanic-collector is the reference crash collector: it receives the panic reports of
+// a fleet over the gRPC `CollectorService`, keeps them in memory, and serves them over
+// HTTP with the `cpanicui` dashboard.
+//
+//	cpanic-collector [-grpc addr] [-http addr] [-capacity n]
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+
+	"google.golang.org/grpc"
+
+	"github.com/demosdemon/cpanic"
+	"github.com/demosdemon/cpanic/cpanicgrpc"
+	"github.com/demosdemon/cpanic/cpanicgrpc/collectorpb"
+	"github.com/demosdemon/cpanic/cpanicui"
+)
+
+func main() {
+	grpcAddr := flag.String("grpc", ":9090", "`address` to receive reports on")
+	httpAddr := flag.String("http", ":8080", "`address` to serve the dashboard on")
+	capacity := flag.Int("capacity", 10000, "maximum `number` of panics kept")
+	flag.Parse()
+
+	store := cpanic.NewHistory(*capacity)
+
+	lis, err := net.Listen("tcp", *grpcAddr)
+	if err != nil {
+		log.Fatal(err)
+	}
+	srv := grpc.NewServer()
+	collectorpb.RegisterCollectorServiceServer(srv, cpanicgrpc.NewServer(store))
+	go func() {
+		log.Fatal(srv.Serve(lis))
+	}()
+
+	log.Printf("receiving reports on %s, serving panics on %s", lis.Addr(), *httpAddr)
+	if err := http.ListenAndServe(*httpAddr, cpanicui.Handler(store)); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,358 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        (unknown)
+// source: collector.proto
+
+package collectorpb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	structpb "google.golang.org/protobuf/types/known/structpb"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// PanicReport mirrors the JSON report written by `cpanic.NDJSON`.
+type PanicReport struct {
+	state         protoimpl.MessageState     `protogen:"open.v1"`
+	Id            string                     `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Time          *timestamppb.Timestamp     `protobuf:"bytes,2,opt,name=time,proto3" json:"time,omitempty"`
+	Value         string                     `protobuf:"bytes,3,opt,name=value,proto3" json:"value,omitempty"`
+	Type          string                     `protobuf:"bytes,4,opt,name=type,proto3" json:"type,omitempty"`
+	Fingerprint   string                     `protobuf:"bytes,5,opt,name=fingerprint,proto3" json:"fingerprint,omitempty"`
+	BuildId       string                     `protobuf:"bytes,6,opt,name=build_id,json=buildId,proto3" json:"build_id,omitempty"`
+	Severity      string                     `protobuf:"bytes,7,opt,name=severity,proto3" json:"severity,omitempty"`
+	Culprit       string                     `protobuf:"bytes,8,opt,name=culprit,proto3" json:"culprit,omitempty"`
+	Attrs         map[string]*structpb.Value `protobuf:"bytes,9,rep,name=attrs,proto3" json:"attrs,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	Trace         string                     `protobuf:"bytes,10,opt,name=trace,proto3" json:"trace,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PanicReport) Reset() {
+	*x = PanicReport{}
+	mi := &file_collector_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PanicReport) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PanicReport) ProtoMessage() {}
+
+func (x *PanicReport) ProtoReflect() protoreflect.Message {
+	mi := &file_collector_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PanicReport.ProtoReflect.Descriptor instead.
+func (*PanicReport) Descriptor() ([]byte, []int) {
+	return file_collector_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *PanicReport) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *PanicReport) GetTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Time
+	}
+	return nil
+}
+
+func (x *PanicReport) GetValue() string {
+	if x != nil {
+		return x.Value
+	}
+	return ""
+}
+
+func (x *PanicReport) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *PanicReport) GetFingerprint() string {
+	if x != nil {
+		return x.Fingerprint
+	}
+	return ""
+}
+
+func (x *PanicReport) GetBuildId() string {
+	if x != nil {
+		return x.BuildId
+	}
+	return ""
+}
+
+func (x *PanicReport) GetSeverity() string {
+	if x != nil {
+		return x.Severity
+	}
+	return ""
+}
+
+func (x *PanicReport) GetCulprit() string {
+	if x != nil {
+		return x.Culprit
+	}
+	return ""
+}
+
+func (x *PanicReport) GetAttrs() map[string]*structpb.Value {
+	if x != nil {
+		return x.Attrs
+	}
+	return nil
+}
+
+func (x *PanicReport) GetTrace() string {
+	if x != nil {
+		return x.Trace
+	}
+	return ""
+}
+
+type ReportRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Report        *PanicReport           `protobuf:"bytes,1,opt,name=report,proto3" json:"report,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReportRequest) Reset() {
+	*x = ReportRequest{}
+	mi := &file_collector_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReportRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReportRequest) ProtoMessage() {}
+
+func (x *ReportRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_collector_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReportRequest.ProtoReflect.Descriptor instead.
+func (*ReportRequest) Descriptor() ([]byte, []int) {
+	return file_collector_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *ReportRequest) GetReport() *PanicReport {
+	if x != nil {
+		return x.Report
+	}
+	return nil
+}
+
+type ReportResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReportResponse) Reset() {
+	*x = ReportResponse{}
+	mi := &file_collector_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReportResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReportResponse) ProtoMessage() {}
+
+func (x *ReportResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_collector_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReportResponse.ProtoReflect.Descriptor instead.
+func (*ReportResponse) Descriptor() ([]byte, []int) {
+	return file_collector_proto_rawDescGZIP(), []int{2}
+}
+
+type StreamReportsResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The number of reports stored.
+	Accepted      int64 `protobuf:"varint,1,opt,name=accepted,proto3" json:"accepted,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StreamReportsResponse) Reset() {
+	*x = StreamReportsResponse{}
+	mi := &file_collector_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StreamReportsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamReportsResponse) ProtoMessage() {}
+
+func (x *StreamReportsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_collector_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamReportsResponse.ProtoReflect.Descriptor instead.
+func (*StreamReportsResponse) Descriptor() ([]byte, []int) {
+	return file_collector_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *StreamReportsResponse) GetAccepted() int64 {
+	if x != nil {
+		return x.Accepted
+	}
+	return 0
+}
+
+var File_collector_proto protoreflect.FileDescriptor
+
+const file_collector_proto_rawDesc = "" +
+	"\n" +
+	"\x0fcollector.proto\x12\x13cpanic.collector.v1\x1a\x1cgoogle/protobuf/struct.proto\x1a\x1fgoogle/protobuf/timestamp.proto\"\x95\x03\n" +
+	"\vPanicReport\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12.\n" +
+	"\x04time\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\x04time\x12\x14\n" +
+	"\x05value\x18\x03 \x01(\tR\x05value\x12\x12\n" +
+	"\x04type\x18\x04 \x01(\tR\x04type\x12 \n" +
+	"\vfingerprint\x18\x05 \x01(\tR\vfingerprint\x12\x19\n" +
+	"\bbuild_id\x18\x06 \x01(\tR\abuildId\x12\x1a\n" +
+	"\bseverity\x18\a \x01(\tR\bseverity\x12\x18\n" +
+	"\aculprit\x18\b \x01(\tR\aculprit\x12A\n" +
+	"\x05attrs\x18\t \x03(\v2+.cpanic.collector.v1.PanicReport.AttrsEntryR\x05attrs\x12\x14\n" +
+	"\x05trace\x18\n" +
+	" \x01(\tR\x05trace\x1aP\n" +
+	"\n" +
+	"AttrsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12,\n" +
+	"\x05value\x18\x02 \x01(\v2\x16.google.protobuf.ValueR\x05value:\x028\x01\"I\n" +
+	"\rReportRequest\x128\n" +
+	"\x06report\x18\x01 \x01(\v2 .cpanic.collector.v1.PanicReportR\x06report\"\x10\n" +
+	"\x0eReportResponse\"3\n" +
+	"\x15StreamReportsResponse\x12\x1a\n" +
+	"\baccepted\x18\x01 \x01(\x03R\baccepted2\xc8\x01\n" +
+	"\x10CollectorService\x12Q\n" +
+	"\x06Report\x12\".cpanic.collector.v1.ReportRequest\x1a#.cpanic.collector.v1.ReportResponse\x12a\n" +
+	"\rStreamReports\x12\".cpanic.collector.v1.ReportRequest\x1a*.cpanic.collector.v1.StreamReportsResponse(\x01B5Z3github.com/demosdemon/cpanic/cpanicgrpc/collectorpbb\x06proto3"
+
+var (
+	file_collector_proto_rawDescOnce sync.Once
+	file_collector_proto_rawDescData []byte
+)
+
+func file_collector_proto_rawDescGZIP() []byte {
+	file_collector_proto_rawDescOnce.Do(func() {
+		file_collector_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_collector_proto_rawDesc), len(file_collector_proto_rawDesc)))
+	})
+	return file_collector_proto_rawDescData
+}
+
+var file_collector_proto_msgTypes = make([]protoimpl.MessageInfo, 5)
+var file_collector_proto_goTypes = []any{
+	(*PanicReport)(nil),           // 0: cpanic.collector.v1.PanicReport
+	(*ReportRequest)(nil),         // 1: cpanic.collector.v1.ReportRequest
+	(*ReportResponse)(nil),        // 2: cpanic.collector.v1.ReportResponse
+	(*StreamReportsResponse)(nil), // 3: cpanic.collector.v1.StreamReportsResponse
+	nil,                           // 4: cpanic.collector.v1.PanicReport.AttrsEntry
+	(*timestamppb.Timestamp)(nil), // 5: google.protobuf.Timestamp
+	(*structpb.Value)(nil),        // 6: google.protobuf.Value
+}
+var file_collector_proto_depIdxs = []int32{
+	5, // 0: cpanic.collector.v1.PanicReport.time:type_name -> google.protobuf.Timestamp
+	4, // 1: cpanic.collector.v1.PanicReport.attrs:type_name -> cpanic.collector.v1.PanicReport.AttrsEntry
+	0, // 2: cpanic.collector.v1.ReportRequest.report:type_name -> cpanic.collector.v1.PanicReport
+	6, // 3: cpanic.collector.v1.PanicReport.AttrsEntry.value:type_name -> google.protobuf.Value
+	1, // 4: cpanic.collector.v1.CollectorService.Report:input_type -> cpanic.collector.v1.ReportRequest
+	1, // 5: cpanic.collector.v1.CollectorService.StreamReports:input_type -> cpanic.collector.v1.ReportRequest
+	2, // 6: cpanic.collector.v1.CollectorService.Report:output_type -> cpanic.collector.v1.ReportResponse
+	3, // 7: cpanic.collector.v1.CollectorService.StreamReports:output_type -> cpanic.collector.v1.StreamReportsResponse
+	6, // [6:8] is the sub-list for method output_type
+	4, // [4:6] is the sub-list for method input_type
+	4, // [4:4] is the sub-list for extension type_name
+	4, // [4:4] is the sub-list for extension extendee
+	0, // [0:4] is the sub-list for field type_name
+}
+
+func init() { file_collector_proto_init() }
+func file_collector_proto_init() {
+	if File_collector_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_collector_proto_rawDesc), len(file_collector_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   5,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_collector_proto_goTypes,
+		DependencyIndexes: file_collector_proto_depIdxs,
+		MessageInfos:      file_collector_proto_msgTypes,
+	}.Build()
+	File_collector_proto = out.File
+	file_collector_proto_goTypes = nil
+	file_collector_proto_depIdxs = nil
+}
@@ -0,0 +1,163 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: collector.proto
+
+package collectorpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	CollectorService_Report_FullMethodName        = "/cpanic.collector.v1.CollectorService/Report"
+	CollectorService_StreamReports_FullMethodName = "/cpanic.collector.v1.CollectorService/StreamReports"
+)
+
+// CollectorServiceClient is the client API for CollectorService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// CollectorService receives panic reports from the processes of a fleet.
+type CollectorServiceClient interface {
+	// Report stores a single panic report.
+	Report(ctx context.Context, in *ReportRequest, opts ...grpc.CallOption) (*ReportResponse, error)
+	// StreamReports stores every report sent on the stream, acknowledging them once the
+	// client closes it.
+	StreamReports(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[ReportRequest, StreamReportsResponse], error)
+}
+
+type collectorServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewCollectorServiceClient(cc grpc.ClientConnInterface) CollectorServiceClient {
+	return &collectorServiceClient{cc}
+}
+
+func (c *collectorServiceClient) Report(ctx context.Context, in *ReportRequest, opts ...grpc.CallOption) (*ReportResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ReportResponse)
+	err := c.cc.Invoke(ctx, CollectorService_Report_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *collectorServiceClient) StreamReports(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[ReportRequest, StreamReportsResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &CollectorService_ServiceDesc.Streams[0], CollectorService_StreamReports_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[ReportRequest, StreamReportsResponse]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type CollectorService_StreamReportsClient = grpc.ClientStreamingClient[ReportRequest, StreamReportsResponse]
+
+// CollectorServiceServer is the server API for CollectorService service.
+// All implementations must embed UnimplementedCollectorServiceServer
+// for forward compatibility.
+//
+// CollectorService receives panic reports from the processes of a fleet.
+type CollectorServiceServer interface {
+	// Report stores a single panic report.
+	Report(context.Context, *ReportRequest) (*ReportResponse, error)
+	// StreamReports stores every report sent on the stream, acknowledging them once the
+	// client closes it.
+	StreamReports(grpc.ClientStreamingServer[ReportRequest, StreamReportsResponse]) error
+	mustEmbedUnimplementedCollectorServiceServer()
+}
+
+// UnimplementedCollectorServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedCollectorServiceServer struct{}
+
+func (UnimplementedCollectorServiceServer) Report(context.Context, *ReportRequest) (*ReportResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Report not implemented")
+}
+func (UnimplementedCollectorServiceServer) StreamReports(grpc.ClientStreamingServer[ReportRequest, StreamReportsResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method StreamReports not implemented")
+}
+func (UnimplementedCollectorServiceServer) mustEmbedUnimplementedCollectorServiceServer() {}
+func (UnimplementedCollectorServiceServer) testEmbeddedByValue()                          {}
+
+// UnsafeCollectorServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to CollectorServiceServer will
+// result in compilation errors.
+type UnsafeCollectorServiceServer interface {
+	mustEmbedUnimplementedCollectorServiceServer()
+}
+
+func RegisterCollectorServiceServer(s grpc.ServiceRegistrar, srv CollectorServiceServer) {
+	// If the following call pancis, it indicates UnimplementedCollectorServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&CollectorService_ServiceDesc, srv)
+}
+
+func _CollectorService_Report_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReportRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CollectorServiceServer).Report(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CollectorService_Report_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CollectorServiceServer).Report(ctx, req.(*ReportRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CollectorService_StreamReports_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(CollectorServiceServer).StreamReports(&grpc.GenericServerStream[ReportRequest, StreamReportsResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type CollectorService_StreamReportsServer = grpc.ClientStreamingServer[ReportRequest, StreamReportsResponse]
+
+// CollectorService_ServiceDesc is the grpc.ServiceDesc for CollectorService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var CollectorService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "cpanic.collector.v1.CollectorService",
+	HandlerType: (*CollectorServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Report",
+			Handler:    _CollectorService_Report_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamReports",
+			Handler:       _CollectorService_StreamReports_Handler,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "collector.proto",
+}
@@ -0,0 +1,26 @@
+module github.com/demosdemon/cpanic/cpanicgrpc
+
+go 1.25.0
+
+require (
+	github.com/demosdemon/cpanic v0.0.0
+	github.com/stretchr/testify v1.8.2
+	google.golang.org/grpc v1.84.0
+	google.golang.org/protobuf v1.36.11
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/demosdemon/cpanic/cpanicui v0.0.0
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	golang.org/x/net v0.57.0 // indirect
+	golang.org/x/sys v0.47.0 // indirect
+	golang.org/x/text v0.40.0 // indirect
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20260706201446-f0a921348800 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
+
+replace (
+	github.com/demosdemon/cpanic => ../
+	github.com/demosdemon/cpanic/cpanicui => ../cpanicui
+)
@@ -0,0 +1,91 @@
+// cpanicgrpc streams panic reports to a central crash collector over gRPC, and provides
+// the collector server, which stores the reports it receives in a `cpanic.Appender`.
+// The `CollectorService` is defined in "collectorpb/collector.proto", so collectors and
+// clients in other languages can be generated from it. The reference collector is in
+// "cmd/cpanic-collector".
+package cpanicgrpc
+
+//go:generate protoc -I collectorpb --go_out=collectorpb --go_opt=paths=source_relative --go-grpc_out=collectorpb --go-grpc_opt=paths=source_relative collector.proto
+
+import (
+	"encoding/json"
+	"time"
+
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/demosdemon/cpanic"
+	"github.com/demosdemon/cpanic/cpanicgrpc/collectorpb"
+)
+
+// ToProto converts the panic to its report message, with the fields of the JSON report
+// written by `cpanic.NDJSON`.
+func ToProto(p *cpanic.Panic) (*collectorpb.PanicReport, error) {
+	b, err := cpanic.NDJSON.Format(p)
+	if err != nil {
+		return nil, err
+	}
+	var r struct {
+		ID          string                 `json:"id"`
+		Time        time.Time              `json:"time"`
+		Value       string                 `json:"value"`
+		Type        string                 `json:"type"`
+		Fingerprint string                 `json:"fingerprint"`
+		BuildID     string                 `json:"build_id"`
+		Severity    string                 `json:"severity"`
+		Culprit     string                 `json:"culprit"`
+		Attrs       map[string]interface{} `json:"attrs"`
+		Trace       string                 `json:"trace"`
+	}
+	if err := json.Unmarshal(b, &r); err != nil {
+		return nil, err
+	}
+
+	report := &collectorpb.PanicReport{
+		Id:          r.ID,
+		Time:        timestamppb.New(r.Time),
+		Value:       r.Value,
+		Type:        r.Type,
+		Fingerprint: r.Fingerprint,
+		BuildId:     r.BuildID,
+		Severity:    r.Severity,
+		Culprit:     r.Culprit,
+		Trace:       r.Trace,
+	}
+	if len(r.Attrs) > 0 {
+		report.Attrs = make(map[string]*structpb.Value, len(r.Attrs))
+		for k, v := range r.Attrs {
+			value, err := structpb.NewValue(v)
+			if err != nil {
+				return nil, err
+			}
+			report.Attrs[k] = value
+		}
+	}
+	return report, nil
+}
+
+// FromProto converts the report message back to a panic. As with `cpanic.Parse`, the
+// value of the panic is its message, a `string`, but the type of the original value is
+// kept in its `cpanic.ValueSnapshot`, so the panic has the fingerprint of the original.
+func FromProto(r *collectorpb.PanicReport) (*cpanic.Panic, error) {
+	p := &cpanic.Panic{
+		ID:            r.GetId(),
+		Value:         r.GetValue(),
+		ValueSnapshot: &cpanic.ValueSnapshot{Type: r.GetType(), Text: r.GetValue()},
+		Trace:         r.GetTrace(),
+		BuildID:       r.GetBuildId(),
+	}
+	if r.GetTime() != nil {
+		p.Time = r.GetTime().AsTime()
+	}
+	if s := r.GetSeverity(); s != "" {
+		if err := p.Severity.UnmarshalText([]byte(s)); err != nil {
+			return nil, err
+		}
+	}
+	for k, v := range r.GetAttrs() {
+		p.SetAttr(k, v.AsInterface())
+	}
+	return p, nil
+}
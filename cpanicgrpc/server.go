@@ -0,0 +1,66 @@
+package cpanicgrpc
+
+import (
+	"context"
+	"io"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/demosdemon/cpanic"
+	"github.com/demosdemon/cpanic/cpanicgrpc/collectorpb"
+)
+
+// Server is a `collectorpb.CollectorServiceServer` storing the reports it receives.
+type Server struct {
+	collectorpb.UnimplementedCollectorServiceServer
+
+	store cpanic.Appender
+}
+
+// NewServer creates a server appending reports to the store. Register it with
+// `collectorpb.RegisterCollectorServiceServer`.
+func NewServer(store cpanic.Appender) *Server {
+	return &Server{store: store}
+}
+
+// Report stores a single report.
+func (s *Server) Report(_ context.Context, req *collectorpb.ReportRequest) (*collectorpb.ReportResponse, error) {
+	if err := s.append(req); err != nil {
+		return nil, err
+	}
+	return &collectorpb.ReportResponse{}, nil
+}
+
+// StreamReports stores every report sent on the stream. A report that cannot be
+// stored ends the stream with an error.
+func (s *Server) StreamReports(stream collectorpb.CollectorService_StreamReportsServer) error {
+	var accepted int64
+	for {
+		req, err := stream.Recv()
+		if err == io.EOF {
+			return stream.SendAndClose(&collectorpb.StreamReportsResponse{Accepted: accepted})
+		}
+		if err != nil {
+			return err
+		}
+		if err := s.append(req); err != nil {
+			return err
+		}
+		accepted++
+	}
+}
+
+func (s *Server) append(req *collectorpb.ReportRequest) error {
+	if req.GetReport() == nil {
+		return status.Error(codes.InvalidArgument, "missing report")
+	}
+	p, err := FromProto(req.GetReport())
+	if err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	if err := s.store.Append(p); err != nil {
+		return status.Error(codes.Internal, err.Error())
+	}
+	return nil
+}
@@ -0,0 +1,42 @@
+package cpanicgrpc_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/demosdemon/cpanic"
+	"github.com/demosdemon/cpanic/cpanicgrpc"
+	"github.com/demosdemon/cpanic/cpanicgrpc/collectorpb"
+)
+
+func TestServerReport(t *testing.T) {
+	store := cpanic.NewHistory(0)
+	srv := cpanicgrpc.NewServer(store)
+
+	report, err := cpanicgrpc.ToProto(cpanic.New("not at a disco"))
+	require.NoError(t, err)
+	report.Severity = "fatal"
+	_, err = srv.Report(context.Background(), &collectorpb.ReportRequest{Report: report})
+	require.NoError(t, err)
+	require.Len(t, store.Panics(), 1)
+	assert.Equal(t, cpanic.SeverityFatal, store.Panics()[0].Severity)
+
+	tests := []struct {
+		name string
+		req  *collectorpb.ReportRequest
+	}{
+		{"missing report", &collectorpb.ReportRequest{}},
+		{"unknown severity", &collectorpb.ReportRequest{Report: &collectorpb.PanicReport{Severity: "dire"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := srv.Report(context.Background(), tt.req)
+			assert.Equal(t, codes.InvalidArgument, status.Code(err))
+		})
+	}
+}
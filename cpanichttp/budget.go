@@ -0,0 +1,133 @@
+package cpanichttp
+
+import (
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// KeyFunc returns the key under which the panics of a request are counted.
+type KeyFunc func(r *http.Request) string
+
+// ByRoute counts panics per request path.
+func ByRoute(r *http.Request) string {
+	return r.URL.Path
+}
+
+// ByClient counts panics per client IP address.
+func ByClient(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// Budget counts the panics of each key, such as a route or client, within a fixed
+// window. If the budget has a limit, requests for a key that has panicked at least that
+// many times in the current window are shed with a 503 response, without calling the
+// wrapped handler, until the window ends. This protects the rest of the server from a
+// crash-looping endpoint.
+type Budget struct {
+	key    KeyFunc
+	limit  int
+	window time.Duration
+
+	mu      sync.Mutex
+	windows map[string]*budgetWindow
+}
+
+type budgetWindow struct {
+	start time.Time
+	count int
+}
+
+// pruneThreshold is the number of keys above which expired windows are discarded.
+const pruneThreshold = 1024
+
+// NewBudget creates a budget counting panics by key within each window. If limit is
+// zero or less, panics are only counted and requests are never shed.
+func NewBudget(key KeyFunc, limit int, window time.Duration) *Budget {
+	return &Budget{
+		key:     key,
+		limit:   limit,
+		window:  window,
+		windows: make(map[string]*budgetWindow),
+	}
+}
+
+// WithBudget counts the panics of the wrapped handler with the budget, shedding
+// requests once it is exhausted.
+func WithBudget(b *Budget) Option {
+	return func(c *config) {
+		c.budget = b
+	}
+}
+
+// Count returns the number of panics recorded for the key in its current window.
+func (b *Budget) Count(key string) int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if w := b.current(key, time.Now()); w != nil {
+		return w.count
+	}
+	return 0
+}
+
+func (b *Budget) record(r *http.Request) {
+	key := b.key(r)
+	now := time.Now()
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	w := b.current(key, now)
+	if w == nil {
+		if len(b.windows) >= pruneThreshold {
+			b.prune(now)
+		}
+		w = &budgetWindow{start: now}
+		b.windows[key] = w
+	}
+	w.count++
+}
+
+func (b *Budget) exhausted(r *http.Request) bool {
+	if b.limit <= 0 {
+		return false
+	}
+
+	key := b.key(r)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	w := b.current(key, time.Now())
+	return w != nil && w.count >= b.limit
+}
+
+func (b *Budget) retryAfter() string {
+	return strconv.Itoa(int((b.window + time.Second - 1) / time.Second))
+}
+
+// current returns the window of the key, if it has not expired. The lock must be held.
+func (b *Budget) current(key string, now time.Time) *budgetWindow {
+	w, ok := b.windows[key]
+	if !ok {
+		return nil
+	}
+	if now.Sub(w.start) >= b.window {
+		delete(b.windows, key)
+		return nil
+	}
+	return w
+}
+
+// prune discards all expired windows. The lock must be held.
+func (b *Budget) prune(now time.Time) {
+	for key, w := range b.windows {
+		if now.Sub(w.start) >= b.window {
+			delete(b.windows, key)
+		}
+	}
+}
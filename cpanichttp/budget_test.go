@@ -0,0 +1,77 @@
+package cpanichttp_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/demosdemon/cpanic/cpanichttp"
+)
+
+func TestBudget(t *testing.T) {
+	calls := 0
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if r.URL.Path == "/boom" {
+			panic("not at a disco")
+		}
+	})
+
+	budget := cpanichttp.NewBudget(cpanichttp.ByRoute, 2, time.Minute)
+	h := cpanichttp.Handler(next, nil, cpanichttp.WithBudget(budget))
+
+	serve := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		return w
+	}
+
+	assert.Equal(t, http.StatusInternalServerError, serve("/boom").Code)
+	assert.Equal(t, http.StatusInternalServerError, serve("/boom").Code)
+	assert.Equal(t, 2, budget.Count("/boom"))
+
+	w := serve("/boom")
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Equal(t, "60", w.Header().Get("Retry-After"))
+	assert.Equal(t, 2, calls, "shed requests must not reach the handler")
+
+	assert.Equal(t, http.StatusOK, serve("/ok").Code)
+	assert.Equal(t, 0, budget.Count("/ok"))
+}
+
+func TestBudgetWindow(t *testing.T) {
+	next := http.HandlerFunc(func(http.ResponseWriter, *http.Request) { panic("not at a disco") })
+	budget := cpanichttp.NewBudget(cpanichttp.ByClient, 1, 10*time.Millisecond)
+	h := cpanichttp.Handler(next, nil, cpanichttp.WithBudget(budget))
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	h.ServeHTTP(httptest.NewRecorder(), r)
+	assert.Equal(t, 1, budget.Count("192.0.2.1"))
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+
+	time.Sleep(20 * time.Millisecond)
+	assert.Equal(t, 0, budget.Count("192.0.2.1"))
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+}
+
+func TestBudgetCountOnly(t *testing.T) {
+	next := http.HandlerFunc(func(http.ResponseWriter, *http.Request) { panic("not at a disco") })
+	budget := cpanichttp.NewBudget(cpanichttp.ByRoute, 0, time.Minute)
+	h := cpanichttp.Handler(next, nil, cpanichttp.WithBudget(budget))
+
+	for i := 0; i < 3; i++ {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/boom", nil))
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+	}
+	assert.Equal(t, 3, budget.Count("/boom"))
+}
@@ -0,0 +1,105 @@
+package cpanichttp
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+
+	"github.com/demosdemon/cpanic"
+)
+
+// The `cpanic.Panic` attribute keys set by `WithRequestCapture`.
+const (
+	MethodAttr  = "http.method"
+	PathAttr    = "http.path"
+	RouteAttr   = "http.route"
+	HeadersAttr = "http.headers"
+	BodyAttr    = "http.body"
+)
+
+// Redacted replaces the value of sensitive headers captured by `WithRequestCapture`.
+const Redacted = "[REDACTED]"
+
+// sensitiveHeaders are always redacted when captured.
+var sensitiveHeaders = map[string]bool{
+	"Authorization":       true,
+	"Cookie":              true,
+	"Proxy-Authorization": true,
+	"Set-Cookie":          true,
+	"X-Api-Key":           true,
+}
+
+// RequestCapture selects the details of a request that `WithRequestCapture` attaches to
+// a recovered panic.
+type RequestCapture struct {
+	// Headers are the names of the request headers to capture. Values of credential
+	// headers, such as Authorization and Cookie, are replaced with `Redacted`.
+	Headers []string
+	// MaxBodyBytes is the number of bytes of the request body, as read by the handler,
+	// to capture. If zero, the body is not captured.
+	MaxBodyBytes int
+}
+
+// WithRequestCapture attaches the method, path, route pattern, and the selected
+// headers and body of the request to every recovered panic so a crash can be reproduced
+// without searching the access logs. The route pattern is only available on Go 1.23
+// and later, with `http.ServeMux` routing.
+func WithRequestCapture(rc RequestCapture) Option {
+	return func(c *config) {
+		c.capture = &rc
+	}
+}
+
+// begin prepares the request for capture, returning the request to serve and a
+// function that attaches the captured details to a panic.
+func (rc *RequestCapture) begin(r *http.Request) (*http.Request, func(*cpanic.Panic)) {
+	var body *capturingBody
+	if rc.MaxBodyBytes > 0 && r.Body != nil && r.Body != http.NoBody {
+		body = &capturingBody{ReadCloser: r.Body, limit: rc.MaxBodyBytes}
+		r.Body = body
+	}
+
+	return r, func(p *cpanic.Panic) {
+		p.SetAttr(MethodAttr, r.Method)
+		p.SetAttr(PathAttr, r.URL.Path)
+		if route := routePattern(r); route != "" {
+			p.SetAttr(RouteAttr, route)
+		}
+
+		if len(rc.Headers) > 0 {
+			headers := make(map[string]string, len(rc.Headers))
+			for _, name := range rc.Headers {
+				name = http.CanonicalHeaderKey(name)
+				if value := r.Header.Get(name); value != "" {
+					if sensitiveHeaders[name] {
+						value = Redacted
+					}
+					headers[name] = value
+				}
+			}
+			p.SetAttr(HeadersAttr, headers)
+		}
+
+		if body != nil {
+			p.SetAttr(BodyAttr, body.buf.String())
+		}
+	}
+}
+
+// capturingBody records up to limit bytes read from the request body.
+type capturingBody struct {
+	io.ReadCloser
+	limit int
+	buf   bytes.Buffer
+}
+
+func (b *capturingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if remaining := b.limit - b.buf.Len(); remaining > 0 {
+		if n < remaining {
+			remaining = n
+		}
+		b.buf.Write(p[:remaining])
+	}
+	return n, err
+}
@@ -0,0 +1,54 @@
+package cpanichttp_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/demosdemon/cpanic"
+	"github.com/demosdemon/cpanic/cpanichttp"
+)
+
+func TestWithRequestCapture(t *testing.T) {
+	var handled *cpanic.Panic
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = ioutil.ReadAll(r.Body)
+		panic("not at a disco")
+	})
+	h := cpanichttp.Handler(next, func(p *cpanic.Panic) { handled = p }, cpanichttp.WithRequestCapture(cpanichttp.RequestCapture{
+		Headers:      []string{"user-agent", "Authorization", "X-Missing"},
+		MaxBodyBytes: 8,
+	}))
+
+	r := httptest.NewRequest(http.MethodPost, "/orders?id=1", strings.NewReader(`{"item":"disco ball"}`))
+	r.Header.Set("User-Agent", "test")
+	r.Header.Set("Authorization", "Bearer secret")
+	r.Header.Set("X-Other", "ignored")
+	h.ServeHTTP(httptest.NewRecorder(), r)
+
+	if assert.NotNil(t, handled) {
+		assert.Equal(t, http.MethodPost, handled.Attrs[cpanichttp.MethodAttr])
+		assert.Equal(t, "/orders", handled.Attrs[cpanichttp.PathAttr])
+		assert.Equal(t, map[string]string{
+			"User-Agent":    "test",
+			"Authorization": cpanichttp.Redacted,
+		}, handled.Attrs[cpanichttp.HeadersAttr])
+		assert.Equal(t, `{"item":`, handled.Attrs[cpanichttp.BodyAttr])
+	}
+}
+
+func TestWithRequestCaptureNoBody(t *testing.T) {
+	var handled *cpanic.Panic
+	next := http.HandlerFunc(func(http.ResponseWriter, *http.Request) { panic("not at a disco") })
+	h := cpanichttp.Handler(next, func(p *cpanic.Panic) { handled = p }, cpanichttp.WithRequestCapture(cpanichttp.RequestCapture{}))
+
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	if assert.NotNil(t, handled) {
+		assert.NotContains(t, handled.Attrs, cpanichttp.HeadersAttr)
+		assert.NotContains(t, handled.Attrs, cpanichttp.BodyAttr)
+	}
+}
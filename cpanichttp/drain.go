@@ -0,0 +1,102 @@
+package cpanichttp
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/demosdemon/cpanic"
+)
+
+// Drainer drains the process after a fatal panic. Once its `Fatal` method is registered
+// with `cpanic.OnFatal`, the first panic of `cpanic.SeverityFatal` gracefully shuts down
+// the servers registered with `ShutdownOnFatal` after the crash report has been sent,
+// letting in-flight requests finish before the process exits. Create one with
+// `NewDrainer`.
+//
+//	d := cpanichttp.NewDrainer()
+//	d.ShutdownOnFatal(srv, 30*time.Second)
+//	cpanic.OnFatal(d.Fatal)
+//	srv.Handler = cpanichttp.Handler(mux, reporter)
+//	if err := srv.ListenAndServe(); err != http.ErrServerClosed {
+//		log.Fatal(err)
+//	}
+//	if d.Draining() {
+//		<-d.Done()
+//		os.Exit(cpanic.ExitCodePanic)
+//	}
+type Drainer struct {
+	mu       sync.Mutex
+	servers  []func()
+	draining bool
+	done     chan struct{}
+}
+
+// NewDrainer returns a `Drainer` without servers.
+func NewDrainer() *Drainer {
+	return &Drainer{done: make(chan struct{})}
+}
+
+// ShutdownOnFatal registers the server to be gracefully shut down after the first fatal
+// panic, as with `http.Server.Shutdown`, waiting at most timeout for in-flight requests
+// to finish before closing the remaining connections. A timeout of zero waits
+// indefinitely. Servers are shut down in the order they were registered, in a new
+// goroutine, so the request that panicked can finish.
+func (d *Drainer) ShutdownOnFatal(srv *http.Server, timeout time.Duration) {
+	shutdown := func() {
+		ctx := context.Background()
+		if timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, timeout)
+			defer cancel()
+		}
+		if err := srv.Shutdown(ctx); err != nil {
+			_ = srv.Close()
+		}
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.servers = append(d.servers, shutdown)
+}
+
+// Fatal starts shutting down the servers if the panic is the first of
+// `cpanic.SeverityFatal`. It is meant to be registered with `cpanic.OnFatal`, which also
+// calls it for `cpanic.Unrecoverable` panics of lower severity; those are ignored, since
+// they crash the process anyway.
+func (d *Drainer) Fatal(p *cpanic.Panic) {
+	if p.Severity < cpanic.SeverityFatal {
+		return
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.draining {
+		return
+	}
+	d.draining = true
+	go d.drain(d.servers)
+}
+
+func (d *Drainer) drain(servers []func()) {
+	defer close(d.done)
+	for _, shutdown := range servers {
+		_ = cpanic.Try(shutdown)
+	}
+}
+
+// Draining reports whether a fatal panic has started shutting down the servers, so a
+// program can tell a drain from a normal shutdown.
+func (d *Drainer) Draining() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.draining
+}
+
+// Done returns a channel that is closed once the servers have been shut down after a
+// fatal panic, so the process can exit. It is never closed unless the drainer is
+// `Draining`.
+func (d *Drainer) Done() <-chan struct{} {
+	return d.done
+}
@@ -0,0 +1,80 @@
+package cpanichttp_test
+
+import (
+	"context"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/demosdemon/cpanic"
+	"github.com/demosdemon/cpanic/cpanichttp"
+)
+
+func TestDrainer(t *testing.T) {
+	reported := make(chan *cpanic.Panic, 2)
+	report := func(p *cpanic.Panic) { reported <- p }
+	d := cpanichttp.NewDrainer()
+	defer cpanic.OnFatal(d.Fatal)()
+
+	release := make(chan struct{})
+	mux := http.NewServeMux()
+	mux.HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		_, _ = w.Write([]byte("done"))
+	})
+	mux.HandleFunc("/fatal", func(w http.ResponseWriter, r *http.Request) {
+		panic("corrupted")
+	})
+	ctx := cpanic.WithSeverity(context.Background(), cpanic.SeverityFatal)
+	srv := &http.Server{
+		Handler: cpanichttp.Handler(mux, report),
+		BaseContext: func(net.Listener) context.Context {
+			return ctx
+		},
+	}
+	d.ShutdownOnFatal(srv, time.Second)
+	cpanic.Deliver(cpanic.New("not fatal"), nil)
+	assert.False(t, d.Draining())
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	served := make(chan error, 1)
+	go func() { served <- srv.Serve(ln) }()
+	base := "http://" + ln.Addr().String()
+
+	slow := make(chan string, 1)
+	go func() {
+		resp, err := http.Get(base + "/slow")
+		if err != nil {
+			slow <- err.Error()
+			return
+		}
+		defer resp.Body.Close()
+		b, _ := ioutil.ReadAll(resp.Body)
+		slow <- string(b)
+	}()
+	time.Sleep(50 * time.Millisecond)
+
+	resp, err := http.Get(base + "/fatal")
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+	assert.Equal(t, "corrupted", (<-reported).Value)
+
+	assert.Equal(t, http.ErrServerClosed, <-served)
+	assert.True(t, d.Draining())
+	select {
+	case <-d.Done():
+		t.Fatal("drained before the in-flight request finished")
+	default:
+	}
+
+	close(release)
+	assert.Equal(t, "done", <-slow)
+	<-d.Done()
+}
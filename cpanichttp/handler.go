@@ -0,0 +1,168 @@
+package cpanichttp
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"net/http"
+
+	"github.com/demosdemon/cpanic"
+	"github.com/demosdemon/cpanic/chaos"
+)
+
+// Option configures `Handler`.
+type Option func(*config)
+
+type config struct {
+	budget          *Budget
+	capture         *RequestCapture
+	requestIDHeader string
+	formatter       cpanic.Formatter
+}
+
+// ErrorIDHeader is the response header holding the `cpanic.Panic.ID` of the panic that
+// caused a 500 response, which is also included in the response body, so an error id
+// reported by a user can be matched to the exact crash.
+const ErrorIDHeader = "X-Error-Id"
+
+// WithRequestID uses the value of the request header, such as "X-Request-Id", as the
+// ID of a panic recovered while serving the request, so the ID matches the one already
+// in the logs of upstream proxies. Requests without the header keep the generated ID.
+func WithRequestID(header string) Option {
+	return func(c *config) {
+		c.requestIDHeader = header
+	}
+}
+
+// WithFormatter renders the body of the 500 response with f, such as `cpanic.HTML` for
+// a development server, instead of the public message. The body then includes the
+// panic's internal details, so only use it where every client is trusted.
+func WithFormatter(f cpanic.Formatter) Option {
+	return func(c *config) {
+		c.formatter = f
+	}
+}
+
+// Handler wraps the provided `http.Handler` so that a panic while serving a request is
+// recovered instead of tearing down the connection. The recovered panic is tagged with
+// the remote address of the request and reported to the handler, if provided. If
+// nothing has been written yet, the client receives a 500 response whose body is the
+// panic's `PublicMessage`, or the status text if it has none, followed by the panic's
+// ID, unless `WithFormatter` is used. A panic with `http.ErrAbortHandler` is always
+// allowed to continue. The handler is the `chaos` trigger point named
+// "cpanichttp.Handler".
+func Handler(next http.Handler, h cpanic.Handler, opts ...Option) http.Handler {
+	var c config
+	for _, opt := range opts {
+		opt(&c)
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if c.budget != nil && c.budget.exhausted(r) {
+			w.Header().Set("Retry-After", c.budget.retryAfter())
+			http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+			return
+		}
+
+		var attach func(*cpanic.Panic)
+		if c.capture != nil {
+			r, attach = c.capture.begin(r)
+		}
+
+		rw := &responseWriter{ResponseWriter: w}
+		defer func() {
+			if value := recover(); value != nil {
+				if value == http.ErrAbortHandler {
+					panic(value)
+				}
+
+				p := cpanic.New(value)
+				p.SetAttr(RemoteAddrAttr, r.RemoteAddr)
+				if c.requestIDHeader != "" {
+					if id := r.Header.Get(c.requestIDHeader); id != "" {
+						p.ID = id
+					}
+				}
+				if s, ok := cpanic.SeverityFromContext(r.Context()); ok {
+					p.Severity = s
+				}
+				if attach != nil {
+					attach(p)
+				}
+				if c.budget != nil {
+					c.budget.record(r)
+				}
+				cpanic.Deliver(p, h)
+
+				if !rw.wroteHeader {
+					w.Header().Set(ErrorIDHeader, p.ID)
+					if c.formatter != nil {
+						if body, err := c.formatter.Format(p); err == nil {
+							w.Header().Set("Content-Type", contentType(c.formatter, body))
+							w.Header().Set("X-Content-Type-Options", "nosniff")
+							w.WriteHeader(http.StatusInternalServerError)
+							_, _ = w.Write(body)
+							return
+						}
+					}
+					msg := p.PublicMessageOr(http.StatusText(http.StatusInternalServerError))
+					http.Error(w, msg+"\nerror id: "+p.ID, http.StatusInternalServerError)
+				}
+			}
+		}()
+
+		chaos.Point("cpanichttp.Handler")
+		next.ServeHTTP(rw, r)
+	})
+}
+
+// contentType returns the content type of the output of f, sniffing body if f does not
+// have a `ContentType` method.
+func contentType(f cpanic.Formatter, body []byte) string {
+	if ct, ok := f.(interface{ ContentType() string }); ok {
+		return ct.ContentType()
+	}
+	return http.DetectContentType(body)
+}
+
+// responseWriter tracks whether the response has started while preserving the
+// `http.Flusher` and `http.Hijacker` interfaces that streaming and WebSocket handlers
+// depend on.
+type responseWriter struct {
+	http.ResponseWriter
+	wroteHeader bool
+}
+
+func (w *responseWriter) WriteHeader(statusCode int) {
+	w.wroteHeader = true
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (w *responseWriter) Write(b []byte) (int, error) {
+	w.wroteHeader = true
+	return w.ResponseWriter.Write(b)
+}
+
+// Flush implements the `http.Flusher` interface if the underlying writer does.
+func (w *responseWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		w.wroteHeader = true
+		f.Flush()
+	}
+}
+
+// Hijack implements the `http.Hijacker` interface if the underlying writer does. A
+// hijacked connection is considered written, since no error response can be sent.
+func (w *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hj, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("cpanichttp: response writer does not implement http.Hijacker")
+	}
+	w.wroteHeader = true
+	return hj.Hijack()
+}
+
+// Unwrap returns the underlying writer for `http.ResponseController`.
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
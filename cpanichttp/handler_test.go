@@ -0,0 +1,153 @@
+package cpanichttp_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/demosdemon/cpanic"
+	"github.com/demosdemon/cpanic/cpanichttp"
+)
+
+func TestHandler(t *testing.T) {
+	tests := []struct {
+		name    string
+		fn      http.HandlerFunc
+		status  int
+		body    string
+		handled bool
+	}{
+		{
+			name: "does nothing",
+			fn: func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte("ok"))
+			},
+			status: http.StatusOK,
+			body:   "ok",
+		},
+		{
+			name:    "returns 500",
+			fn:      func(http.ResponseWriter, *http.Request) { panic("not at a disco") },
+			status:  http.StatusInternalServerError,
+			body:    "Internal Server Error\nerror id: %s\n",
+			handled: true,
+		},
+		{
+			name: "keeps written response",
+			fn: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusAccepted)
+				panic("not at a disco")
+			},
+			status:  http.StatusAccepted,
+			handled: true,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			var handled *cpanic.Panic
+			h := cpanichttp.Handler(tt.fn, func(p *cpanic.Panic) { handled = p })
+
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			h.ServeHTTP(w, r)
+
+			assert.Equal(t, tt.status, w.Code)
+			if tt.handled {
+				if assert.NotNil(t, handled) {
+					assert.Equal(t, r.RemoteAddr, handled.Attrs[cpanichttp.RemoteAddrAttr])
+					assert.Equal(t, strings.Replace(tt.body, "%s", handled.ID, 1), w.Body.String())
+				}
+			} else {
+				assert.Nil(t, handled)
+				assert.Equal(t, tt.body, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestHandlerSeverity(t *testing.T) {
+	var handled *cpanic.Panic
+	h := cpanichttp.Handler(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		panic("not at a disco")
+	}), func(p *cpanic.Panic) { handled = p })
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r = r.WithContext(cpanic.WithSeverity(r.Context(), cpanic.SeverityWarning))
+	h.ServeHTTP(httptest.NewRecorder(), r)
+
+	if assert.NotNil(t, handled) {
+		assert.Equal(t, cpanic.SeverityWarning, handled.Severity)
+	}
+}
+
+func TestHandlerPublicMessage(t *testing.T) {
+	h := cpanichttp.Handler(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		panic(cpanic.WithPublicMessage(errors.New("db: connection refused"), "Try again later"))
+	}), nil)
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Equal(t, "Try again later\nerror id: "+w.Header().Get(cpanichttp.ErrorIDHeader)+"\n", w.Body.String())
+}
+
+func TestHandlerRequestID(t *testing.T) {
+	var handled *cpanic.Panic
+	h := cpanichttp.Handler(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		panic("not at a disco")
+	}), func(p *cpanic.Panic) { handled = p }, cpanichttp.WithRequestID("X-Request-Id"))
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("X-Request-Id", "req-123")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	assert.Equal(t, "req-123", w.Header().Get(cpanichttp.ErrorIDHeader))
+	assert.Equal(t, "Internal Server Error\nerror id: req-123\n", w.Body.String())
+	if assert.NotNil(t, handled) {
+		assert.Equal(t, "req-123", handled.ID)
+	}
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Len(t, w.Header().Get(cpanichttp.ErrorIDHeader), 26)
+}
+
+func TestHandlerAbortHandler(t *testing.T) {
+	h := cpanichttp.Handler(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		panic(http.ErrAbortHandler)
+	}), nil)
+
+	assert.PanicsWithValue(t, http.ErrAbortHandler, func() {
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	})
+}
+
+func TestHandlerPreservesInterfaces(t *testing.T) {
+	h := cpanichttp.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, flusher := w.(http.Flusher)
+		_, hijacker := w.(http.Hijacker)
+		assert.True(t, flusher)
+		assert.True(t, hijacker)
+	}), nil)
+
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+}
+
+func TestHandlerFormatter(t *testing.T) {
+	h := cpanichttp.Handler(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		panic("not at a <disco>")
+	}), nil, cpanichttp.WithFormatter(cpanic.HTML))
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Equal(t, "text/html; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Len(t, w.Header().Get(cpanichttp.ErrorIDHeader), 26)
+	assert.True(t, strings.HasPrefix(w.Body.String(), "<article class=\"cpanic\">\n<h3>panic: not at a &lt;disco&gt;</h3>\n"), w.Body.String())
+}
@@ -0,0 +1,170 @@
+package cpanichttp
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"github.com/demosdemon/cpanic"
+)
+
+// DefaultMaxIngestBytes is the default size limit of an ingestion request body.
+const DefaultMaxIngestBytes = 1 << 20
+
+// IngestOption configures `IngestHandler`.
+type IngestOption func(*ingestConfig)
+
+type ingestConfig struct {
+	auth       func(r *http.Request) error
+	maxBytes   int64
+	maxReports int
+	verifyKeys []ed25519.PublicKey
+}
+
+// WithIngestAuth authenticates every request with auth, such as a check of a bearer
+// token or client certificate, rejecting it with a 401 response if auth returns an
+// error.
+func WithIngestAuth(auth func(r *http.Request) error) IngestOption {
+	return func(c *ingestConfig) {
+		c.auth = auth
+	}
+}
+
+// WithMaxIngestBytes sets the size limit of the request body, above which the request
+// is rejected with a 413 response. It defaults to `DefaultMaxIngestBytes`.
+func WithMaxIngestBytes(n int64) IngestOption {
+	return func(c *ingestConfig) {
+		c.maxBytes = n
+	}
+}
+
+// WithMaxIngestReports limits the number of reports in a single request, above which
+// the request is rejected with a 413 response and none of its reports are stored.
+func WithMaxIngestReports(n int) IngestOption {
+	return func(c *ingestConfig) {
+		c.maxReports = n
+	}
+}
+
+// WithIngestVerifyKeys rejects with a 401 response any request with a report that is
+// not signed by the private key of one of the public keys, as checked by
+// `cpanic.Verify`, such as reports from untrusted edge nodes signed with
+// `cpanic.WithSigningKey`. Raw crash output cannot be signed and is rejected too.
+func WithIngestVerifyKeys(keys ...ed25519.PublicKey) IngestOption {
+	return func(c *ingestConfig) {
+		c.verifyKeys = append(c.verifyKeys, keys...)
+	}
+}
+
+// IngestHandler returns an `http.Handler` that appends the panics POSTed to it to the
+// store, so sidecars and programs not written in Go can feed the same store as Go
+// processes. The body is either reports as written by `cpanic.JSON` or `cpanic.NDJSON`,
+// parsed with `cpanic.ParseReport`, or, with a "text/plain" content type, the raw crash
+// output of a Go program, parsed with `cpanic.Parse`. Reports are validated before any
+// is stored, and the response is 202 Accepted with the number of stored reports as
+// JSON.
+func IngestHandler(store cpanic.Appender, opts ...IngestOption) http.Handler {
+	c := ingestConfig{maxBytes: DefaultMaxIngestBytes}
+	for _, opt := range opts {
+		opt(&c)
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+		if c.auth != nil {
+			if err := c.auth(r); err != nil {
+				http.Error(w, err.Error(), http.StatusUnauthorized)
+				return
+			}
+		}
+
+		body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, c.maxBytes))
+		if err != nil {
+			http.Error(w, http.StatusText(http.StatusRequestEntityTooLarge), http.StatusRequestEntityTooLarge)
+			return
+		}
+
+		var panics []*cpanic.Panic
+		mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		switch mediaType {
+		case "text/plain":
+			if len(c.verifyKeys) > 0 {
+				http.Error(w, cpanic.ErrUnsigned.Error(), http.StatusUnauthorized)
+				return
+			}
+			var p *cpanic.Panic
+			if p, err = cpanic.Parse(string(body)); err == nil {
+				panics = append(panics, p)
+			}
+		case "", "application/json", "application/x-ndjson":
+			panics, err = parseReports(body, c.verifyKeys)
+		default:
+			http.Error(w, http.StatusText(http.StatusUnsupportedMediaType), http.StatusUnsupportedMediaType)
+			return
+		}
+		if errors.Is(err, cpanic.ErrUnsigned) || errors.Is(err, cpanic.ErrInvalidSignature) {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		} else if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if c.maxReports > 0 && len(panics) > c.maxReports {
+			http.Error(w, "too many reports: limit is "+strconv.Itoa(c.maxReports), http.StatusRequestEntityTooLarge)
+			return
+		}
+
+		for i, p := range panics {
+			if err := store.Append(p); err != nil {
+				http.Error(w, fmt.Sprintf("stored %d of %d reports: %s", i, len(panics), err), http.StatusInternalServerError)
+				return
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusAccepted)
+		_ = json.NewEncoder(w).Encode(struct {
+			Accepted int `json:"accepted"`
+		}{len(panics)})
+	})
+}
+
+// parseReports parses a sequence of JSON reports, such as an NDJSON stream, verifying
+// each with the keys, if any.
+func parseReports(body []byte, keys []ed25519.PublicKey) ([]*cpanic.Panic, error) {
+	var panics []*cpanic.Panic
+	dec := json.NewDecoder(bytes.NewReader(body))
+	for {
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		if len(keys) > 0 {
+			if err := cpanic.Verify(raw, keys...); err != nil {
+				return nil, fmt.Errorf("report %d: %w", len(panics)+1, err)
+			}
+		}
+		p, err := cpanic.ParseReport(raw)
+		if err != nil {
+			return nil, fmt.Errorf("report %d: %w", len(panics)+1, err)
+		}
+		panics = append(panics, p)
+	}
+	if len(panics) == 0 {
+		return nil, errors.New("no reports")
+	}
+	return panics, nil
+}
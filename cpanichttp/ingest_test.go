@@ -0,0 +1,172 @@
+package cpanichttp_test
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/demosdemon/cpanic"
+	"github.com/demosdemon/cpanic/cpanichttp"
+)
+
+const crashDump = `panic: assignment to entry in nil map
+
+goroutine 1 [running]:
+main.main()
+	/src/main.go:5 +0x2e
+`
+
+func report(t *testing.T, value string) string {
+	b, err := cpanic.NDJSON.Format(cpanic.New(value))
+	require.NoError(t, err)
+	return string(b)
+}
+
+var signingKey = ed25519.NewKeyFromSeed(bytes.Repeat([]byte{1}, ed25519.SeedSize))
+
+func signedReport(t *testing.T, value string) string {
+	b, err := cpanic.WithSigningKey(cpanic.NDJSON, signingKey).Format(cpanic.New(value))
+	require.NoError(t, err)
+	return string(b)
+}
+
+func tokenAuth(r *http.Request) error {
+	if r.Header.Get("Authorization") != "Bearer token" {
+		return errors.New("invalid token")
+	}
+	return nil
+}
+
+func TestIngestHandler(t *testing.T) {
+	tests := []struct {
+		name        string
+		method      string
+		contentType string
+		body        string
+		opts        []cpanichttp.IngestOption
+		status      int
+		values      []interface{}
+	}{
+		{
+			name:        "stores an NDJSON stream",
+			contentType: "application/x-ndjson",
+			body:        report(t, "one") + report(t, "two"),
+			status:      http.StatusAccepted,
+			values:      []interface{}{"one", "two"},
+		},
+		{
+			name:   "stores a JSON report",
+			body:   report(t, "one"),
+			status: http.StatusAccepted,
+			values: []interface{}{"one"},
+		},
+		{
+			name:        "stores a crash dump",
+			contentType: "text/plain; charset=utf-8",
+			body:        crashDump,
+			status:      http.StatusAccepted,
+			values:      []interface{}{"assignment to entry in nil map"},
+		},
+		{
+			name:   "rejects other methods",
+			method: http.MethodGet,
+			status: http.StatusMethodNotAllowed,
+		},
+		{
+			name:   "rejects unauthenticated requests",
+			body:   report(t, "one"),
+			opts:   []cpanichttp.IngestOption{cpanichttp.WithIngestAuth(tokenAuth)},
+			status: http.StatusUnauthorized,
+		},
+		{
+			name:   "stores signed reports",
+			body:   signedReport(t, "one") + signedReport(t, "two"),
+			opts:   []cpanichttp.IngestOption{cpanichttp.WithIngestVerifyKeys(signingKey.Public().(ed25519.PublicKey))},
+			status: http.StatusAccepted,
+			values: []interface{}{"one", "two"},
+		},
+		{
+			name:   "rejects unsigned reports",
+			body:   signedReport(t, "one") + report(t, "two"),
+			opts:   []cpanichttp.IngestOption{cpanichttp.WithIngestVerifyKeys(signingKey.Public().(ed25519.PublicKey))},
+			status: http.StatusUnauthorized,
+		},
+		{
+			name:        "rejects crash dumps when verifying",
+			contentType: "text/plain",
+			body:        crashDump,
+			opts:        []cpanichttp.IngestOption{cpanichttp.WithIngestVerifyKeys(signingKey.Public().(ed25519.PublicKey))},
+			status:      http.StatusUnauthorized,
+		},
+		{
+			name:   "rejects large bodies",
+			body:   report(t, "one"),
+			opts:   []cpanichttp.IngestOption{cpanichttp.WithMaxIngestBytes(16)},
+			status: http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:   "rejects too many reports",
+			body:   report(t, "one") + report(t, "two"),
+			opts:   []cpanichttp.IngestOption{cpanichttp.WithMaxIngestReports(1)},
+			status: http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:   "rejects invalid reports",
+			body:   report(t, "one") + `{"value": "two"}`,
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "rejects empty bodies",
+			status: http.StatusBadRequest,
+		},
+		{
+			name:        "rejects other content types",
+			contentType: "application/xml",
+			body:        "<panic/>",
+			status:      http.StatusUnsupportedMediaType,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			method := tt.method
+			if method == "" {
+				method = http.MethodPost
+			}
+			req := httptest.NewRequest(method, "/ingest", strings.NewReader(tt.body))
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+
+			store := cpanic.NewHistory(0)
+			rec := httptest.NewRecorder()
+			cpanichttp.IngestHandler(store, tt.opts...).ServeHTTP(rec, req)
+			assert.Equal(t, tt.status, rec.Code, rec.Body.String())
+
+			var values []interface{}
+			for _, p := range store.Panics() {
+				values = append(values, p.Value)
+			}
+			assert.Equal(t, tt.values, values)
+		})
+	}
+}
+
+func TestIngestHandlerAuth(t *testing.T) {
+	store := cpanic.NewHistory(0)
+	h := cpanichttp.IngestHandler(store, cpanichttp.WithIngestAuth(tokenAuth))
+
+	req := httptest.NewRequest(http.MethodPost, "/ingest", strings.NewReader(report(t, "one")))
+	req.Header.Set("Authorization", "Bearer token")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusAccepted, rec.Code)
+	assert.JSONEq(t, `{"accepted": 1}`, rec.Body.String())
+	assert.Len(t, store.Panics(), 1)
+}
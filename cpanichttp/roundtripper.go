@@ -0,0 +1,48 @@
+// cpanichttp provides `net/http` integrations for cpanic.
+package cpanichttp
+
+import (
+	"net/http"
+
+	"github.com/demosdemon/cpanic"
+	"github.com/demosdemon/cpanic/chaos"
+)
+
+// RoundTripper wraps the provided `http.RoundTripper` so that a panic raised during
+// `RoundTrip` is recovered and returned to the caller as a `*cpanic.Panic` error
+// instead of unwinding through the `http.Client`. If a handler is provided, it is
+// called with the recovered panic before the error is returned. If next is nil,
+// `http.DefaultTransport` is used. The transport is the `chaos` trigger point named
+// "cpanichttp.RoundTripper".
+func RoundTripper(next http.RoundTripper, h cpanic.Handler) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	return &roundTripper{next: next, handler: h}
+}
+
+type roundTripper struct {
+	next    http.RoundTripper
+	handler cpanic.Handler
+}
+
+// RoundTrip implements the `http.RoundTripper` interface.
+func (rt *roundTripper) RoundTrip(req *http.Request) (resp *http.Response, err error) {
+	defer func() {
+		if value := recover(); value != nil {
+			p := cpanic.New(value)
+			cpanic.Deliver(p, rt.handler)
+
+			// RoundTrip must always close the request body, even on errors.
+			if req.Body != nil {
+				_ = req.Body.Close()
+			}
+
+			resp, err = nil, p
+		}
+	}()
+
+	chaos.Point("cpanichttp.RoundTripper")
+	return rt.next.RoundTrip(req)
+}
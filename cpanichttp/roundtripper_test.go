@@ -0,0 +1,105 @@
+package cpanichttp_test
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/demosdemon/cpanic"
+	"github.com/demosdemon/cpanic/cpanichttp"
+)
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (fn roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return fn(req)
+}
+
+type closeRecorder struct {
+	*strings.Reader
+	closed bool
+}
+
+func (c *closeRecorder) Close() error {
+	c.closed = true
+	return nil
+}
+
+func TestRoundTripper(t *testing.T) {
+	tests := []struct {
+		name    string
+		fn      roundTripFunc
+		status  int
+		errMsg  string
+		handled bool
+	}{
+		{
+			name: "passes through response",
+			fn: func(*http.Request) (*http.Response, error) {
+				return &http.Response{StatusCode: http.StatusNoContent}, nil
+			},
+			status: http.StatusNoContent,
+		},
+		{
+			name: "passes through error",
+			fn: func(*http.Request) (*http.Response, error) {
+				return nil, errors.New("test")
+			},
+			errMsg: "test",
+		},
+		{
+			name: "returns panic",
+			fn: func(*http.Request) (*http.Response, error) {
+				panic("not at a disco")
+			},
+			errMsg:  "panic: not at a disco",
+			handled: true,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			var handled *cpanic.Panic
+			rt := cpanichttp.RoundTripper(tt.fn, func(p *cpanic.Panic) { handled = p })
+
+			body := &closeRecorder{Reader: strings.NewReader("body")}
+			req, err := http.NewRequest(http.MethodPost, "http://example.com", body)
+			assert.NoError(t, err)
+
+			resp, err := rt.RoundTrip(req)
+			if tt.errMsg == "" {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.status, resp.StatusCode)
+			} else {
+				assert.EqualError(t, err, tt.errMsg)
+				assert.Nil(t, resp)
+			}
+
+			if tt.handled {
+				var p *cpanic.Panic
+				assert.True(t, errors.As(err, &p))
+				assert.Same(t, p, handled)
+				assert.True(t, body.closed)
+			} else {
+				assert.Nil(t, handled)
+			}
+		})
+	}
+}
+
+func TestRoundTripperNilHandler(t *testing.T) {
+	rt := cpanichttp.RoundTripper(roundTripFunc(func(*http.Request) (*http.Response, error) {
+		panic("not at a disco")
+	}), nil)
+
+	req, err := http.NewRequest(http.MethodGet, "http://example.com", nil)
+	assert.NoError(t, err)
+
+	resp, err := rt.RoundTrip(req)
+	assert.Nil(t, resp)
+	assert.EqualError(t, err, "panic: not at a disco")
+}
@@ -0,0 +1,11 @@
+//go:build !go1.23
+// +build !go1.23
+
+package cpanichttp
+
+import "net/http"
+
+// routePattern is unavailable before Go 1.23, which added `http.Request.Pattern`.
+func routePattern(*http.Request) string {
+	return ""
+}
@@ -0,0 +1,11 @@
+//go:build go1.23
+// +build go1.23
+
+package cpanichttp
+
+import "net/http"
+
+// routePattern returns the `http.ServeMux` pattern that matched the request.
+func routePattern(r *http.Request) string {
+	return r.Pattern
+}
@@ -0,0 +1,31 @@
+//go:build go1.23
+// +build go1.23
+
+package cpanichttp_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/demosdemon/cpanic"
+	"github.com/demosdemon/cpanic/cpanichttp"
+)
+
+func TestWithRequestCaptureRoute(t *testing.T) {
+	var handled *cpanic.Panic
+	next := http.HandlerFunc(func(http.ResponseWriter, *http.Request) { panic("not at a disco") })
+
+	// The pattern is set as a mux would; this module's Go version keeps the mux from
+	// matching patterns with methods and wildcards.
+	r := httptest.NewRequest(http.MethodGet, "/orders/42", nil)
+	r.Pattern = "GET /orders/{id}"
+	h := cpanichttp.Handler(next, func(p *cpanic.Panic) { handled = p }, cpanichttp.WithRequestCapture(cpanichttp.RequestCapture{}))
+	h.ServeHTTP(httptest.NewRecorder(), r)
+
+	if assert.NotNil(t, handled) {
+		assert.Equal(t, "GET /orders/{id}", handled.Attrs[cpanichttp.RouteAttr])
+	}
+}
@@ -0,0 +1,107 @@
+package cpanichttp
+
+import (
+	"encoding/binary"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/demosdemon/cpanic"
+	"github.com/demosdemon/cpanic/chaos"
+)
+
+// RemoteAddrAttr is the `cpanic.Panic` attribute key holding the remote address of
+// the connection that was being served when the panic occurred.
+const RemoteAddrAttr = "remote_addr"
+
+const (
+	// closeMessage is the WebSocket close control frame opcode, as defined in RFC 6455.
+	closeMessage = 8
+	// closeInternalServerErr is the WebSocket close status sent after a panic.
+	closeInternalServerErr = 1011
+	// closeTimeout is how long to wait for the close frame to be written.
+	closeTimeout = time.Second
+	// maxCloseReason is the longest close reason that fits in a control frame after the
+	// status code.
+	maxCloseReason = 123
+)
+
+// WebSocketConn is the subset of a WebSocket connection needed to close it after a
+// panic. A `*websocket.Conn` from `github.com/gorilla/websocket` satisfies it.
+type WebSocketConn interface {
+	WriteControl(messageType int, data []byte, deadline time.Time) error
+	Close() error
+	RemoteAddr() net.Addr
+}
+
+// ServeWebSocket calls fn, typically the read loop of a single WebSocket connection,
+// and recovers any panic it raises. The recovered panic is tagged with the remote
+// address of the connection, reported to the handler, if provided, and returned. The
+// connection is then closed with a 1011 (internal error) close frame so the peer can
+// tell a crash from a network failure; its reason is the panic's `PublicMessage`, if
+// it has one that fits. Otherwise, the error from fn is returned and
+// the connection is left open. This is the `chaos` trigger point named
+// "cpanichttp.ServeWebSocket".
+func ServeWebSocket(conn WebSocketConn, h cpanic.Handler, fn func() error) (err error) {
+	defer func() {
+		if value := recover(); value != nil {
+			p := cpanic.New(value)
+			p.SetAttr(RemoteAddrAttr, conn.RemoteAddr().String())
+			cpanic.Deliver(p, h)
+
+			reason := p.PublicMessageOr(http.StatusText(http.StatusInternalServerError))
+			if len(reason) > maxCloseReason {
+				reason = http.StatusText(http.StatusInternalServerError)
+			}
+			msg := make([]byte, 2, 2+len(reason))
+			binary.BigEndian.PutUint16(msg, closeInternalServerErr)
+			msg = append(msg, reason...)
+			_ = conn.WriteControl(closeMessage, msg, time.Now().Add(closeTimeout))
+			_ = conn.Close()
+
+			err = p
+		}
+	}()
+
+	chaos.Point("cpanichttp.ServeWebSocket")
+	return fn()
+}
+
+// SSE wraps a long-lived Server-Sent Events handler so a panic while streaming is
+// recovered instead of tearing down the server's connection handling. The recovered
+// panic is tagged with the remote address of the request and reported to the
+// handler, if provided. If nothing has been written yet, the client receives a 500
+// response; otherwise, a final `error` event is written and flushed before the stream
+// is ended. Either carries the panic's `PublicMessage`, if it has one. A panic with
+// `http.ErrAbortHandler` is always allowed to continue. The
+// handler is the `chaos` trigger point named "cpanichttp.SSE".
+func SSE(next http.Handler, h cpanic.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sw := &responseWriter{ResponseWriter: w}
+		defer func() {
+			if value := recover(); value != nil {
+				if value == http.ErrAbortHandler {
+					panic(value)
+				}
+
+				p := cpanic.New(value)
+				p.SetAttr(RemoteAddrAttr, r.RemoteAddr)
+				cpanic.Deliver(p, h)
+
+				msg := p.PublicMessageOr(http.StatusText(http.StatusInternalServerError))
+				if !sw.wroteHeader {
+					http.Error(w, msg, http.StatusInternalServerError)
+					return
+				}
+
+				data := strings.Replace(msg, "\n", "\ndata: ", -1)
+				_, _ = w.Write([]byte("event: error\ndata: " + data + "\n\n"))
+				sw.Flush()
+			}
+		}()
+
+		chaos.Point("cpanichttp.SSE")
+		next.ServeHTTP(sw, r)
+	})
+}
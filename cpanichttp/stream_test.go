@@ -0,0 +1,148 @@
+package cpanichttp_test
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/demosdemon/cpanic"
+	"github.com/demosdemon/cpanic/cpanichttp"
+)
+
+type fakeConn struct {
+	messageType int
+	data        []byte
+	closed      bool
+}
+
+func (c *fakeConn) WriteControl(messageType int, data []byte, _ time.Time) error {
+	c.messageType = messageType
+	c.data = data
+	return nil
+}
+
+func (c *fakeConn) Close() error {
+	c.closed = true
+	return nil
+}
+
+func (c *fakeConn) RemoteAddr() net.Addr {
+	return &net.TCPAddr{IP: net.IPv4(192, 0, 2, 1), Port: 1234}
+}
+
+func TestServeWebSocket(t *testing.T) {
+	tests := []struct {
+		name   string
+		fn     func() error
+		errMsg string
+		closed bool
+	}{
+		{
+			name: "does nothing",
+			fn:   func() error { return nil },
+		},
+		{
+			name:   "returns error",
+			fn:     func() error { return errors.New("test") },
+			errMsg: "test",
+		},
+		{
+			name:   "closes on panic",
+			fn:     func() error { panic("not at a disco") },
+			errMsg: "panic: not at a disco",
+			closed: true,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			var handled *cpanic.Panic
+			conn := &fakeConn{}
+			err := cpanichttp.ServeWebSocket(conn, func(p *cpanic.Panic) { handled = p }, tt.fn)
+			if tt.errMsg == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tt.errMsg)
+			}
+
+			assert.Equal(t, tt.closed, conn.closed)
+			if tt.closed {
+				assert.Equal(t, 8, conn.messageType)
+				assert.Equal(t, append([]byte{0x03, 0xf3}, "Internal Server Error"...), conn.data)
+				if assert.NotNil(t, handled) {
+					assert.Equal(t, "192.0.2.1:1234", handled.Attrs[cpanichttp.RemoteAddrAttr])
+				}
+			} else {
+				assert.Nil(t, handled)
+			}
+		})
+	}
+}
+
+func TestSSE(t *testing.T) {
+	tests := []struct {
+		name   string
+		fn     http.HandlerFunc
+		status int
+		body   string
+	}{
+		{
+			name: "does nothing",
+			fn: func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte("data: hello\n\n"))
+			},
+			status: http.StatusOK,
+			body:   "data: hello\n\n",
+		},
+		{
+			name:   "panics before streaming",
+			fn:     func(w http.ResponseWriter, r *http.Request) { panic("not at a disco") },
+			status: http.StatusInternalServerError,
+			body:   "Internal Server Error\n",
+		},
+		{
+			name: "panics while streaming",
+			fn: func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte("data: hello\n\n"))
+				w.(http.Flusher).Flush()
+				panic("not at a disco")
+			},
+			status: http.StatusOK,
+			body:   "data: hello\n\nevent: error\ndata: Internal Server Error\n\n",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			var handled *cpanic.Panic
+			h := cpanichttp.SSE(tt.fn, func(p *cpanic.Panic) { handled = p })
+
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, "/events", nil)
+			h.ServeHTTP(w, r)
+
+			assert.Equal(t, tt.status, w.Code)
+			assert.Equal(t, tt.body, w.Body.String())
+			if handled != nil {
+				assert.Equal(t, r.RemoteAddr, handled.Attrs[cpanichttp.RemoteAddrAttr])
+			}
+		})
+	}
+}
+
+func TestSSEAbortHandler(t *testing.T) {
+	h := cpanichttp.SSE(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		panic(http.ErrAbortHandler)
+	}), nil)
+
+	assert.PanicsWithValue(t, http.ErrAbortHandler, func() {
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	})
+}
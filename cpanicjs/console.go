@@ -0,0 +1,80 @@
+//go:build js && wasm
+// +build js,wasm
+
+package cpanicjs
+
+import (
+	"fmt"
+	"syscall/js"
+
+	"github.com/demosdemon/cpanic"
+)
+
+// Option configures `Handler`.
+type Option func(*config)
+
+type config struct {
+	endpoint string
+}
+
+// WithEndpoint posts each panic to url as the JSON report written by `cpanic.NDJSON`. The
+// request is sent with `keepalive`, so it completes even if the page is unloading.
+// Failed requests are ignored.
+func WithEndpoint(url string) Option {
+	return func(c *config) {
+		c.endpoint = url
+	}
+}
+
+// Handler returns a handler that writes each panic to the developer console with
+// `console.error`: the panic message followed by an object with its ID, type,
+// fingerprint, severity, culprit, attributes, and trace, which the console renders as
+// expandable fields.
+func Handler(opts ...Option) cpanic.Handler {
+	var c config
+	for _, opt := range opts {
+		opt(&c)
+	}
+
+	ignore := js.FuncOf(func(js.Value, []js.Value) interface{} { return nil })
+	return func(p *cpanic.Panic) {
+		js.Global().Get("console").Call("error", p.Error(), js.ValueOf(fields(p)))
+
+		if c.endpoint == "" {
+			return
+		}
+		body, err := cpanic.NDJSON.Format(p)
+		if err != nil {
+			return
+		}
+		js.Global().Call("fetch", c.endpoint, map[string]interface{}{
+			"method":    "POST",
+			"headers":   map[string]interface{}{"Content-Type": "application/json"},
+			"body":      string(body),
+			"keepalive": true,
+		}).Call("catch", ignore)
+	}
+}
+
+// fields returns the structured fields logged with the panic, in the form accepted by
+// `js.ValueOf`.
+func fields(p *cpanic.Panic) map[string]interface{} {
+	f := map[string]interface{}{
+		"id":          p.ID,
+		"type":        p.Type(),
+		"fingerprint": p.Fingerprint(),
+		"severity":    p.Severity.String(),
+		"trace":       p.Trace,
+	}
+	if culprit, ok := p.Culprit(); ok {
+		f["culprit"] = culprit.String()
+	}
+	if len(p.Attrs) > 0 {
+		attrs := make(map[string]interface{}, len(p.Attrs))
+		for k, v := range p.Attrs {
+			attrs[k] = fmt.Sprint(v)
+		}
+		f["attrs"] = attrs
+	}
+	return f
+}
@@ -0,0 +1,76 @@
+//go:build js && wasm
+// +build js,wasm
+
+package cpanicjs_test
+
+import (
+	"encoding/json"
+	"syscall/js"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/demosdemon/cpanic"
+	"github.com/demosdemon/cpanic/cpanicjs"
+)
+
+// stub replaces the global JS function with one that records its arguments.
+func stub(obj js.Value, name string, result func() js.Value) (calls *[][]js.Value, restore func()) {
+	calls = new([][]js.Value)
+	orig := obj.Get(name)
+	fn := js.FuncOf(func(_ js.Value, args []js.Value) interface{} {
+		*calls = append(*calls, args)
+		if result != nil {
+			return result()
+		}
+		return nil
+	})
+	obj.Set(name, fn)
+	return calls, func() {
+		obj.Set(name, orig)
+		fn.Release()
+	}
+}
+
+func TestHandler(t *testing.T) {
+	console := js.Global().Get("console")
+	logged, restore := stub(console, "error", nil)
+	defer restore()
+
+	p := cpanic.New("not at a disco")
+	p.SetAttr("retries", 3)
+	cpanicjs.Handler()(p)
+
+	require.Len(t, *logged, 1)
+	args := (*logged)[0]
+	assert.Equal(t, "panic: not at a disco", args[0].String())
+	assert.Equal(t, p.ID, args[1].Get("id").String())
+	assert.Equal(t, "string", args[1].Get("type").String())
+	assert.Equal(t, p.Fingerprint(), args[1].Get("fingerprint").String())
+	assert.Equal(t, p.Trace, args[1].Get("trace").String())
+	assert.Equal(t, "3", args[1].Get("attrs").Get("retries").String())
+}
+
+func TestHandlerEndpoint(t *testing.T) {
+	_, restoreConsole := stub(js.Global().Get("console"), "error", nil)
+	defer restoreConsole()
+	promise := js.Global().Get("Promise")
+	requests, restoreFetch := stub(js.Global(), "fetch", func() js.Value {
+		return promise.Call("resolve")
+	})
+	defer restoreFetch()
+
+	p := cpanic.New("not at a disco")
+	cpanicjs.Handler(cpanicjs.WithEndpoint("/crashes"))(p)
+
+	require.Len(t, *requests, 1)
+	args := (*requests)[0]
+	assert.Equal(t, "/crashes", args[0].String())
+	assert.Equal(t, "POST", args[1].Get("method").String())
+	assert.True(t, args[1].Get("keepalive").Bool())
+
+	var report map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(args[1].Get("body").String()), &report))
+	assert.Equal(t, p.ID, report["id"])
+}
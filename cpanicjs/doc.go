@@ -0,0 +1,9 @@
+// cpanicjs reports panics in Go programs compiled for the browser with `GOOS=js
+// GOARCH=wasm`, which otherwise lose panics entirely once the page is in users' hands.
+// Panics are written to the developer console with `console.error` and can be posted to
+// a collection endpoint with `fetch`.
+//
+//	defer cpanic.Recover(cpanicjs.Handler(cpanicjs.WithEndpoint("/crashes")))
+//
+// The package is empty on other platforms.
+package cpanicjs
@@ -0,0 +1,11 @@
+module github.com/demosdemon/cpanic/cpanickafka
+
+go 1.13
+
+require (
+	github.com/demosdemon/cpanic v0.0.0
+	github.com/segmentio/kafka-go v0.4.47
+	github.com/stretchr/testify v1.8.2
+)
+
+replace github.com/demosdemon/cpanic => ../
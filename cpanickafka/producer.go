@@ -0,0 +1,129 @@
+// cpanickafka publishes panic reports to a Kafka topic using
+// `github.com/segmentio/kafka-go`, so crashes can feed a self-hosted analytics
+// pipeline. Messages are keyed by the fingerprint of the panic, so a compacted topic
+// keeps the latest occurrence of every distinct crash and all occurrences of a crash
+// land on the same partition.
+package cpanickafka
+
+import (
+	"context"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+
+	"github.com/demosdemon/cpanic"
+)
+
+// The headers set on every message.
+const (
+	SeverityHeader = "cpanic-severity"
+	TypeHeader     = "cpanic-type"
+	IDHeader       = "cpanic-id"
+)
+
+// DefaultTimeout is the default time allowed for each write made by `Handler`.
+const DefaultTimeout = 10 * time.Second
+
+// MessageWriter writes messages to Kafka. It is implemented by `*kafka.Writer`.
+type MessageWriter interface {
+	WriteMessages(ctx context.Context, msgs ...kafka.Message) error
+}
+
+// Option configures a `Producer`.
+type Option func(*Producer)
+
+// WithTopic sets the topic of the messages, for writers that do not set one.
+func WithTopic(topic string) Option {
+	return func(p *Producer) {
+		p.topic = topic
+	}
+}
+
+// WithFormatter sets how the panic is serialized in the message value. It defaults to
+// `cpanic.NDJSON`; a formatter encoding a protobuf message can be used instead.
+func WithFormatter(f cpanic.Formatter) Option {
+	return func(p *Producer) {
+		p.formatter = f
+	}
+}
+
+// WithTimeout sets the time allowed for each write made by `Handler`.
+func WithTimeout(d time.Duration) Option {
+	return func(p *Producer) {
+		p.timeout = d
+	}
+}
+
+// Producer publishes panics with a `MessageWriter`.
+type Producer struct {
+	w         MessageWriter
+	topic     string
+	formatter cpanic.Formatter
+	timeout   time.Duration
+}
+
+// New creates a producer writing with w.
+func New(w MessageWriter, opts ...Option) *Producer {
+	p := &Producer{
+		w:         w,
+		formatter: cpanic.NDJSON,
+		timeout:   DefaultTimeout,
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// NewWriter creates a producer with a `*kafka.Writer` publishing to the topic on the
+// brokers, hashing keys to pick the partition. The writer is closed by `Close`.
+func NewWriter(brokers []string, topic string, opts ...Option) *Producer {
+	w := &kafka.Writer{
+		Addr:         kafka.TCP(brokers...),
+		Topic:        topic,
+		Balancer:     &kafka.Hash{},
+		RequiredAcks: kafka.RequireAll,
+	}
+	return New(w, opts...)
+}
+
+// Handler returns a `cpanic.Handler` that publishes each panic, discarding errors.
+func (p *Producer) Handler() cpanic.Handler {
+	return func(pn *cpanic.Panic) {
+		ctx, cancel := context.WithTimeout(context.Background(), p.timeout)
+		defer cancel()
+		_ = p.Produce(ctx, pn)
+	}
+}
+
+// Produce writes the panic as a single message keyed by its fingerprint.
+func (p *Producer) Produce(ctx context.Context, pn *cpanic.Panic) error {
+	value, err := p.formatter.Format(pn)
+	if err != nil {
+		return err
+	}
+
+	headers := []kafka.Header{
+		{Key: SeverityHeader, Value: []byte(pn.Severity.String())},
+		{Key: TypeHeader, Value: []byte(pn.Type())},
+	}
+	if pn.ID != "" {
+		headers = append(headers, kafka.Header{Key: IDHeader, Value: []byte(pn.ID)})
+	}
+	return p.w.WriteMessages(ctx, kafka.Message{
+		Topic:   p.topic,
+		Key:     []byte(pn.Fingerprint()),
+		Value:   value,
+		Headers: headers,
+		Time:    pn.Time,
+	})
+}
+
+// Close closes the writer, if it has a `Close() error` method, flushing buffered
+// messages.
+func (p *Producer) Close() error {
+	if c, ok := p.w.(interface{ Close() error }); ok {
+		return c.Close()
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package cpanickafka_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/demosdemon/cpanic"
+	"github.com/demosdemon/cpanic/cpanickafka"
+)
+
+type writer struct {
+	msgs []kafka.Message
+	err  error
+}
+
+func (w *writer) WriteMessages(_ context.Context, msgs ...kafka.Message) error {
+	w.msgs = append(w.msgs, msgs...)
+	return w.err
+}
+
+func TestProducer(t *testing.T) {
+	var w writer
+	prod := cpanickafka.New(&w, cpanickafka.WithTopic("panics"))
+	p := cpanic.New("not at a disco")
+	prod.Handler()(p)
+
+	require.Len(t, w.msgs, 1)
+	msg := w.msgs[0]
+	assert.Equal(t, "panics", msg.Topic)
+	assert.Equal(t, p.Fingerprint(), string(msg.Key))
+	assert.Equal(t, p.Time, msg.Time)
+	assert.Equal(t, []kafka.Header{
+		{Key: cpanickafka.SeverityHeader, Value: []byte("error")},
+		{Key: cpanickafka.TypeHeader, Value: []byte("string")},
+		{Key: cpanickafka.IDHeader, Value: []byte(p.ID)},
+	}, msg.Headers)
+
+	var report map[string]interface{}
+	require.NoError(t, json.Unmarshal(msg.Value, &report))
+	assert.Equal(t, "not at a disco", report["value"])
+	assert.NoError(t, prod.Close())
+}
+
+func TestProducerFormatter(t *testing.T) {
+	var w writer
+	prod := cpanickafka.New(&w, cpanickafka.WithFormatter(cpanic.Text))
+	require.NoError(t, prod.Produce(context.Background(), cpanic.New("not at a disco")))
+	require.Len(t, w.msgs, 1)
+	assert.Contains(t, string(w.msgs[0].Value), "panic: not at a disco")
+}
+
+func TestProducerError(t *testing.T) {
+	errWrite := errors.New("broker unavailable")
+	prod := cpanickafka.New(&writer{err: errWrite})
+	assert.Equal(t, errWrite, prod.Produce(context.Background(), cpanic.New("not at a disco")))
+}
@@ -0,0 +1,61 @@
+// cpanicmobile bridges cpanic to the crash reporting SDK of an Android or iOS app that
+// embeds a Go library with `gomobile bind`, such as Crashlytics. The app implements
+// `Reporter` in Java, Kotlin, Objective-C, or Swift and registers it with `Register`;
+// the Go library reports its panics with `Handler`.
+//
+//	// Go
+//	defer cpanic.Recover(cpanicmobile.Handler())
+//
+//	// Kotlin
+//	Cpanicmobile.register { report -> crashlytics.log(report) }
+//
+// The API exported to the app only uses types supported by `gomobile bind`.
+package cpanicmobile
+
+import (
+	"bytes"
+	"sync"
+
+	"github.com/demosdemon/cpanic"
+)
+
+// Reporter receives panics on behalf of the app. It is implemented by the app and must
+// be safe to call from any thread.
+type Reporter interface {
+	// ReportPanic is called with the panic as the JSON report written by
+	// `cpanic.NDJSON`, without the trailing newline, and its fingerprint, which can be
+	// used as the issue key of the crash SDK.
+	ReportPanic(report, fingerprint string)
+}
+
+var registered struct {
+	sync.RWMutex
+	reporter Reporter
+}
+
+// Register makes r receive every panic reported by `Handler`, replacing the reporter
+// registered before, if any. A nil reporter unregisters it.
+func Register(r Reporter) {
+	registered.Lock()
+	defer registered.Unlock()
+	registered.reporter = r
+}
+
+// Handler returns a handler that forwards each panic to the registered `Reporter`.
+// Panics are dropped while no reporter is registered.
+func Handler() cpanic.Handler {
+	return func(p *cpanic.Panic) {
+		registered.RLock()
+		r := registered.reporter
+		registered.RUnlock()
+		if r == nil {
+			return
+		}
+
+		report, err := cpanic.NDJSON.Format(p)
+		if err != nil {
+			return
+		}
+		r.ReportPanic(string(bytes.TrimSuffix(report, []byte("\n"))), p.Fingerprint())
+	}
+}
@@ -0,0 +1,45 @@
+package cpanicmobile_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/demosdemon/cpanic"
+	"github.com/demosdemon/cpanic/cpanicmobile"
+)
+
+type reporter struct {
+	reports      []string
+	fingerprints []string
+}
+
+func (r *reporter) ReportPanic(report, fingerprint string) {
+	r.reports = append(r.reports, report)
+	r.fingerprints = append(r.fingerprints, fingerprint)
+}
+
+func TestHandler(t *testing.T) {
+	h := cpanicmobile.Handler()
+	h(cpanic.New("dropped"))
+
+	r := &reporter{}
+	cpanicmobile.Register(r)
+	defer cpanicmobile.Register(nil)
+
+	p := cpanic.New("not at a disco")
+	h(p)
+	require.Len(t, r.reports, 1)
+	assert.Equal(t, []string{p.Fingerprint()}, r.fingerprints)
+
+	var report map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(r.reports[0]), &report))
+	assert.Equal(t, "not at a disco", report["value"])
+	assert.NotContains(t, r.reports[0], "\n")
+
+	cpanicmobile.Register(nil)
+	h(p)
+	assert.Len(t, r.reports, 1)
+}
@@ -0,0 +1,420 @@
+// cpanicmqtt publishes compact CBOR panic reports to an MQTT broker, speaking MQTT
+// 3.1.1 directly, so fleets of edge devices report crashes over their existing
+// telemetry channel. Reports that cannot be delivered while the device is offline are
+// buffered in memory and sent, in order, once the broker is reachable again.
+package cpanicmqtt
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/demosdemon/cpanic"
+)
+
+// DefaultBufferSize is the default number of reports kept while the broker is
+// unreachable.
+const DefaultBufferSize = 100
+
+// DefaultTimeout is the default time allowed for each publish made by `Handler` and
+// `Flush`.
+const DefaultTimeout = 10 * time.Second
+
+// ErrUnsupportedQoS is returned by `New` for a QoS other than 0 or 1.
+var ErrUnsupportedQoS = errors.New("cpanicmqtt: only QoS 0 and 1 are supported")
+
+// The MQTT control packet types used by the publisher.
+const (
+	packetConnect    = 1
+	packetConnack    = 2
+	packetPublish    = 3
+	packetPuback     = 4
+	packetDisconnect = 14
+)
+
+// Option configures a `Publisher`.
+type Option func(*Publisher)
+
+// WithClientID sets the client identifier. It defaults to "cpanic-" followed by random
+// hex digits.
+func WithClientID(id string) Option {
+	return func(p *Publisher) {
+		p.clientID = id
+	}
+}
+
+// WithAuth authenticates with the username and password.
+func WithAuth(username, password string) Option {
+	return func(p *Publisher) {
+		p.username, p.password = username, password
+	}
+}
+
+// WithTLS connects to the broker over TLS with the configuration.
+func WithTLS(config *tls.Config) Option {
+	return func(p *Publisher) {
+		p.tls = config
+	}
+}
+
+// WithQoS sets the quality of service of the messages: 0, at most once, or 1, at
+// least once, where a report stays buffered until the broker acknowledges it. It
+// defaults to 1.
+func WithQoS(qos byte) Option {
+	return func(p *Publisher) {
+		p.qos = qos
+	}
+}
+
+// WithRetain publishes the reports as retained messages, so a subscriber connecting
+// later receives the latest crash of the device.
+func WithRetain() Option {
+	return func(p *Publisher) {
+		p.retain = true
+	}
+}
+
+// WithBufferSize sets the number of reports kept while the broker is unreachable. The
+// oldest report is dropped when the buffer is full.
+func WithBufferSize(n int) Option {
+	return func(p *Publisher) {
+		p.bufferSize = n
+	}
+}
+
+// WithTimeout sets the time allowed for each publish made by `Handler` and `Flush`.
+func WithTimeout(d time.Duration) Option {
+	return func(p *Publisher) {
+		p.timeout = d
+	}
+}
+
+// Publisher publishes panics to a topic over a single connection, which is dialed on
+// the first publish and redialed after an error.
+type Publisher struct {
+	addr       string
+	topic      string
+	clientID   string
+	username   string
+	password   string
+	tls        *tls.Config
+	qos        byte
+	retain     bool
+	bufferSize int
+	timeout    time.Duration
+
+	mu       sync.Mutex
+	conn     net.Conn
+	r        *bufio.Reader
+	packetID uint16
+	buffer   [][]byte
+}
+
+// New creates a publisher sending to the topic on the broker at addr, such as
+// "localhost:1883".
+func New(addr, topic string, opts ...Option) (*Publisher, error) {
+	p := &Publisher{
+		addr:       addr,
+		topic:      topic,
+		clientID:   "cpanic-" + randomHex(8),
+		qos:        1,
+		bufferSize: DefaultBufferSize,
+		timeout:    DefaultTimeout,
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	if p.qos > 1 {
+		return nil, ErrUnsupportedQoS
+	}
+	return p, nil
+}
+
+// Handler returns a `cpanic.Handler` that publishes each panic, discarding errors.
+func (p *Publisher) Handler() cpanic.Handler {
+	return func(pn *cpanic.Panic) {
+		ctx, cancel := context.WithTimeout(context.Background(), p.timeout)
+		defer cancel()
+		_ = p.Publish(ctx, pn)
+	}
+}
+
+// Publish buffers the CBOR report of the panic and sends all buffered reports. If the
+// broker cannot be reached, the reports stay buffered for the next publish or `Flush`
+// and the error is returned.
+func (p *Publisher) Publish(ctx context.Context, pn *cpanic.Panic) error {
+	report, err := pn.MarshalCBOR()
+	if err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.bufferSize > 0 && len(p.buffer) >= p.bufferSize {
+		p.buffer = p.buffer[1:]
+	}
+	p.buffer = append(p.buffer, report)
+	return p.drain(ctx)
+}
+
+// Flush sends the buffered reports, if any. It implements `cpanic.Flusher`, so
+// `cpanic.WithFlush` can deliver reports buffered before a crash.
+func (p *Publisher) Flush() {
+	ctx, cancel := context.WithTimeout(context.Background(), p.timeout)
+	defer cancel()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	_ = p.drain(ctx)
+}
+
+// Buffered returns the number of reports waiting to be delivered.
+func (p *Publisher) Buffered() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.buffer)
+}
+
+// Close disconnects from the broker. Buffered reports are kept.
+func (p *Publisher) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.conn == nil {
+		return nil
+	}
+	_, _ = p.conn.Write([]byte{packetDisconnect << 4, 0})
+	return p.closeLocked()
+}
+
+func (p *Publisher) closeLocked() error {
+	err := p.conn.Close()
+	p.conn, p.r = nil, nil
+	return err
+}
+
+// drain sends the buffered reports in order. A connection that was kept from an
+// earlier publish may have been closed by the broker in the meantime, so a failure on
+// it is retried once on a new connection.
+func (p *Publisher) drain(ctx context.Context) error {
+	for len(p.buffer) > 0 {
+		reused := p.conn != nil
+		err := p.send(ctx, p.buffer[0])
+		if err != nil && reused {
+			err = p.send(ctx, p.buffer[0])
+		}
+		if err != nil {
+			return err
+		}
+		p.buffer = p.buffer[1:]
+	}
+	p.buffer = nil
+	return nil
+}
+
+// send publishes a single report, dialing first if needed. The connection is closed
+// on error.
+func (p *Publisher) send(ctx context.Context, report []byte) error {
+	if p.conn == nil {
+		if err := p.dial(ctx); err != nil {
+			return err
+		}
+	}
+	if err := p.publish(ctx, report); err != nil {
+		_ = p.closeLocked()
+		return err
+	}
+	return nil
+}
+
+func (p *Publisher) publish(ctx context.Context, report []byte) error {
+	deadline, _ := ctx.Deadline()
+	if err := p.conn.SetDeadline(deadline); err != nil {
+		return err
+	}
+
+	header := byte(packetPublish<<4) | p.qos<<1
+	if p.retain {
+		header |= 1
+	}
+	body := appendString(nil, p.topic)
+	var id uint16
+	if p.qos > 0 {
+		p.packetID++
+		if p.packetID == 0 {
+			p.packetID = 1
+		}
+		id = p.packetID
+		body = appendUint16(body, id)
+	}
+	body = append(body, report...)
+	if _, err := p.conn.Write(appendPacket(nil, header, body)); err != nil {
+		return err
+	}
+	if p.qos == 0 {
+		return nil
+	}
+
+	for {
+		typ, body, err := readPacket(p.r)
+		if err != nil {
+			return err
+		}
+		if typ == packetPuback && len(body) == 2 && binary.BigEndian.Uint16(body) == id {
+			return nil
+		}
+	}
+}
+
+func (p *Publisher) dial(ctx context.Context) error {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", p.addr)
+	if err != nil {
+		return err
+	}
+	if p.tls != nil {
+		tlsConn := tls.Client(conn, p.tls)
+		deadline, _ := ctx.Deadline()
+		_ = tlsConn.SetDeadline(deadline)
+		if err := tlsConn.Handshake(); err != nil {
+			conn.Close()
+			return err
+		}
+		conn = tlsConn
+	}
+	p.conn, p.r = conn, bufio.NewReader(conn)
+
+	if err := p.connect(ctx); err != nil {
+		_ = p.closeLocked()
+		return err
+	}
+	return nil
+}
+
+// connect sends the CONNECT packet, requesting a clean session without keep alive,
+// and waits for the CONNACK.
+func (p *Publisher) connect(ctx context.Context) error {
+	deadline, _ := ctx.Deadline()
+	if err := p.conn.SetDeadline(deadline); err != nil {
+		return err
+	}
+
+	flags := byte(0x02)
+	if p.username != "" {
+		flags |= 0x80
+	}
+	if p.password != "" {
+		flags |= 0x40
+	}
+	body := appendString(nil, "MQTT")
+	body = append(body, 4, flags, 0, 0)
+	body = appendString(body, p.clientID)
+	if p.username != "" {
+		body = appendString(body, p.username)
+	}
+	if p.password != "" {
+		body = appendString(body, p.password)
+	}
+	if _, err := p.conn.Write(appendPacket(nil, packetConnect<<4, body)); err != nil {
+		return err
+	}
+
+	typ, body, err := readPacket(p.r)
+	if err != nil {
+		return err
+	}
+	if typ != packetConnack || len(body) != 2 {
+		return fmt.Errorf("cpanicmqtt: unexpected packet type %d", typ)
+	}
+	if body[1] != 0 {
+		return fmt.Errorf("cpanicmqtt: connection refused: %s", connackReason(body[1]))
+	}
+	return nil
+}
+
+func connackReason(code byte) string {
+	switch code {
+	case 1:
+		return "unacceptable protocol version"
+	case 2:
+		return "identifier rejected"
+	case 3:
+		return "server unavailable"
+	case 4:
+		return "bad user name or password"
+	case 5:
+		return "not authorized"
+	default:
+		return fmt.Sprintf("return code %d", code)
+	}
+}
+
+// appendPacket appends the packet with the fixed header byte and its remaining length
+// encoded as a variable byte integer.
+func appendPacket(buf []byte, header byte, body []byte) []byte {
+	buf = append(buf, header)
+	n := len(body)
+	for {
+		b := byte(n % 128)
+		n /= 128
+		if n > 0 {
+			b |= 0x80
+		}
+		buf = append(buf, b)
+		if n == 0 {
+			break
+		}
+	}
+	return append(buf, body...)
+}
+
+// readPacket reads a packet, returning its type and the bytes after the fixed header.
+func readPacket(r *bufio.Reader) (byte, []byte, error) {
+	header, err := r.ReadByte()
+	if err != nil {
+		return 0, nil, err
+	}
+	n, shift := 0, uint(0)
+	for {
+		b, err := r.ReadByte()
+		if err != nil {
+			return 0, nil, err
+		}
+		n |= int(b&0x7f) << shift
+		if b&0x80 == 0 {
+			break
+		}
+		shift += 7
+		if shift > 21 {
+			return 0, nil, errors.New("cpanicmqtt: malformed remaining length")
+		}
+	}
+	body := make([]byte, n)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return 0, nil, err
+	}
+	return header >> 4, body, nil
+}
+
+func appendString(buf []byte, s string) []byte {
+	buf = appendUint16(buf, uint16(len(s)))
+	return append(buf, s...)
+}
+
+func appendUint16(buf []byte, v uint16) []byte {
+	return append(buf, byte(v>>8), byte(v))
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
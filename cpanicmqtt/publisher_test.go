@@ -0,0 +1,179 @@
+package cpanicmqtt_test
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/demosdemon/cpanic"
+	"github.com/demosdemon/cpanic/cpanicmqtt"
+)
+
+// broker is a fake MQTT broker recording the packets it receives.
+type broker struct {
+	ln      net.Listener
+	mu      sync.Mutex
+	refuse  byte
+	connect [][]byte
+	publish [][]byte
+}
+
+func newBroker(t *testing.T) *broker {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	b := &broker{ln: ln}
+	go b.serve()
+	return b
+}
+
+func (b *broker) addr() string { return b.ln.Addr().String() }
+
+func (b *broker) setRefuse(code byte) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refuse = code
+}
+
+func (b *broker) packets() (connect, publish [][]byte) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.connect, b.publish
+}
+
+func (b *broker) serve() {
+	for {
+		conn, err := b.ln.Accept()
+		if err != nil {
+			return
+		}
+		go b.handle(conn)
+	}
+}
+
+func (b *broker) handle(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	for {
+		header, body, err := readPacket(r)
+		if err != nil {
+			return
+		}
+
+		b.mu.Lock()
+		var reply []byte
+		switch header >> 4 {
+		case 1:
+			b.connect = append(b.connect, body)
+			reply = []byte{0x20, 2, 0, b.refuse}
+		case 3:
+			b.publish = append(b.publish, append([]byte{header}, body...))
+			if qos := header >> 1 & 3; qos == 1 {
+				topicLen := int(binary.BigEndian.Uint16(body))
+				reply = append([]byte{0x40, 2}, body[2+topicLen:4+topicLen]...)
+			}
+		}
+		b.mu.Unlock()
+		if _, err := conn.Write(reply); err != nil {
+			return
+		}
+	}
+}
+
+func readPacket(r *bufio.Reader) (byte, []byte, error) {
+	header, err := r.ReadByte()
+	if err != nil {
+		return 0, nil, err
+	}
+	n, shift := 0, uint(0)
+	for {
+		c, err := r.ReadByte()
+		if err != nil {
+			return 0, nil, err
+		}
+		n |= int(c&0x7f) << shift
+		if c&0x80 == 0 {
+			break
+		}
+		shift += 7
+	}
+	body := make([]byte, n)
+	_, err = io.ReadFull(r, body)
+	return header, body, err
+}
+
+func TestPublisher(t *testing.T) {
+	b := newBroker(t)
+	defer b.ln.Close()
+
+	pub, err := cpanicmqtt.New(b.addr(), "devices/42/panics",
+		cpanicmqtt.WithClientID("device-42"),
+		cpanicmqtt.WithAuth("device", "secret"),
+		cpanicmqtt.WithRetain(),
+	)
+	require.NoError(t, err)
+	defer pub.Close()
+
+	p := cpanic.New("not at a disco")
+	require.NoError(t, pub.Publish(context.Background(), p))
+	pub.Handler()(p)
+	assert.Zero(t, pub.Buffered())
+
+	connect, publish := b.packets()
+	require.Len(t, connect, 1)
+	assert.Equal(t, []byte("\x00\x04MQTT\x04\xc2\x00\x00\x00\x09device-42\x00\x06device\x00\x06secret"), connect[0])
+
+	report, err := p.MarshalCBOR()
+	require.NoError(t, err)
+	require.Len(t, publish, 2)
+	for i, pkt := range publish {
+		assert.Equal(t, byte(0x33), pkt[0], "QoS 1, retained")
+		topic := "\x00\x11devices/42/panics"
+		assert.Equal(t, topic, string(pkt[1:1+len(topic)]))
+		assert.Equal(t, uint16(i+1), binary.BigEndian.Uint16(pkt[1+len(topic):]))
+		assert.True(t, bytes.Equal(report, pkt[3+len(topic):]))
+	}
+}
+
+func TestPublisherOffline(t *testing.T) {
+	b := newBroker(t)
+	defer b.ln.Close()
+	b.setRefuse(3)
+
+	pub, err := cpanicmqtt.New(b.addr(), "panics", cpanicmqtt.WithQoS(0), cpanicmqtt.WithBufferSize(2))
+	require.NoError(t, err)
+	defer pub.Close()
+
+	for _, v := range []string{"one", "two", "three"} {
+		err := pub.Publish(context.Background(), cpanic.New(v))
+		assert.EqualError(t, err, "cpanicmqtt: connection refused: server unavailable")
+	}
+	assert.Equal(t, 2, pub.Buffered())
+
+	b.setRefuse(0)
+	pub.Flush()
+	assert.Zero(t, pub.Buffered())
+
+	var publish [][]byte
+	require.Eventually(t, func() bool {
+		_, publish = b.packets()
+		return len(publish) == 2
+	}, time.Second, time.Millisecond)
+	for i, v := range []string{"two", "three"} {
+		assert.Equal(t, byte(0x30), publish[i][0], "QoS 0")
+		assert.Contains(t, string(publish[i]), v)
+	}
+}
+
+func TestNewUnsupportedQoS(t *testing.T) {
+	_, err := cpanicmqtt.New("localhost:1883", "panics", cpanicmqtt.WithQoS(2))
+	assert.Equal(t, cpanicmqtt.ErrUnsupportedQoS, err)
+}
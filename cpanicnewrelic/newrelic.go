@@ -0,0 +1,88 @@
+// cpanicnewrelic reports panics to New Relic through the transactions of the New
+// Relic Go agent, without depending on it: a `*newrelic.Transaction` implements
+// `Transaction`.
+//
+//	h := cpanicnewrelic.HandlerFunc(func(ctx context.Context) cpanicnewrelic.Transaction {
+//		if txn := newrelic.FromContext(ctx); txn != nil {
+//			return txn
+//		}
+//		return nil
+//	})
+//
+// New Relic records errors within transactions, so panics are reported to the
+// transaction carried by the context they were recovered with, which correlates them
+// with the request that panicked.
+package cpanicnewrelic
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/demosdemon/cpanic"
+)
+
+// The attributes of the reported error, in addition to those of the panic.
+const (
+	FingerprintAttr = "cpanic.fingerprint"
+	IDAttr          = "cpanic.id"
+	CulpritAttr     = "cpanic.culprit"
+)
+
+// ErrNoTransaction is returned by the handler when the context carries no transaction.
+var ErrNoTransaction = errors.New("cpanicnewrelic: no transaction in context")
+
+// Transaction is the error-capture API of a New Relic transaction, implemented by
+// `*newrelic.Transaction`.
+type Transaction interface {
+	NoticeError(err error)
+}
+
+// HandlerFunc returns a `cpanic.HandlerFunc` that notices each panic as an error of the
+// transaction returned by fromContext. The error passed to `NoticeError` has the
+// `ErrorClass` and `ErrorAttributes` methods read by the agent, so it is grouped by the
+// panic value's type and carries the panic's attributes, fingerprint, ID, and culprit.
+// If fromContext returns nil, `ErrNoTransaction` is returned.
+func HandlerFunc(fromContext func(ctx context.Context) Transaction) cpanic.HandlerFunc {
+	return func(ctx context.Context, p *cpanic.Panic) error {
+		txn := fromContext(ctx)
+		if txn == nil {
+			return ErrNoTransaction
+		}
+		txn.NoticeError(&Error{Panic: p})
+		return nil
+	}
+}
+
+// Error is a panic as noticed by a transaction.
+type Error struct {
+	*cpanic.Panic
+}
+
+// ErrorClass returns the type of the panic value, which New Relic groups errors by.
+func (e *Error) ErrorClass() string {
+	return e.Type()
+}
+
+// ErrorAttributes returns the attributes of the panic, formatted with `fmt.Sprint`
+// unless they are strings, numbers, or booleans, along with its fingerprint, ID, and
+// culprit.
+func (e *Error) ErrorAttributes() map[string]interface{} {
+	attrs := make(map[string]interface{}, len(e.Attrs)+3)
+	for k, v := range e.Attrs {
+		switch v.(type) {
+		case string, bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+			attrs[k] = v
+		default:
+			attrs[k] = fmt.Sprint(v)
+		}
+	}
+	attrs[FingerprintAttr] = e.Fingerprint()
+	if e.ID != "" {
+		attrs[IDAttr] = e.ID
+	}
+	if f, ok := e.Culprit(); ok {
+		attrs[CulpritAttr] = f.String()
+	}
+	return attrs
+}
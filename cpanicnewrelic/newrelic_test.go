@@ -0,0 +1,59 @@
+package cpanicnewrelic_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/demosdemon/cpanic"
+	"github.com/demosdemon/cpanic/cpanicnewrelic"
+)
+
+type txnKey struct{}
+
+// transaction records the errors it notices.
+type transaction struct {
+	errs []error
+}
+
+func (t *transaction) NoticeError(err error) {
+	t.errs = append(t.errs, err)
+}
+
+func TestHandlerFunc(t *testing.T) {
+	h := cpanicnewrelic.HandlerFunc(func(ctx context.Context) cpanicnewrelic.Transaction {
+		if txn, ok := ctx.Value(txnKey{}).(*transaction); ok {
+			return txn
+		}
+		return nil
+	})
+
+	p := &cpanic.Panic{
+		ID:    "01F2",
+		Value: "not at a disco",
+		Trace: "goroutine 1 [running]:\nmain.main()\n\t/src/main.go:3 +0x1d\n",
+	}
+	p.SetAttr("route", "/")
+	p.SetAttr("user", struct{ Name string }{"brendon"})
+
+	assert.Equal(t, cpanicnewrelic.ErrNoTransaction, h(context.Background(), p))
+
+	txn := &transaction{}
+	require.NoError(t, h(context.WithValue(context.Background(), txnKey{}, txn), p))
+	require.Len(t, txn.errs, 1)
+
+	var e *cpanicnewrelic.Error
+	require.True(t, errors.As(txn.errs[0], &e))
+	assert.Equal(t, "panic: not at a disco", e.Error())
+	assert.Equal(t, "string", e.ErrorClass())
+	assert.Equal(t, map[string]interface{}{
+		"route":                        "/",
+		"user":                         "{brendon}",
+		cpanicnewrelic.FingerprintAttr: p.Fingerprint(),
+		cpanicnewrelic.IDAttr:          "01F2",
+		cpanicnewrelic.CulpritAttr:     "main.main (/src/main.go:3)",
+	}, e.ErrorAttributes())
+}
@@ -0,0 +1,186 @@
+// cpanicotlp exports panics as OpenTelemetry log records using the OTLP/HTTP protocol
+// with JSON encoding, so panics can be sent to an OpenTelemetry collector without
+// depending on the OpenTelemetry SDK. The gRPC transport is not supported; collectors
+// accept OTLP/HTTP on port 4318 by default.
+package cpanicotlp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/demosdemon/cpanic"
+)
+
+// The OpenTelemetry semantic convention attribute keys set on every log record.
+const (
+	ExceptionTypeKey       = "exception.type"
+	ExceptionMessageKey    = "exception.message"
+	ExceptionStacktraceKey = "exception.stacktrace"
+	FingerprintKey         = "cpanic.fingerprint"
+	IDKey                  = "cpanic.id"
+	BuildIDKey             = "cpanic.build_id"
+)
+
+// scopeName is the instrumentation scope of the exported log records.
+const scopeName = "github.com/demosdemon/cpanic"
+
+// severityError is the OTLP severity number of the ERROR level.
+const severityError = 17
+
+// DefaultTimeout is the default time allowed for each export request.
+const DefaultTimeout = 10 * time.Second
+
+// Option configures an `Exporter`.
+type Option func(*Exporter)
+
+// WithHTTPClient sets the client used to send export requests.
+func WithHTTPClient(c *http.Client) Option {
+	return func(e *Exporter) {
+		e.client = c
+	}
+}
+
+// WithHeaders sets additional headers, such as authentication, sent with every export
+// request.
+func WithHeaders(headers map[string]string) Option {
+	return func(e *Exporter) {
+		for k, v := range headers {
+			e.headers.Set(k, v)
+		}
+	}
+}
+
+// WithResource sets the resource attributes, such as "service.name", describing the
+// process that panicked.
+func WithResource(attrs map[string]string) Option {
+	return func(e *Exporter) {
+		for k, v := range attrs {
+			e.resource[k] = v
+		}
+	}
+}
+
+// WithTimeout sets the time allowed for each export request made by `Handler`.
+func WithTimeout(d time.Duration) Option {
+	return func(e *Exporter) {
+		e.timeout = d
+	}
+}
+
+// Exporter sends panics to an OTLP/HTTP logs endpoint.
+type Exporter struct {
+	url      string
+	client   *http.Client
+	headers  http.Header
+	resource map[string]string
+	timeout  time.Duration
+}
+
+// New creates an exporter sending to the collector at endpoint, such as
+// "http://localhost:4318". The "/v1/logs" path is appended unless the endpoint already
+// ends with it.
+func New(endpoint string, opts ...Option) *Exporter {
+	url := strings.TrimSuffix(endpoint, "/")
+	if !strings.HasSuffix(url, "/v1/logs") {
+		url += "/v1/logs"
+	}
+
+	e := &Exporter{
+		url:      url,
+		client:   http.DefaultClient,
+		headers:  make(http.Header),
+		resource: make(map[string]string),
+		timeout:  DefaultTimeout,
+	}
+	for _, opt := range opts {
+		opt(e)
+	}
+	return e
+}
+
+// Handler returns a `cpanic.Handler` that exports each panic, discarding export errors.
+func (e *Exporter) Handler() cpanic.Handler {
+	return func(p *cpanic.Panic) {
+		ctx, cancel := context.WithTimeout(context.Background(), e.timeout)
+		defer cancel()
+		_ = e.Export(ctx, p)
+	}
+}
+
+// Export sends the panic as a single log record. The panic value is mapped to the
+// `exception.message` attribute and the trace to `exception.stacktrace`, per the
+// OpenTelemetry semantic conventions for exceptions.
+func (e *Exporter) Export(ctx context.Context, p *cpanic.Panic) error {
+	body, err := json.Marshal(e.request(p))
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, e.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	for k, v := range e.headers {
+		req.Header[k] = v
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(ioutil.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("cpanicotlp: export failed: %s", resp.Status)
+	}
+	return nil
+}
+
+func (e *Exporter) request(p *cpanic.Panic) exportLogsRequest {
+	attrs := []keyValue{
+		stringAttr(ExceptionTypeKey, p.Type()),
+		stringAttr(ExceptionMessageKey, p.Message()),
+		stringAttr(ExceptionStacktraceKey, p.Trace),
+		stringAttr(FingerprintKey, p.Fingerprint()),
+		stringAttr(IDKey, p.ID),
+	}
+	if p.BuildID != "" {
+		attrs = append(attrs, stringAttr(BuildIDKey, p.BuildID))
+	}
+	for k, v := range p.Attrs {
+		attrs = append(attrs, keyValue{Key: k, Value: anyValueOf(v)})
+	}
+
+	resource := make([]keyValue, 0, len(e.resource))
+	for k, v := range e.resource {
+		resource = append(resource, stringAttr(k, v))
+	}
+
+	return exportLogsRequest{
+		ResourceLogs: []resourceLogs{{
+			Resource: resourceAttrs{Attributes: resource},
+			ScopeLogs: []scopeLogs{{
+				Scope: scope{Name: scopeName},
+				LogRecords: []logRecord{{
+					TimeUnixNano:         strconv.FormatInt(p.Time.UnixNano(), 10),
+					ObservedTimeUnixNano: strconv.FormatInt(time.Now().UnixNano(), 10),
+					SeverityNumber:       severityError,
+					SeverityText:         "ERROR",
+					Body:                 anyValue{StringValue: stringPtr(p.Error())},
+					Attributes:           attrs,
+				}},
+			}},
+		}},
+	}
+}
@@ -0,0 +1,107 @@
+package cpanicotlp_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/demosdemon/cpanic"
+	"github.com/demosdemon/cpanic/cpanicotlp"
+)
+
+type request struct {
+	ResourceLogs []struct {
+		Resource struct {
+			Attributes []attribute `json:"attributes"`
+		} `json:"resource"`
+		ScopeLogs []struct {
+			LogRecords []struct {
+				SeverityNumber int `json:"severityNumber"`
+				Body           struct {
+					StringValue string `json:"stringValue"`
+				} `json:"body"`
+				Attributes []attribute `json:"attributes"`
+			} `json:"logRecords"`
+		} `json:"scopeLogs"`
+	} `json:"resourceLogs"`
+}
+
+type attribute struct {
+	Key   string                 `json:"key"`
+	Value map[string]interface{} `json:"value"`
+}
+
+func attrs(list []attribute) map[string]interface{} {
+	m := make(map[string]interface{}, len(list))
+	for _, a := range list {
+		for _, v := range a.Value {
+			m[a.Key] = v
+		}
+	}
+	return m
+}
+
+func TestExporter(t *testing.T) {
+	var (
+		path    string
+		headers http.Header
+		req     request
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path, headers = r.URL.Path, r.Header
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+	}))
+	defer srv.Close()
+
+	e := cpanicotlp.New(srv.URL,
+		cpanicotlp.WithHeaders(map[string]string{"Authorization": "Bearer token"}),
+		cpanicotlp.WithResource(map[string]string{"service.name": "test"}),
+	)
+
+	p := cpanic.New("not at a disco")
+	p.SetAttr("retries", 3)
+	assert.NoError(t, e.Export(context.Background(), p))
+
+	assert.Equal(t, "/v1/logs", path)
+	assert.Equal(t, "application/json", headers.Get("Content-Type"))
+	assert.Equal(t, "Bearer token", headers.Get("Authorization"))
+
+	if assert.Len(t, req.ResourceLogs, 1) {
+		rl := req.ResourceLogs[0]
+		assert.Equal(t, map[string]interface{}{"service.name": "test"}, attrs(rl.Resource.Attributes))
+
+		record := rl.ScopeLogs[0].LogRecords[0]
+		assert.Equal(t, 17, record.SeverityNumber)
+		assert.Equal(t, "panic: not at a disco", record.Body.StringValue)
+		want := map[string]interface{}{
+			cpanicotlp.ExceptionTypeKey:       "string",
+			cpanicotlp.ExceptionMessageKey:    "not at a disco",
+			cpanicotlp.ExceptionStacktraceKey: p.Trace,
+			cpanicotlp.FingerprintKey:         p.Fingerprint(),
+			cpanicotlp.IDKey:                  p.ID,
+			"retries":                         "3",
+		}
+		if p.BuildID != "" {
+			want[cpanicotlp.BuildIDKey] = p.BuildID
+		}
+		assert.Equal(t, want, attrs(record.Attributes))
+	}
+}
+
+func TestExporterError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	e := cpanicotlp.New(srv.URL + "/v1/logs")
+	err := e.Export(context.Background(), cpanic.New("not at a disco"))
+	assert.EqualError(t, err, "cpanicotlp: export failed: 503 Service Unavailable")
+
+	// The handler discards the error.
+	assert.NotPanics(t, func() { e.Handler()(cpanic.New("not at a disco")) })
+}
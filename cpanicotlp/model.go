@@ -0,0 +1,87 @@
+package cpanicotlp
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// The types below mirror the JSON encoding of the OTLP logs protocol buffers.
+
+type exportLogsRequest struct {
+	ResourceLogs []resourceLogs `json:"resourceLogs"`
+}
+
+type resourceLogs struct {
+	Resource  resourceAttrs `json:"resource"`
+	ScopeLogs []scopeLogs   `json:"scopeLogs"`
+}
+
+type resourceAttrs struct {
+	Attributes []keyValue `json:"attributes"`
+}
+
+type scopeLogs struct {
+	Scope      scope       `json:"scope"`
+	LogRecords []logRecord `json:"logRecords"`
+}
+
+type scope struct {
+	Name string `json:"name"`
+}
+
+type logRecord struct {
+	TimeUnixNano         string     `json:"timeUnixNano"`
+	ObservedTimeUnixNano string     `json:"observedTimeUnixNano"`
+	SeverityNumber       int        `json:"severityNumber"`
+	SeverityText         string     `json:"severityText"`
+	Body                 anyValue   `json:"body"`
+	Attributes           []keyValue `json:"attributes"`
+}
+
+type keyValue struct {
+	Key   string   `json:"key"`
+	Value anyValue `json:"value"`
+}
+
+type anyValue struct {
+	StringValue *string  `json:"stringValue,omitempty"`
+	BoolValue   *bool    `json:"boolValue,omitempty"`
+	IntValue    *string  `json:"intValue,omitempty"`
+	DoubleValue *float64 `json:"doubleValue,omitempty"`
+}
+
+func stringPtr(s string) *string {
+	return &s
+}
+
+func stringAttr(key, value string) keyValue {
+	return keyValue{Key: key, Value: anyValue{StringValue: &value}}
+}
+
+// anyValueOf maps the attribute value to the closest OTLP type, falling back to its
+// `fmt` representation.
+func anyValueOf(v interface{}) anyValue {
+	switch v := v.(type) {
+	case string:
+		return anyValue{StringValue: &v}
+	case bool:
+		return anyValue{BoolValue: &v}
+	case int:
+		return anyValue{IntValue: stringPtr(strconv.FormatInt(int64(v), 10))}
+	case int64:
+		return anyValue{IntValue: stringPtr(strconv.FormatInt(v, 10))}
+	case int32:
+		return anyValue{IntValue: stringPtr(strconv.FormatInt(int64(v), 10))}
+	case uint:
+		return anyValue{IntValue: stringPtr(strconv.FormatUint(uint64(v), 10))}
+	case uint32:
+		return anyValue{IntValue: stringPtr(strconv.FormatUint(uint64(v), 10))}
+	case float64:
+		return anyValue{DoubleValue: &v}
+	case float32:
+		f := float64(v)
+		return anyValue{DoubleValue: &f}
+	default:
+		return anyValue{StringValue: stringPtr(fmt.Sprint(v))}
+	}
+}
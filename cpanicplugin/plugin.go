@@ -0,0 +1,60 @@
+// cpanicplugin calls functions of Go plugins, recovering any panic they raise. It is
+// separate from cpanic because importing "plugin" requires cgo and dynamic linking,
+// which every program importing cpanic would otherwise pay for.
+package cpanicplugin
+
+import (
+	"plugin"
+	"reflect"
+
+	"github.com/demosdemon/cpanic"
+)
+
+// SymbolLookup is implemented by `*plugin.Plugin`.
+type SymbolLookup interface {
+	Lookup(symName string) (plugin.Symbol, error)
+}
+
+// CallSymbol looks up the named function in the plugin and calls it with the provided
+// arguments using `cpanic.Call`. A nil argument is passed as the zero value of the
+// matching parameter. The error returned is either the lookup error or a
+// `*cpanic.Panic` if the call panicked.
+func CallSymbol(p SymbolLookup, name string, args ...interface{}) ([]interface{}, error) {
+	sym, err := p.Lookup(name)
+	if err != nil {
+		return nil, err
+	}
+
+	fn := reflect.ValueOf(sym)
+	in := make([]reflect.Value, len(args))
+	for i, arg := range args {
+		in[i] = reflect.ValueOf(arg)
+		if arg == nil && fn.Kind() == reflect.Func {
+			in[i] = reflect.Zero(paramType(fn.Type(), i))
+		}
+	}
+
+	out, err := cpanic.Call(fn, in...)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]interface{}, len(out))
+	for i, v := range out {
+		results[i] = v.Interface()
+	}
+	return results, nil
+}
+
+// paramType returns the type of the i'th argument of a call to a function of type ft,
+// accounting for variadic parameters.
+func paramType(ft reflect.Type, i int) reflect.Type {
+	if ft.IsVariadic() && i >= ft.NumIn()-1 {
+		return ft.In(ft.NumIn() - 1).Elem()
+	}
+	if i >= ft.NumIn() {
+		// Out of range; let `Call` report the mismatch.
+		return reflect.TypeOf((*interface{})(nil)).Elem()
+	}
+	return ft.In(i)
+}
@@ -0,0 +1,41 @@
+package cpanicplugin_test
+
+import (
+	"errors"
+	"plugin"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/demosdemon/cpanic/cpanicplugin"
+)
+
+type fakePlugin map[string]plugin.Symbol
+
+func (p fakePlugin) Lookup(name string) (plugin.Symbol, error) {
+	if sym, ok := p[name]; ok {
+		return sym, nil
+	}
+	return nil, errors.New("symbol " + name + " not found")
+}
+
+func TestCallSymbol(t *testing.T) {
+	p := fakePlugin{
+		"Join": strings.Join,
+		"Deref": func(v *int) int {
+			return *v
+		},
+	}
+
+	results, err := cpanicplugin.CallSymbol(p, "Join", []string{"a", "b"}, ",")
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{"a,b"}, results)
+
+	results, err = cpanicplugin.CallSymbol(p, "Deref", nil)
+	assert.EqualError(t, err, "panic: runtime error: invalid memory address or nil pointer dereference")
+	assert.Nil(t, results)
+
+	_, err = cpanicplugin.CallSymbol(p, "Missing")
+	assert.EqualError(t, err, "symbol Missing not found")
+}
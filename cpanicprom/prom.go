@@ -0,0 +1,55 @@
+// cpanicprom serves the panic count and the `cpanic.PipelineStats` counters in the
+// Prometheus text exposition format, without depending on the Prometheus client
+// library, so a scraper can alert both on panics and on a reporting pipeline that has
+// stopped delivering them.
+package cpanicprom
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/demosdemon/cpanic"
+)
+
+// ContentType is the media type of the text exposition format.
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+type metric struct {
+	name  string
+	kind  string
+	help  string
+	value float64
+}
+
+// Handler returns an `http.Handler` serving the metrics, to be mounted at "/metrics"
+// or merged into an existing scrape target.
+func Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", ContentType)
+		_ = Write(w)
+	})
+}
+
+// Write writes the metrics to w in the text exposition format.
+func Write(w io.Writer) error {
+	for _, m := range metrics() {
+		if _, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n%s %v\n", m.name, m.help, m.name, m.kind, m.name, m.value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func metrics() []metric {
+	s := cpanic.PipelineStats()
+	return []metric{
+		{"cpanic_panics_total", "counter", "Panics recovered since the process started.", float64(cpanic.PanicCount())},
+		{"cpanic_handler_invocations_total", "counter", "Panics delivered to a handler.", float64(s.HandlersInvoked)},
+		{"cpanic_handler_failures_total", "counter", "Handler errors absorbed by the reporting pipeline.", float64(s.HandlerFailures)},
+		{"cpanic_dropped_total", "counter", "Panics that were never delivered to a handler.", float64(s.Dropped)},
+		{"cpanic_queue_depth", "gauge", "Panics waiting to be delivered in a batch.", float64(s.QueueDepth)},
+		{"cpanic_flushes_total", "counter", "Batches of panics delivered.", float64(s.Flushes)},
+		{"cpanic_flush_duration_seconds_total", "counter", "Time spent delivering batches of panics.", s.FlushDuration.Seconds()},
+	}
+}
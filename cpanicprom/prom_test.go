@@ -0,0 +1,34 @@
+package cpanicprom_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/demosdemon/cpanic"
+	"github.com/demosdemon/cpanic/cpanicprom"
+)
+
+func TestHandler(t *testing.T) {
+	b := cpanic.Batch(func([]*cpanic.Panic) {}, 0, 0)
+	b.Handle(cpanic.New("not at a disco"))
+
+	rec := httptest.NewRecorder()
+	cpanicprom.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, cpanicprom.ContentType, rec.Header().Get("Content-Type"))
+
+	body := rec.Body.String()
+	assert.Contains(t, body, fmt.Sprintf("# TYPE cpanic_panics_total counter\ncpanic_panics_total %d\n", cpanic.PanicCount()))
+	assert.Contains(t, body, "# TYPE cpanic_queue_depth gauge\ncpanic_queue_depth 1\n")
+
+	b.Flush()
+	var buf strings.Builder
+	assert.NoError(t, cpanicprom.Write(&buf))
+	assert.Contains(t, buf.String(), "cpanic_queue_depth 0\n")
+	assert.Contains(t, buf.String(), fmt.Sprintf("cpanic_flushes_total %d\n", cpanic.PipelineStats().Flushes))
+}
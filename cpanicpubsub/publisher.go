@@ -0,0 +1,236 @@
+// cpanicpubsub publishes panic reports to a Google Cloud Pub/Sub topic using the
+// Pub/Sub REST API, without depending on the Google Cloud client libraries. Each message
+// carries the fingerprint, severity, and type of the panic as attributes, so
+// subscription filters can route panics without decoding the report.
+package cpanicpubsub
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/demosdemon/cpanic"
+)
+
+// The message attributes set on every message.
+const (
+	FingerprintAttr = "fingerprint"
+	SeverityAttr    = "severity"
+	TypeAttr        = "type"
+	IDAttr          = "id"
+)
+
+// DefaultEndpoint is the Pub/Sub REST API endpoint.
+const DefaultEndpoint = "https://pubsub.googleapis.com"
+
+// DefaultTimeout is the default time allowed for each publish request made by
+// `Handler`.
+const DefaultTimeout = 10 * time.Second
+
+// metadataTokenURL is the endpoint of the metadata server returning an access token
+// for the default service account.
+const metadataTokenURL = "http://metadata.google.internal/computeMetadata/v1/instance/service-accounts/default/token"
+
+// TokenSource returns an OAuth 2.0 access token authorized for Pub/Sub.
+type TokenSource func(ctx context.Context) (string, error)
+
+// Option configures a `Publisher`.
+type Option func(*Publisher)
+
+// WithHTTPClient sets the client used to send requests.
+func WithHTTPClient(c *http.Client) Option {
+	return func(p *Publisher) {
+		p.client = c
+	}
+}
+
+// WithEndpoint sets the REST API endpoint, such as that of the Pub/Sub emulator.
+func WithEndpoint(url string) Option {
+	return func(p *Publisher) {
+		p.endpoint = strings.TrimSuffix(url, "/")
+	}
+}
+
+// WithTokenSource sets how access tokens are obtained. It defaults to
+// `MetadataTokenSource`; a nil source sends requests without a token, as the emulator
+// expects.
+func WithTokenSource(ts TokenSource) Option {
+	return func(p *Publisher) {
+		p.token = ts
+	}
+}
+
+// WithFormatter sets how the panic is serialized in the message data. It defaults to
+// `cpanic.NDJSON`.
+func WithFormatter(f cpanic.Formatter) Option {
+	return func(p *Publisher) {
+		p.formatter = f
+	}
+}
+
+// WithOrderingKey publishes messages with the fingerprint of the panic as the ordering
+// key, so consumers of a subscription with message ordering see the occurrences of a
+// crash in order.
+func WithOrderingKey() Option {
+	return func(p *Publisher) {
+		p.ordered = true
+	}
+}
+
+// WithTimeout sets the time allowed for each publish request made by `Handler`.
+func WithTimeout(d time.Duration) Option {
+	return func(p *Publisher) {
+		p.timeout = d
+	}
+}
+
+// Publisher publishes panics to a topic.
+type Publisher struct {
+	topic     string
+	endpoint  string
+	client    *http.Client
+	token     TokenSource
+	formatter cpanic.Formatter
+	ordered   bool
+	timeout   time.Duration
+}
+
+// New creates a publisher for the topic of the project.
+func New(project, topic string, opts ...Option) *Publisher {
+	p := &Publisher{
+		topic:     "projects/" + project + "/topics/" + topic,
+		endpoint:  DefaultEndpoint,
+		client:    http.DefaultClient,
+		formatter: cpanic.NDJSON,
+		timeout:   DefaultTimeout,
+	}
+	p.token = MetadataTokenSource(p.client)
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// Handler returns a `cpanic.Handler` that publishes each panic, discarding errors.
+func (p *Publisher) Handler() cpanic.Handler {
+	return func(pn *cpanic.Panic) {
+		ctx, cancel := context.WithTimeout(context.Background(), p.timeout)
+		defer cancel()
+		_ = p.Publish(ctx, pn)
+	}
+}
+
+// Publish sends the panic as a single message.
+func (p *Publisher) Publish(ctx context.Context, pn *cpanic.Panic) error {
+	data, err := p.formatter.Format(pn)
+	if err != nil {
+		return err
+	}
+
+	msg := message{
+		Data: bytes.TrimSuffix(data, []byte("\n")),
+		Attributes: map[string]string{
+			FingerprintAttr: pn.Fingerprint(),
+			SeverityAttr:    pn.Severity.String(),
+			TypeAttr:        pn.Type(),
+		},
+	}
+	if pn.ID != "" {
+		msg.Attributes[IDAttr] = pn.ID
+	}
+	if p.ordered {
+		msg.OrderingKey = pn.Fingerprint()
+	}
+	body, err := json.Marshal(publishRequest{Messages: []message{msg}})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, p.endpoint+"/v1/"+p.topic+":publish", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+	if p.token != nil {
+		token, err := p.token(ctx)
+		if err != nil {
+			return fmt.Errorf("cpanicpubsub: token: %w", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(ioutil.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("cpanicpubsub: publish failed: %s", resp.Status)
+	}
+	return nil
+}
+
+type publishRequest struct {
+	Messages []message `json:"messages"`
+}
+
+type message struct {
+	// Data is encoded in base64, as the API expects.
+	Data        []byte            `json:"data"`
+	Attributes  map[string]string `json:"attributes"`
+	OrderingKey string            `json:"orderingKey,omitempty"`
+}
+
+// MetadataTokenSource returns a token source fetching the access token of the default
+// service account from the metadata server of Compute Engine, GKE, Cloud Run, and
+// Cloud Functions. Tokens are cached until shortly before they expire.
+func MetadataTokenSource(client *http.Client) TokenSource {
+	var (
+		mu      sync.Mutex
+		token   string
+		expires time.Time
+	)
+	return func(ctx context.Context) (string, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if token != "" && time.Now().Before(expires) {
+			return token, nil
+		}
+
+		req, err := http.NewRequest(http.MethodGet, metadataTokenURL, nil)
+		if err != nil {
+			return "", err
+		}
+		req = req.WithContext(ctx)
+		req.Header.Set("Metadata-Flavor", "Google")
+		resp, err := client.Do(req)
+		if err != nil {
+			return "", err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return "", fmt.Errorf("metadata server: %s", resp.Status)
+		}
+
+		var t struct {
+			AccessToken string `json:"access_token"`
+			ExpiresIn   int    `json:"expires_in"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&t); err != nil {
+			return "", err
+		}
+		token = t.AccessToken
+		expires = time.Now().Add(time.Duration(t.ExpiresIn)*time.Second - time.Minute)
+		return token, nil
+	}
+}
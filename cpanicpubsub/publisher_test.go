@@ -0,0 +1,78 @@
+package cpanicpubsub_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/demosdemon/cpanic"
+	"github.com/demosdemon/cpanic/cpanicpubsub"
+)
+
+func TestPublisher(t *testing.T) {
+	var (
+		path string
+		auth string
+		req  struct {
+			Messages []struct {
+				Data        []byte            `json:"data"`
+				Attributes  map[string]string `json:"attributes"`
+				OrderingKey string            `json:"orderingKey"`
+			} `json:"messages"`
+		}
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path, auth = r.URL.Path, r.Header.Get("Authorization")
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		_, _ = w.Write([]byte(`{"messageIds":["1"]}`))
+	}))
+	defer srv.Close()
+
+	pub := cpanicpubsub.New("acme", "panics",
+		cpanicpubsub.WithEndpoint(srv.URL+"/"),
+		cpanicpubsub.WithTokenSource(func(context.Context) (string, error) { return "token", nil }),
+		cpanicpubsub.WithOrderingKey(),
+	)
+	p := cpanic.New("not at a disco")
+	require.NoError(t, pub.Publish(context.Background(), p))
+
+	assert.Equal(t, "/v1/projects/acme/topics/panics:publish", path)
+	assert.Equal(t, "Bearer token", auth)
+	require.Len(t, req.Messages, 1)
+	msg := req.Messages[0]
+	assert.Equal(t, map[string]string{
+		cpanicpubsub.FingerprintAttr: p.Fingerprint(),
+		cpanicpubsub.SeverityAttr:    "error",
+		cpanicpubsub.TypeAttr:        "string",
+		cpanicpubsub.IDAttr:          p.ID,
+	}, msg.Attributes)
+	assert.Equal(t, p.Fingerprint(), msg.OrderingKey)
+
+	var report map[string]interface{}
+	require.NoError(t, json.Unmarshal(msg.Data, &report))
+	assert.Equal(t, "not at a disco", report["value"])
+}
+
+func TestPublisherErrors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Empty(t, r.Header.Get("Authorization"))
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer srv.Close()
+
+	pub := cpanicpubsub.New("acme", "panics", cpanicpubsub.WithEndpoint(srv.URL), cpanicpubsub.WithTokenSource(nil))
+	err := pub.Publish(context.Background(), cpanic.New("not at a disco"))
+	assert.EqualError(t, err, "cpanicpubsub: publish failed: 404 Not Found")
+
+	errToken := errors.New("no credentials")
+	pub = cpanicpubsub.New("acme", "panics", cpanicpubsub.WithEndpoint(srv.URL),
+		cpanicpubsub.WithTokenSource(func(context.Context) (string, error) { return "", errToken }))
+	err = pub.Publish(context.Background(), cpanic.New("not at a disco"))
+	assert.True(t, errors.Is(err, errToken))
+}
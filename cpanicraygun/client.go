@@ -0,0 +1,238 @@
+// cpanicraygun sends panics to Raygun Crash Reporting using the Raygun API, without
+// depending on a Raygun provider. Panics are grouped by their fingerprint rather than
+// by Raygun's own hashing of the stack trace.
+package cpanicraygun
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/demosdemon/cpanic"
+)
+
+// DefaultEndpoint is the Raygun API endpoint for crash reports.
+const DefaultEndpoint = "https://api.raygun.com/entries"
+
+// DefaultTimeout is the default time allowed for each report sent by `Handler`.
+const DefaultTimeout = 10 * time.Second
+
+// Option configures a `Client`.
+type Option func(*Client)
+
+// WithEndpoint sets the API endpoint.
+func WithEndpoint(url string) Option {
+	return func(c *Client) {
+		c.endpoint = url
+	}
+}
+
+// WithHTTPClient sets the client used to send reports.
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) {
+		c.client = hc
+	}
+}
+
+// WithVersion sets the version of the application. It defaults to the build ID of the
+// process, as returned by `cpanic.BuildID`.
+func WithVersion(version string) Option {
+	return func(c *Client) {
+		c.version = version
+	}
+}
+
+// WithTags adds tags, such as "production", to every report.
+func WithTags(tags ...string) Option {
+	return func(c *Client) {
+		c.tags = append(c.tags, tags...)
+	}
+}
+
+// WithTimeout sets the time allowed for each report sent by `Handler`.
+func WithTimeout(d time.Duration) Option {
+	return func(c *Client) {
+		c.timeout = d
+	}
+}
+
+// Client sends panics to a Raygun application.
+type Client struct {
+	endpoint string
+	apiKey   string
+	version  string
+	tags     []string
+	client   *http.Client
+	timeout  time.Duration
+}
+
+// New creates a client for the application with the API key.
+func New(apiKey string, opts ...Option) *Client {
+	c := &Client{
+		endpoint: DefaultEndpoint,
+		apiKey:   apiKey,
+		version:  cpanic.BuildID(),
+		client:   http.DefaultClient,
+		timeout:  DefaultTimeout,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Handler returns a `cpanic.Handler` that sends each panic, discarding errors.
+func (c *Client) Handler() cpanic.Handler {
+	return func(p *cpanic.Panic) {
+		ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+		defer cancel()
+		_ = c.Send(ctx, p)
+	}
+}
+
+// Send sends the panic as a crash report. The frames of the panicking goroutine become
+// the stack trace, the attributes become the custom data, the severity is added to the
+// tags, and the runtime of the process becomes the environment.
+func (c *Client) Send(ctx context.Context, p *cpanic.Panic) error {
+	body, err := json.Marshal(c.entry(p))
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, c.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-ApiKey", c.apiKey)
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(ioutil.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("cpanicraygun: send failed: %s", resp.Status)
+	}
+	return nil
+}
+
+// The types below mirror the crash report of the Raygun API.
+
+type entry struct {
+	OccurredOn string  `json:"occurredOn"`
+	Details    details `json:"details"`
+}
+
+type details struct {
+	MachineName    string                 `json:"machineName,omitempty"`
+	Version        string                 `json:"version,omitempty"`
+	GroupingKey    string                 `json:"groupingKey"`
+	Client         clientInfo             `json:"client"`
+	Error          errorInfo              `json:"error"`
+	Environment    environment            `json:"environment"`
+	Tags           []string               `json:"tags"`
+	UserCustomData map[string]interface{} `json:"userCustomData,omitempty"`
+}
+
+type clientInfo struct {
+	Name      string `json:"name"`
+	Version   string `json:"version"`
+	ClientURL string `json:"clientUrl"`
+}
+
+type errorInfo struct {
+	ClassName  string       `json:"className"`
+	Message    string       `json:"message"`
+	StackTrace []stackFrame `json:"stackTrace"`
+}
+
+type stackFrame struct {
+	LineNumber int    `json:"lineNumber"`
+	ClassName  string `json:"className"`
+	FileName   string `json:"fileName"`
+	MethodName string `json:"methodName"`
+}
+
+type environment struct {
+	OSVersion      string `json:"osVersion"`
+	Architecture   string `json:"architecture"`
+	ProcessorCount int    `json:"processorCount"`
+	UtcOffset      int    `json:"utcOffset"`
+}
+
+func (c *Client) entry(p *cpanic.Panic) entry {
+	t := p.Time
+	if t.IsZero() {
+		t = time.Now()
+	}
+	hostname, _ := os.Hostname()
+
+	e := errorInfo{ClassName: p.Type(), Message: p.Error(), StackTrace: []stackFrame{}}
+	for _, f := range p.Frames() {
+		class, method := splitFunction(f.Function)
+		e.StackTrace = append(e.StackTrace, stackFrame{
+			LineNumber: f.Line,
+			ClassName:  class,
+			FileName:   f.File,
+			MethodName: method,
+		})
+	}
+
+	var data map[string]interface{}
+	if len(p.Attrs) > 0 || p.ID != "" {
+		data = make(map[string]interface{}, len(p.Attrs)+1)
+		for k, v := range p.Attrs {
+			if _, err := json.Marshal(v); err != nil {
+				v = fmt.Sprint(v)
+			}
+			data[k] = v
+		}
+		if p.ID != "" {
+			data["cpanic.id"] = p.ID
+		}
+	}
+
+	_, offset := t.Zone()
+	return entry{
+		OccurredOn: t.UTC().Format(time.RFC3339),
+		Details: details{
+			MachineName: hostname,
+			Version:     c.version,
+			GroupingKey: p.Fingerprint(),
+			Client:      clientInfo{Name: "cpanic", Version: "1", ClientURL: "https://github.com/demosdemon/cpanic"},
+			Error:       e,
+			Environment: environment{
+				OSVersion:      runtime.GOOS,
+				Architecture:   runtime.GOARCH,
+				ProcessorCount: runtime.NumCPU(),
+				UtcOffset:      offset / 3600,
+			},
+			Tags:           append(append([]string(nil), c.tags...), p.Severity.String()),
+			UserCustomData: data,
+		},
+	}
+}
+
+// splitFunction splits a qualified function name, such as "github.com/a/b.(*T).M", into
+// its package path and the function name within it, which Raygun shows as the class
+// and method.
+func splitFunction(fn string) (pkg, function string) {
+	slash := strings.LastIndex(fn, "/")
+	dot := strings.Index(fn[slash+1:], ".")
+	if dot < 0 {
+		return "", fn
+	}
+	return fn[:slash+1+dot], fn[slash+2+dot:]
+}
@@ -0,0 +1,73 @@
+package cpanicraygun_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/demosdemon/cpanic"
+	"github.com/demosdemon/cpanic/cpanicraygun"
+)
+
+func TestClient(t *testing.T) {
+	var (
+		apiKey string
+		entry  struct {
+			OccurredOn string                 `json:"occurredOn"`
+			Details    map[string]interface{} `json:"details"`
+		}
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		apiKey = r.Header.Get("X-ApiKey")
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&entry))
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer srv.Close()
+
+	c := cpanicraygun.New("key",
+		cpanicraygun.WithEndpoint(srv.URL),
+		cpanicraygun.WithVersion("1.2.3"),
+		cpanicraygun.WithTags("production"),
+	)
+	p := &cpanic.Panic{
+		ID:    "01F2",
+		Time:  time.Date(2021, 4, 1, 12, 0, 0, 0, time.UTC),
+		Value: "not at a disco",
+		Trace: "goroutine 1 [running]:\ngithub.com/a/b.(*T).M()\n\t/src/b/t.go:3 +0x1d\n",
+	}
+	p.SetAttr("route", "/")
+	require.NoError(t, c.Send(context.Background(), p))
+
+	assert.Equal(t, "key", apiKey)
+	assert.Equal(t, "2021-04-01T12:00:00Z", entry.OccurredOn)
+	assert.Equal(t, "1.2.3", entry.Details["version"])
+	assert.Equal(t, p.Fingerprint(), entry.Details["groupingKey"])
+	assert.Equal(t, []interface{}{"production", "error"}, entry.Details["tags"])
+	assert.Equal(t, map[string]interface{}{"route": "/", "cpanic.id": "01F2"}, entry.Details["userCustomData"])
+	assert.Equal(t, map[string]interface{}{
+		"className": "string",
+		"message":   "panic: not at a disco",
+		"stackTrace": []interface{}{map[string]interface{}{
+			"lineNumber": float64(3),
+			"className":  "github.com/a/b",
+			"fileName":   "/src/b/t.go",
+			"methodName": "(*T).M",
+		}},
+	}, entry.Details["error"])
+}
+
+func TestClientError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer srv.Close()
+
+	err := cpanicraygun.New("key", cpanicraygun.WithEndpoint(srv.URL)).Send(context.Background(), cpanic.New("not at a disco"))
+	assert.EqualError(t, err, "cpanicraygun: send failed: 403 Forbidden")
+}
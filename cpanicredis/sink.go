@@ -0,0 +1,328 @@
+// cpanicredis pushes panic reports into a Redis stream or capped list, speaking the
+// RESP protocol directly, so teams already running Redis keep a crash history without
+// standing up another datastore or adding a client dependency.
+package cpanicredis
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/demosdemon/cpanic"
+)
+
+// The stream entry fields set by `Stream`. `ReportField` holds the serialized panic.
+const (
+	FingerprintField = "fingerprint"
+	SeverityField    = "severity"
+	TypeField        = "type"
+	IDField          = "id"
+	ReportField      = "report"
+)
+
+// DefaultMaxLen is the default number of entries kept in the stream or list.
+const DefaultMaxLen = 10000
+
+// DefaultTimeout is the default time allowed for each push made by `Handler`.
+const DefaultTimeout = 5 * time.Second
+
+// Option configures a `Sink`.
+type Option func(*Sink)
+
+// WithAuth authenticates with the password and, for Redis 6 ACLs, the username, which
+// may be empty.
+func WithAuth(username, password string) Option {
+	return func(s *Sink) {
+		s.username, s.password = username, password
+	}
+}
+
+// WithDB selects the database of the connection.
+func WithDB(db int) Option {
+	return func(s *Sink) {
+		s.db = db
+	}
+}
+
+// WithMaxLen sets the number of entries kept in the stream or list; older entries are
+// trimmed on every push. Streams are trimmed approximately, as `XADD MAXLEN ~` does. A
+// length of zero disables trimming.
+func WithMaxLen(n int) Option {
+	return func(s *Sink) {
+		s.maxLen = n
+	}
+}
+
+// WithFormatter sets how the panic is serialized. It defaults to `cpanic.NDJSON`.
+func WithFormatter(f cpanic.Formatter) Option {
+	return func(s *Sink) {
+		s.formatter = f
+	}
+}
+
+// WithTimeout sets the time allowed for each push made by `Handler`.
+func WithTimeout(d time.Duration) Option {
+	return func(s *Sink) {
+		s.timeout = d
+	}
+}
+
+// Sink pushes panics to a Redis key over a single connection, which is dialed on the
+// first push and redialed after an error.
+type Sink struct {
+	addr      string
+	key       string
+	commands  func(s *Sink, p *cpanic.Panic, report []byte) [][]string
+	username  string
+	password  string
+	db        int
+	maxLen    int
+	formatter cpanic.Formatter
+	timeout   time.Duration
+
+	mu   sync.Mutex
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+// Stream creates a sink appending each panic to the stream at key with `XADD`, with
+// its fingerprint, severity, and type as separate fields so consumers can filter
+// without decoding the report.
+func Stream(addr, key string, opts ...Option) *Sink {
+	return newSink(addr, key, streamCommands, opts)
+}
+
+// List creates a sink pushing each report onto the head of the list at key with
+// `LPUSH`, trimming it with `LTRIM`, so `LRANGE key 0 n` returns the latest panics.
+func List(addr, key string, opts ...Option) *Sink {
+	return newSink(addr, key, listCommands, opts)
+}
+
+func newSink(addr, key string, commands func(*Sink, *cpanic.Panic, []byte) [][]string, opts []Option) *Sink {
+	s := &Sink{
+		addr:      addr,
+		key:       key,
+		commands:  commands,
+		maxLen:    DefaultMaxLen,
+		formatter: cpanic.NDJSON,
+		timeout:   DefaultTimeout,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+func streamCommands(s *Sink, p *cpanic.Panic, report []byte) [][]string {
+	cmd := []string{"XADD", s.key}
+	if s.maxLen > 0 {
+		cmd = append(cmd, "MAXLEN", "~", strconv.Itoa(s.maxLen))
+	}
+	cmd = append(cmd, "*",
+		FingerprintField, p.Fingerprint(),
+		SeverityField, p.Severity.String(),
+		TypeField, p.Type(),
+	)
+	if p.ID != "" {
+		cmd = append(cmd, IDField, p.ID)
+	}
+	cmd = append(cmd, ReportField, string(report))
+	return [][]string{cmd}
+}
+
+func listCommands(s *Sink, _ *cpanic.Panic, report []byte) [][]string {
+	cmds := [][]string{{"LPUSH", s.key, string(report)}}
+	if s.maxLen > 0 {
+		cmds = append(cmds, []string{"LTRIM", s.key, "0", strconv.Itoa(s.maxLen - 1)})
+	}
+	return cmds
+}
+
+// Handler returns a `cpanic.Handler` that pushes each panic, discarding errors.
+func (s *Sink) Handler() cpanic.Handler {
+	return func(p *cpanic.Panic) {
+		ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+		defer cancel()
+		_ = s.Push(ctx, p)
+	}
+}
+
+// Push sends the panic to Redis, pipelining the commands.
+func (s *Sink) Push(ctx context.Context, p *cpanic.Panic) error {
+	report, err := s.formatter.Format(p)
+	if err != nil {
+		return err
+	}
+	if len(report) > 0 && report[len(report)-1] == '\n' {
+		report = report[:len(report)-1]
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.do(ctx, s.commands(s, p, report)...); err != nil {
+		var rerr redisError
+		if !errors.As(err, &rerr) {
+			s.closeLocked()
+		}
+		return err
+	}
+	return nil
+}
+
+// Close closes the connection, if any.
+func (s *Sink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.closeLocked()
+}
+
+func (s *Sink) closeLocked() error {
+	if s.conn == nil {
+		return nil
+	}
+	err := s.conn.Close()
+	s.conn, s.r = nil, nil
+	return err
+}
+
+// do sends the commands and reads a reply for each, dialing first if needed.
+func (s *Sink) do(ctx context.Context, cmds ...[]string) error {
+	if s.conn == nil {
+		if err := s.dial(ctx); err != nil {
+			return err
+		}
+	}
+
+	deadline, _ := ctx.Deadline()
+	if err := s.conn.SetDeadline(deadline); err != nil {
+		return err
+	}
+
+	var buf []byte
+	for _, cmd := range cmds {
+		buf = appendCommand(buf, cmd)
+	}
+	if _, err := s.conn.Write(buf); err != nil {
+		return err
+	}
+
+	var first error
+	for range cmds {
+		if err := readReply(s.r); err != nil {
+			var rerr redisError
+			if !errors.As(err, &rerr) {
+				return err
+			}
+			if first == nil {
+				first = err
+			}
+		}
+	}
+	return first
+}
+
+func (s *Sink) dial(ctx context.Context) error {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", s.addr)
+	if err != nil {
+		return err
+	}
+	s.conn, s.r = conn, bufio.NewReader(conn)
+
+	var cmds [][]string
+	switch {
+	case s.username != "":
+		cmds = append(cmds, []string{"AUTH", s.username, s.password})
+	case s.password != "":
+		cmds = append(cmds, []string{"AUTH", s.password})
+	}
+	if s.db != 0 {
+		cmds = append(cmds, []string{"SELECT", strconv.Itoa(s.db)})
+	}
+	if len(cmds) == 0 {
+		return nil
+	}
+	if err := s.do(ctx, cmds...); err != nil {
+		s.closeLocked()
+		return err
+	}
+	return nil
+}
+
+// appendCommand encodes the command as a RESP array of bulk strings.
+func appendCommand(buf []byte, cmd []string) []byte {
+	buf = append(buf, '*')
+	buf = strconv.AppendInt(buf, int64(len(cmd)), 10)
+	buf = append(buf, '\r', '\n')
+	for _, arg := range cmd {
+		buf = append(buf, '$')
+		buf = strconv.AppendInt(buf, int64(len(arg)), 10)
+		buf = append(buf, '\r', '\n')
+		buf = append(buf, arg...)
+		buf = append(buf, '\r', '\n')
+	}
+	return buf
+}
+
+// redisError is an error reply from the server.
+type redisError string
+
+func (e redisError) Error() string {
+	return "cpanicredis: " + string(e)
+}
+
+// readReply reads and discards a single reply, returning the error if it is one.
+func readReply(r *bufio.Reader) error {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return err
+	}
+	if len(line) < 3 || line[len(line)-2] != '\r' {
+		return fmt.Errorf("cpanicredis: malformed reply %q", line)
+	}
+	kind, line := line[0], line[1:len(line)-2]
+
+	switch kind {
+	case '+', ':':
+		return nil
+	case '-':
+		return redisError(line)
+	case '$':
+		n, err := strconv.Atoi(line)
+		if err != nil {
+			return fmt.Errorf("cpanicredis: malformed reply length %q", line)
+		}
+		if n < 0 {
+			return nil
+		}
+		_, err = io.CopyN(ioutil.Discard, r, int64(n)+2)
+		return err
+	case '*':
+		n, err := strconv.Atoi(line)
+		if err != nil {
+			return fmt.Errorf("cpanicredis: malformed reply length %q", line)
+		}
+		var first error
+		for i := 0; i < n; i++ {
+			if err := readReply(r); err != nil {
+				var rerr redisError
+				if !errors.As(err, &rerr) {
+					return err
+				}
+				if first == nil {
+					first = err
+				}
+			}
+		}
+		return first
+	default:
+		return fmt.Errorf("cpanicredis: unexpected reply type %q", kind)
+	}
+}
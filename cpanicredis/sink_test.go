@@ -0,0 +1,166 @@
+package cpanicredis_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/demosdemon/cpanic"
+	"github.com/demosdemon/cpanic/cpanicredis"
+)
+
+// server is a fake Redis server recording the commands it receives.
+type server struct {
+	ln   net.Listener
+	mu   sync.Mutex
+	cmds [][]string
+}
+
+func newServer(t *testing.T) *server {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	s := &server{ln: ln}
+	go s.serve()
+	return s
+}
+
+func (s *server) addr() string { return s.ln.Addr().String() }
+
+func (s *server) commands() [][]string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.cmds
+}
+
+func (s *server) serve() {
+	for {
+		conn, err := s.ln.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *server) handle(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	for {
+		cmd, err := readCommand(r)
+		if err != nil {
+			return
+		}
+		s.mu.Lock()
+		s.cmds = append(s.cmds, cmd)
+		s.mu.Unlock()
+
+		var reply string
+		switch cmd[0] {
+		case "XADD":
+			reply = "$3\r\n1-0\r\n"
+		case "LPUSH":
+			reply = ":1\r\n"
+		case "AUTH":
+			if cmd[len(cmd)-1] != "secret" {
+				reply = "-WRONGPASS invalid password\r\n"
+				break
+			}
+			reply = "+OK\r\n"
+		default:
+			reply = "+OK\r\n"
+		}
+		if _, err := io.WriteString(conn, reply); err != nil {
+			return
+		}
+	}
+}
+
+func readCommand(r *bufio.Reader) ([]string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	n, _ := strconv.Atoi(line[1 : len(line)-2])
+	cmd := make([]string, n)
+	for i := range cmd {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		size, _ := strconv.Atoi(line[1 : len(line)-2])
+		arg := make([]byte, size+2)
+		if _, err := io.ReadFull(r, arg); err != nil {
+			return nil, err
+		}
+		cmd[i] = string(arg[:size])
+	}
+	return cmd, nil
+}
+
+func TestStream(t *testing.T) {
+	srv := newServer(t)
+	defer srv.ln.Close()
+
+	sink := cpanicredis.Stream(srv.addr(), "panics", cpanicredis.WithAuth("", "secret"), cpanicredis.WithDB(2))
+	defer sink.Close()
+	p := cpanic.New("not at a disco")
+	require.NoError(t, sink.Push(context.Background(), p))
+	sink.Handler()(p)
+
+	cmds := srv.commands()
+	require.Len(t, cmds, 4)
+	assert.Equal(t, []string{"AUTH", "secret"}, cmds[0])
+	assert.Equal(t, []string{"SELECT", "2"}, cmds[1])
+	xadd := cmds[2]
+	assert.Equal(t, []string{
+		"XADD", "panics", "MAXLEN", "~", "10000", "*",
+		cpanicredis.FingerprintField, p.Fingerprint(),
+		cpanicredis.SeverityField, "error",
+		cpanicredis.TypeField, "string",
+		cpanicredis.IDField, p.ID,
+		cpanicredis.ReportField,
+	}, xadd[:len(xadd)-1])
+	var report map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(xadd[len(xadd)-1]), &report))
+	assert.Equal(t, "not at a disco", report["value"])
+	assert.Equal(t, cmds[2], cmds[3])
+}
+
+func TestList(t *testing.T) {
+	srv := newServer(t)
+	defer srv.ln.Close()
+
+	sink := cpanicredis.List(srv.addr(), "panics", cpanicredis.WithMaxLen(50), cpanicredis.WithFormatter(cpanic.Text))
+	defer sink.Close()
+	require.NoError(t, sink.Push(context.Background(), cpanic.New("not at a disco")))
+
+	cmds := srv.commands()
+	require.Len(t, cmds, 2)
+	assert.Equal(t, "LPUSH", cmds[0][0])
+	assert.Contains(t, cmds[0][2], "panic: not at a disco")
+	assert.Equal(t, []string{"LTRIM", "panics", "0", "49"}, cmds[1])
+}
+
+func TestPushErrors(t *testing.T) {
+	srv := newServer(t)
+	defer srv.ln.Close()
+
+	sink := cpanicredis.Stream(srv.addr(), "panics", cpanicredis.WithAuth("default", "wrong"))
+	defer sink.Close()
+	err := sink.Push(context.Background(), cpanic.New("not at a disco"))
+	assert.EqualError(t, err, "cpanicredis: WRONGPASS invalid password")
+
+	sink = cpanicredis.Stream(srv.addr(), "panics")
+	require.NoError(t, sink.Push(context.Background(), cpanic.New("not at a disco")))
+	srv.ln.Close()
+	require.NoError(t, sink.Close())
+	assert.Error(t, sink.Push(context.Background(), cpanic.New("not at a disco")))
+}
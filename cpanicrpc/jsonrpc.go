@@ -0,0 +1,149 @@
+package cpanicrpc
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/rpc"
+	"sync"
+
+	"github.com/demosdemon/cpanic"
+)
+
+var (
+	errMissingParams = errors.New("jsonrpc: request body missing params")
+	null             = json.RawMessage("null")
+)
+
+// PanicError is the value of the error field of a JSON-RPC response to a call that
+// panicked.
+type PanicError struct {
+	Message string         `json:"message"`
+	Data    PanicErrorData `json:"data"`
+}
+
+// PanicErrorData holds the details of a recovered panic that are safe to share with
+// the client for correlation.
+type PanicErrorData struct {
+	Fingerprint string `json:"fingerprint"`
+}
+
+// NewServerCodec returns a JSON-RPC 1.0 server codec, compatible with
+// `net/rpc/jsonrpc`, that implements `PanicWriter`. Responses to calls that panicked
+// carry a `PanicError` object in the error field instead of a string. Note that
+// `net/rpc/jsonrpc` clients treat an error object as a protocol error and close their
+// connection; serve those clients with `jsonrpc.NewServerCodec` and `ServeCodec`
+// instead.
+func NewServerCodec(conn io.ReadWriteCloser) rpc.ServerCodec {
+	return &serverCodec{
+		dec:     json.NewDecoder(conn),
+		enc:     json.NewEncoder(conn),
+		c:       conn,
+		pending: make(map[uint64]*json.RawMessage),
+	}
+}
+
+type serverCodec struct {
+	dec *json.Decoder
+	enc *json.Encoder
+	c   io.Closer
+
+	// temporary work space
+	req serverRequest
+
+	mutex   sync.Mutex // protects seq, pending
+	seq     uint64
+	pending map[uint64]*json.RawMessage
+}
+
+type serverRequest struct {
+	Method string           `json:"method"`
+	Params *json.RawMessage `json:"params"`
+	ID     *json.RawMessage `json:"id"`
+}
+
+type serverResponse struct {
+	ID     *json.RawMessage `json:"id"`
+	Result interface{}      `json:"result"`
+	Error  interface{}      `json:"error"`
+}
+
+func (c *serverCodec) ReadRequestHeader(r *rpc.Request) error {
+	c.req = serverRequest{}
+	if err := c.dec.Decode(&c.req); err != nil {
+		return err
+	}
+	r.ServiceMethod = c.req.Method
+
+	c.mutex.Lock()
+	c.seq++
+	c.pending[c.seq] = c.req.ID
+	c.req.ID = nil
+	r.Seq = c.seq
+	c.mutex.Unlock()
+
+	return nil
+}
+
+func (c *serverCodec) ReadRequestBody(x interface{}) error {
+	if x == nil {
+		return nil
+	}
+	if c.req.Params == nil {
+		return errMissingParams
+	}
+
+	// JSON params is array value. RPC params is struct. Unmarshal into array containing
+	// the struct for now.
+	params := [1]interface{}{x}
+	return json.Unmarshal(*c.req.Params, &params)
+}
+
+func (c *serverCodec) WriteResponse(r *rpc.Response, x interface{}) error {
+	resp, err := c.response(r)
+	if err != nil {
+		return err
+	}
+
+	if r.Error == "" {
+		resp.Result = x
+	} else {
+		resp.Error = r.Error
+	}
+	return c.enc.Encode(resp)
+}
+
+// WritePanic implements `PanicWriter`.
+func (c *serverCodec) WritePanic(r *rpc.Response, p *cpanic.Panic) error {
+	resp, err := c.response(r)
+	if err != nil {
+		return err
+	}
+
+	resp.Error = PanicError{
+		Message: r.Error,
+		Data:    PanicErrorData{Fingerprint: p.Fingerprint()},
+	}
+	return c.enc.Encode(resp)
+}
+
+func (c *serverCodec) response(r *rpc.Response) (*serverResponse, error) {
+	c.mutex.Lock()
+	b, ok := c.pending[r.Seq]
+	if !ok {
+		c.mutex.Unlock()
+		return nil, errors.New("invalid sequence number in response")
+	}
+	delete(c.pending, r.Seq)
+	c.mutex.Unlock()
+
+	if b == nil {
+		// Invalid request so no id. Use JSON null.
+		b = &null
+	}
+	return &serverResponse{ID: b}, nil
+}
+
+func (c *serverCodec) Close() error {
+	return c.c.Close()
+}
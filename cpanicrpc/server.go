@@ -0,0 +1,91 @@
+// cpanicrpc provides a `net/rpc` integration for cpanic.
+package cpanicrpc
+
+import (
+	"io"
+	"net/rpc"
+
+	"github.com/demosdemon/cpanic"
+)
+
+// ServiceMethodAttr is the `cpanic.Panic` attribute key holding the RPC method, in the
+// form "Service.Method", that panicked.
+const ServiceMethodAttr = "rpc.service_method"
+
+// PublicMessage is the error sent to clients in place of the panic value, unless the
+// panic has its own `cpanic.Panic.PublicMessage`.
+const PublicMessage = "internal error"
+
+// PanicWriter is implemented by server codecs that can encode a recovered panic into
+// the error field of their protocol's response with more detail than its message.
+type PanicWriter interface {
+	WritePanic(r *rpc.Response, p *cpanic.Panic) error
+}
+
+// ServeConn runs the server on a single connection using the JSON-RPC codec from
+// `NewServerCodec`, recovering panics in method bodies. See `ServeCodec`.
+func ServeConn(server *rpc.Server, conn io.ReadWriteCloser, h cpanic.Handler) {
+	ServeCodec(server, NewServerCodec(conn), h)
+}
+
+// ServeCodec is like `rpc.Server.ServeCodec` but recovers panics raised by method
+// bodies instead of crashing the process. A recovered panic is tagged with the method
+// name, reported to the handler, if provided, and answered with `PublicMessage` as the
+// error response for the call that panicked; the connection stays open for subsequent
+// calls.
+// If the codec implements `PanicWriter`, it is used to write the response.
+//
+// Unlike `rpc.Server.ServeCodec`, requests on the connection are processed one at a
+// time, so that a panic can be attributed to the request that caused it.
+func ServeCodec(server *rpc.Server, codec rpc.ServerCodec, h cpanic.Handler) {
+	rc := &recordingCodec{ServerCodec: codec}
+	defer codec.Close()
+
+	for {
+		if err := serveRequest(server, rc, h); err != nil && rc.headerErr != nil {
+			return
+		}
+	}
+}
+
+func serveRequest(server *rpc.Server, rc *recordingCodec, h cpanic.Handler) (err error) {
+	defer func() {
+		if value := recover(); value != nil {
+			p := cpanic.New(value)
+			p.SetAttr(ServiceMethodAttr, rc.header.ServiceMethod)
+			cpanic.Deliver(p, h)
+
+			resp := &rpc.Response{
+				ServiceMethod: rc.header.ServiceMethod,
+				Seq:           rc.header.Seq,
+				Error:         p.PublicMessageOr(PublicMessage),
+			}
+			if pw, ok := rc.ServerCodec.(PanicWriter); ok {
+				err = pw.WritePanic(resp, p)
+			} else {
+				err = rc.ServerCodec.WriteResponse(resp, struct{}{})
+			}
+		}
+	}()
+
+	return server.ServeRequest(rc)
+}
+
+// recordingCodec remembers the header of the request being served so a response can
+// be sent for it after a panic.
+type recordingCodec struct {
+	rpc.ServerCodec
+	header    rpc.Request
+	headerErr error
+}
+
+func (c *recordingCodec) ReadRequestHeader(r *rpc.Request) error {
+	c.headerErr = c.ServerCodec.ReadRequestHeader(r)
+	c.header = *r
+	return c.headerErr
+}
+
+// Close is a no-op; `ServeCodec` closes the underlying codec when it returns.
+func (c *recordingCodec) Close() error {
+	return nil
+}
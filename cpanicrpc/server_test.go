@@ -0,0 +1,92 @@
+package cpanicrpc_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/rpc"
+	"net/rpc/jsonrpc"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/demosdemon/cpanic"
+	"github.com/demosdemon/cpanic/cpanicrpc"
+)
+
+type Arith struct{}
+
+type Args struct {
+	A, B int
+}
+
+func (Arith) Divide(args Args, reply *int) error {
+	*reply = args.A / args.B
+	return nil
+}
+
+func (Arith) Fail(Args, *int) error {
+	return errors.New("test")
+}
+
+func (Arith) Public(Args, *int) error {
+	panic(cpanic.WithPublicMessage(errors.New("secret"), "try again later"))
+}
+
+func serve(t *testing.T, newCodec func(io.ReadWriteCloser) rpc.ServerCodec, h cpanic.Handler) net.Conn {
+	t.Helper()
+
+	server := rpc.NewServer()
+	assert.NoError(t, server.Register(Arith{}))
+
+	client, conn := net.Pipe()
+	go cpanicrpc.ServeCodec(server, newCodec(conn), h)
+	return client
+}
+
+func TestServeCodec(t *testing.T) {
+	var handled []*cpanic.Panic
+	client := jsonrpc.NewClient(serve(t, jsonrpc.NewServerCodec, func(p *cpanic.Panic) {
+		handled = append(handled, p)
+	}))
+	defer client.Close()
+
+	var reply int
+	assert.NoError(t, client.Call("Arith.Divide", Args{6, 3}, &reply))
+	assert.Equal(t, 2, reply)
+
+	assert.EqualError(t, client.Call("Arith.Fail", Args{}, &reply), "test")
+
+	err := client.Call("Arith.Divide", Args{1, 0}, &reply)
+	assert.EqualError(t, err, cpanicrpc.PublicMessage)
+	if assert.Len(t, handled, 1) {
+		assert.Equal(t, "Arith.Divide", handled[0].Attrs[cpanicrpc.ServiceMethodAttr])
+	}
+
+	assert.EqualError(t, client.Call("Arith.Public", Args{}, &reply), "try again later")
+
+	assert.NoError(t, client.Call("Arith.Divide", Args{9, 3}, &reply))
+	assert.Equal(t, 3, reply)
+}
+
+func TestNewServerCodec(t *testing.T) {
+	var handled *cpanic.Panic
+	conn := serve(t, cpanicrpc.NewServerCodec, func(p *cpanic.Panic) { handled = p })
+	defer conn.Close()
+
+	_, err := conn.Write([]byte(`{"method":"Arith.Divide","params":[{"A":1,"B":0}],"id":7}`))
+	assert.NoError(t, err)
+
+	var resp struct {
+		ID    int                  `json:"id"`
+		Error cpanicrpc.PanicError `json:"error"`
+	}
+	assert.NoError(t, json.NewDecoder(bufio.NewReader(conn)).Decode(&resp))
+	assert.Equal(t, 7, resp.ID)
+	assert.Equal(t, cpanicrpc.PublicMessage, resp.Error.Message)
+	if assert.NotNil(t, handled) {
+		assert.Equal(t, handled.Fingerprint(), resp.Error.Data.Fingerprint)
+	}
+}
@@ -0,0 +1,403 @@
+// cpanicsql provides a `database/sql/driver` integration for cpanic.
+package cpanicsql
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+
+	"github.com/demosdemon/cpanic"
+)
+
+// WrapDriver wraps the provided driver so that a panic raised by the driver while
+// opening connections, preparing and executing statements, decoding rows, or managing
+// transactions is recovered and returned as a `*cpanic.Panic` error from the call that
+// triggered it. If a handler is provided, it is called with the recovered panic first.
+// A connection that panicked is reported as invalid so `database/sql` discards it
+// instead of returning it to the pool.
+//
+// Panics raised by `sql.Scanner` implementations happen in `database/sql` itself, on the
+// caller's goroutine, and are not intercepted.
+func WrapDriver(d driver.Driver, h cpanic.Handler) driver.Driver {
+	return &wrappedDriver{driver: d, guard: guard{handler: h}}
+}
+
+// WrapConnector is like `WrapDriver` but for use with `sql.OpenDB`.
+func WrapConnector(c driver.Connector, h cpanic.Handler) driver.Connector {
+	g := guard{handler: h}
+	return &connector{
+		connector: c,
+		driver:    &wrappedDriver{driver: c.Driver(), guard: g},
+		guard:     g,
+	}
+}
+
+type guard struct {
+	handler cpanic.Handler
+}
+
+// report converts the recovered value into a `*cpanic.Panic` and calls the handler.
+func (g guard) report(value interface{}) *cpanic.Panic {
+	p := cpanic.New(value)
+	cpanic.Deliver(p, g.handler)
+	return p
+}
+
+// recover is a defer function that converts a panic into an error, like
+// `cpanic.Forward`, reporting it to the handler. The returned error is always the
+// `*cpanic.Panic`, even if the error pointer was already set.
+func (g guard) recover(errPtr *error) {
+	if value := recover(); value != nil {
+		*errPtr = g.report(value)
+	}
+}
+
+type wrappedDriver struct {
+	driver driver.Driver
+	guard  guard
+}
+
+func (d *wrappedDriver) Open(name string) (_ driver.Conn, err error) {
+	defer d.guard.recover(&err)
+	c, err := d.driver.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return &conn{conn: c, guard: d.guard}, nil
+}
+
+func (d *wrappedDriver) OpenConnector(name string) (_ driver.Connector, err error) {
+	defer d.guard.recover(&err)
+	if dc, ok := d.driver.(driver.DriverContext); ok {
+		c, err := dc.OpenConnector(name)
+		if err != nil {
+			return nil, err
+		}
+		return &connector{connector: c, driver: d, guard: d.guard}, nil
+	}
+	return &connector{connector: dsnConnector{name: name, driver: d.driver}, driver: d, guard: d.guard}, nil
+}
+
+type dsnConnector struct {
+	name   string
+	driver driver.Driver
+}
+
+func (c dsnConnector) Connect(context.Context) (driver.Conn, error) {
+	return c.driver.Open(c.name)
+}
+
+func (c dsnConnector) Driver() driver.Driver {
+	return c.driver
+}
+
+type connector struct {
+	connector driver.Connector
+	driver    driver.Driver
+	guard     guard
+}
+
+func (c *connector) Connect(ctx context.Context) (_ driver.Conn, err error) {
+	defer c.guard.recover(&err)
+	cn, err := c.connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &conn{conn: cn, guard: c.guard}, nil
+}
+
+func (c *connector) Driver() driver.Driver {
+	return c.driver
+}
+
+type conn struct {
+	conn     driver.Conn
+	guard    guard
+	panicked bool
+}
+
+// recover is like `guard.recover` but also marks the connection as no longer valid.
+func (c *conn) recover(errPtr *error) {
+	if value := recover(); value != nil {
+		c.panicked = true
+		*errPtr = c.guard.report(value)
+	}
+}
+
+func (c *conn) Prepare(query string) (_ driver.Stmt, err error) {
+	defer c.recover(&err)
+	s, err := c.conn.Prepare(query)
+	if err != nil {
+		return nil, err
+	}
+	return newStmt(s, c), nil
+}
+
+func (c *conn) PrepareContext(ctx context.Context, query string) (_ driver.Stmt, err error) {
+	defer c.recover(&err)
+	var s driver.Stmt
+	if pc, ok := c.conn.(driver.ConnPrepareContext); ok {
+		s, err = pc.PrepareContext(ctx, query)
+	} else {
+		s, err = c.conn.Prepare(query)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return newStmt(s, c), nil
+}
+
+func (c *conn) Close() (err error) {
+	defer c.recover(&err)
+	return c.conn.Close()
+}
+
+func (c *conn) Begin() (_ driver.Tx, err error) {
+	defer c.recover(&err)
+	tx, err := c.conn.Begin()
+	if err != nil {
+		return nil, err
+	}
+	return &transaction{tx: tx, conn: c}, nil
+}
+
+func (c *conn) BeginTx(ctx context.Context, opts driver.TxOptions) (_ driver.Tx, err error) {
+	defer c.recover(&err)
+	var tx driver.Tx
+	if bc, ok := c.conn.(driver.ConnBeginTx); ok {
+		tx, err = bc.BeginTx(ctx, opts)
+	} else {
+		if opts.Isolation != 0 {
+			return nil, errors.New("cpanicsql: driver does not support non-default isolation level")
+		}
+		if opts.ReadOnly {
+			return nil, errors.New("cpanicsql: driver does not support read-only transactions")
+		}
+		tx, err = c.conn.Begin()
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &transaction{tx: tx, conn: c}, nil
+}
+
+func (c *conn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (_ driver.Result, err error) {
+	defer c.recover(&err)
+	if ec, ok := c.conn.(driver.ExecerContext); ok {
+		return ec.ExecContext(ctx, query, args)
+	}
+	return nil, driver.ErrSkip
+}
+
+func (c *conn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (_ driver.Rows, err error) {
+	defer c.recover(&err)
+	if qc, ok := c.conn.(driver.QueryerContext); ok {
+		r, err := qc.QueryContext(ctx, query, args)
+		if err != nil {
+			return nil, err
+		}
+		return &rows{rows: r, conn: c}, nil
+	}
+	return nil, driver.ErrSkip
+}
+
+func (c *conn) Ping(ctx context.Context) (err error) {
+	defer c.recover(&err)
+	if p, ok := c.conn.(driver.Pinger); ok {
+		return p.Ping(ctx)
+	}
+	return nil
+}
+
+func (c *conn) ResetSession(ctx context.Context) (err error) {
+	if c.panicked {
+		return driver.ErrBadConn
+	}
+	defer c.recover(&err)
+	if sr, ok := c.conn.(driver.SessionResetter); ok {
+		return sr.ResetSession(ctx)
+	}
+	return nil
+}
+
+// IsValid implements `driver.Validator`.
+func (c *conn) IsValid() bool {
+	if c.panicked {
+		return false
+	}
+	if v, ok := c.conn.(interface{ IsValid() bool }); ok {
+		return v.IsValid()
+	}
+	return true
+}
+
+func (c *conn) CheckNamedValue(nv *driver.NamedValue) (err error) {
+	defer c.recover(&err)
+	if nvc, ok := c.conn.(driver.NamedValueChecker); ok {
+		return nvc.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
+
+type stmt struct {
+	stmt driver.Stmt
+	conn *conn
+}
+
+// newStmt wraps the statement, implementing `driver.ColumnConverter` only if it does, so
+// that `database/sql` falls back to its default conversion otherwise.
+func newStmt(s driver.Stmt, c *conn) driver.Stmt {
+	if _, ok := s.(driver.ColumnConverter); ok {
+		return &converterStmt{stmt{stmt: s, conn: c}}
+	}
+	return &stmt{stmt: s, conn: c}
+}
+
+func (s *stmt) Close() (err error) {
+	defer s.conn.recover(&err)
+	return s.stmt.Close()
+}
+
+func (s *stmt) NumInput() int {
+	return s.stmt.NumInput()
+}
+
+func (s *stmt) Exec(args []driver.Value) (_ driver.Result, err error) {
+	defer s.conn.recover(&err)
+	return s.stmt.Exec(args)
+}
+
+func (s *stmt) Query(args []driver.Value) (_ driver.Rows, err error) {
+	defer s.conn.recover(&err)
+	r, err := s.stmt.Query(args)
+	if err != nil {
+		return nil, err
+	}
+	return &rows{rows: r, conn: s.conn}, nil
+}
+
+func (s *stmt) ExecContext(ctx context.Context, args []driver.NamedValue) (_ driver.Result, err error) {
+	defer s.conn.recover(&err)
+	if ec, ok := s.stmt.(driver.StmtExecContext); ok {
+		return ec.ExecContext(ctx, args)
+	}
+	values, err := namedValuesToValues(args)
+	if err != nil {
+		return nil, err
+	}
+	return s.stmt.Exec(values)
+}
+
+func (s *stmt) QueryContext(ctx context.Context, args []driver.NamedValue) (_ driver.Rows, err error) {
+	defer s.conn.recover(&err)
+	var r driver.Rows
+	if qc, ok := s.stmt.(driver.StmtQueryContext); ok {
+		r, err = qc.QueryContext(ctx, args)
+	} else {
+		var values []driver.Value
+		if values, err = namedValuesToValues(args); err != nil {
+			return nil, err
+		}
+		r, err = s.stmt.Query(values)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &rows{rows: r, conn: s.conn}, nil
+}
+
+// CheckNamedValue implements `driver.NamedValueChecker` with the checker of the wrapped
+// statement or, as `database/sql` does when the statement has none, of the wrapped
+// connection.
+func (s *stmt) CheckNamedValue(nv *driver.NamedValue) (err error) {
+	defer s.conn.recover(&err)
+	if nvc, ok := s.stmt.(driver.NamedValueChecker); ok {
+		return nvc.CheckNamedValue(nv)
+	}
+	if nvc, ok := s.conn.conn.(driver.NamedValueChecker); ok {
+		return nvc.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
+
+// converterStmt is a `stmt` wrapping a `driver.ColumnConverter`.
+type converterStmt struct {
+	stmt
+}
+
+func (s *converterStmt) ColumnConverter(idx int) driver.ValueConverter {
+	return &converter{
+		converter: s.stmt.stmt.(driver.ColumnConverter).ColumnConverter(idx),
+		conn:      s.conn,
+	}
+}
+
+type converter struct {
+	converter driver.ValueConverter
+	conn      *conn
+}
+
+func (c *converter) ConvertValue(v interface{}) (_ driver.Value, err error) {
+	defer c.conn.recover(&err)
+	return c.converter.ConvertValue(v)
+}
+
+func namedValuesToValues(named []driver.NamedValue) ([]driver.Value, error) {
+	values := make([]driver.Value, len(named))
+	for i, nv := range named {
+		if nv.Name != "" {
+			return nil, errors.New("cpanicsql: driver does not support the use of Named Parameters")
+		}
+		values[i] = nv.Value
+	}
+	return values, nil
+}
+
+type rows struct {
+	rows driver.Rows
+	conn *conn
+}
+
+func (r *rows) Columns() []string {
+	return r.rows.Columns()
+}
+
+func (r *rows) Close() (err error) {
+	defer r.conn.recover(&err)
+	return r.rows.Close()
+}
+
+func (r *rows) Next(dest []driver.Value) (err error) {
+	defer r.conn.recover(&err)
+	return r.rows.Next(dest)
+}
+
+func (r *rows) HasNextResultSet() bool {
+	if nrs, ok := r.rows.(driver.RowsNextResultSet); ok {
+		return nrs.HasNextResultSet()
+	}
+	return false
+}
+
+func (r *rows) NextResultSet() (err error) {
+	defer r.conn.recover(&err)
+	if nrs, ok := r.rows.(driver.RowsNextResultSet); ok {
+		return nrs.NextResultSet()
+	}
+	return errors.New("cpanicsql: driver does not support multiple result sets")
+}
+
+type transaction struct {
+	tx   driver.Tx
+	conn *conn
+}
+
+func (t *transaction) Commit() (err error) {
+	defer t.conn.recover(&err)
+	return t.tx.Commit()
+}
+
+func (t *transaction) Rollback() (err error) {
+	defer t.conn.recover(&err)
+	return t.tx.Rollback()
+}
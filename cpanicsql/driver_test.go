@@ -0,0 +1,189 @@
+package cpanicsql_test
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/demosdemon/cpanic"
+	"github.com/demosdemon/cpanic/cpanicsql"
+)
+
+// fakeDriver is a driver whose statements panic when the query is "panic" and whose
+// rows panic while decoding when the query is "bad row".
+type fakeDriver struct{}
+
+func (fakeDriver) Open(string) (driver.Conn, error) { return fakeConn{}, nil }
+
+type fakeConn struct{}
+
+func (fakeConn) Prepare(query string) (driver.Stmt, error) { return fakeStmt(query), nil }
+func (fakeConn) Close() error                              { return nil }
+func (fakeConn) Begin() (driver.Tx, error)                 { return nil, errors.New("unsupported") }
+
+type fakeStmt string
+
+func (fakeStmt) Close() error  { return nil }
+func (fakeStmt) NumInput() int { return 0 }
+
+func (s fakeStmt) Exec([]driver.Value) (driver.Result, error) {
+	if s == "panic" {
+		panic("not at a disco")
+	}
+	return driver.RowsAffected(1), nil
+}
+
+func (s fakeStmt) Query([]driver.Value) (driver.Rows, error) {
+	if s == "panic" {
+		panic("not at a disco")
+	}
+	return &fakeRows{bad: s == "bad row"}, nil
+}
+
+type fakeRows struct {
+	bad  bool
+	done bool
+}
+
+func (*fakeRows) Columns() []string { return []string{"n"} }
+func (*fakeRows) Close() error      { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.done {
+		return io.EOF
+	}
+	if r.bad {
+		panic("bad row")
+	}
+	r.done = true
+	dest[0] = int64(42)
+	return nil
+}
+
+// runs numbers the drivers registered by `TestWrapDriver`, since drivers cannot be
+// unregistered.
+var runs int
+
+func TestWrapDriver(t *testing.T) {
+	var handled []*cpanic.Panic
+	runs++
+	name := fmt.Sprintf("cpanicsql-test-%d", runs)
+	sql.Register(name, cpanicsql.WrapDriver(fakeDriver{}, func(p *cpanic.Panic) {
+		handled = append(handled, p)
+	}))
+
+	db, err := sql.Open(name, "")
+	assert.NoError(t, err)
+	defer db.Close()
+
+	var n int
+	assert.NoError(t, db.QueryRow("ok").Scan(&n))
+	assert.Equal(t, 42, n)
+
+	_, err = db.Exec("ok")
+	assert.NoError(t, err)
+	assert.Empty(t, handled)
+
+	_, err = db.Exec("panic")
+	assert.EqualError(t, err, "panic: not at a disco")
+
+	_, err = db.Query("panic")
+	assert.EqualError(t, err, "panic: not at a disco")
+
+	err = db.QueryRow("bad row").Scan(&n)
+	assert.EqualError(t, err, "panic: bad row")
+
+	assert.Len(t, handled, 3)
+
+	// The database remains usable after the panicking connections are discarded.
+	assert.NoError(t, db.QueryRow("ok").Scan(&n))
+	assert.NoError(t, db.Ping())
+}
+
+// point is an argument type accepted only by the checkers of the drivers below.
+type point struct{ x, y int }
+
+func convertPoint(v interface{}) (driver.Value, error) {
+	if p, ok := v.(point); ok {
+		return fmt.Sprintf("(%d,%d)", p.x, p.y), nil
+	}
+	return driver.DefaultParameterConverter.ConvertValue(v)
+}
+
+// checkerDriver is a driver whose connection accepts `point` arguments, or whose
+// statements do if columns is set, recording the arguments of each statement.
+type checkerDriver struct {
+	columns bool
+	args    *[]driver.Value
+}
+
+func (d checkerDriver) Open(string) (driver.Conn, error) { return checkerConn(d), nil }
+
+type checkerConn checkerDriver
+
+func (c checkerConn) Prepare(string) (driver.Stmt, error) {
+	if c.columns {
+		return converterStmt{checkerStmt{c.args}}, nil
+	}
+	return checkerStmt{c.args}, nil
+}
+func (checkerConn) Close() error              { return nil }
+func (checkerConn) Begin() (driver.Tx, error) { return nil, errors.New("unsupported") }
+
+func (c checkerConn) CheckNamedValue(nv *driver.NamedValue) error {
+	if c.columns {
+		return driver.ErrSkip
+	}
+	var err error
+	nv.Value, err = convertPoint(nv.Value)
+	return err
+}
+
+type checkerStmt struct {
+	args *[]driver.Value
+}
+
+func (checkerStmt) Close() error  { return nil }
+func (checkerStmt) NumInput() int { return 1 }
+
+func (s checkerStmt) Exec(args []driver.Value) (driver.Result, error) {
+	*s.args = append(*s.args, args...)
+	return driver.RowsAffected(1), nil
+}
+
+func (checkerStmt) Query([]driver.Value) (driver.Rows, error) {
+	return nil, errors.New("unsupported")
+}
+
+type converterStmt struct {
+	checkerStmt
+}
+
+func (converterStmt) ColumnConverter(int) driver.ValueConverter { return pointConverter{} }
+
+type pointConverter struct{}
+
+func (pointConverter) ConvertValue(v interface{}) (driver.Value, error) { return convertPoint(v) }
+
+func TestWrapDriverChecker(t *testing.T) {
+	for _, columns := range []bool{false, true} {
+		var args []driver.Value
+		runs++
+		name := fmt.Sprintf("cpanicsql-test-%d", runs)
+		sql.Register(name, cpanicsql.WrapDriver(checkerDriver{columns: columns, args: &args}, nil))
+
+		db, err := sql.Open(name, "")
+		assert.NoError(t, err)
+		stmt, err := db.Prepare("insert")
+		assert.NoError(t, err)
+		_, err = stmt.Exec(point{1, 2})
+		assert.NoError(t, err, "columns: %v", columns)
+		assert.Equal(t, []driver.Value{"(1,2)"}, args, "columns: %v", columns)
+		assert.NoError(t, db.Close())
+	}
+}
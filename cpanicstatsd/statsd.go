@@ -0,0 +1,84 @@
+// cpanicstatsd emits StatsD counters for recovered panics, for environments without
+// Prometheus scraping.
+package cpanicstatsd
+
+import (
+	"io"
+	"net"
+	"strings"
+	"sync"
+
+	"github.com/demosdemon/cpanic"
+)
+
+// Metric is the name of the counter incremented for every recovered panic.
+const Metric = "cpanic.recovered"
+
+// Tag names added to every counter.
+const (
+	FingerprintTag = "fingerprint"
+	KindTag        = "kind"
+)
+
+// Option configures the handler returned by `Handler`.
+type Option func(*config)
+
+type config struct {
+	prefix string
+	tags   []string
+}
+
+// WithPrefix prepends prefix, such as "myapp.", to the metric name.
+func WithPrefix(prefix string) Option {
+	return func(c *config) {
+		c.prefix = prefix
+	}
+}
+
+// WithTags adds constant "key:value" tags, such as "env:prod", to every counter.
+func WithTags(tags ...string) Option {
+	return func(c *config) {
+		c.tags = append(c.tags, tags...)
+	}
+}
+
+// Dial connects to the StatsD agent at addr, such as "127.0.0.1:8125", over UDP and
+// returns a handler writing to it. The connection is never closed; it lives as long as
+// the process.
+func Dial(addr string, opts ...Option) (cpanic.Handler, error) {
+	conn, err := net.Dial("udp", addr)
+	if err != nil {
+		return nil, err
+	}
+	return Handler(conn, opts...), nil
+}
+
+// Handler returns a `cpanic.Handler` that writes a single `Metric` counter increment per
+// panic to w, tagged DogStatsD-style with the panic's fingerprint and value type. Write
+// errors are discarded as StatsD is best-effort.
+func Handler(w io.Writer, opts ...Option) cpanic.Handler {
+	var cfg config
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	var mu sync.Mutex
+	return func(p *cpanic.Panic) {
+		tags := append([]string{
+			FingerprintTag + ":" + p.Fingerprint(),
+			KindTag + ":" + sanitize(p.Type()),
+		}, cfg.tags...)
+		line := cfg.prefix + Metric + ":1|c|#" + strings.Join(tags, ",")
+
+		mu.Lock()
+		defer mu.Unlock()
+		_, _ = io.WriteString(w, line)
+	}
+}
+
+var replacer = strings.NewReplacer("|", "_", ",", "_", "#", "_", ":", "_", " ", "_")
+
+// sanitize replaces the characters reserved by the StatsD line protocol.
+func sanitize(s string) string {
+	return replacer.Replace(s)
+}
@@ -0,0 +1,70 @@
+package cpanicstatsd_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/demosdemon/cpanic"
+	"github.com/demosdemon/cpanic/cpanicstatsd"
+)
+
+func TestHandler(t *testing.T) {
+	cases := []struct {
+		name  string
+		value interface{}
+		opts  []cpanicstatsd.Option
+		want  string
+	}{
+		{
+			name:  "string",
+			value: "not at a disco",
+			want:  "cpanic.recovered:1|c|#fingerprint:%s,kind:string",
+		},
+		{
+			name:  "sanitized",
+			value: struct{ A, B int }{},
+			want:  "cpanic.recovered:1|c|#fingerprint:%s,kind:struct_{_A_int;_B_int_}",
+		},
+		{
+			name:  "error",
+			value: errors.New("boom"),
+			opts:  []cpanicstatsd.Option{cpanicstatsd.WithPrefix("app."), cpanicstatsd.WithTags("env:test")},
+			want:  "app.cpanic.recovered:1|c|#fingerprint:%s,kind:*errors.errorString,env:test",
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			p := cpanic.New(tc.value)
+			cpanicstatsd.Handler(&buf, tc.opts...)(p)
+			assert.Equal(t, fmt.Sprintf(tc.want, p.Fingerprint()), buf.String())
+		})
+	}
+}
+
+func TestDial(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer conn.Close()
+
+	h, err := cpanicstatsd.Dial(conn.LocalAddr().String())
+	require.NoError(t, err)
+
+	p := cpanic.New("not at a disco")
+	h(p)
+
+	buf := make([]byte, 512)
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(time.Second)))
+	n, _, err := conn.ReadFrom(buf)
+	require.NoError(t, err)
+	assert.Equal(t, "cpanic.recovered:1|c|#fingerprint:"+p.Fingerprint()+",kind:string", string(buf[:n]))
+}
@@ -0,0 +1,12 @@
+module github.com/demosdemon/cpanic/cpanictwirp
+
+go 1.13
+
+require (
+	github.com/demosdemon/cpanic v0.0.0
+	github.com/pkg/errors v0.9.1 // indirect
+	github.com/stretchr/testify v1.8.2
+	github.com/twitchtv/twirp v8.1.3+incompatible
+)
+
+replace github.com/demosdemon/cpanic => ../
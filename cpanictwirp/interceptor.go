@@ -0,0 +1,53 @@
+// cpanictwirp provides a `github.com/twitchtv/twirp` integration for cpanic.
+package cpanictwirp
+
+import (
+	"context"
+
+	"github.com/twitchtv/twirp"
+
+	"github.com/demosdemon/cpanic"
+)
+
+const (
+	// MethodAttr is the `cpanic.Panic` attribute key holding the Twirp method, in the
+	// form "Service/Method", that panicked.
+	MethodAttr = "twirp.method"
+	// FingerprintMeta is the Twirp error metadata key carrying the fingerprint of the
+	// recovered panic so clients can correlate failures with reports.
+	FingerprintMeta = "fingerprint"
+)
+
+// PublicMessage is the message of the error returned to clients in place of the panic
+// value, unless the panic has its own `cpanic.Panic.PublicMessage`.
+const PublicMessage = "internal service panic"
+
+// Interceptor returns a `twirp.Interceptor` that recovers panics raised by method
+// implementations and returns a `twirp.Internal` error carrying the panic fingerprint
+// in its metadata. The recovered panic, tagged with the method name, is reported to
+// the handler, if provided.
+//
+// Twirp's `ServerHooks` only observe the error written after the generated server has
+// already handled a panic, and the panic is then re-raised. Installing this
+// interceptor with `twirp.WithServerInterceptors` stops the panic before it reaches
+// the generated code, so the hooks see an ordinary internal error instead.
+func Interceptor(h cpanic.Handler) twirp.Interceptor {
+	return func(next twirp.Method) twirp.Method {
+		return func(ctx context.Context, req interface{}) (resp interface{}, err error) {
+			defer func() {
+				if value := recover(); value != nil {
+					p := cpanic.New(value)
+					if service, ok := twirp.ServiceName(ctx); ok {
+						method, _ := twirp.MethodName(ctx)
+						p.SetAttr(MethodAttr, service+"/"+method)
+					}
+					cpanic.Deliver(p, h)
+
+					resp, err = nil, twirp.InternalError(p.PublicMessageOr(PublicMessage)).WithMeta(FingerprintMeta, p.Fingerprint())
+				}
+			}()
+
+			return next(ctx, req)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package cpanictwirp_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/twitchtv/twirp"
+	"github.com/twitchtv/twirp/ctxsetters"
+
+	"github.com/demosdemon/cpanic"
+	"github.com/demosdemon/cpanic/cpanictwirp"
+)
+
+func TestInterceptor(t *testing.T) {
+	tests := []struct {
+		name   string
+		method twirp.Method
+		resp   interface{}
+		errMsg string
+		panics bool
+	}{
+		{
+			name:   "passes through response",
+			method: func(context.Context, interface{}) (interface{}, error) { return "ok", nil },
+			resp:   "ok",
+		},
+		{
+			name:   "passes through error",
+			method: func(context.Context, interface{}) (interface{}, error) { return nil, errors.New("test") },
+			errMsg: "test",
+		},
+		{
+			name:   "returns internal error",
+			method: func(context.Context, interface{}) (interface{}, error) { panic("not at a disco") },
+			errMsg: "twirp error internal: " + cpanictwirp.PublicMessage,
+			panics: true,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			var handled *cpanic.Panic
+			method := cpanictwirp.Interceptor(func(p *cpanic.Panic) { handled = p })(tt.method)
+
+			ctx := ctxsetters.WithServiceName(context.Background(), "Haberdasher")
+			ctx = ctxsetters.WithMethodName(ctx, "MakeHat")
+			resp, err := method(ctx, nil)
+			assert.Equal(t, tt.resp, resp)
+			if tt.errMsg == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tt.errMsg)
+			}
+
+			if tt.panics {
+				var twerr twirp.Error
+				if assert.True(t, errors.As(err, &twerr)) && assert.NotNil(t, handled) {
+					assert.Equal(t, twirp.Internal, twerr.Code())
+					assert.Equal(t, handled.Fingerprint(), twerr.Meta(cpanictwirp.FingerprintMeta))
+					assert.Equal(t, "Haberdasher/MakeHat", handled.Attrs[cpanictwirp.MethodAttr])
+				}
+			} else {
+				assert.Nil(t, handled)
+			}
+		})
+	}
+}
@@ -0,0 +1,10 @@
+module github.com/demosdemon/cpanic/cpanicui
+
+go 1.16
+
+require (
+	github.com/demosdemon/cpanic v0.0.0
+	github.com/stretchr/testify v1.8.2
+)
+
+replace github.com/demosdemon/cpanic => ../
@@ -0,0 +1,267 @@
+// cpanicui serves a small crash dashboard for the panics in a `cpanic.Store`: the
+// panics grouped by fingerprint, with their occurrence counts and a sparkline of their
+// frequency, and a detail view of every group and occurrence rendered with
+// `cpanic.HTML`. It is a separate module, as its embedded templates and assets require
+// Go 1.16.
+package cpanicui
+
+import (
+	"bytes"
+	"embed"
+	"html/template"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/demosdemon/cpanic"
+)
+
+//go:embed templates static
+var assets embed.FS
+
+var templates = template.Must(template.New("").Funcs(template.FuncMap{
+	"render":    renderPanic,
+	"culprit":   culprit,
+	"timestamp": timestamp,
+}).ParseFS(assets, "templates/*.html"))
+
+// DefaultWindow is the default time span covered by the sparklines.
+const DefaultWindow = 24 * time.Hour
+
+// sparklineBuckets is the number of points of a sparkline.
+const sparklineBuckets = 24
+
+// Option configures `Handler`.
+type Option func(*config)
+
+type config struct {
+	title  string
+	window time.Duration
+}
+
+// WithTitle sets the title of the pages. It defaults to "Panics".
+func WithTitle(title string) Option {
+	return func(c *config) {
+		c.title = title
+	}
+}
+
+// WithWindow sets the time span, ending now, covered by the sparklines. It defaults to
+// `DefaultWindow`.
+func WithWindow(d time.Duration) Option {
+	return func(c *config) {
+		c.window = d
+	}
+}
+
+// Handler returns an `http.Handler` serving the dashboard of the panics in the store,
+// queried with `cpanic.Query` on every request. The list of groups can be narrowed to
+// the panics of a type with the "kind" URL parameter, such as "?kind=runtime.Error". It can be mounted under any prefix with `http.StripPrefix`,
+// such as:
+//
+//	mux.Handle("/debug/panics/", http.StripPrefix("/debug/panics", cpanicui.Handler(store)))
+//
+// The pages include the traces and attributes of the panics, so only serve it to
+// trusted clients.
+func Handler(store cpanic.Store, opts ...Option) http.Handler {
+	c := config{title: "Panics", window: DefaultWindow}
+	for _, opt := range opts {
+		opt(&c)
+	}
+
+	static, _ := fs.Sub(assets, "static")
+	files := http.StripPrefix("/static", http.FileServer(http.FS(static)))
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path := r.URL.Path
+		if strings.HasPrefix(path, "/static/") {
+			files.ServeHTTP(w, r)
+			return
+		}
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+
+		var filter cpanic.Filter
+		switch {
+		case path == "/" || path == "":
+			filter.Kind = r.URL.Query().Get("kind")
+		case strings.HasPrefix(path, "/fingerprint/"):
+			filter.Fingerprint = strings.TrimPrefix(path, "/fingerprint/")
+		case strings.HasPrefix(path, "/panic/"):
+		default:
+			http.NotFound(w, r)
+			return
+		}
+
+		panics, err := cpanic.Query(store, filter)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		page := page{Title: c.title, Base: basePath(r), Kind: filter.Kind}
+
+		switch {
+		case filter.Fingerprint != "":
+			groups := groupPanics(panics, time.Now(), c.window)
+			if len(groups) == 0 {
+				http.NotFound(w, r)
+				return
+			}
+			page.Group = groups[0]
+			render(w, "group.html", page)
+		case strings.HasPrefix(path, "/panic/"):
+			id := strings.TrimPrefix(path, "/panic/")
+			for _, p := range panics {
+				if p.ID == id {
+					page.Panic = p
+					render(w, "panic.html", page)
+					return
+				}
+			}
+			http.NotFound(w, r)
+		default:
+			page.Groups = groupPanics(panics, time.Now(), c.window)
+			render(w, "index.html", page)
+		}
+	})
+}
+
+// page is the data of every template.
+type page struct {
+	Title  string
+	Base   string
+	Kind   string
+	Groups []*group
+	Group  *group
+	Panic  *cpanic.Panic
+}
+
+// group is the panics sharing a fingerprint.
+type group struct {
+	Fingerprint string
+	Latest      *cpanic.Panic
+	Count       int
+	FirstSeen   time.Time
+	LastSeen    time.Time
+	// Sparkline holds the points of an SVG polyline plotting the number of occurrences
+	// over the window.
+	Sparkline string
+	// Panics are the occurrences, newest first.
+	Panics []*cpanic.Panic
+}
+
+// groupPanics groups the panics by fingerprint, with the most recently seen group
+// first.
+func groupPanics(panics []*cpanic.Panic, now time.Time, window time.Duration) []*group {
+	byFingerprint := make(map[string]*group)
+	var groups []*group
+	for _, p := range panics {
+		fp := p.Fingerprint()
+		g, ok := byFingerprint[fp]
+		if !ok {
+			g = &group{Fingerprint: fp, FirstSeen: p.Time}
+			byFingerprint[fp] = g
+			groups = append(groups, g)
+		}
+		g.Count++
+		if p.Time.Before(g.FirstSeen) {
+			g.FirstSeen = p.Time
+		}
+		if g.Latest == nil || !p.Time.Before(g.LastSeen) {
+			g.Latest, g.LastSeen = p, p.Time
+		}
+		g.Panics = append(g.Panics, p)
+	}
+
+	for _, g := range groups {
+		sort.SliceStable(g.Panics, func(i, j int) bool {
+			return g.Panics[i].Time.After(g.Panics[j].Time)
+		})
+		g.Sparkline = sparkline(g.Panics, now, window)
+	}
+	sort.SliceStable(groups, func(i, j int) bool {
+		return groups[i].LastSeen.After(groups[j].LastSeen)
+	})
+	return groups
+}
+
+// sparkline returns the points of a polyline, in a 100 by 20 box, plotting the number of
+// panics in each bucket of the window ending at now.
+func sparkline(panics []*cpanic.Panic, now time.Time, window time.Duration) string {
+	var counts [sparklineBuckets]int
+	start := now.Add(-window)
+	for _, p := range panics {
+		if p.Time.Before(start) || p.Time.After(now) {
+			continue
+		}
+		i := int(int64(p.Time.Sub(start)) * sparklineBuckets / int64(window))
+		if i == sparklineBuckets {
+			i--
+		}
+		counts[i]++
+	}
+
+	max := 1
+	for _, n := range counts {
+		if n > max {
+			max = n
+		}
+	}
+	points := make([]string, sparklineBuckets)
+	for i, n := range counts {
+		x := float64(i) * 100 / (sparklineBuckets - 1)
+		y := 19 - float64(n)*18/float64(max)
+		points[i] = strconv.FormatFloat(x, 'f', 1, 64) + "," + strconv.FormatFloat(y, 'f', 1, 64)
+	}
+	return strings.Join(points, " ")
+}
+
+// basePath returns the prefix stripped from the request path by `http.StripPrefix`, so
+// the links of the pages work wherever the handler is mounted.
+func basePath(r *http.Request) string {
+	u, err := url.ParseRequestURI(r.RequestURI)
+	if err != nil || !strings.HasSuffix(u.Path, r.URL.Path) {
+		return ""
+	}
+	return strings.TrimSuffix(u.Path[:len(u.Path)-len(r.URL.Path)], "/")
+}
+
+func render(w http.ResponseWriter, name string, data page) {
+	var buf bytes.Buffer
+	if err := templates.ExecuteTemplate(&buf, name, data); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = buf.WriteTo(w)
+}
+
+// renderPanic renders the panic with `cpanic.HTML`, which escapes its content.
+func renderPanic(p *cpanic.Panic) template.HTML {
+	b, err := cpanic.HTML.Format(p)
+	if err != nil {
+		return template.HTML(template.HTMLEscapeString(err.Error()))
+	}
+	return template.HTML(b)
+}
+
+func culprit(p *cpanic.Panic) string {
+	if f, ok := p.Culprit(); ok {
+		return f.Function
+	}
+	return ""
+}
+
+func timestamp(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.UTC().Format(time.RFC3339)
+}
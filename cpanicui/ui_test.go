@@ -0,0 +1,99 @@
+package cpanicui_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/demosdemon/cpanic"
+	"github.com/demosdemon/cpanic/cpanicui"
+)
+
+type failingStore struct{}
+
+func (failingStore) Load() ([]*cpanic.Panic, error) {
+	return nil, errors.New("disk on fire")
+}
+
+func newPanic(value interface{}, age time.Duration) *cpanic.Panic {
+	p := cpanic.New(value)
+	p.Time = time.Now().Add(-age)
+	return p
+}
+
+func TestHandler(t *testing.T) {
+	store := cpanic.NewHistory(0)
+	old := newPanic("not at a disco", 2*time.Hour)
+	recent := newPanic("not at a disco", time.Minute)
+	other := newPanic(errors.New("<script>"), time.Hour)
+	for _, p := range []*cpanic.Panic{old, recent, other} {
+		store.Handle(p)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/debug/panics/", http.StripPrefix("/debug/panics", cpanicui.Handler(store, cpanicui.WithTitle("Crashes"))))
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	get := func(path string) (int, string) {
+		resp, err := http.Get(srv.URL + path)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		b, err := ioutil.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return resp.StatusCode, string(b)
+	}
+
+	status, body := get("/debug/panics/")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Contains(t, body, "<title>Crashes</title>")
+	assert.Contains(t, body, `href="/debug/panics/static/style.css"`)
+	assert.NotContains(t, body, "<script>", "values must be escaped")
+	first := strings.Index(body, "/debug/panics/fingerprint/"+recent.Fingerprint())
+	second := strings.Index(body, "/debug/panics/fingerprint/"+other.Fingerprint())
+	assert.True(t, first >= 0 && second > first, "groups must be ordered by last seen")
+	assert.Contains(t, body, `<td class="count">2</td>`)
+	assert.Contains(t, body, "<polyline")
+
+	status, body = get("/debug/panics/?kind=string")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Contains(t, body, "/debug/panics/fingerprint/"+recent.Fingerprint())
+	assert.NotContains(t, body, "/debug/panics/fingerprint/"+other.Fingerprint())
+
+	status, body = get("/debug/panics/fingerprint/" + recent.Fingerprint())
+	assert.Equal(t, http.StatusOK, status)
+	assert.Contains(t, body, "2 occurrences")
+	assert.Contains(t, body, `<article class="cpanic">`)
+	assert.True(t, strings.Index(body, "/panic/"+recent.ID) < strings.Index(body, "/panic/"+old.ID))
+
+	status, body = get("/debug/panics/panic/" + old.ID)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Contains(t, body, old.ID)
+
+	status, body = get("/debug/panics/static/style.css")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Contains(t, body, ".sparkline")
+
+	for _, path := range []string{"/debug/panics/fingerprint/unknown", "/debug/panics/panic/unknown", "/debug/panics/other"} {
+		status, _ = get(path)
+		assert.Equal(t, http.StatusNotFound, status, path)
+	}
+}
+
+func TestHandlerErrors(t *testing.T) {
+	rec := httptest.NewRecorder()
+	cpanicui.Handler(failingStore{}).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	assert.Contains(t, rec.Body.String(), "disk on fire")
+
+	rec = httptest.NewRecorder()
+	cpanicui.Handler(failingStore{}).ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+}
@@ -0,0 +1,154 @@
+package cpanic
+
+import (
+	"io"
+	"regexp"
+	"strings"
+)
+
+// maxInterleavedLines is the number of consecutive lines that cannot be part of a trace
+// after which a `Demux` considers the trace to have ended.
+const maxInterleavedLines = 16
+
+// Demux separates the crash dumps of Go programs from the surrounding log lines in the
+// same stream, such as container logs where stdout and stderr are merged. Unlike
+// `Scanner`, which ends a dump at the first line that cannot be part of a trace, a
+// demux keeps following the goroutine blocks of a trace across lines written
+// concurrently by other output, which are passed through as log lines. Successive calls
+// to `Scan` step through the log lines and panics in the order they are complete.
+type Demux struct {
+	lines lineReader
+	queue []demuxRecord
+	cur   demuxRecord
+	err   error
+	done  bool
+
+	block   []string
+	held    []string
+	inTrace bool
+}
+
+type demuxRecord struct {
+	line  string
+	panic *Panic
+}
+
+// NewDemux returns a demux reading from r.
+func NewDemux(r io.Reader) *Demux {
+	return &Demux{lines: newLineReader(r)}
+}
+
+// SetPrefix sets a pattern stripped from the start of every line before it is
+// inspected, as with `(*Scanner).SetPrefix`. Log lines are returned without it. It must
+// be called before the first call to `Scan`.
+func (d *Demux) SetPrefix(re *regexp.Regexp) {
+	d.lines.prefix = re
+}
+
+// Scan advances to the next log line or panic, which is then available through `Line`
+// or `Panic`. A trace ends once more than a few consecutive lines cannot be part of it,
+// on the start of another crash, or when the input ends. Scan returns false when the
+// input ends or fails, after which `Err` returns the error, if any.
+func (d *Demux) Scan() bool {
+	for len(d.queue) == 0 {
+		if d.done {
+			d.cur = demuxRecord{}
+			return false
+		}
+		line, ok := d.lines.next()
+		if !ok {
+			d.finish()
+			d.done = true
+			d.err = d.lines.err()
+			continue
+		}
+		d.feed(line)
+	}
+	d.cur = d.queue[0]
+	d.queue[0] = demuxRecord{}
+	d.queue = d.queue[1:]
+	return true
+}
+
+// Panic returns the panic found by the last call to `Scan`, or nil if it found a log
+// line.
+func (d *Demux) Panic() *Panic {
+	return d.cur.panic
+}
+
+// Line returns the log line found by the last call to `Scan`, or an empty string if it
+// found a panic.
+func (d *Demux) Line() string {
+	return d.cur.line
+}
+
+// Err returns the first error reading the input, if any.
+func (d *Demux) Err() error {
+	return d.err
+}
+
+func (d *Demux) feed(line string) {
+	switch {
+	case d.inTrace && isCrashStart(line):
+		d.endTrace()
+		d.block = []string{line}
+
+	case d.inTrace:
+		if isTraceLine(line) {
+			d.emitLines(d.held)
+			d.held = nil
+			d.block = append(d.block, line)
+			return
+		}
+		d.held = append(d.held, line)
+		if len(d.held) > maxInterleavedLines {
+			d.endTrace()
+		}
+
+	case d.block != nil && isCrashStart(line):
+		d.emitLines(d.block)
+		d.block = []string{line}
+
+	case d.block != nil:
+		d.block = append(d.block, line)
+		if isGoroutineHeader(line) {
+			d.inTrace = true
+		} else if len(d.block) > maxMessageLines {
+			d.emitLines(d.block)
+			d.block = nil
+		}
+
+	case isCrashStart(line):
+		d.block = []string{line}
+
+	default:
+		d.emitLines([]string{line})
+	}
+}
+
+// endTrace queues the panic of the current block, followed by the lines held since the
+// end of its trace.
+func (d *Demux) endTrace() {
+	if p, err := Parse(strings.Join(d.block, "\n")); err == nil {
+		d.queue = append(d.queue, demuxRecord{panic: p})
+	}
+	d.emitLines(d.held)
+	d.block, d.held, d.inTrace = nil, nil, false
+}
+
+// finish queues whatever is pending when the input ends. A crash whose trace never
+// started is passed through as log lines.
+func (d *Demux) finish() {
+	if d.inTrace {
+		d.endTrace()
+		return
+	}
+	d.emitLines(d.block)
+	d.block = nil
+}
+
+func (d *Demux) emitLines(lines []string) {
+	for _, line := range lines {
+		d.queue = append(d.queue, demuxRecord{line: line})
+	}
+}
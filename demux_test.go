@@ -0,0 +1,107 @@
+package cpanic_test
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/demosdemon/cpanic"
+)
+
+const interleavedLog = `starting server
+panic: first
+
+goroutine 1 [running]:
+main.main()
+GET /healthz 200
+	/src/main.go:6 +0x34
+
+goroutine 2 [select]:
+main.idle()
+	/src/main.go:20 +0x1d
+exit status 2
+restarting server
+panic: not followed by a trace
+serving requests
+fatal error: all goroutines are asleep - deadlock!
+
+goroutine 1 [chan receive]:
+main.main()
+	/src/main.go:9 +0x34
+panic: second
+
+goroutine 1 [running]:
+main.handler()
+	/src/main.go:30 +0x34`
+
+// demuxAll returns the log lines and the values of the panics, prefixed with "panic: ",
+// found by d.
+func demuxAll(d *cpanic.Demux) []string {
+	var out []string
+	for d.Scan() {
+		if p := d.Panic(); p != nil {
+			out = append(out, "panic: "+p.Message())
+		} else {
+			out = append(out, d.Line())
+		}
+	}
+	return out
+}
+
+func TestDemux(t *testing.T) {
+	d := cpanic.NewDemux(strings.NewReader(interleavedLog))
+	assert.Equal(t, []string{
+		"starting server",
+		"GET /healthz 200",
+		"panic: first",
+		"exit status 2",
+		"restarting server",
+		"panic: not followed by a trace",
+		"serving requests",
+		"panic: all goroutines are asleep - deadlock!",
+		"panic: second",
+	}, demuxAll(d))
+	assert.NoError(t, d.Err())
+}
+
+func TestDemuxTrace(t *testing.T) {
+	d := cpanic.NewDemux(strings.NewReader(interleavedLog))
+	for d.Scan() {
+		if p := d.Panic(); p != nil {
+			assert.Equal(t, `goroutine 1 [running]:
+main.main()
+	/src/main.go:6 +0x34
+
+goroutine 2 [select]:
+main.idle()
+	/src/main.go:20 +0x1d
+`, p.Trace)
+			return
+		}
+	}
+	t.Fatal("no panic found")
+}
+
+func TestDemuxTraceEnd(t *testing.T) {
+	log := "panic: boom\n\ngoroutine 1 [running]:\nmain.main()\n\t/src/main.go:6 +0x34\n" +
+		strings.Repeat("serving requests\n", 20)
+	got := demuxAll(cpanic.NewDemux(strings.NewReader(log)))
+	assert.Len(t, got, 21)
+	assert.Equal(t, "panic: boom", got[0])
+}
+
+func TestDemuxPrefix(t *testing.T) {
+	log := "[app] starting\n[app] panic: boom\n[app] \n[app] goroutine 1 [running]:\n[app] main.main()\n"
+	d := cpanic.NewDemux(strings.NewReader(log))
+	d.SetPrefix(regexp.MustCompile(`^\[app\] `))
+	assert.Equal(t, []string{"starting", "panic: boom"}, demuxAll(d))
+}
+
+func TestDemuxError(t *testing.T) {
+	d := cpanic.NewDemux(iotest.TimeoutReader(strings.NewReader("hello\n")))
+	assert.Equal(t, []string{"hello"}, demuxAll(d))
+	assert.Equal(t, iotest.ErrTimeout, d.Err())
+}
@@ -0,0 +1,95 @@
+package cpanic
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ecsVersion is the version of the Elastic Common Schema written by `ECS`.
+const ecsVersion = "8.11.0"
+
+// ECS renders the panic as a single line of JSON with Elastic Common Schema fields, so
+// it is mapped correctly by Elasticsearch without an ingest pipeline:
+//
+//	{"@timestamp":"2021-04-01T12:00:00Z","log.level":"error","message":"panic: ...",
+//	"ecs.version":"8.11.0","event.kind":"event","event.type":["error"],
+//	"error.type":"string","error.message":"not at a disco","error.stack_trace":"...",...}
+//
+// ECS has no "error" event kind, so panics are events of type "error". The culprit is
+// written as "log.origin", the ID as "error.id", and the fingerprint and build ID as
+// "cpanic" fields. Attributes are written as "labels", which ECS requires to be
+// strings without dots in their keys, so values are formatted with `fmt.Sprint` and
+// dots in keys are replaced with underscores.
+var ECS Formatter = formatter{formatECS, "application/x-ndjson"}
+
+func formatECS(p *Panic) ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteByte('{')
+	field := func(key string, value interface{}) error {
+		v, err := json.Marshal(value)
+		if err != nil {
+			return err
+		}
+		if b.Len() > 1 {
+			b.WriteByte(',')
+		}
+		k, _ := json.Marshal(key)
+		b.Write(k)
+		b.WriteByte(':')
+		b.Write(v)
+		return nil
+	}
+
+	type kv struct {
+		key   string
+		value interface{}
+	}
+	var fields []kv
+	if !p.Time.IsZero() {
+		fields = append(fields, kv{"@timestamp", p.Time.UTC().Format(time.RFC3339Nano)})
+	}
+	fields = append(fields,
+		kv{"log.level", p.Severity.String()},
+		kv{"message", p.Error()},
+		kv{"ecs.version", ecsVersion},
+		kv{"event.kind", "event"},
+		kv{"event.type", []string{"error"}},
+		kv{"error.type", p.Type()},
+		kv{"error.message", p.Message()},
+	)
+	if p.ID != "" {
+		fields = append(fields, kv{"error.id", p.ID})
+	}
+	if p.Trace != "" {
+		fields = append(fields, kv{"error.stack_trace", p.Trace})
+	}
+	if f, ok := p.Culprit(); ok {
+		fields = append(fields,
+			kv{"log.origin.function", f.Function},
+			kv{"log.origin.file.name", f.File},
+			kv{"log.origin.file.line", f.Line},
+		)
+	}
+	fields = append(fields, kv{"cpanic.fingerprint", p.Fingerprint()})
+	if p.BuildID != "" {
+		fields = append(fields, kv{"cpanic.build_id", p.BuildID})
+	}
+	if len(p.Attrs) > 0 {
+		labels := make(map[string]string, len(p.Attrs))
+		for k, v := range p.Attrs {
+			labels[strings.Replace(k, ".", "_", -1)] = fmt.Sprint(v)
+		}
+		fields = append(fields, kv{"labels", labels})
+	}
+
+	for _, f := range fields {
+		if err := field(f.key, f.value); err != nil {
+			return nil, err
+		}
+	}
+	b.WriteString("}\n")
+	return b.Bytes(), nil
+}
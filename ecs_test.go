@@ -0,0 +1,30 @@
+package cpanic_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/demosdemon/cpanic"
+)
+
+func TestECS(t *testing.T) {
+	p := &cpanic.Panic{
+		ID:    "01F2",
+		Time:  time.Date(2021, 4, 1, 12, 0, 0, 0, time.UTC),
+		Value: "not at a disco",
+		Trace: "goroutine 1 [running]:\nmain.main()\n\t/src/main.go:3 +0x1d\n",
+	}
+	p.SetAttr("http.route", "/")
+	p.SetAttr("status", 500)
+
+	b, err := cpanic.ECS.Format(p)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"@timestamp":"2021-04-01T12:00:00Z","log.level":"error",`+
+		`"message":"panic: not at a disco","ecs.version":"8.11.0","event.kind":"event",`+
+		`"event.type":["error"],"error.type":"string","error.message":"not at a disco",`+
+		`"error.id":"01F2","error.stack_trace":"goroutine 1 [running]:\nmain.main()\n\t/src/main.go:3 +0x1d\n",`+
+		`"log.origin.function":"main.main","log.origin.file.name":"/src/main.go","log.origin.file.line":3,`+
+		`"cpanic.fingerprint":"`+p.Fingerprint()+`","labels":{"http_route":"/","status":"500"}}`+"\n", string(b))
+}
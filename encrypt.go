@@ -0,0 +1,154 @@
+package cpanic
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+)
+
+// encryptedMagic starts every message sealed by a `Keyring`.
+const encryptedMagic = "cpe1"
+
+// keyIDSize is the size of the key identifier following the magic, the start of the
+// SHA-256 hash of the key.
+const keyIDSize = 8
+
+var (
+	// ErrNotEncrypted is returned by `(*Keyring).Open` for data not sealed by a keyring.
+	ErrNotEncrypted = errors.New("cpanic: data is not encrypted")
+	// ErrUnknownKey is returned by `(*Keyring).Open` for data sealed with a key missing
+	// from the keyring.
+	ErrUnknownKey = errors.New("cpanic: data is encrypted with an unknown key")
+)
+
+// Keyring encrypts crash data at rest with AES-GCM, since crash dumps often contain
+// fragments of request payloads that must not sit on disk in plaintext. Data is sealed
+// with the primary key and tagged with its identifier, so it can be opened with any key
+// of the keyring. To rotate keys, create a keyring with the new key as primary and the
+// previous keys after it, and optionally `Reseal` existing data.
+type Keyring struct {
+	primary *keyringKey
+	keys    map[string]*keyringKey
+}
+
+type keyringKey struct {
+	id   string
+	aead cipher.AEAD
+}
+
+// NewKeyring returns a keyring sealing with the primary key and opening with it or any of
+// the previous keys. Keys must be 16, 24, or 32 bytes long, selecting AES-128, AES-192,
+// or AES-256.
+func NewKeyring(primary []byte, previous ...[]byte) (*Keyring, error) {
+	k := &Keyring{keys: make(map[string]*keyringKey, 1+len(previous))}
+	for i, key := range append([][]byte{primary}, previous...) {
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, fmt.Errorf("cpanic: invalid key %d: %w", i, err)
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, err
+		}
+		sum := sha256.Sum256(key)
+		kk := &keyringKey{id: string(sum[:keyIDSize]), aead: aead}
+		if i == 0 {
+			k.primary = kk
+		}
+		if _, ok := k.keys[kk.id]; !ok {
+			k.keys[kk.id] = kk
+		}
+	}
+	return k, nil
+}
+
+// Seal encrypts and authenticates the plaintext with the primary key.
+func (k *Keyring) Seal(plaintext []byte) ([]byte, error) {
+	aead := k.primary.aead
+	header := encryptedMagic + k.primary.id
+	out := make([]byte, len(header)+aead.NonceSize(), len(header)+aead.NonceSize()+len(plaintext)+aead.Overhead())
+	copy(out, header)
+	nonce := out[len(header):]
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(out, nonce, plaintext, out[:len(header)]), nil
+}
+
+// Open decrypts data sealed by `Seal` with any key of the keyring.
+func (k *Keyring) Open(data []byte) ([]byte, error) {
+	header := len(encryptedMagic) + keyIDSize
+	if len(data) < header || string(data[:len(encryptedMagic)]) != encryptedMagic {
+		return nil, ErrNotEncrypted
+	}
+	kk, ok := k.keys[string(data[len(encryptedMagic):header])]
+	if !ok {
+		return nil, ErrUnknownKey
+	}
+
+	nonceSize := kk.aead.NonceSize()
+	if len(data) < header+nonceSize {
+		return nil, ErrNotEncrypted
+	}
+	nonce := data[header : header+nonceSize]
+	plaintext, err := kk.aead.Open(nil, nonce, data[header+nonceSize:], data[:header])
+	if err != nil {
+		return nil, fmt.Errorf("cpanic: decryption failed: %w", err)
+	}
+	return plaintext, nil
+}
+
+// Reseal opens data sealed with any key of the keyring and seals it again with the
+// primary key, so data can be migrated off a previous key before it is retired.
+func (k *Keyring) Reseal(data []byte) ([]byte, error) {
+	plaintext, err := k.Open(data)
+	if err != nil {
+		return nil, err
+	}
+	return k.Seal(plaintext)
+}
+
+// NewWriter returns a writer that seals everything written to it and writes the result
+// to w when closed, such as to encrypt the archive written by `cpanicarchive.Export`.
+func (k *Keyring) NewWriter(w io.Writer) io.WriteCloser {
+	return &sealWriter{k: k, w: w}
+}
+
+// NewReader reads all of r and returns a reader of the data opened with the keyring,
+// such as to decrypt an archive for `cpanicarchive.Import`.
+func (k *Keyring) NewReader(r io.Reader) (io.Reader, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := k.Open(data)
+	if err != nil {
+		return nil, err
+	}
+	return bytes.NewReader(plaintext), nil
+}
+
+type sealWriter struct {
+	k   *Keyring
+	w   io.Writer
+	buf bytes.Buffer
+}
+
+func (s *sealWriter) Write(b []byte) (int, error) {
+	return s.buf.Write(b)
+}
+
+func (s *sealWriter) Close() error {
+	data, err := s.k.Seal(s.buf.Bytes())
+	if err != nil {
+		return err
+	}
+	_, err = s.w.Write(data)
+	return err
+}
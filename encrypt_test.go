@@ -0,0 +1,53 @@
+package cpanic_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/demosdemon/cpanic"
+)
+
+func TestKeyring(t *testing.T) {
+	oldKey, newKey := bytes.Repeat([]byte{1}, 32), bytes.Repeat([]byte{2}, 32)
+	old, err := cpanic.NewKeyring(oldKey)
+	require.NoError(t, err)
+	sealed, err := old.Seal([]byte("card 4242"))
+	require.NoError(t, err)
+	assert.NotContains(t, string(sealed), "4242")
+
+	rotated, err := cpanic.NewKeyring(newKey, oldKey)
+	require.NoError(t, err)
+	plaintext, err := rotated.Open(sealed)
+	require.NoError(t, err)
+	assert.Equal(t, "card 4242", string(plaintext))
+
+	resealed, err := rotated.Reseal(sealed)
+	require.NoError(t, err)
+	_, err = old.Open(resealed)
+	assert.Equal(t, cpanic.ErrUnknownKey, err)
+
+	retired, err := cpanic.NewKeyring(newKey)
+	require.NoError(t, err)
+	plaintext, err = retired.Open(resealed)
+	require.NoError(t, err)
+	assert.Equal(t, "card 4242", string(plaintext))
+}
+
+func TestKeyringErrors(t *testing.T) {
+	_, err := cpanic.NewKeyring([]byte("short"))
+	assert.Error(t, err)
+
+	k, err := cpanic.NewKeyring(bytes.Repeat([]byte{1}, 16))
+	require.NoError(t, err)
+	_, err = k.Open([]byte("plaintext"))
+	assert.Equal(t, cpanic.ErrNotEncrypted, err)
+
+	sealed, err := k.Seal([]byte("card 4242"))
+	require.NoError(t, err)
+	sealed[len(sealed)-1] ^= 1
+	_, err = k.Open(sealed)
+	assert.Error(t, err)
+}
@@ -0,0 +1,73 @@
+package cpanic
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+var attrExtractors struct {
+	sync.RWMutex
+	funcs []*func(ctx context.Context) []interface{}
+}
+
+// RegisterAttrExtractor adds a function that returns attributes, as alternating
+// key/value pairs as with `NewContext`, to attach to the panics recovered with
+// `RecoverCtx` or `ForwardCtx`, so crash reports carry the same context as the
+// surrounding log lines. It can pull the fields already attached to a logger carried by
+// ctx, such as a `zap.Logger` built with `With`, or those of the default `slog` logger
+// with `SlogDefaultAttrs`. Attributes of the task carried by ctx take precedence over
+// extracted ones. It returns a function that unregisters the extractor.
+//
+//	cpanic.RegisterAttrExtractor(func(ctx context.Context) []interface{} {
+//		var keyvals []interface{}
+//		for _, f := range loggerFields(ctx) { // the []zap.Field passed to With
+//			keyvals = append(keyvals, f.Key, f.Interface)
+//		}
+//		return keyvals
+//	})
+func RegisterAttrExtractor(fn func(ctx context.Context) []interface{}) (unregister func()) {
+	attrExtractors.Lock()
+	defer attrExtractors.Unlock()
+	attrExtractors.funcs = append(attrExtractors.funcs, &fn)
+	return func() {
+		attrExtractors.Lock()
+		defer attrExtractors.Unlock()
+		funcs := make([]*func(ctx context.Context) []interface{}, 0, len(attrExtractors.funcs))
+		for _, f := range attrExtractors.funcs {
+			if f != &fn {
+				funcs = append(funcs, f)
+			}
+		}
+		attrExtractors.funcs = funcs
+	}
+}
+
+// extractAttrs attaches the attributes of the registered extractors to the panic,
+// except for those the task attaches itself. Extractors registered later replace the
+// attributes of earlier ones.
+func extractAttrs(ctx context.Context, t *Task, p *Panic) {
+	attrExtractors.RLock()
+	funcs := attrExtractors.funcs
+	attrExtractors.RUnlock()
+
+	for _, fn := range funcs {
+		keyvals := (*fn)(ctx)
+		for i := 0; i < len(keyvals); i += 2 {
+			key, ok := keyvals[i].(string)
+			if !ok {
+				key = fmt.Sprint(keyvals[i])
+			}
+			if t != nil {
+				if _, ok := t.attrs[key]; ok {
+					continue
+				}
+			}
+			var value interface{}
+			if i+1 < len(keyvals) {
+				value = keyvals[i+1]
+			}
+			p.SetAttr(key, value)
+		}
+	}
+}
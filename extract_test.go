@@ -0,0 +1,42 @@
+package cpanic_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/demosdemon/cpanic"
+)
+
+type extractKey struct{}
+
+func init() {
+	cpanic.RegisterAttrExtractor(func(ctx context.Context) []interface{} {
+		keyvals, _ := ctx.Value(extractKey{}).([]interface{})
+		return keyvals
+	})
+}
+
+func TestRegisterAttrExtractor(t *testing.T) {
+	var got *cpanic.Panic
+	ctx := cpanic.NewContext(context.Background(), func(p *cpanic.Panic) {
+		got = p
+	}, "user", "task")
+	ctx = context.WithValue(ctx, extractKey{}, []interface{}{"user", "logger", "request_id", "abc", 42})
+
+	func() {
+		defer cpanic.RecoverCtx(ctx)
+		panic("boom")
+	}()
+	assert.Equal(t, map[string]interface{}{"user": "task", "request_id": "abc", "42": nil}, got.Attrs)
+
+	var err error
+	func() {
+		defer cpanic.ForwardCtx(context.WithValue(context.Background(), extractKey{}, []interface{}{"request_id", "def"}), &err)
+		panic("boom")
+	}()
+	var p *cpanic.Panic
+	assert.ErrorAs(t, err, &p)
+	assert.Equal(t, map[string]interface{}{"request_id": "def"}, p.Attrs)
+}
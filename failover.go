@@ -0,0 +1,54 @@
+package cpanic
+
+// TryHandler is a handler that reports whether it handled the panic, such as a reporter
+// sending panics to a remote service. `Handler` implements it and never fails, so a
+// plain handler, such as one writing to stderr, can be the last resort of a `Failover`.
+type TryHandler interface {
+	TryHandle(p *Panic) error
+}
+
+// TryHandlerFunc is a function that implements `TryHandler`.
+type TryHandlerFunc func(p *Panic) error
+
+// TryHandle calls f.
+func (f TryHandlerFunc) TryHandle(p *Panic) error {
+	return f(p)
+}
+
+// TryHandle calls `Handle` and returns nil.
+func (h Handler) TryHandle(p *Panic) error {
+	h.Handle(p)
+	return nil
+}
+
+// Failover returns a handler that tries each of the handlers in order until one
+// succeeds, such as a remote reporter, else a file, else stderr. Nil handlers, including
+// a nil `Handler` or `TryHandlerFunc`, are skipped rather than counted as a success.
+// The panic is dropped if every handler fails.
+func Failover(handlers ...TryHandler) Handler {
+	return func(p *Panic) {
+		for _, h := range handlers {
+			if isNilTryHandler(h) {
+				continue
+			}
+			if err := h.TryHandle(p); err == nil {
+				return
+			}
+			countFailure()
+		}
+		countDropped()
+	}
+}
+
+// isNilTryHandler reports whether h is nil or holds a nil function.
+func isNilTryHandler(h TryHandler) bool {
+	switch h := h.(type) {
+	case nil:
+		return true
+	case Handler:
+		return h == nil
+	case TryHandlerFunc:
+		return h == nil
+	}
+	return false
+}
@@ -0,0 +1,68 @@
+package cpanic_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/demosdemon/cpanic"
+)
+
+func TestFailover(t *testing.T) {
+	var calls []string
+	try := func(name string, err error) cpanic.TryHandler {
+		return cpanic.TryHandlerFunc(func(*cpanic.Panic) error {
+			calls = append(calls, name)
+			return err
+		})
+	}
+	errDown := errors.New("down")
+
+	tests := []struct {
+		name     string
+		handlers []cpanic.TryHandler
+		want     []string
+	}{
+		{
+			name:     "primary",
+			handlers: []cpanic.TryHandler{try("sentry", nil), try("disk", nil)},
+			want:     []string{"sentry"},
+		},
+		{
+			name:     "secondary",
+			handlers: []cpanic.TryHandler{try("sentry", errDown), nil, try("disk", nil)},
+			want:     []string{"sentry", "disk"},
+		},
+		{
+			name: "last resort",
+			handlers: []cpanic.TryHandler{
+				try("sentry", errDown),
+				try("disk", errDown),
+				cpanic.Handler(func(*cpanic.Panic) { calls = append(calls, "stderr") }),
+			},
+			want: []string{"sentry", "disk", "stderr"},
+		},
+		{
+			name: "nil functions",
+			handlers: []cpanic.TryHandler{
+				cpanic.Handler(nil),
+				cpanic.TryHandlerFunc(nil),
+				cpanic.Handler(func(*cpanic.Panic) { calls = append(calls, "stderr") }),
+			},
+			want: []string{"stderr"},
+		},
+		{
+			name:     "all fail",
+			handlers: []cpanic.TryHandler{try("sentry", errDown), try("disk", errDown)},
+			want:     []string{"sentry", "disk"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls = nil
+			cpanic.Failover(tt.handlers...).Handle(cpanic.New("not at a disco"))
+			assert.Equal(t, tt.want, calls)
+		})
+	}
+}
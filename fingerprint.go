@@ -0,0 +1,24 @@
+package cpanic
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"strings"
+)
+
+// Fingerprint returns a short, stable hash that groups panics with the same cause. It
+// is derived from the type of the panic value and the functions on the stack of the
+// panicking goroutine, so it is unaffected by the panic message, memory addresses, and
+// line numbers.
+func (p *Panic) Fingerprint() string {
+	h := sha256.New()
+	_, _ = io.WriteString(h, p.Type()+"\n")
+	for _, f := range p.Frames() {
+		if strings.HasPrefix(f.Function, "runtime.") {
+			continue
+		}
+		_, _ = io.WriteString(h, f.Function+"\n")
+	}
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
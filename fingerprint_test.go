@@ -0,0 +1,36 @@
+package cpanic_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/demosdemon/cpanic"
+)
+
+func panicHere(v interface{}) error {
+	return cpanic.Go(func() error { panic(v) })
+}
+
+func panicThere(v interface{}) error {
+	return cpanic.Go(func() error { panic(v) })
+}
+
+func fingerprint(t *testing.T, err error) string {
+	t.Helper()
+	var p *cpanic.Panic
+	if !errors.As(err, &p) {
+		t.Fatalf("expected a *cpanic.Panic, got %T", err)
+	}
+	return p.Fingerprint()
+}
+
+func TestFingerprint(t *testing.T) {
+	a := fingerprint(t, panicHere("not at a disco"))
+	assert.Len(t, a, 16)
+
+	assert.Equal(t, a, fingerprint(t, panicHere("at a disco")), "message should not matter")
+	assert.NotEqual(t, a, fingerprint(t, panicHere(errors.New("not at a disco"))), "type should matter")
+	assert.NotEqual(t, a, fingerprint(t, panicThere("not at a disco")), "location should matter")
+}
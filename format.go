@@ -0,0 +1,143 @@
+package cpanic
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Formatter renders a panic for output, such as to a log file, a terminal, or the body
+// of a bug report. A formatter may also have a `ContentType() string` method returning
+// the media type of its output, which is used by integrations that serve it over HTTP.
+type Formatter interface {
+	Format(p *Panic) ([]byte, error)
+}
+
+// FormatterFunc is a function that implements `Formatter`.
+type FormatterFunc func(p *Panic) ([]byte, error)
+
+// Format calls f.
+func (f FormatterFunc) Format(p *Panic) ([]byte, error) {
+	return f(p)
+}
+
+// The built-in formatters. Each output ends with a newline.
+var (
+	// Text renders the panic as `(*Panic).String` does, like the Go runtime.
+	Text Formatter = formatter{formatText, "text/plain; charset=utf-8"}
+	// JSON renders the panic as an indented JSON object with the fields written by
+	// `NDJSONHandler`.
+	JSON Formatter = formatter{formatJSON, "application/json"}
+	// NDJSON renders the panic as a single line of JSON, as written by `NDJSONHandler`.
+	NDJSON Formatter = formatter{formatNDJSON, "application/x-ndjson"}
+	// Markdown renders the panic as `(*Panic).Markdown` does.
+	Markdown Formatter = formatter{formatMarkdown, "text/markdown; charset=utf-8"}
+	// HTML renders the panic as an HTML fragment with the same content as `Markdown`.
+	HTML Formatter = formatter{formatHTML, "text/html; charset=utf-8"}
+)
+
+// formatter is a built-in formatter with its content type.
+type formatter struct {
+	format      func(p *Panic) ([]byte, error)
+	contentType string
+}
+
+func (f formatter) Format(p *Panic) ([]byte, error) {
+	return f.format(p)
+}
+
+func (f formatter) ContentType() string {
+	return f.contentType
+}
+
+// WriterHandler returns a handler that writes each panic to w as rendered by f. After
+// every panic, w is flushed if it has a `Flush() error` or `Sync() error` method, such
+// as a `*bufio.Writer` or an `*os.File`. Panics that f fails to render are dropped.
+// Writes are serialized, so the handler can be shared by concurrently recovering
+// goroutines.
+func WriterHandler(w io.Writer, f Formatter) Handler {
+	var mu sync.Mutex
+	return func(p *Panic) {
+		b, err := f.Format(p)
+		if err != nil {
+			return
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		if _, err := w.Write(b); err != nil {
+			return
+		}
+		flush(w)
+	}
+}
+
+func formatText(p *Panic) ([]byte, error) {
+	return []byte(strings.TrimRight(p.String(), "\n") + "\n"), nil
+}
+
+func formatJSON(p *Panic) ([]byte, error) {
+	b, err := json.MarshalIndent(newReport(p), "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(b, '\n'), nil
+}
+
+func formatNDJSON(p *Panic) ([]byte, error) {
+	b, err := json.Marshal(newReport(p))
+	if err != nil {
+		return nil, err
+	}
+	return append(b, '\n'), nil
+}
+
+func formatMarkdown(p *Panic) ([]byte, error) {
+	return []byte(p.Markdown()), nil
+}
+
+func formatHTML(p *Panic) ([]byte, error) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "<article class=\"cpanic\">\n<h3>%s</h3>\n<table>\n", html.EscapeString(p.Error()))
+	row := func(key, value string) {
+		fmt.Fprintf(&b, "<tr><th>%s</th><td>%s</td></tr>\n", key, value)
+	}
+	code := func(s string) string {
+		return "<code>" + html.EscapeString(s) + "</code>"
+	}
+	row("Type", code(p.Type()))
+	if p.ID != "" {
+		row("ID", code(p.ID))
+	}
+	row("Fingerprint", code(p.Fingerprint()))
+	if p.BuildID != "" {
+		row("Build ID", code(p.BuildID))
+	}
+	if f, ok := p.Culprit(); ok {
+		row("Culprit", code(f.String()))
+	}
+	if !p.Time.IsZero() {
+		row("Time", p.Time.UTC().Format(time.RFC3339))
+	}
+	keys := make([]string, 0, len(p.Attrs))
+	for k := range p.Attrs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		row(code(k), code(fmt.Sprint(p.Attrs[k])))
+	}
+	b.WriteString("</table>\n")
+
+	if p.Trace != "" {
+		fmt.Fprintf(&b, "<details>\n<summary>Stack trace</summary>\n<pre>%s</pre>\n</details>\n",
+			html.EscapeString(strings.TrimRight(p.Trace, "\n")))
+	}
+	b.WriteString("</article>\n")
+	return []byte(b.String()), nil
+}
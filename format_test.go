@@ -0,0 +1,87 @@
+package cpanic_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/demosdemon/cpanic"
+)
+
+func TestFormatters(t *testing.T) {
+	p := &cpanic.Panic{
+		ID:    "01F2AB3CD4EF5GH6JK7MN8PQRS",
+		Time:  time.Date(2021, 4, 1, 12, 0, 0, 0, time.UTC),
+		Value: "not at a <disco>",
+		Trace: "goroutine 1 [running]:\nmain.main()\n\t/src/main.go:3 +0x1d\n",
+	}
+	p.SetAttr("route", "/a&b")
+
+	cases := []struct {
+		name      string
+		formatter cpanic.Formatter
+		want      string
+	}{
+		{"text", cpanic.Text, "panic: not at a <disco>\n\ngoroutine 1 [running]:\nmain.main()\n\t/src/main.go:3 +0x1d\n"},
+		{"markdown", cpanic.Markdown, p.Markdown()},
+		{"html", cpanic.HTML, "<article class=\"cpanic\">\n" +
+			"<h3>panic: not at a &lt;disco&gt;</h3>\n<table>\n" +
+			"<tr><th>Type</th><td><code>string</code></td></tr>\n" +
+			"<tr><th>ID</th><td><code>01F2AB3CD4EF5GH6JK7MN8PQRS</code></td></tr>\n" +
+			"<tr><th>Fingerprint</th><td><code>" + p.Fingerprint() + "</code></td></tr>\n" +
+			"<tr><th>Culprit</th><td><code>main.main (/src/main.go:3)</code></td></tr>\n" +
+			"<tr><th>Time</th><td>2021-04-01T12:00:00Z</td></tr>\n" +
+			"<tr><th><code>route</code></th><td><code>/a&amp;b</code></td></tr>\n" +
+			"</table>\n<details>\n<summary>Stack trace</summary>\n" +
+			"<pre>goroutine 1 [running]:\nmain.main()\n\t/src/main.go:3 +0x1d</pre>\n</details>\n" +
+			"</article>\n"},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			b, err := tc.formatter.Format(p)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, string(b))
+		})
+	}
+}
+
+func TestFormatterJSON(t *testing.T) {
+	p := cpanic.New("not at a disco")
+	for _, f := range []cpanic.Formatter{cpanic.JSON, cpanic.NDJSON} {
+		b, err := f.Format(p)
+		assert.NoError(t, err)
+		assert.True(t, bytes.HasSuffix(b, []byte("}\n")), string(b))
+
+		var record map[string]interface{}
+		assert.NoError(t, json.Unmarshal(b, &record))
+		assert.Equal(t, "not at a disco", record["value"])
+		assert.Equal(t, p.Fingerprint(), record["fingerprint"])
+	}
+
+	b, _ := cpanic.NDJSON.Format(p)
+	assert.Equal(t, 1, bytes.Count(b, []byte("\n")))
+	b, _ = cpanic.JSON.Format(p)
+	assert.True(t, bytes.HasPrefix(b, []byte("{\n  \"id\": ")), string(b))
+}
+
+func TestWriterHandler(t *testing.T) {
+	var buf bytes.Buffer
+	failing := cpanic.FormatterFunc(func(p *cpanic.Panic) ([]byte, error) {
+		if p.Value == "fail" {
+			return nil, errors.New("cannot format")
+		}
+		return []byte(p.Error() + "\n"), nil
+	})
+	h := cpanic.WriterHandler(&buf, failing)
+	h(cpanic.New("first"))
+	h(cpanic.New("fail"))
+	h(cpanic.New("second"))
+	assert.Equal(t, []string{"panic: first", "panic: second"}, strings.Split(strings.TrimSpace(buf.String()), "\n"))
+}
@@ -0,0 +1,141 @@
+//go:build !tinygo && !cpanic_minimal
+// +build !tinygo,!cpanic_minimal
+
+package cpanic
+
+import (
+	"bytes"
+	"debug/elf"
+	"debug/gosym"
+	"debug/macho"
+	"encoding/hex"
+	"errors"
+	"io"
+	"os"
+	"runtime"
+	"runtime/pprof"
+	"time"
+)
+
+// captureTrace returns the stack traces of all goroutines, up to 64 KiB.
+func captureTrace() string {
+	var trace [1 << 16]byte
+	n := runtime.Stack(trace[:], true)
+	return string(trace[:n])
+}
+
+// executableBuildID reads the build ID from the notes of the running ELF executable,
+// preferring the GNU build ID.
+func executableBuildID() string {
+	exe, err := os.Executable()
+	if err != nil {
+		return ""
+	}
+	f, err := elf.Open(exe)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	if id := elfNote(f, ".note.gnu.build-id", "GNU"); id != nil {
+		return hex.EncodeToString(id)
+	}
+	return string(elfNote(f, ".note.go.buildid", "Go"))
+}
+
+// elfNote returns the descriptor of the first note in the section with the owner name.
+func elfNote(f *elf.File, section, name string) []byte {
+	sect := f.Section(section)
+	if sect == nil {
+		return nil
+	}
+	data, err := sect.Data()
+	if err != nil {
+		return nil
+	}
+
+	align := func(n uint32) uint32 { return (n + 3) &^ 3 }
+	for len(data) >= 12 {
+		namesz := f.ByteOrder.Uint32(data[0:4])
+		descsz := f.ByteOrder.Uint32(data[4:8])
+		data = data[12:]
+		if uint64(align(namesz))+uint64(align(descsz)) > uint64(len(data)) {
+			return nil
+		}
+		owner := bytes.TrimRight(data[:namesz], "\x00")
+		desc := data[align(namesz) : align(namesz)+descsz]
+		if string(owner) == name {
+			return desc
+		}
+		data = data[align(namesz)+align(descsz):]
+	}
+	return nil
+}
+
+// cpuProfile captures a CPU profile of the process for d, unless another one is
+// running.
+func cpuProfile(d time.Duration) ([]byte, bool) {
+	var buf bytes.Buffer
+	if err := pprof.StartCPUProfile(&buf); err != nil {
+		return nil, false
+	}
+	time.Sleep(d)
+	pprof.StopCPUProfile()
+	return buf.Bytes(), true
+}
+
+// Symbolize resolves program counters recorded by `SetProgramCounters` to frames using
+// the symbol table of the exact binary that recorded them, read from table, which may
+// be an unstripped copy of a binary deployed stripped. ELF and Mach-O binaries are
+// supported. The frames correspond to the program counters; those that cannot be
+// resolved have an empty `Function`.
+func Symbolize(pcs []uintptr, table io.ReaderAt) ([]Frame, error) {
+	pclntab, text, err := readLineTable(table)
+	if err != nil {
+		return nil, err
+	}
+	tab, err := gosym.NewTable(nil, gosym.NewLineTable(pclntab, text))
+	if err != nil {
+		return nil, err
+	}
+	anchor := tab.LookupFunc(anchorFunc)
+	if anchor == nil {
+		return nil, errors.New("cpanic: binary does not contain " + anchorFunc)
+	}
+
+	frames := make([]Frame, len(pcs))
+	for i, pc := range pcs {
+		// Each program counter is a return address; look up the call instruction.
+		file, line, fn := tab.PCToLine(uint64(pc+uintptr(anchor.Entry)) - 1)
+		if fn != nil {
+			frames[i] = Frame{Function: fn.Name, File: file, Line: line}
+		}
+	}
+	return frames, nil
+}
+
+// readLineTable reads the Go line table and the address of the text segment from an
+// executable.
+func readLineTable(r io.ReaderAt) (pclntab []byte, text uint64, err error) {
+	if f, err := elf.NewFile(r); err == nil {
+		defer f.Close()
+		sect, textSect := f.Section(".gopclntab"), f.Section(".text")
+		if sect == nil || textSect == nil {
+			return nil, 0, errors.New("cpanic: ELF binary has no Go line table")
+		}
+		pclntab, err = sect.Data()
+		return pclntab, textSect.Addr, err
+	}
+
+	if f, err := macho.NewFile(r); err == nil {
+		defer f.Close()
+		sect, textSect := f.Section("__gopclntab"), f.Section("__text")
+		if sect == nil || textSect == nil {
+			return nil, 0, errors.New("cpanic: Mach-O binary has no Go line table")
+		}
+		pclntab, err = sect.Data()
+		return pclntab, textSect.Addr, err
+	}
+
+	return nil, 0, errors.New("cpanic: unsupported binary format")
+}
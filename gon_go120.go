@@ -0,0 +1,49 @@
+//go:build go1.20
+// +build go1.20
+
+package cpanic
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// GoN calls the function in n goroutines, passing each its index, and waits for all of
+// them to return. Each goroutine is recovered independently, like `Go`. The error
+// returned is an `errors.Join` of every error and `*Panic`, ordered by index, or nil if
+// all goroutines succeeded. If n is zero or negative, no goroutine is started and the
+// error is nil.
+func GoN(n int, fn func(i int) error) error {
+	return GoNContext(context.Background(), n, func(_ context.Context, i int) error {
+		return fn(i)
+	}, false)
+}
+
+// GoNContext is like `GoN` but passes each goroutine a context derived from ctx. If
+// cancelOnError is true, the context is canceled as soon as any goroutine returns an
+// error or panics, so its siblings can stop early.
+func GoNContext(ctx context.Context, n int, fn func(ctx context.Context, i int) error, cancelOnError bool) error {
+	if n <= 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	errs := make([]error, n)
+	var wg sync.WaitGroup
+	wg.Add(n)
+	for i := 0; i < n; i++ {
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = goContext(ctx, func() error { return fn(ctx, i) })
+			if errs[i] != nil && cancelOnError {
+				cancel()
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,50 @@
+//go:build go1.20
+// +build go1.20
+
+package cpanic_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/demosdemon/cpanic"
+)
+
+func TestGoN(t *testing.T) {
+	var calls int32
+	err := cpanic.GoN(4, func(i int) error {
+		atomic.AddInt32(&calls, 1)
+		switch i {
+		case 1:
+			panic("not at a disco")
+		case 3:
+			return fmt.Errorf("worker %d", i)
+		}
+		return nil
+	})
+
+	assert.Equal(t, int32(4), atomic.LoadInt32(&calls))
+	assert.EqualError(t, err, "panic: not at a disco\nworker 3")
+	assert.True(t, errors.Is(err, cpanic.ErrPanic))
+
+	assert.NoError(t, cpanic.GoN(3, func(int) error { return nil }))
+	assert.NoError(t, cpanic.GoN(0, func(int) error { return errors.New("never called") }))
+	assert.NoError(t, cpanic.GoN(-1, func(int) error { return errors.New("never called") }))
+}
+
+func TestGoNContext(t *testing.T) {
+	err := cpanic.GoNContext(context.Background(), 3, func(ctx context.Context, i int) error {
+		if i == 0 {
+			panic("not at a disco")
+		}
+		<-ctx.Done()
+		return ctx.Err()
+	}, true)
+
+	assert.EqualError(t, err, "panic: not at a disco\ncontext canceled\ncontext canceled")
+}
@@ -0,0 +1,199 @@
+package cpanic
+
+import (
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// Goroutine is a single goroutine parsed from the trace of a panic.
+type Goroutine struct {
+	// ID is the goroutine's unique identifier, as printed in the trace.
+	ID int `json:"id" yaml:"id"`
+	// State is the scheduling state of the goroutine, such as "running" or
+	// "chan receive".
+	State string `json:"state" yaml:"state"`
+	// Labels are the goroutine's `runtime/pprof` labels, such as those set by
+	// `pprof.Do` or `pprof.SetGoroutineLabels`, which tie the goroutine back to request
+	// IDs or tenants.
+	Labels map[string]string `json:"labels,omitempty" yaml:"labels,omitempty"`
+	// Frames are the function calls of the goroutine, innermost call first.
+	Frames []Frame `json:"frames" yaml:"frames"`
+	// CreatedBy is the go statement that started the goroutine. It is the zero value for
+	// the main goroutine.
+	CreatedBy Frame `json:"created_by" yaml:"created_by"`
+}
+
+// Goroutines parses every goroutine in the trace, in the order they were printed: the
+// goroutine that created the panic is first. Unlike `Frames`, no frames are omitted.
+//
+// Labels are only printed in traces by Go 1.27 and later, and only if the
+// `tracebacklabels` GODEBUG setting is enabled, which is the default as of Go 1.27 for
+// main modules declaring that version. Otherwise, set `GODEBUG=tracebacklabels=1`.
+func (p *Panic) Goroutines() []Goroutine {
+	return parseGoroutines(p.Trace)
+}
+
+// SnapshotGoroutines returns every goroutine currently running, for comparison with a
+// later snapshot by `CompareSnapshots`. Unlike the trace of a `*Panic`, the snapshot is
+// never truncated.
+func SnapshotGoroutines() []Goroutine {
+	buf := make([]byte, 1<<16)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) {
+			return parseGoroutines(string(buf[:n]))
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+}
+
+// CompareSnapshots returns the goroutines in b that are not in a, such as goroutines
+// leaked by the code run between the two snapshots. Goroutines are matched by the go
+// statement that created them rather than by ID, so a goroutine that was replaced by
+// another started from the same place is not reported.
+//
+//	before := cpanic.SnapshotGoroutines()
+//	runServer()
+//	leaked := cpanic.CompareSnapshots(before, cpanic.SnapshotGoroutines())
+func CompareSnapshots(a, b []Goroutine) []Goroutine {
+	sites := make(map[Frame]int, len(a))
+	for _, g := range a {
+		sites[g.CreatedBy]++
+	}
+
+	var added []Goroutine
+	for _, g := range b {
+		if sites[g.CreatedBy] > 0 {
+			sites[g.CreatedBy]--
+			continue
+		}
+		added = append(added, g)
+	}
+	return added
+}
+
+// parseGoroutines parses every goroutine in a trace of all goroutines.
+func parseGoroutines(trace string) []Goroutine {
+	var goroutines []Goroutine
+	for _, block := range strings.Split(trace, "\n\n") {
+		lines := strings.Split(strings.TrimSpace(block), "\n")
+		g, ok := parseGoroutineHeader(lines[0])
+		if !ok {
+			continue
+		}
+		g.Frames, g.CreatedBy = parseFrames(lines[1:])
+		goroutines = append(goroutines, g)
+	}
+	return goroutines
+}
+
+// parseGoroutineHeader parses a line of the form
+// "goroutine 7 [chan receive, 5 minutes] {request_id: abc}:".
+func parseGoroutineHeader(line string) (Goroutine, bool) {
+	var g Goroutine
+	if !strings.HasPrefix(line, "goroutine ") || !strings.HasSuffix(line, ":") {
+		return g, false
+	}
+	line = strings.TrimSuffix(strings.TrimPrefix(line, "goroutine "), ":")
+
+	var err error
+	fields := strings.SplitN(line, " ", 2)
+	if g.ID, err = strconv.Atoi(fields[0]); err != nil || len(fields) < 2 {
+		return g, false
+	}
+
+	start := strings.IndexByte(fields[1], '[')
+	end := strings.IndexByte(fields[1], ']')
+	if start < 0 || end < start {
+		return g, false
+	}
+	g.State = strings.SplitN(fields[1][start+1:end], ",", 2)[0]
+
+	if rest := strings.TrimSpace(fields[1][end+1:]); strings.HasPrefix(rest, "{") {
+		g.Labels = parseLabels(rest)
+	}
+	return g, true
+}
+
+// parseLabels parses labels of the form `{key: value, "quoted key": "quoted value"}`.
+// Parsing stops at the first malformed label.
+func parseLabels(s string) map[string]string {
+	s = strings.TrimSuffix(strings.TrimPrefix(s, "{"), "}")
+	labels := make(map[string]string)
+	for s != "" {
+		key, rest, ok := parseLabelToken(s, ": ")
+		if !ok {
+			break
+		}
+		value, rest, ok := parseLabelToken(rest, ", ")
+		if !ok {
+			break
+		}
+		labels[key] = value
+		s = rest
+	}
+	return labels
+}
+
+// parseLabelToken parses a bare or quoted token followed by sep, or the end of s.
+func parseLabelToken(s, sep string) (token, rest string, ok bool) {
+	if strings.HasPrefix(s, `"`) {
+		end := 1
+		for end < len(s) && s[end] != '"' {
+			if s[end] == '\\' {
+				end++
+			}
+			end++
+		}
+		if end >= len(s) {
+			return "", "", false
+		}
+		token, err := strconv.Unquote(s[:end+1])
+		if err != nil {
+			return "", "", false
+		}
+		rest = s[end+1:]
+		if rest != "" && !strings.HasPrefix(rest, sep) {
+			return "", "", false
+		}
+		return token, strings.TrimPrefix(rest, sep), true
+	}
+
+	if idx := strings.Index(s, sep); idx >= 0 {
+		return s[:idx], s[idx+len(sep):], true
+	}
+	return s, "", true
+}
+
+// parseFrames parses the frames following a goroutine header, returning the frame of
+// the go statement that created the goroutine separately.
+func parseFrames(lines []string) (frames []Frame, createdBy Frame) {
+	for i := 0; i < len(lines) && lines[i] != ""; i++ {
+		line := lines[i]
+		if strings.HasPrefix(line, "...") || strings.HasPrefix(line, "\t") {
+			continue
+		}
+
+		var f Frame
+		if strings.HasPrefix(line, "created by ") {
+			f.Function = strings.TrimPrefix(line, "created by ")
+			if idx := strings.Index(f.Function, " in goroutine "); idx >= 0 {
+				f.Function = f.Function[:idx]
+			}
+		} else {
+			f.Function = functionName(line)
+		}
+		if i+1 < len(lines) && strings.HasPrefix(lines[i+1], "\t") {
+			i++
+			f.File, f.Line = parseFileLine(lines[i])
+		}
+
+		if strings.HasPrefix(line, "created by ") {
+			createdBy = f
+			break
+		}
+		frames = append(frames, f)
+	}
+	return frames, createdBy
+}
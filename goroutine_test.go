@@ -0,0 +1,109 @@
+package cpanic_test
+
+import (
+	"context"
+	"runtime/pprof"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/demosdemon/cpanic"
+)
+
+const labeledTrace = `goroutine 1 [running] {request_id: abc, tenant: t1}:
+main.main.func1({0x5e7e40?, 0x74cb8fc81e0?})
+	/src/main.go:17 +0x51
+runtime/pprof.Do({0x5e7e08?, 0x614400?}, {{0x74cb8fc2080?, 0x414b1d?, 0x0?}}, 0x5e8258)
+	/usr/local/go/src/runtime/pprof/runtime.go:57 +0x8c
+main.main()
+	/src/main.go:13 +0x9a
+
+goroutine 7 [chan receive, 12 minutes] {"user name": "a \"quoted\" value", path: /v1/x}:
+main.worker()
+	/src/main.go:30 +0x1d
+...additional frames elided...
+created by main.main.func1 in goroutine 1
+	/src/main.go:14 +0x1e
+
+goroutine 8 [select]:
+main.idle()
+	/src/main.go:40 +0x1d
+created by main.main
+	/src/main.go:15 +0x2e
+`
+
+func TestGoroutines(t *testing.T) {
+	p := &cpanic.Panic{Trace: labeledTrace}
+	assert.Equal(t, []cpanic.Goroutine{
+		{
+			ID:     1,
+			State:  "running",
+			Labels: map[string]string{"request_id": "abc", "tenant": "t1"},
+			Frames: []cpanic.Frame{
+				{Function: "main.main.func1", File: "/src/main.go", Line: 17},
+				{Function: "runtime/pprof.Do", File: "/usr/local/go/src/runtime/pprof/runtime.go", Line: 57},
+				{Function: "main.main", File: "/src/main.go", Line: 13},
+			},
+		},
+		{
+			ID:     7,
+			State:  "chan receive",
+			Labels: map[string]string{"user name": `a "quoted" value`, "path": "/v1/x"},
+			Frames: []cpanic.Frame{
+				{Function: "main.worker", File: "/src/main.go", Line: 30},
+			},
+			CreatedBy: cpanic.Frame{Function: "main.main.func1", File: "/src/main.go", Line: 14},
+		},
+		{
+			ID:    8,
+			State: "select",
+			Frames: []cpanic.Frame{
+				{Function: "main.idle", File: "/src/main.go", Line: 40},
+			},
+			CreatedBy: cpanic.Frame{Function: "main.main", File: "/src/main.go", Line: 15},
+		},
+	}, p.Goroutines())
+
+	assert.Equal(t, []cpanic.Frame{
+		{Function: "main.main.func1", File: "/src/main.go", Line: 17},
+		{Function: "runtime/pprof.Do", File: "/usr/local/go/src/runtime/pprof/runtime.go", Line: 57},
+		{Function: "main.main", File: "/src/main.go", Line: 13},
+	}, p.Frames())
+}
+
+func TestGoroutinesLive(t *testing.T) {
+	var p *cpanic.Panic
+	pprof.Do(context.Background(), pprof.Labels("request_id", "abc"), func(context.Context) {
+		p = cpanic.New("not at a disco")
+	})
+
+	goroutines := p.Goroutines()
+	require.NotEmpty(t, goroutines)
+	assert.Equal(t, "running", goroutines[0].State)
+	if !strings.Contains(strings.SplitN(p.Trace, "\n", 2)[0], "{") {
+		t.Skip("the runtime does not print goroutine labels; set GODEBUG=tracebacklabels=1 on Go 1.27+")
+	}
+	assert.Equal(t, map[string]string{"request_id": "abc"}, goroutines[0].Labels)
+}
+
+func TestCompareSnapshots(t *testing.T) {
+	before := cpanic.SnapshotGoroutines()
+
+	stop := make(chan struct{})
+	defer close(stop)
+	for i := 0; i < 2; i++ {
+		go func() { <-stop }()
+	}
+
+	leaked := cpanic.CompareSnapshots(before, cpanic.SnapshotGoroutines())
+	if assert.Len(t, leaked, 2) {
+		for _, g := range leaked {
+			assert.Equal(t, "github.com/demosdemon/cpanic_test.TestCompareSnapshots", g.CreatedBy.Function)
+		}
+	}
+
+	assert.Empty(t, cpanic.CompareSnapshots(leaked, leaked))
+	assert.Len(t, cpanic.CompareSnapshots(leaked[:1], leaked), 1)
+}
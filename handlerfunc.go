@@ -0,0 +1,47 @@
+package cpanic
+
+import "context"
+
+// HandlerFunc is a handler that takes a context and returns an error, so timeouts,
+// cancellation, and failures can propagate through a chain of middleware and reporters,
+// which the fire-and-forget `Handler` cannot express. It implements `TryHandler`.
+type HandlerFunc func(ctx context.Context, p *Panic) error
+
+// FromHandler adapts h to a `HandlerFunc` that ignores the context and never fails.
+func FromHandler(h Handler) HandlerFunc {
+	if h == nil {
+		return nil
+	}
+	return func(_ context.Context, p *Panic) error {
+		h(p)
+		return nil
+	}
+}
+
+// HandleContext calls f with the context and the panic unless f is nil, in which case
+// it returns nil. As with `(Handler).Handle`, suppressions and `PipelineStats` are
+// applied where the panic is recovered instead.
+func (f HandlerFunc) HandleContext(ctx context.Context, p *Panic) error {
+	if f == nil {
+		return nil
+	}
+	return f(ctx, p)
+}
+
+// TryHandle calls `HandleContext` with a background context.
+func (f HandlerFunc) TryHandle(p *Panic) error {
+	return f.HandleContext(context.Background(), p)
+}
+
+// Handler adapts f to a `Handler` that calls it with a background context, discarding
+// its error, so it can be passed to `Recover` and the integrations.
+func (f HandlerFunc) Handler() Handler {
+	if f == nil {
+		return nil
+	}
+	return func(p *Panic) {
+		if err := f(context.Background(), p); err != nil {
+			countFailure()
+		}
+	}
+}
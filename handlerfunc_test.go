@@ -0,0 +1,65 @@
+package cpanic_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/demosdemon/cpanic"
+)
+
+type ctxKey struct{}
+
+func TestHandlerFunc(t *testing.T) {
+	errDown := errors.New("down")
+	var got []context.Context
+	f := cpanic.HandlerFunc(func(ctx context.Context, p *cpanic.Panic) error {
+		got = append(got, ctx)
+		return errDown
+	})
+
+	ctx := context.WithValue(context.Background(), ctxKey{}, "value")
+	assert.Equal(t, errDown, f.HandleContext(ctx, cpanic.New("not at a disco")))
+	assert.Equal(t, errDown, f.TryHandle(cpanic.New("not at a disco")))
+	func() {
+		defer cpanic.Recover(f.Handler())
+		panic("not at a disco")
+	}()
+
+	if assert.Len(t, got, 3) {
+		assert.Equal(t, "value", got[0].Value(ctxKey{}))
+		assert.Equal(t, context.Background(), got[1])
+		assert.Equal(t, context.Background(), got[2])
+	}
+}
+
+func TestHandlerFuncSuppressed(t *testing.T) {
+	defer cpanic.Suppress(cpanic.MatchType(benign{}))()
+
+	called := false
+	f := cpanic.HandlerFunc(func(context.Context, *cpanic.Panic) error {
+		called = true
+		return errors.New("down")
+	})
+	func() {
+		defer cpanic.Recover(f.Handler())
+		panic(benign{})
+	}()
+	assert.False(t, called)
+
+	assert.Error(t, f.HandleContext(context.Background(), cpanic.New(benign{})))
+	assert.True(t, called, "suppressions apply where panics are recovered")
+}
+
+func TestFromHandler(t *testing.T) {
+	assert.Nil(t, cpanic.FromHandler(nil))
+	assert.Nil(t, cpanic.HandlerFunc(nil).Handler())
+
+	var handled *cpanic.Panic
+	f := cpanic.FromHandler(func(p *cpanic.Panic) { handled = p })
+	p := cpanic.New("not at a disco")
+	assert.NoError(t, f.HandleContext(context.Background(), p))
+	assert.Same(t, p, handled)
+}
@@ -0,0 +1,170 @@
+//go:build !tinygo && !cpanic_minimal
+// +build !tinygo,!cpanic_minimal
+
+package cpanic
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// HarnessEnv is the environment variable set in the child process started by
+// `Harness`. Its value is the number of times the child has been restarted.
+const HarnessEnv = "CPANIC_HARNESS"
+
+// RestartsAttr is the attribute holding the number of times the child process run by
+// `Harness` had been restarted when it crashed.
+const RestartsAttr = "restarts"
+
+// HarnessOption configures `Harness`.
+type HarnessOption func(*harnessConfig)
+
+type harnessConfig struct {
+	handler     Handler
+	maxRestarts int
+	backoff     time.Duration
+	maxBackoff  time.Duration
+}
+
+// WithCrashHandler reports every crash of the child process to h.
+func WithCrashHandler(h Handler) HarnessOption {
+	return func(c *harnessConfig) {
+		c.handler = h
+	}
+}
+
+// WithRestarts restarts the child process after up to max consecutive crashes. If max
+// is negative, the child is always restarted. By default, the child is not restarted.
+func WithRestarts(max int) HarnessOption {
+	return func(c *harnessConfig) {
+		c.maxRestarts = max
+	}
+}
+
+// WithBackoff waits initial before the first restart, doubling the wait after each
+// consecutive crash up to max. It defaults to one second and one minute.
+func WithBackoff(initial, max time.Duration) HarnessOption {
+	return func(c *harnessConfig) {
+		c.backoff = initial
+		c.maxBackoff = max
+	}
+}
+
+// Harness runs main in a monitored child process, a supervision layer for processes
+// deployed without one. The binary is re-executed with the same arguments and
+// `HarnessEnv` set; in the child, `Harness` calls main and returns. In the parent, the
+// crash of the child, including unrecoverable runtime fatal errors, is parsed as with
+// `Command`, tagged with `RestartsAttr`, reported to the handler, and the child is
+// restarted according to the options. A child killed by a signal it was not forwarded,
+// such as by the OOM killer, leaves no crash dump; it is reported as a `*Panic` of the
+// `*exec.ExitError` and restarted the same way. A child that runs for longer than the
+// maximum backoff is considered healthy and resets the backoff and restart count.
+// Interrupt and termination signals are forwarded to the child, which is then not
+// restarted.
+//
+// The parent exits with the exit code of the last child and never returns.
+//
+//	func main() {
+//		cpanic.Harness(run, cpanic.WithRestarts(-1), cpanic.WithCrashHandler(report))
+//	}
+func Harness(main func(), opts ...HarnessOption) {
+	if _, ok := os.LookupEnv(HarnessEnv); ok {
+		main()
+		return
+	}
+
+	cfg := harnessConfig{
+		backoff:    time.Second,
+		maxBackoff: time.Minute,
+	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	os.Exit(supervise(&cfg))
+}
+
+func supervise(cfg *harnessConfig) int {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signals)
+
+	backoff := cfg.backoff
+	failures := 0
+	for restarts := 0; ; restarts++ {
+		start := time.Now()
+		stopped, err := runChild(restarts, signals)
+
+		var p *Panic
+		var exitErr *exec.ExitError
+		if !stopped && errors.As(err, &exitErr) && exitErr.ExitCode() < 0 {
+			p = NewEvent(exitErr)
+			p.SetAttr(ExitCodeAttr, exitErr.ExitCode())
+		} else if !errors.As(err, &p) {
+			if code := ExitCode(err); code >= 0 {
+				return code
+			}
+			return 1
+		}
+		p.SetAttr(RestartsAttr, restarts)
+		cfg.handler.Handle(p)
+
+		code, _ := p.Attrs[ExitCodeAttr].(int)
+		if code <= 0 {
+			code = ExitCodePanic
+		}
+		if time.Since(start) > cfg.maxBackoff {
+			backoff = cfg.backoff
+			failures = 0
+		}
+		failures++
+		if stopped || (cfg.maxRestarts >= 0 && failures > cfg.maxRestarts) {
+			return code
+		}
+
+		select {
+		case <-signals:
+			return code
+		case <-time.After(backoff):
+		}
+		if backoff *= 2; backoff > cfg.maxBackoff {
+			backoff = cfg.maxBackoff
+		}
+	}
+}
+
+// runChild runs the child process to completion, forwarding signals to it. It reports
+// whether a signal was forwarded.
+func runChild(restarts int, signals <-chan os.Signal) (stopped bool, err error) {
+	name, err := os.Executable()
+	if err != nil {
+		name = os.Args[0]
+	}
+	cmd := Command(context.Background(), name, os.Args[1:]...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.Env = append(os.Environ(), HarnessEnv+"="+strconv.Itoa(restarts))
+	if err := cmd.Start(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return false, err
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- cmd.Wait() }()
+	for {
+		select {
+		case sig := <-signals:
+			stopped = true
+			_ = cmd.Process.Signal(sig)
+		case err := <-done:
+			return stopped, err
+		}
+	}
+}
@@ -0,0 +1,57 @@
+//go:build !tinygo && !cpanic_minimal
+// +build !tinygo,!cpanic_minimal
+
+package cpanic_test
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/demosdemon/cpanic"
+)
+
+// harnessOptions configures the harness run by TestHelperProcess to print each crash.
+func harnessOptions(restarts int) []cpanic.HarnessOption {
+	return []cpanic.HarnessOption{
+		cpanic.WithRestarts(restarts),
+		cpanic.WithBackoff(time.Millisecond, time.Minute),
+		cpanic.WithCrashHandler(func(p *cpanic.Panic) {
+			fmt.Fprintf(os.Stderr, "crash %v after %d restarts\n", p.Value, p.Attrs[cpanic.RestartsAttr])
+		}),
+	}
+}
+
+func TestHarness(t *testing.T) {
+	cases := []struct {
+		behavior string
+		code     int
+		crashes  int
+	}{
+		{"harness", 0, 2},
+		{"harness-give-up", cpanic.ExitCodePanic, 2},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.behavior, func(t *testing.T) {
+			out, err := helperCommand(tc.behavior).CombinedOutput()
+			assert.Equal(t, tc.code, cpanic.ExitCode(err), "%v", err)
+			assert.Equal(t, tc.crashes, strings.Count(string(out), "crash not at a disco"), string(out))
+			for i := 0; i < tc.crashes; i++ {
+				assert.Contains(t, string(out), fmt.Sprintf("after %d restarts\n", i))
+			}
+		})
+	}
+}
+
+func TestHarnessKilled(t *testing.T) {
+	out, err := helperCommand("harness-killed").CombinedOutput()
+	assert.Equal(t, 0, cpanic.ExitCode(err), "%v", err)
+	assert.Equal(t, 1, strings.Count(string(out), "crash "), string(out))
+	assert.Contains(t, string(out), "after 0 restarts\n")
+}
@@ -0,0 +1,91 @@
+package cpanic
+
+import (
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// closureSuffixPattern matches the suffix the compiler adds to the name of a closure,
+// such as ".func1", or ".1" when the enclosing function was inlined.
+var closureSuffixPattern = regexp.MustCompile(`\.(?:func|gowrap|deferwrap)?\d+$`)
+
+var helpers struct {
+	sync.RWMutex
+	names map[string]int
+}
+
+// RegisterHelper marks the named function as a helper, like `testing.T.Helper`, so
+// that wrappers such as middlewares and `Must` functions are skipped by `Culprit` and
+// elided from the trace rendered by `String`, pointing blame at the application code
+// that called them. The name is the fully qualified function name as it appears in
+// stack traces, such as "github.com/me/app.Must"; closures defined within the function
+// are also treated as helpers. It returns a function that unregisters the helper, such
+// as at the end of a test; a name registered more than once remains a helper until
+// every registration is undone.
+func RegisterHelper(funcName string) (unregister func()) {
+	helpers.Lock()
+	defer helpers.Unlock()
+	if helpers.names == nil {
+		helpers.names = make(map[string]int)
+	}
+	helpers.names[funcName]++
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			helpers.Lock()
+			defer helpers.Unlock()
+			if helpers.names[funcName]--; helpers.names[funcName] <= 0 {
+				delete(helpers.names, funcName)
+			}
+		})
+	}
+}
+
+// isHelper reports whether the function, or the function enclosing a closure, was
+// registered with `RegisterHelper`.
+func isHelper(fn string) bool {
+	helpers.RLock()
+	defer helpers.RUnlock()
+	if len(helpers.names) == 0 {
+		return false
+	}
+
+	for {
+		if _, ok := helpers.names[fn]; ok {
+			return true
+		}
+
+		loc := closureSuffixPattern.FindStringIndex(fn)
+		if loc == nil {
+			return false
+		}
+		fn = fn[:loc[0]]
+	}
+}
+
+// elideHelpers removes the frames of registered helpers from every goroutine in the
+// trace.
+func elideHelpers(trace string) string {
+	helpers.RLock()
+	n := len(helpers.names)
+	helpers.RUnlock()
+	if n == 0 {
+		return trace
+	}
+
+	lines := strings.Split(trace, "\n")
+	kept := lines[:0]
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		if line != "" && !strings.HasPrefix(line, "\t") && isHelper(functionName(line)) {
+			if i+1 < len(lines) && strings.HasPrefix(lines[i+1], "\t") {
+				i++
+			}
+			continue
+		}
+		kept = append(kept, line)
+	}
+	return strings.Join(kept, "\n")
+}
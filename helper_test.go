@@ -0,0 +1,31 @@
+package cpanic_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/demosdemon/cpanic"
+)
+
+func mustPositive(n int) int {
+	if n <= 0 {
+		func() { panic("not positive") }()
+	}
+	return n
+}
+
+func TestRegisterHelper(t *testing.T) {
+	p := recovered(func() { mustPositive(0) })
+	f, _ := p.Culprit()
+	assert.True(t, strings.HasPrefix(f.Function, "github.com/demosdemon/cpanic_test.mustPositive."), f.Function)
+	assert.Contains(t, p.String(), "cpanic_test.mustPositive(")
+
+	defer cpanic.RegisterHelper("github.com/demosdemon/cpanic_test.mustPositive")()
+
+	f, _ = p.Culprit()
+	assert.True(t, strings.HasPrefix(f.Function, "github.com/demosdemon/cpanic_test.TestRegisterHelper."), f.Function)
+	assert.False(t, strings.Contains(p.String(), "cpanic_test.mustPositive"), p.String())
+	assert.Contains(t, p.Trace, "cpanic_test.mustPositive(", "the raw trace is untouched")
+}
@@ -0,0 +1,267 @@
+package cpanic
+
+import (
+	"sync"
+	"time"
+	"unsafe"
+)
+
+// Retention controls what a `History` keeps of each recorded panic.
+type Retention uint8
+
+const (
+	// KeepFrames parses the trace when the panic is recorded and keeps the frames of the
+	// panicking goroutine in `Panic.Stack`. The function and file names of the frames are
+	// interned, so panics recorded from the same code share them.
+	KeepFrames Retention = 1 << iota
+	// DropRawTrace discards the raw trace, which is usually the bulk of the memory held
+	// by a panic, once it has been recorded.
+	DropRawTrace
+)
+
+// HistoryOption configures a `History`.
+type HistoryOption func(*History)
+
+// WithRetention sets what the history keeps of each recorded panic.
+//
+//	cpanic.NewHistory(100, cpanic.WithRetention(cpanic.KeepFrames, cpanic.DropRawTrace))
+func WithRetention(policies ...Retention) HistoryOption {
+	return func(h *History) {
+		for _, r := range policies {
+			h.retention |= r
+		}
+	}
+}
+
+// WithMemoryBudget limits the approximate number of bytes held by the recorded panics.
+// The oldest panics are evicted once the budget is exceeded. The most recent panic is
+// always kept.
+func WithMemoryBudget(bytes int) HistoryOption {
+	return func(h *History) {
+		h.budget = bytes
+	}
+}
+
+// WithMaxAge evicts the panics that occurred more than d ago, or, for panics without a
+// time, that were recorded more than d ago. Expired panics are evicted whenever a panic
+// is recorded or read, and by `Compact`.
+func WithMaxAge(d time.Duration) HistoryOption {
+	return func(h *History) {
+		h.maxAge = d
+	}
+}
+
+// History is a bounded, in-memory record of the most recent panics. Its `Handle` method
+// can be used as a `Handler`.
+type History struct {
+	capacity  int
+	budget    int
+	maxAge    time.Duration
+	retention Retention
+
+	mu      sync.Mutex
+	entries []historyEntry
+	size    int
+	strings map[string]*internedString
+}
+
+type historyEntry struct {
+	panic    *Panic
+	size     int
+	recorded time.Time
+	interned bool
+}
+
+// internedString is a frame string shared by the recorded panics, counted once in the
+// size of the history.
+type internedString struct {
+	s    string
+	refs int
+}
+
+// NewHistory creates a history holding at most capacity panics. If capacity is zero or
+// less, the number of panics is only limited by the memory budget, if any.
+func NewHistory(capacity int, opts ...HistoryOption) *History {
+	h := &History{capacity: capacity}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
+}
+
+// Handle records the panic, applying the retention policy to a copy so that the panic
+// seen by other handlers is unchanged.
+func (h *History) Handle(p *Panic) {
+	cp := *p
+	if p.Attrs != nil {
+		cp.Attrs = make(map[string]interface{}, len(p.Attrs))
+		for k, v := range p.Attrs {
+			cp.Attrs[k] = v
+		}
+	}
+	keepFrames := h.retention&KeepFrames != 0
+	if keepFrames {
+		cp.Stack = append([]Frame(nil), p.Frames()...)
+	}
+	if h.retention&DropRawTrace != 0 {
+		cp.Trace = ""
+	}
+
+	entry := historyEntry{panic: &cp, recorded: time.Now(), interned: keepFrames}
+	entry.size = approximateSize(&cp)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.expireLocked(entry.recorded)
+	if entry.interned {
+		entry.size -= h.internLocked(cp.Stack)
+	}
+	h.entries = append(h.entries, entry)
+	h.size += entry.size
+	for len(h.entries) > 1 && ((h.capacity > 0 && len(h.entries) > h.capacity) || (h.budget > 0 && h.size > h.budget)) {
+		h.evictLocked(h.entries[0])
+		h.entries[0] = historyEntry{}
+		h.entries = h.entries[1:]
+	}
+}
+
+// Panics returns the recorded panics, oldest first.
+func (h *History) Panics() []*Panic {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.expireLocked(time.Now())
+	panics := make([]*Panic, len(h.entries))
+	for i, e := range h.entries {
+		panics[i] = e.panic
+	}
+	return panics
+}
+
+// Len returns the number of recorded panics.
+func (h *History) Len() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.expireLocked(time.Now())
+	return len(h.entries)
+}
+
+// Compact evicts the panics older than the maximum age set by `WithMaxAge`, releasing
+// their memory even if no panic is recorded or read. It implements `Compactor`.
+func (h *History) Compact() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.expireLocked(time.Now())
+	return nil
+}
+
+// expireLocked evicts the panics older than the maximum age.
+func (h *History) expireLocked(now time.Time) {
+	if h.maxAge <= 0 {
+		return
+	}
+
+	cutoff := now.Add(-h.maxAge)
+	kept := h.entries[:0]
+	for _, e := range h.entries {
+		t := e.panic.Time
+		if t.IsZero() {
+			t = e.recorded
+		}
+		if t.Before(cutoff) {
+			h.evictLocked(e)
+			continue
+		}
+		kept = append(kept, e)
+	}
+	for i := len(kept); i < len(h.entries); i++ {
+		h.entries[i] = historyEntry{}
+	}
+	h.entries = kept
+}
+
+// evictLocked releases the memory held by the entry.
+func (h *History) evictLocked(e historyEntry) {
+	h.size -= e.size
+	if e.interned {
+		for _, f := range e.panic.Stack {
+			h.releaseLocked(f.Function)
+			h.releaseLocked(f.File)
+		}
+	}
+}
+
+// internLocked replaces the function and file names of the frames with the interned
+// strings, interning new ones. It returns the number of bytes of the names, which are
+// counted in the size of the history once per string rather than per panic.
+func (h *History) internLocked(frames []Frame) int {
+	if h.strings == nil {
+		h.strings = make(map[string]*internedString)
+	}
+	n := 0
+	for i := range frames {
+		n += len(frames[i].Function) + len(frames[i].File)
+		frames[i].Function = h.internStringLocked(frames[i].Function)
+		frames[i].File = h.internStringLocked(frames[i].File)
+	}
+	return n
+}
+
+func (h *History) internStringLocked(s string) string {
+	if is, ok := h.strings[s]; ok {
+		is.refs++
+		return is.s
+	}
+	// Copy the string, which may be a slice of the raw trace being dropped.
+	s = string([]byte(s))
+	h.strings[s] = &internedString{s: s, refs: 1}
+	h.size += len(s)
+	return s
+}
+
+func (h *History) releaseLocked(s string) {
+	is, ok := h.strings[s]
+	if !ok {
+		return
+	}
+	if is.refs--; is.refs == 0 {
+		delete(h.strings, s)
+		h.size -= len(s)
+	}
+}
+
+// Size returns the approximate number of bytes held by the recorded panics.
+func (h *History) Size() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.expireLocked(time.Now())
+	return h.size
+}
+
+// approximateSize estimates the memory held by the panic.
+func approximateSize(p *Panic) int {
+	n := int(unsafe.Sizeof(*p)) + len(p.Trace) + valueSize(p.Value)
+	for _, f := range p.Stack {
+		n += int(unsafe.Sizeof(f)) + len(f.Function) + len(f.File)
+	}
+	for k, v := range p.Attrs {
+		n += len(k) + valueSize(v)
+	}
+	return n
+}
+
+// maxRenderedSize is the number of bytes of a value rendered to estimate its size.
+const maxRenderedSize = 1 << 16
+
+// valueSize estimates the memory held by the value from its length or its text, without
+// rendering more than `maxRenderedSize` bytes of it.
+func valueSize(v interface{}) int {
+	switch v := v.(type) {
+	case string:
+		return len(v)
+	case []byte:
+		return cap(v)
+	}
+	return len(renderValueLimit(v, maxRenderedSize))
+}
@@ -0,0 +1,118 @@
+package cpanic_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/demosdemon/cpanic"
+)
+
+func TestHistory(t *testing.T) {
+	h := cpanic.NewHistory(2)
+	assert.Empty(t, h.Panics())
+
+	a, b, c := cpanic.New("a"), cpanic.New("b"), cpanic.New("c")
+	h.Handle(a)
+	h.Handle(b)
+	h.Handle(c)
+
+	panics := h.Panics()
+	if assert.Len(t, panics, 2) {
+		assert.Equal(t, "b", panics[0].Value)
+		assert.Equal(t, "c", panics[1].Value)
+		assert.Equal(t, c.Trace, panics[1].Trace)
+	}
+}
+
+func TestHistoryRetention(t *testing.T) {
+	h := cpanic.NewHistory(0, cpanic.WithRetention(cpanic.KeepFrames, cpanic.DropRawTrace))
+
+	p := recovered(nilDeref)
+	h.Handle(p)
+	assert.NotEmpty(t, p.Trace, "the original panic is unchanged")
+	assert.Nil(t, p.Stack)
+
+	stored := h.Panics()[0]
+	assert.Empty(t, stored.Trace)
+	assert.Equal(t, p.Frames(), stored.Frames())
+	assert.Equal(t, p.Fingerprint(), stored.Fingerprint())
+
+	f, ok := stored.Culprit()
+	assert.True(t, ok)
+	assert.Equal(t, "github.com/demosdemon/cpanic_test.nilDeref", f.Function)
+
+	full := cpanic.NewHistory(0)
+	full.Handle(p)
+	assert.Less(t, h.Size(), full.Size())
+
+	// The frames of a second panic from the same code share the interned names.
+	size := h.Size()
+	h.Handle(p)
+	assert.Less(t, h.Size()-size, size)
+}
+
+func TestHistoryAttrs(t *testing.T) {
+	h := cpanic.NewHistory(0)
+	p := cpanic.New("not at a disco")
+	p.SetAttr("request_id", "abc")
+	h.Handle(p)
+	p.SetAttr("request_id", "def")
+
+	assert.Equal(t, map[string]interface{}{"request_id": "abc"}, h.Panics()[0].Attrs)
+}
+
+func TestHistoryInternEviction(t *testing.T) {
+	p := recovered(nilDeref)
+	h := cpanic.NewHistory(1, cpanic.WithRetention(cpanic.KeepFrames, cpanic.DropRawTrace))
+	h.Handle(p)
+	size := h.Size()
+
+	h.Handle(cpanic.New("not at a disco"))
+	h.Handle(p)
+	assert.Equal(t, size, h.Size(), "the names of evicted frames are released")
+}
+
+func TestHistoryMemoryBudget(t *testing.T) {
+	p := cpanic.New("not at a disco")
+	single := cpanic.NewHistory(0)
+	single.Handle(p)
+
+	h := cpanic.NewHistory(0, cpanic.WithMemoryBudget(single.Size()*2))
+	for i := 0; i < 5; i++ {
+		h.Handle(p)
+	}
+	assert.Equal(t, 2, h.Len())
+	assert.LessOrEqual(t, h.Size(), single.Size()*2)
+
+	// The most recent panic is kept even if it exceeds the budget on its own.
+	tiny := cpanic.NewHistory(0, cpanic.WithMemoryBudget(1))
+	tiny.Handle(p)
+	tiny.Handle(p)
+	assert.Equal(t, 1, tiny.Len())
+}
+
+func TestHistoryMaxAge(t *testing.T) {
+	h := cpanic.NewHistory(0, cpanic.WithMaxAge(time.Hour))
+
+	old, recent, parsed := cpanic.New("old"), cpanic.New("recent"), cpanic.New("parsed")
+	old.Time = time.Now().Add(-2 * time.Hour)
+	recent.Time = time.Now().Add(-time.Minute)
+	parsed.Time = time.Time{}
+	h.Handle(old)
+	h.Handle(recent)
+	h.Handle(parsed)
+
+	assert.NoError(t, h.Compact())
+	panics := h.Panics()
+	if assert.Len(t, panics, 2) {
+		assert.Equal(t, "recent", panics[0].Value)
+		assert.Equal(t, "parsed", panics[1].Value, "panics without a time expire by the time recorded")
+	}
+
+	single := cpanic.NewHistory(0)
+	single.Handle(recent)
+	single.Handle(parsed)
+	assert.Equal(t, single.Size(), h.Size())
+}
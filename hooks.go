@@ -0,0 +1,115 @@
+package cpanic
+
+import "sync"
+
+var hooks struct {
+	sync.RWMutex
+	recovered []*func(p *Panic)
+	fatal     []*func(p *Panic)
+	flush     []*func()
+}
+
+// OnRecovered registers a hook called with every panic recovered by the recovery
+// functions of this package, such as `Recover`, `Forward`, `RecoverCtx`, and `Catch`,
+// or passed to `Deliver` by an integration, once it has been reported, so frameworks
+// embedding cpanic can coordinate around crash events, such as by invalidating caches
+// the panicking code may have left inconsistent. Hooks are called in the order they
+// were registered; a panic in a hook is recovered and ignored. It returns a function
+// that unregisters the hook, such as at the end of a test.
+func OnRecovered(fn func(p *Panic)) (unregister func()) {
+	hooks.Lock()
+	defer hooks.Unlock()
+	hooks.recovered = append(hooks.recovered, &fn)
+	return func() {
+		hooks.Lock()
+		defer hooks.Unlock()
+		hooks.recovered = removePanicHook(hooks.recovered, &fn)
+	}
+}
+
+// OnFatal registers a hook called, after the `OnRecovered` hooks, with every recovered
+// panic of `SeverityFatal` or that is `Unrecoverable`, so frameworks can begin shutting
+// down or checkpoint their state before the process exits. It returns a function that
+// unregisters the hook.
+func OnFatal(fn func(p *Panic)) (unregister func()) {
+	hooks.Lock()
+	defer hooks.Unlock()
+	hooks.fatal = append(hooks.fatal, &fn)
+	return func() {
+		hooks.Lock()
+		defer hooks.Unlock()
+		hooks.fatal = removePanicHook(hooks.fatal, &fn)
+	}
+}
+
+// OnFlush registers a hook called whenever a `*Batcher` has delivered a batch of
+// panics, such as when it is flushed before the process exits with `WithFlush`. It
+// returns a function that unregisters the hook.
+func OnFlush(fn func()) (unregister func()) {
+	hooks.Lock()
+	defer hooks.Unlock()
+	hooks.flush = append(hooks.flush, &fn)
+	return func() {
+		hooks.Lock()
+		defer hooks.Unlock()
+		flush := make([]*func(), 0, len(hooks.flush))
+		for _, h := range hooks.flush {
+			if h != &fn {
+				flush = append(flush, h)
+			}
+		}
+		hooks.flush = flush
+	}
+}
+
+// removePanicHook returns a copy of hooks without the hook, leaving the slice read by
+// `settle` untouched.
+func removePanicHook(hooks []*func(p *Panic), hook *func(p *Panic)) []*func(p *Panic) {
+	out := make([]*func(p *Panic), 0, len(hooks))
+	for _, h := range hooks {
+		if h != hook {
+			out = append(out, h)
+		}
+	}
+	return out
+}
+
+// settle calls the hooks for a recovered panic once it has been reported, and panics
+// again if it is `Unrecoverable`.
+func settle(p *Panic) {
+	hooks.RLock()
+	recovered, fatal := hooks.recovered, hooks.fatal
+	hooks.RUnlock()
+
+	for _, fn := range recovered {
+		fn := *fn
+		callHook(func() { fn(p) })
+	}
+	if p.Severity >= SeverityFatal || Unrecoverable(p) {
+		for _, fn := range fatal {
+			fn := *fn
+			callHook(func() { fn(p) })
+		}
+	}
+	repanic(p)
+}
+
+// flushed calls the `OnFlush` hooks.
+func flushed() {
+	hooks.RLock()
+	flush := hooks.flush
+	hooks.RUnlock()
+
+	for _, fn := range flush {
+		callHook(*fn)
+	}
+}
+
+// callHook calls the hook, ignoring any panic. It does not use `Try`, whose recovery
+// would call the hooks again.
+func callHook(fn func()) {
+	defer func() {
+		_ = recover()
+	}()
+	fn()
+}
@@ -0,0 +1,110 @@
+package cpanic_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/demosdemon/cpanic"
+)
+
+type hookValue string
+
+var hookEvents struct {
+	sync.Mutex
+	events []string
+}
+
+func init() {
+	record := func(event string) func(p *cpanic.Panic) {
+		return func(p *cpanic.Panic) {
+			if v, ok := p.Value.(hookValue); ok {
+				hookEvents.Lock()
+				hookEvents.events = append(hookEvents.events, event+" "+string(v))
+				hookEvents.Unlock()
+			}
+		}
+	}
+	cpanic.OnRecovered(record("recovered"))
+	cpanic.OnFatal(record("fatal"))
+	cpanic.OnRecovered(func(p *cpanic.Panic) {
+		if p.Value == hookValue("panicking hook") {
+			panic("hook failed")
+		}
+	})
+}
+
+func takeHookEvents() []string {
+	hookEvents.Lock()
+	defer hookEvents.Unlock()
+	events := hookEvents.events
+	hookEvents.events = nil
+	return events
+}
+
+func TestOnRecovered(t *testing.T) {
+	var reported []string
+	handler := func(p *cpanic.Panic) {
+		v := string(p.Value.(hookValue))
+		reported = append(reported, v)
+		hookEvents.Lock()
+		assert.NotContains(t, hookEvents.events, "recovered "+v, "hooks run after the handler")
+		hookEvents.Unlock()
+	}
+
+	func() {
+		defer cpanic.Recover(handler)
+		panic(hookValue("one"))
+	}()
+	_ = cpanic.Catch(func() { panic(hookValue("two")) })
+	func() {
+		defer cpanic.RecoverCtx(cpanic.WithSeverity(cpanic.NewContext(context.Background(), handler), cpanic.SeverityFatal))
+		panic(hookValue("three"))
+	}()
+	cpanic.Deliver(cpanic.New(hookValue("four")), handler)
+	var err error
+	func() {
+		defer cpanic.ForwardCtx(cpanic.WithSeverity(context.Background(), cpanic.SeverityFatal), &err)
+		panic(hookValue("five"))
+	}()
+	assert.NotPanics(t, func() {
+		defer cpanic.Recover(handler)
+		panic(hookValue("panicking hook"))
+	})
+
+	assert.Equal(t, []string{"one", "three", "four", "panicking hook"}, reported)
+	assert.Equal(t, []string{
+		"recovered one",
+		"recovered two",
+		"recovered three",
+		"fatal three",
+		"recovered four",
+		"recovered five",
+		"fatal five",
+		"recovered panicking hook",
+	}, takeHookEvents())
+}
+
+func TestOnFlush(t *testing.T) {
+	flushes := 0
+	var mu sync.Mutex
+	cpanic.OnFlush(func() {
+		mu.Lock()
+		flushes++
+		mu.Unlock()
+	})
+
+	b := cpanic.Batch(func(ps []*cpanic.Panic) {}, 0, 0)
+	b.Flush()
+	mu.Lock()
+	before := flushes
+	mu.Unlock()
+
+	b.Handle(cpanic.New("boom"))
+	b.Flush()
+	mu.Lock()
+	assert.Equal(t, before+1, flushes)
+	mu.Unlock()
+}
@@ -0,0 +1,39 @@
+package cpanic
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"time"
+)
+
+// crockford is the Crockford base32 alphabet used by ULIDs.
+const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// newID returns a ULID for the time: a 26 character, lexically sortable identifier
+// made of a millisecond timestamp and 80 random bits.
+func newID(t time.Time) string {
+	var b [16]byte
+	ms := uint64(t.UnixNano() / int64(time.Millisecond))
+	binary.BigEndian.PutUint64(b[:8], ms<<16)
+	_, _ = rand.Read(b[6:])
+
+	// Encode the 128 bits 5 at a time, most significant first; the leading character
+	// only holds 3 bits.
+	var out [26]byte
+	hi := binary.BigEndian.Uint64(b[:8])
+	lo := binary.BigEndian.Uint64(b[8:])
+	for i := 25; i >= 0; i-- {
+		out[i] = crockford[lo&0x1f]
+		lo = lo>>5 | hi<<59
+		hi >>= 5
+	}
+	return string(out[:])
+}
+
+// Stamp sets the time of the panic to t and gives it a new ID, as `New` does, for
+// panics parsed from crash output, such as by `Parse` or a `Scanner`, which have
+// neither.
+func (p *Panic) Stamp(t time.Time) {
+	p.Time = t
+	p.ID = newID(t)
+}
@@ -0,0 +1,51 @@
+package cpanic_test
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/demosdemon/cpanic"
+)
+
+func TestID(t *testing.T) {
+	first := cpanic.New("not at a disco")
+	time.Sleep(2 * time.Millisecond)
+	second := cpanic.New("not at a disco")
+
+	ulid := regexp.MustCompile(`^[0-7][0-9A-HJKMNP-TV-Z]{25}$`)
+	assert.Regexp(t, ulid, first.ID)
+	assert.Regexp(t, ulid, second.ID)
+	assert.NotEqual(t, first.ID, second.ID)
+	assert.True(t, first.ID < second.ID, "%s should sort before %s", first.ID, second.ID)
+
+	// The first 10 characters encode the time in milliseconds.
+	var ms int64
+	for _, c := range first.ID[:10] {
+		ms = ms<<5 | int64(indexCrockford(c))
+	}
+	assert.Equal(t, first.Time.UnixNano()/int64(time.Millisecond), ms)
+}
+
+func TestStamp(t *testing.T) {
+	p, err := cpanic.Parse("panic: boom\n\ngoroutine 1 [running]:\nmain.main()\n\t/src/main.go:6 +0x34\n")
+	if assert.NoError(t, err) {
+		assert.Empty(t, p.ID)
+		now := time.Now()
+		p.Stamp(now)
+		assert.Equal(t, now, p.Time)
+		assert.Regexp(t, `^[0-7][0-9A-HJKMNP-TV-Z]{25}$`, p.ID)
+	}
+}
+
+func indexCrockford(c rune) int {
+	const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+	for i, r := range crockford {
+		if r == c {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,37 @@
+//go:build !tinygo && !cpanic_minimal
+// +build !tinygo,!cpanic_minimal
+
+package cpanic
+
+import (
+	"context"
+	"runtime/pprof"
+)
+
+// GoLabeled calls fn in a new goroutine with the `runtime/pprof` labels applied, as with
+// `pprof.Do`, recovering from any panics like `Go`. The labels are inherited by any
+// goroutine fn starts, so that the goroutines are identifiable in the all-goroutine
+// trace of any later panic (see `Goroutines`) as well as in CPU and goroutine profiles.
+// The context passed to fn carries the labels. The returned channel receives the error
+// returned by fn, or the `*Panic` if it panicked.
+func GoLabeled(ctx context.Context, labels pprof.LabelSet, fn func(ctx context.Context) error) <-chan error {
+	errc := make(chan error, 1)
+	go pprof.Do(ctx, labels, func(ctx context.Context) {
+		errc <- goContext(ctx, func() error {
+			return fn(ctx)
+		})
+	})
+	return errc
+}
+
+// doLabeled calls fn with the labels, given as key/value pairs, applied to the calling
+// goroutine.
+func doLabeled(labels []string, fn func()) {
+	if len(labels) == 0 {
+		fn()
+		return
+	}
+	pprof.Do(context.Background(), pprof.Labels(labels...), func(context.Context) {
+		fn()
+	})
+}
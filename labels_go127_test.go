@@ -0,0 +1,60 @@
+//go:build go1.27 && !tinygo && !cpanic_minimal
+// +build go1.27,!tinygo,!cpanic_minimal
+
+package cpanic_test
+
+import (
+	"context"
+	"errors"
+	"runtime/pprof"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/demosdemon/cpanic"
+)
+
+func TestGoLabeledTrace(t *testing.T) {
+	t.Setenv("GODEBUG", "tracebacklabels=1")
+
+	err := <-cpanic.GoLabeled(context.Background(), pprof.Labels("request_id", "abc"), func(ctx context.Context) error {
+		panic("not at a disco")
+	})
+	var p *cpanic.Panic
+	require.True(t, errors.As(err, &p))
+	assert.Equal(t, map[string]string{"request_id": "abc"}, p.Goroutines()[0].Labels)
+}
+
+func TestGoLabeledInherited(t *testing.T) {
+	t.Setenv("GODEBUG", "tracebacklabels=1")
+
+	done := make(chan *cpanic.Panic)
+	err := <-cpanic.GoLabeled(context.Background(), pprof.Labels("worker", "1"), func(ctx context.Context) error {
+		go func() {
+			var p *cpanic.Panic
+			defer func() { done <- p }()
+			defer cpanic.Recover(func(r *cpanic.Panic) { p = r })
+			panic("not at a disco")
+		}()
+		return nil
+	})
+	assert.NoError(t, err)
+
+	p := <-done
+	require.NotNil(t, p)
+	assert.Equal(t, map[string]string{"worker": "1"}, p.Goroutines()[0].Labels)
+}
+
+func TestTaskSpawnTrace(t *testing.T) {
+	t.Setenv("GODEBUG", "tracebacklabels=1")
+
+	done := make(chan *cpanic.Panic, 1)
+	task := cpanic.NewTask(func(p *cpanic.Panic) { done <- p }).WithLabels("worker", "1")
+	task.Spawn(func(*cpanic.Task) {
+		panic("not at a disco")
+	})
+
+	p := <-done
+	assert.Equal(t, map[string]string{"worker": "1"}, p.Goroutines()[0].Labels)
+}
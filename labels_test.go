@@ -0,0 +1,76 @@
+//go:build !tinygo && !cpanic_minimal
+// +build !tinygo,!cpanic_minimal
+
+package cpanic_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"runtime/pprof"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/demosdemon/cpanic"
+)
+
+// goroutineProfile returns the goroutine profile, which lists the labels of each
+// goroutine in every Go version.
+func goroutineProfile(t *testing.T) string {
+	var buf bytes.Buffer
+	assert.NoError(t, pprof.Lookup("goroutine").WriteTo(&buf, 1))
+	return buf.String()
+}
+
+func TestGoLabeled(t *testing.T) {
+	labels := pprof.Labels("request_id", "abc")
+
+	var profile string
+	err := <-cpanic.GoLabeled(context.Background(), labels, func(ctx context.Context) error {
+		v, ok := pprof.Label(ctx, "request_id")
+		assert.True(t, ok)
+		assert.Equal(t, "abc", v)
+		profile = goroutineProfile(t)
+		return errors.New("boom")
+	})
+	assert.EqualError(t, err, "boom")
+	assert.Contains(t, profile, `# labels: {"request_id":"abc"}`)
+	assert.NotContains(t, goroutineProfile(t), `"request_id"`, "the calling goroutine is not labeled")
+
+	err = <-cpanic.GoLabeled(context.Background(), labels, func(ctx context.Context) error {
+		panic("not at a disco")
+	})
+	var p *cpanic.Panic
+	if assert.True(t, errors.As(err, &p)) {
+		assert.Equal(t, "not at a disco", p.Value)
+	}
+}
+
+func TestGoLabeledSeverity(t *testing.T) {
+	ctx := cpanic.WithSeverity(context.Background(), cpanic.SeverityFatal)
+	err := <-cpanic.GoLabeled(ctx, pprof.Labels(), func(context.Context) error {
+		panic(hookValue("labeled"))
+	})
+	var p *cpanic.Panic
+	if assert.True(t, errors.As(err, &p)) {
+		assert.Equal(t, cpanic.SeverityFatal, p.Severity)
+	}
+	assert.Equal(t, []string{"recovered labeled", "fatal labeled"}, takeHookEvents(), "the severity is applied before the hooks")
+}
+
+func TestTaskWithLabels(t *testing.T) {
+	task := cpanic.NewTask(nil).WithLabels("worker", "1")
+	profile := make(chan string)
+	task.WithLabels("job", "2").Spawn(func(*cpanic.Task) {
+		profile <- goroutineProfile(t)
+	})
+	assert.Contains(t, <-profile, `# labels: {"job":"2", "worker":"1"}`)
+
+	g := cpanic.NewGroup(task)
+	g.Spawn(func(*cpanic.Task) error {
+		assert.Contains(t, goroutineProfile(t), `# labels: {"worker":"1"}`)
+		return nil
+	})
+	assert.NoError(t, g.Wait())
+}
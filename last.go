@@ -0,0 +1,32 @@
+package cpanic
+
+import "sync/atomic"
+
+var (
+	panicCount uint64
+	lastPanic  atomic.Value // *Panic
+)
+
+// record remembers the panic as the latest one created by `New`. A copy is stored, so
+// attributes set afterwards by the recovering goroutine are not visible to readers of
+// `LastPanic` and cannot race with them.
+func record(p *Panic) {
+	last := *p
+	lastPanic.Store(&last)
+	atomic.AddUint64(&panicCount, 1)
+}
+
+// LastPanic returns a copy of the most recent panic created by `New`, as of creation,
+// so application code, health checks, and admin endpoints can ask whether anything has
+// crashed recently without wiring their own handler. The copy does not include
+// attributes set after the panic was created. If no panic has been created, false is
+// returned.
+func LastPanic() (*Panic, bool) {
+	p, ok := lastPanic.Load().(*Panic)
+	return p, ok
+}
+
+// PanicCount returns the number of panics created by `New` since the process started.
+func PanicCount() uint64 {
+	return atomic.LoadUint64(&panicCount)
+}
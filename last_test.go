@@ -0,0 +1,37 @@
+package cpanic_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/demosdemon/cpanic"
+)
+
+func TestLastPanic(t *testing.T) {
+	before := cpanic.PanicCount()
+
+	err := cpanic.Go(func() error { panic("not at a disco") })
+	p := err.(*cpanic.Panic)
+	p.SetAttr("key", "value")
+
+	assert.Equal(t, before+1, cpanic.PanicCount())
+	last, ok := cpanic.LastPanic()
+	if assert.True(t, ok) {
+		assert.NotSame(t, p, last)
+		assert.Equal(t, p.ID, last.ID)
+		assert.Equal(t, "not at a disco", last.Value)
+		assert.Nil(t, last.Attrs)
+	}
+}
+
+func TestNewEvent(t *testing.T) {
+	before := cpanic.PanicCount()
+	p := cpanic.NewEvent("stalled")
+	assert.Equal(t, "stalled", p.Value)
+	assert.NotEmpty(t, p.ID)
+	assert.Equal(t, before, cpanic.PanicCount())
+	if last, ok := cpanic.LastPanic(); ok {
+		assert.NotEqual(t, p.ID, last.ID)
+	}
+}
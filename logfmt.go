@@ -0,0 +1,117 @@
+package cpanic
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	// maxLogfmtValue is the longest value written by `Logfmt`, other than the trace.
+	maxLogfmtValue = 1 << 10
+	// maxLogfmtTrace is the longest trace written by `Logfmt`.
+	maxLogfmtTrace = 16 << 10
+)
+
+// Logfmt renders the panic as a single logfmt line for log pipelines that expect
+// key=value pairs:
+//
+//	time=2021-04-01T12:00:00Z id=01F2... panic="not at a disco" type=string
+//	fingerprint=734b2d414474eb11 severity=error culprit="main.main (/src/main.go:3)"
+//	goroutines=42 attr.route=/ trace="goroutine 1 [running]:\n..."
+//
+// Values are quoted when needed, with Go escapes for quotes, backslashes, newlines, and
+// other control characters. Attributes are prefixed with "attr.". The trace is
+// truncated to 16 KiB and other values to 1 KiB, marked with a trailing "...".
+var Logfmt Formatter = formatter{formatLogfmt, "text/plain; charset=utf-8"}
+
+func formatLogfmt(p *Panic) ([]byte, error) {
+	var b strings.Builder
+	pair := func(key, value string, max int) {
+		if b.Len() > 0 {
+			b.WriteByte(' ')
+		}
+		b.WriteString(logfmtKey(key))
+		b.WriteByte('=')
+		b.WriteString(logfmtValue(truncate(value, max)))
+	}
+
+	if !p.Time.IsZero() {
+		pair("time", p.Time.UTC().Format(time.RFC3339Nano), maxLogfmtValue)
+	}
+	if p.ID != "" {
+		pair("id", p.ID, maxLogfmtValue)
+	}
+	pair("panic", p.Message(), maxLogfmtValue)
+	pair("type", p.Type(), maxLogfmtValue)
+	pair("fingerprint", p.Fingerprint(), maxLogfmtValue)
+	if p.BuildID != "" {
+		pair("build_id", p.BuildID, maxLogfmtValue)
+	}
+	pair("severity", p.Severity.String(), maxLogfmtValue)
+	if f, ok := p.Culprit(); ok {
+		pair("culprit", f.String(), maxLogfmtValue)
+	}
+	if p.Trace != "" {
+		pair("goroutines", strconv.Itoa(len(p.Goroutines())), maxLogfmtValue)
+	}
+
+	keys := make([]string, 0, len(p.Attrs))
+	for k := range p.Attrs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		pair("attr."+k, fmt.Sprint(p.Attrs[k]), maxLogfmtValue)
+	}
+
+	if p.Trace != "" {
+		pair("trace", strings.TrimRight(p.Trace, "\n"), maxLogfmtTrace)
+	}
+	b.WriteByte('\n')
+	return []byte(b.String()), nil
+}
+
+// truncate shortens s to at most max bytes, without splitting a rune, marking the cut
+// with "...".
+func truncate(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	s = s[:max-3]
+	i := len(s) - 1
+	for i > 0 && !utf8.RuneStart(s[i]) {
+		i--
+	}
+	if !utf8.FullRuneInString(s[i:]) {
+		s = s[:i]
+	}
+	return s + "..."
+}
+
+// logfmtKey replaces the characters that cannot appear in a logfmt key.
+func logfmtKey(key string) string {
+	return strings.Map(func(r rune) rune {
+		if r <= ' ' || r == '=' || r == '"' || r == utf8.RuneError {
+			return '_'
+		}
+		return r
+	}, key)
+}
+
+// logfmtValue quotes the value if it is empty or contains a space, '=', '"', or a
+// character that must be escaped.
+func logfmtValue(value string) string {
+	if value == "" {
+		return `""`
+	}
+	for _, r := range value {
+		if r <= ' ' || r == '=' || r == '"' || r == '\\' || r == utf8.RuneError || !strconv.IsPrint(r) {
+			return strconv.Quote(value)
+		}
+	}
+	return value
+}
@@ -0,0 +1,42 @@
+package cpanic_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/demosdemon/cpanic"
+)
+
+func TestLogfmt(t *testing.T) {
+	p := &cpanic.Panic{
+		Time:  time.Date(2021, 4, 1, 12, 0, 0, 0, time.UTC),
+		Value: `not at a "disco"`,
+		Trace: "goroutine 1 [running]:\nmain.main()\n\t/src/main.go:3 +0x1d\n",
+	}
+	p.SetAttr("route", "/")
+	p.SetAttr("user name", "")
+
+	b, err := cpanic.Logfmt.Format(p)
+	assert.NoError(t, err)
+	assert.Equal(t, `time=2021-04-01T12:00:00Z panic="not at a \"disco\"" type=string `+
+		`fingerprint=`+p.Fingerprint()+` severity=error culprit="main.main (/src/main.go:3)" `+
+		`goroutines=1 attr.route=/ attr.user_name="" `+
+		`trace="goroutine 1 [running]:\nmain.main()\n\t/src/main.go:3 +0x1d"`+"\n", string(b))
+}
+
+func TestLogfmtTruncate(t *testing.T) {
+	p := &cpanic.Panic{Value: strings.Repeat("é", 1<<10)}
+	b, err := cpanic.Logfmt.Format(p)
+	assert.NoError(t, err)
+
+	line := string(b)
+	start := strings.Index(line, "panic=") + len("panic=")
+	value := line[start:strings.Index(line, " type=")]
+	assert.Len(t, value, 1<<10-1)
+	assert.True(t, strings.HasSuffix(value, "é..."), value)
+	assert.Equal(t, 1, strings.Count(line, "\n"))
+	assert.NotContains(t, line, "goroutines=")
+}
@@ -0,0 +1,214 @@
+package cpanic
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// ExitCodePanic is the exit code for an unmapped panic, matching the exit code of the
+// Go runtime when a panic is not recovered.
+const ExitCodePanic = 2
+
+// ExitCode returns the process exit code for err. It is 0 if err is nil, the result of
+// the `ExitCode() int` method if err has one (such as `*exec.ExitError`),
+// `ExitCodePanic` if err is a `*Panic`, and 1 otherwise.
+func ExitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+
+	var coder interface{ ExitCode() int }
+	if errors.As(err, &coder) {
+		return coder.ExitCode()
+	}
+
+	var p *Panic
+	if errors.As(err, &p) {
+		return ExitCodePanic
+	}
+
+	return 1
+}
+
+// SignalAttr is the attribute holding the name of the signal that requested a
+// goroutine dump.
+const SignalAttr = "signal"
+
+// ErrGoroutineDump is the value of the `*Panic` reported by `WithDumpOnSIGQUIT`.
+var ErrGoroutineDump = errors.New("goroutine dump requested")
+
+// MainOption configures `Main` and `Run`.
+type MainOption func(*mainConfig)
+
+type mainConfig struct {
+	stderr       io.Writer
+	bugURL       string
+	bundleDir    string
+	keyring      *Keyring
+	kinds        map[string]int
+	fingerprints map[string]int
+	dumpHandler  Handler
+	formatter    Formatter
+	flushers     []Flusher
+	snapshot     *snapshotConfig
+}
+
+// WithKindExitCode exits with code when the panic value has the type kind, as returned
+// by `(*Panic).Type`, such as "runtime.boundsError".
+func WithKindExitCode(kind string, code int) MainOption {
+	return func(c *mainConfig) {
+		c.kinds[kind] = code
+	}
+}
+
+// WithFingerprintExitCode exits with code when the panic has the fingerprint. It takes
+// precedence over `WithKindExitCode`.
+func WithFingerprintExitCode(fingerprint string, code int) MainOption {
+	return func(c *mainConfig) {
+		c.fingerprints[fingerprint] = code
+	}
+}
+
+// WithBugReportURL asks users to report panics at url, quoting the panic's fingerprint
+// as the crash id.
+func WithBugReportURL(url string) MainOption {
+	return func(c *mainConfig) {
+		c.bugURL = url
+	}
+}
+
+// WithBundleDir writes a crash report bundle for panics to dir, as with
+// `(*Panic).Bundle`, and prints its path so users can attach it to a bug report.
+func WithBundleDir(dir string) MainOption {
+	return func(c *mainConfig) {
+		c.bundleDir = dir
+	}
+}
+
+// WithEncryption encrypts the crash report bundles written by `WithBundleDir` with the
+// keyring, as with `(*Panic).SealedBundle`. Crash output sealed with the same keyring
+// can be read back with `SealedDumpFiles`.
+func WithEncryption(k *Keyring) MainOption {
+	return func(c *mainConfig) {
+		c.keyring = k
+	}
+}
+
+// WithValueSnapshot captures a `ValueSnapshot` of at most maxBytes of each panic value
+// created while `Run` calls fn, dropping the value if dropValue is true, as with
+// `SetValueSnapshot`. The previous configuration is restored when `Run` returns.
+func WithValueSnapshot(maxBytes int, dropValue bool) MainOption {
+	return func(c *mainConfig) {
+		c.snapshot = &snapshotConfig{maxBytes: maxBytes, dropValue: dropValue}
+	}
+}
+
+// WithFormatter sets how panics are printed. It defaults to `Text`.
+func WithFormatter(f Formatter) MainOption {
+	return func(c *mainConfig) {
+		c.formatter = f
+	}
+}
+
+// WithFlush flushes each of the flushers, such as a `*Batcher`, before `Run` returns,
+// so panics reported by other goroutines are not lost when the process exits.
+func WithFlush(flushers ...Flusher) MainOption {
+	return func(c *mainConfig) {
+		c.flushers = append(c.flushers, flushers...)
+	}
+}
+
+// WithStderr sets where errors and panics are printed. It defaults to `os.Stderr`.
+func WithStderr(w io.Writer) MainOption {
+	return func(c *mainConfig) {
+		c.stderr = w
+	}
+}
+
+// WithDumpOnSIGQUIT reports a dump of all goroutines to h whenever the process receives
+// SIGQUIT, instead of the Go runtime printing the dump and exiting, so operators can
+// snapshot a wedged process through the usual reporting pipeline. The dump is a
+// `*Panic` with the value `ErrGoroutineDump`, `SeverityWarning`, and the `SignalAttr`
+// attribute, and the process continues to run. It has no effect on plan9 and in minimal
+// builds.
+func WithDumpOnSIGQUIT(h Handler) MainOption {
+	return func(c *mainConfig) {
+		c.dumpHandler = h
+	}
+}
+
+// Main calls fn and exits the process with the code returned by `Run`. It is meant to
+// be the only call in a CLI's `main` function.
+func Main(fn func() error, opts ...MainOption) {
+	os.Exit(Run(fn, opts...))
+}
+
+// Run calls fn, recovering any panic, and prints the error, if any, to stderr. It
+// returns the exit code for the error, as mapped by the options, or `ExitCode`.
+func Run(fn func() error, opts ...MainOption) int {
+	cfg := mainConfig{
+		stderr:       os.Stderr,
+		formatter:    Text,
+		kinds:        make(map[string]int),
+		fingerprints: make(map[string]int),
+	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	defer func() {
+		for _, f := range cfg.flushers {
+			f.Flush()
+		}
+	}()
+	if cfg.snapshot != nil {
+		prev, _ := snapshots.Load().(snapshotConfig)
+		snapshots.Store(*cfg.snapshot)
+		defer snapshots.Store(prev)
+	}
+	if cfg.dumpHandler != nil && len(dumpSignals) > 0 {
+		defer notifyDump(cfg.dumpHandler)()
+	}
+
+	err := Go(fn)
+	if err == nil {
+		return 0
+	}
+
+	var p *Panic
+	if !errors.As(err, &p) {
+		fmt.Fprintln(cfg.stderr, err)
+		return ExitCode(err)
+	}
+
+	if b, err := cfg.formatter.Format(p); err != nil {
+		fmt.Fprintln(cfg.stderr, p.String())
+	} else {
+		_, _ = cfg.stderr.Write(b)
+	}
+	fingerprint := p.Fingerprint()
+	if cfg.bugURL != "" {
+		fmt.Fprintf(cfg.stderr, "\nplease report this bug at %s, crash id %s\n", cfg.bugURL, fingerprint)
+	}
+	if cfg.bundleDir != "" {
+		bundle := p.Bundle
+		if cfg.keyring != nil {
+			bundle = func(dir string) (string, error) { return p.SealedBundle(dir, cfg.keyring) }
+		}
+		if path, err := bundle(cfg.bundleDir); err != nil {
+			fmt.Fprintf(cfg.stderr, "failed to write crash report: %v\n", err)
+		} else {
+			fmt.Fprintf(cfg.stderr, "crash report written to %s\n", path)
+		}
+	}
+
+	if code, ok := cfg.fingerprints[fingerprint]; ok {
+		return code
+	}
+	if code, ok := cfg.kinds[p.Type()]; ok {
+		return code
+	}
+	return ExitCodePanic
+}
@@ -0,0 +1,130 @@
+package cpanic_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/demosdemon/cpanic"
+)
+
+type exitError int
+
+func (e exitError) Error() string { return fmt.Sprintf("exit %d", int(e)) }
+func (e exitError) ExitCode() int { return int(e) }
+
+func TestExitCode(t *testing.T) {
+	cases := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"nil", nil, 0},
+		{"error", errors.New("boom"), 1},
+		{"panic", cpanic.New("boom"), cpanic.ExitCodePanic},
+		{"wrapped panic", fmt.Errorf("wrapped: %w", cpanic.New("boom")), cpanic.ExitCodePanic},
+		{"exit coder", fmt.Errorf("wrapped: %w", exitError(42)), 42},
+		{"exec", &exec.ExitError{}, -1},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, cpanic.ExitCode(tc.err))
+		})
+	}
+}
+
+func TestRun(t *testing.T) {
+	var stderr bytes.Buffer
+	code := cpanic.Run(func() error { return nil }, cpanic.WithStderr(&stderr))
+	assert.Equal(t, 0, code)
+	assert.Empty(t, stderr.String())
+
+	code = cpanic.Run(func() error { return errors.New("boom") }, cpanic.WithStderr(&stderr))
+	assert.Equal(t, 1, code)
+	assert.Equal(t, "boom\n", stderr.String())
+}
+
+func crash() error {
+	panic("not at a disco")
+}
+
+// runCrash runs crash on a new goroutine so that its fingerprint does not depend on
+// the caller.
+func runCrash(opts ...cpanic.MainOption) (code int, stderr string) {
+	var buf bytes.Buffer
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		code = cpanic.Run(crash, append(opts, cpanic.WithStderr(&buf))...)
+	}()
+	<-done
+	return code, buf.String()
+}
+
+// crashID returns the crash id printed for crash.
+func crashID() string {
+	_, stderr := runCrash(cpanic.WithBugReportURL("https://example.com/issues"))
+	out := strings.TrimSpace(stderr)
+	return out[strings.LastIndex(out, " ")+1:]
+}
+
+func TestRunPanic(t *testing.T) {
+	fp := crashID()
+
+	cases := []struct {
+		name string
+		opts []cpanic.MainOption
+		want int
+	}{
+		{"default", nil, cpanic.ExitCodePanic},
+		{"kind", []cpanic.MainOption{cpanic.WithKindExitCode("string", 3)}, 3},
+		{"other kind", []cpanic.MainOption{cpanic.WithKindExitCode("runtime.Error", 3)}, cpanic.ExitCodePanic},
+		{"fingerprint", []cpanic.MainOption{
+			cpanic.WithKindExitCode("string", 3),
+			cpanic.WithFingerprintExitCode(fp, 4),
+		}, 4},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			code, stderr := runCrash(tc.opts...)
+			assert.Equal(t, tc.want, code)
+			assert.True(t, strings.HasPrefix(stderr, "panic: not at a disco\n"), stderr)
+			assert.NotContains(t, stderr, "please report this bug")
+		})
+	}
+}
+
+func TestRunBugReportURL(t *testing.T) {
+	_, stderr := runCrash(cpanic.WithBugReportURL("https://example.com/issues"))
+	want := "\n\nplease report this bug at https://example.com/issues, crash id " + crashID() + "\n"
+	assert.True(t, strings.HasPrefix(stderr, "panic: not at a disco\n\n"), stderr)
+	assert.True(t, strings.HasSuffix(stderr, want), stderr)
+}
+
+func TestRunFormatter(t *testing.T) {
+	_, stderr := runCrash(cpanic.WithFormatter(cpanic.NDJSON))
+	var record map[string]interface{}
+	assert.NoError(t, json.Unmarshal([]byte(stderr), &record), stderr)
+	assert.Equal(t, "not at a disco", record["value"])
+}
+
+func TestRunValueSnapshot(t *testing.T) {
+	var stderr bytes.Buffer
+	code := cpanic.Run(func() error {
+		panic(strings.Repeat("x", 1<<10))
+	}, cpanic.WithStderr(&stderr), cpanic.WithValueSnapshot(4, true))
+	assert.Equal(t, cpanic.ExitCodePanic, code)
+	assert.True(t, strings.HasPrefix(stderr.String(), "panic: x...\n"), stderr.String())
+
+	assert.Nil(t, cpanic.New("not at a disco").ValueSnapshot, "the configuration is restored")
+}
@@ -0,0 +1,82 @@
+package cpanic
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Markdown renders the panic as GitHub-flavored Markdown, suitable for an issue or a
+// chat message: a heading with the panic message, a table of its type, ID,
+// fingerprint, build ID, culprit, time, and attributes, and the trace in a collapsed code block.
+func (p *Panic) Markdown() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "### %s\n\n", markdownInline(p.Error()))
+
+	b.WriteString("| | |\n| --- | --- |\n")
+	row := func(key, value string) {
+		fmt.Fprintf(&b, "| %s | %s |\n", markdownCell(key), markdownCell(value))
+	}
+	row("Type", markdownCode(p.Type()))
+	if p.ID != "" {
+		row("ID", markdownCode(p.ID))
+	}
+	row("Fingerprint", markdownCode(p.Fingerprint()))
+	if p.BuildID != "" {
+		row("Build ID", markdownCode(p.BuildID))
+	}
+	if f, ok := p.Culprit(); ok {
+		row("Culprit", markdownCode(f.String()))
+	}
+	if !p.Time.IsZero() {
+		row("Time", p.Time.UTC().Format(time.RFC3339))
+	}
+	keys := make([]string, 0, len(p.Attrs))
+	for k := range p.Attrs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		row(markdownCode(k), markdownCode(fmt.Sprint(p.Attrs[k])))
+	}
+
+	if p.Trace != "" {
+		fence := "```"
+		for strings.Contains(p.Trace, fence) {
+			fence += "`"
+		}
+		fmt.Fprintf(&b, "\n<details>\n<summary>Stack trace</summary>\n\n%s\n%s\n%s\n\n</details>\n",
+			fence, strings.TrimRight(p.Trace, "\n"), fence)
+	}
+	return b.String()
+}
+
+var markdownEscaper = strings.NewReplacer(
+	"\\", "\\\\", "`", "\\`", "*", "\\*", "_", "\\_", "[", "\\[", "]", "\\]",
+	"<", "&lt;", ">", "&gt;", "#", "\\#", "\n", " ",
+)
+
+// markdownInline escapes s for use in a heading or paragraph.
+func markdownInline(s string) string {
+	return markdownEscaper.Replace(s)
+}
+
+// markdownCode renders s as an inline code span, using a longer delimiter if s
+// contains backticks.
+func markdownCode(s string) string {
+	s = strings.Replace(s, "\n", " ", -1)
+	delim := "`"
+	for strings.Contains(s, delim) {
+		delim += "`"
+	}
+	if strings.HasPrefix(s, "`") || strings.HasSuffix(s, "`") {
+		s = " " + s + " "
+	}
+	return delim + s + delim
+}
+
+// markdownCell escapes the pipes in s for use in a table cell.
+func markdownCell(s string) string {
+	return strings.Replace(s, "|", "\\|", -1)
+}
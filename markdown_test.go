@@ -0,0 +1,40 @@
+package cpanic_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/demosdemon/cpanic"
+)
+
+func TestMarkdown(t *testing.T) {
+	p := &cpanic.Panic{
+		Time:  time.Date(2021, 4, 1, 12, 0, 0, 0, time.UTC),
+		Value: "not at a *disco*",
+		Trace: "goroutine 1 [running]:\nmain.main()\n\t/src/main.go:3 +0x1d\n",
+	}
+	p.SetAttr("route", "/a|b")
+
+	assert.Equal(t, "### panic: not at a \\*disco\\*\n\n"+
+		"| | |\n| --- | --- |\n"+
+		"| Type | `string` |\n"+
+		"| Fingerprint | `"+p.Fingerprint()+"` |\n"+
+		"| Culprit | `main.main (/src/main.go:3)` |\n"+
+		"| Time | 2021-04-01T12:00:00Z |\n"+
+		"| `route` | `/a\\|b` |\n"+
+		"\n<details>\n<summary>Stack trace</summary>\n\n"+
+		"```\ngoroutine 1 [running]:\nmain.main()\n\t/src/main.go:3 +0x1d\n```\n"+
+		"\n</details>\n", p.Markdown())
+}
+
+func TestMarkdownFences(t *testing.T) {
+	p := &cpanic.Panic{Value: "`code`", Trace: "```\n"}
+	md := p.Markdown()
+	assert.Contains(t, md, "| Type | `string` |")
+	assert.True(t, strings.HasPrefix(md, "### panic: \\`code\\`\n"), md)
+	assert.Contains(t, md, "\n````\n```\n````\n")
+	assert.NotContains(t, md, "| Time |")
+}
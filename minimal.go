@@ -0,0 +1,57 @@
+//go:build tinygo || cpanic_minimal
+// +build tinygo cpanic_minimal
+
+package cpanic
+
+import (
+	"errors"
+	"io"
+	"runtime"
+	"time"
+)
+
+// The minimal build is used by TinyGo and with the `cpanic_minimal` build tag, for
+// constrained targets such as WASM plugins. It captures only the panicking goroutine
+// in a small buffer, and leaves out the executable parsing used by `BuildID` and
+// `Symbolize`, and the packages for processes, signals, archives, and profiles: `Cmd`,
+// `Harness`, `GoLabeled`, and the SIGQUIT dumps of `Run` are unavailable, and `Bundle`
+// and `ProfileRepeated` do nothing useful. The labels of a `Task` are not applied.
+
+// captureTrace returns the stack trace of the current goroutine, up to 4 KiB.
+func captureTrace() string {
+	var trace [1 << 12]byte
+	n := runtime.Stack(trace[:], false)
+	return string(trace[:n])
+}
+
+// executableBuildID is not read from the executable in minimal builds.
+func executableBuildID() string {
+	return ""
+}
+
+// Bundle is unsupported in minimal builds, which leave out "archive/zip" and
+// "runtime/pprof".
+func (p *Panic) Bundle(dir string) (string, error) {
+	return "", errors.New("cpanic: Bundle is unsupported in minimal builds")
+}
+
+// SealedBundle is unsupported in minimal builds, like `Bundle`.
+func (p *Panic) SealedBundle(dir string, k *Keyring) (string, error) {
+	return "", errors.New("cpanic: SealedBundle is unsupported in minimal builds")
+}
+
+// cpuProfile does not profile in minimal builds, which leave out "runtime/pprof".
+func cpuProfile(d time.Duration) ([]byte, bool) {
+	return nil, false
+}
+
+// Symbolize is unsupported in minimal builds; symbolize program counters recorded by a
+// minimal build with a full build of `cpanic`.
+func Symbolize(pcs []uintptr, table io.ReaderAt) ([]Frame, error) {
+	return nil, errors.New("cpanic: Symbolize is unsupported in minimal builds")
+}
+
+// doLabeled calls fn; goroutine labels require `runtime/pprof`.
+func doLabeled(labels []string, fn func()) {
+	fn()
+}
@@ -0,0 +1,24 @@
+package cpanic
+
+import "io"
+
+// NDJSONHandler returns a handler that writes each panic to w as a single line of JSON,
+// so panics can be tailed by log shippers without any custom parsing. Each object holds
+// the time, value, value type, fingerprint, culprit, attributes, and trace of the
+// panic. After every line, w is flushed if it has a `Flush() error` or `Sync() error`
+// method, such as a `*bufio.Writer` or an `*os.File`. Writes are serialized, so the
+// handler can be shared by concurrently recovering goroutines. It is `WriterHandler`
+// with the `NDJSON` formatter.
+func NDJSONHandler(w io.Writer) Handler {
+	return WriterHandler(w, NDJSON)
+}
+
+// flush flushes the writer if it supports it.
+func flush(w io.Writer) {
+	switch f := w.(type) {
+	case interface{ Flush() error }:
+		_ = f.Flush()
+	case interface{ Sync() error }:
+		_ = f.Sync()
+	}
+}
@@ -0,0 +1,110 @@
+package cpanic_test
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/demosdemon/cpanic"
+)
+
+func TestNDJSONHandler(t *testing.T) {
+	var buf bytes.Buffer
+	w := bufio.NewWriter(&buf)
+	h := cpanic.NDJSONHandler(w)
+
+	p := recovered(nilDeref)
+	p.SetAttr("request_id", "abc")
+	p.SetAttr("callback", func() {})
+	h(p)
+	h(cpanic.New(errors.New("test")))
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if !assert.Len(t, lines, 2, "the writer must be flushed after each line") {
+		return
+	}
+
+	var record map[string]interface{}
+	assert.NoError(t, json.Unmarshal([]byte(lines[0]), &record))
+	assert.Equal(t, "runtime error: invalid memory address or nil pointer dereference", record["value"])
+	assert.Equal(t, "runtime.errorString", record["type"])
+	assert.Equal(t, p.Fingerprint(), record["fingerprint"])
+	assert.Contains(t, record["culprit"], "cpanic_test.nilDeref")
+	assert.Equal(t, p.Trace, record["trace"])
+	if attrs, ok := record["attrs"].(map[string]interface{}); assert.True(t, ok) {
+		assert.Equal(t, "abc", attrs["request_id"])
+		assert.Contains(t, attrs["callback"], "0x")
+	}
+
+	assert.NoError(t, json.Unmarshal([]byte(lines[1]), &record))
+	assert.Equal(t, "test", record["value"])
+	assert.Equal(t, "*errors.errorString", record["type"])
+}
+
+func TestParseReport(t *testing.T) {
+	p := recovered(nilDeref)
+	p.Severity = cpanic.SeverityFatal
+	p.SetAttr("request_id", "abc")
+	b, err := cpanic.NDJSON.Format(p)
+	require.NoError(t, err)
+
+	got, err := cpanic.ParseReport(b)
+	require.NoError(t, err)
+	assert.Equal(t, p.ID, got.ID)
+	assert.True(t, p.Time.Equal(got.Time))
+	assert.Equal(t, p.Message(), got.Value)
+	assert.Equal(t, p.Type(), got.Type())
+	assert.Equal(t, p.Fingerprint(), got.Fingerprint())
+	assert.Equal(t, cpanic.SeverityFatal, got.Severity)
+	assert.Equal(t, map[string]interface{}{"request_id": "abc"}, got.Attrs)
+
+	_, err = cpanic.ParseReport([]byte(`{"value": "no type"}`))
+	assert.Equal(t, cpanic.ErrInvalidReport, err)
+	_, err = cpanic.ParseReport([]byte(`{"type": "string", "severity": "dire"}`))
+	assert.Error(t, err)
+}
+
+func TestParseReportSchemaVersion(t *testing.T) {
+	b, err := cpanic.NDJSON.Format(cpanic.New("boom"))
+	require.NoError(t, err)
+	assert.Contains(t, string(b), fmt.Sprintf(`"schema_version":%d`, cpanic.SchemaVersion))
+
+	p, err := cpanic.ParseReport([]byte(`{"time": "2020-01-02T03:04:05Z", "value": "boom", "type": "string", "severity": "fatal"}`))
+	require.NoError(t, err)
+	assert.Equal(t, "boom", p.Value)
+	assert.Equal(t, cpanic.SeverityFatal, p.Severity)
+
+	_, err = cpanic.ParseReport([]byte(fmt.Sprintf(`{"type": "string", "schema_version": %d}`, cpanic.SchemaVersion+1)))
+	assert.True(t, errors.Is(err, cpanic.ErrSchemaVersion), err)
+}
+
+func TestParseReportMigrateV1(t *testing.T) {
+	p := recovered(nilDeref)
+	b, err := cpanic.NDJSON.Format(p)
+	require.NoError(t, err)
+
+	var v1 map[string]interface{}
+	require.NoError(t, json.Unmarshal(b, &v1))
+	require.Contains(t, v1, "stack")
+	for _, member := range []string{"schema_version", "stack", "public_message", "pcs"} {
+		delete(v1, member)
+	}
+	b, err = json.Marshal(v1)
+	require.NoError(t, err)
+
+	got, err := cpanic.ParseReport(b)
+	require.NoError(t, err)
+	assert.Equal(t, p.Frames(), got.Stack, "the stack is derived from the trace")
+	assert.Equal(t, p.Fingerprint(), got.Fingerprint())
+
+	got, err = cpanic.ParseReport([]byte(`{"type": "string", "value": "boom"}`))
+	require.NoError(t, err)
+	assert.Nil(t, got.Stack)
+}
@@ -0,0 +1,75 @@
+package cpanic
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+var (
+	goroutineIDPattern  = regexp.MustCompile(`\bgoroutine \d+\b`)
+	waitDurationPattern = regexp.MustCompile(`, \d+ minutes\]`)
+	hexPattern          = regexp.MustCompile(`\b0x[0-9a-fA-F]+\b`)
+	lineNumberPattern   = regexp.MustCompile(`(\.go|\.s|\?):\d+\b`)
+)
+
+// NormalizeOption configures `Normalize`.
+type NormalizeOption func(*normalizeConfig)
+
+type normalizeConfig struct {
+	stripLineNumbers bool
+	tempDirs         []string
+}
+
+// StripLineNumbers replaces line numbers with "N", for comparisons that should survive
+// unrelated edits to the source files.
+func StripLineNumbers() NormalizeOption {
+	return func(c *normalizeConfig) {
+		c.stripLineNumbers = true
+	}
+}
+
+// WithTempDir adds a directory whose paths are treated as temporary, in addition to
+// `os.TempDir`.
+func WithTempDir(dir string) NormalizeOption {
+	return func(c *normalizeConfig) {
+		c.tempDirs = append(c.tempDirs, dir)
+	}
+}
+
+// Normalize strips the details of a stack trace that differ between otherwise
+// identical panics so traces can be compared, deduplicated, or checked against golden
+// files. Goroutine IDs are replaced with "N", hexadecimal values such as addresses,
+// pointers in panic messages, and program counter offsets with "0x?", and the unique
+// directory under a temporary directory with "$TMPDIR". Goroutine wait durations are
+// removed.
+func Normalize(trace string, opts ...NormalizeOption) string {
+	c := normalizeConfig{tempDirs: []string{os.TempDir()}}
+	for _, opt := range opts {
+		opt(&c)
+	}
+
+	for _, dir := range c.tempDirs {
+		trace = stripTempDir(trace, filepath.ToSlash(filepath.Clean(dir)))
+	}
+
+	trace = goroutineIDPattern.ReplaceAllString(trace, "goroutine N")
+	trace = waitDurationPattern.ReplaceAllString(trace, "]")
+	trace = hexPattern.ReplaceAllString(trace, "0x?")
+	if c.stripLineNumbers {
+		trace = lineNumberPattern.ReplaceAllString(trace, "${1}:N")
+	}
+	return trace
+}
+
+// stripTempDir replaces the temporary directory and the first path element beneath it,
+// which is usually randomly generated, with "$TMPDIR".
+func stripTempDir(trace, dir string) string {
+	if dir == "" || dir == "." || dir == "/" {
+		return trace
+	}
+
+	pattern := regexp.MustCompile(regexp.QuoteMeta(strings.TrimSuffix(dir, "/")) + `/[^/\s:]+`)
+	return pattern.ReplaceAllString(trace, "$$TMPDIR")
+}
@@ -0,0 +1,87 @@
+package cpanic_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/demosdemon/cpanic"
+)
+
+const rawTrace = `goroutine 42 [running]:
+main.boom(0xc000012345)
+	/tmp/go-build123456/b001/main.go:12 +0x1d
+main.main()
+	/src/app/main.go:7 +0x25
+
+goroutine 7 [chan receive, 3 minutes]:
+main.worker()
+	/src/app/worker.go:30 +0x3f
+created by main.main in goroutine 1
+	/src/app/main.go:5 +0x1b
+`
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []cpanic.NormalizeOption
+		want string
+	}{
+		{
+			name: "default",
+			opts: []cpanic.NormalizeOption{cpanic.WithTempDir("/tmp")},
+			want: `goroutine N [running]:
+main.boom(0x?)
+	$TMPDIR/b001/main.go:12 +0x?
+main.main()
+	/src/app/main.go:7 +0x?
+
+goroutine N [chan receive]:
+main.worker()
+	/src/app/worker.go:30 +0x?
+created by main.main in goroutine N
+	/src/app/main.go:5 +0x?
+`,
+		},
+		{
+			name: "strip line numbers",
+			opts: []cpanic.NormalizeOption{cpanic.StripLineNumbers(), cpanic.WithTempDir("/tmp"), cpanic.WithTempDir("/src")},
+			want: `goroutine N [running]:
+main.boom(0x?)
+	$TMPDIR/b001/main.go:N +0x?
+main.main()
+	$TMPDIR/main.go:N +0x?
+
+goroutine N [chan receive]:
+main.worker()
+	$TMPDIR/worker.go:N +0x?
+created by main.main in goroutine N
+	$TMPDIR/main.go:N +0x?
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, cpanic.Normalize(rawTrace, tt.opts...))
+		})
+	}
+}
+
+func TestNormalizeTrace(t *testing.T) {
+	a := cpanic.New("not at a disco")
+	b := cpanic.New("not at a disco")
+	assert.Equal(t,
+		cpanic.Normalize(firstGoroutine(a.Trace), cpanic.StripLineNumbers()),
+		cpanic.Normalize(firstGoroutine(b.Trace), cpanic.StripLineNumbers()),
+	)
+}
+
+func firstGoroutine(trace string) string {
+	if idx := strings.Index(trace, "\n\n"); idx >= 0 {
+		return trace[:idx]
+	}
+	return trace
+}
@@ -0,0 +1,15 @@
+//go:build plan9 || tinygo || cpanic_minimal
+// +build plan9 tinygo cpanic_minimal
+
+package cpanic
+
+import "os"
+
+// dumpSignals is empty on plan9, which has notes instead of SIGQUIT, and in minimal
+// builds, which leave out "os/signal".
+var dumpSignals []os.Signal
+
+// notifyDump is never called without `dumpSignals`.
+func notifyDump(h Handler) (stop func()) {
+	return func() {}
+}
@@ -0,0 +1,126 @@
+package cpanic
+
+import (
+	"errors"
+	"strings"
+)
+
+// ErrNoTrace is returned by `Parse` when the input holds no goroutine trace.
+var ErrNoTrace = errors.New("cpanic: no goroutine trace found")
+
+// Parse parses the crash output of a Go program, such as the "panic: ..." message and
+// goroutine dump written to stderr by an unrecovered panic, back into a `*Panic`, so
+// crash logs can be analyzed with the same tools as recovered panics. Output that
+// precedes the message or follows the trace is ignored.
+//
+// The value of the returned panic is its message, a `string`, and its time is zero.
+// Runtime errors such as "fatal error: all goroutines are asleep" are parsed too, with
+// the message as the value. As the fingerprint includes the type of the value, a
+// parsed panic only shares its fingerprint with the recovered panic if its value was a
+// string.
+func Parse(dump string) (*Panic, error) {
+	lines := strings.Split(strings.Replace(dump, "\r\n", "\n", -1), "\n")
+
+	start := -1
+	for i, line := range lines {
+		if isGoroutineHeader(line) {
+			start = i
+			break
+		}
+	}
+	if start < 0 {
+		return nil, ErrNoTrace
+	}
+
+	p := &Panic{Value: parseMessage(lines[:start])}
+
+	end := start
+	for end < len(lines) && isTraceLine(lines[end]) {
+		end++
+	}
+	p.Trace = strings.TrimRight(strings.Join(lines[start:end], "\n"), "\n") + "\n"
+	return p, nil
+}
+
+// parseMessage finds the last panic message before the trace. Nested panics, printed
+// as "panic: a [recovered]" followed by indented "panic: b" lines, are joined as they
+// were printed.
+func parseMessage(lines []string) string {
+	for i := len(lines) - 1; i >= 0; i-- {
+		line := lines[i]
+		for _, prefix := range []string{"panic: ", "fatal error: "} {
+			if !strings.HasPrefix(line, prefix) {
+				continue
+			}
+
+			// Explicit message lines belong to a nested panic if indented, or to a
+			// multi-line panic message otherwise, up to the blank line before the trace.
+			msg := []string{strings.TrimPrefix(line, prefix)}
+			for _, next := range lines[i+1:] {
+				if next == "" || strings.HasPrefix(next, "[signal ") {
+					break
+				}
+				msg = append(msg, next)
+			}
+			for j := i - 1; j >= 0 && strings.HasPrefix(lines[j], "panic: ") && strings.HasSuffix(lines[j], " [recovered]"); j-- {
+				msg = append([]string{strings.TrimPrefix(lines[j], "panic: ")}, msg...)
+			}
+			return strings.Join(msg, "\n")
+		}
+	}
+	return ""
+}
+
+// isGoroutineHeader reports whether the line starts a goroutine in a trace, such as
+// "goroutine 1 [running]:".
+func isGoroutineHeader(line string) bool {
+	_, ok := parseGoroutineHeader(line)
+	return ok
+}
+
+// isTraceLine reports whether the line can be part of a goroutine trace.
+func isTraceLine(line string) bool {
+	switch {
+	case line == "",
+		strings.HasPrefix(line, "\t"),
+		strings.HasPrefix(line, "created by "),
+		strings.HasPrefix(line, "..."),
+		isGoroutineHeader(line):
+		return true
+	default:
+		// A function call, such as "main.(*T).Method(0x1, ...)" or "panic({0x1, 0x2})".
+		idx := strings.IndexByte(line, '(')
+		return idx > 0 && strings.HasSuffix(line, ")") && !strings.Contains(line[:idx], " ")
+	}
+}
+
+// MustParseLenient is like `Parse` for crash output that is truncated or interleaved
+// with other output, such as application logs merged into the same stream. It never
+// fails: every goroutine trace in the dump is kept, lines that cannot be part of a trace
+// are dropped, and if the dump holds no trace, the returned panic has only the message,
+// if any.
+func MustParseLenient(dump string) *Panic {
+	lines := strings.Split(strings.Replace(dump, "\r\n", "\n", -1), "\n")
+
+	start := len(lines)
+	for i, line := range lines {
+		if isGoroutineHeader(line) {
+			start = i
+			break
+		}
+	}
+
+	p := &Panic{Value: parseMessage(lines[:start])}
+
+	var trace []string
+	for _, line := range lines[start:] {
+		if !isTraceLine(line) || (line == "" && (len(trace) == 0 || trace[len(trace)-1] == "")) {
+			continue
+		}
+		trace = append(trace, line)
+	}
+	if len(trace) > 0 {
+		p.Trace = strings.TrimRight(strings.Join(trace, "\n"), "\n") + "\n"
+	}
+	return p
+}
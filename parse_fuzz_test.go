@@ -0,0 +1,46 @@
+//go:build go1.18
+// +build go1.18
+
+package cpanic_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/demosdemon/cpanic"
+)
+
+// parseSeeds are real-world shapes of crash output: complete dumps, truncated ones, and
+// dumps interleaved with application logs.
+var parseSeeds = []string{
+	crashLog,
+	crashLog[:strings.Index(crashLog, "\t/src/main.go:5")],
+	strings.Replace(crashLog, "main.main.func1()\n", "main.main.func1()\n2021/04/01 12:00:01 request served\n", 1),
+	"panic: boom [recovered]\n\tpanic: again\n\ngoroutine 1 [running]:\n",
+	"fatal error: all goroutines are asleep - deadlock!\n\ngoroutine 1 [chan receive]:\nmain.main()\n",
+	"goroutine 1 [running, locked to thread] {key: value}:\n...additional frames elided...\n",
+	"goroutine x [running]:\n",
+	"goroutine 1 [:\n",
+	"goroutine 1 ]running[:\n",
+	"",
+}
+
+func FuzzParse(f *testing.F) {
+	for _, seed := range parseSeeds {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, dump string) {
+		if p, err := cpanic.Parse(dump); err == nil {
+			if p.Trace == "" {
+				t.Fatal("parsed panic has no trace")
+			}
+			_ = p.Frames()
+			_, _ = p.Culprit()
+			_ = p.Fingerprint()
+		}
+
+		p := cpanic.MustParseLenient(dump)
+		_ = p.Frames()
+		_ = p.Fingerprint()
+	})
+}
@@ -0,0 +1,145 @@
+package cpanic_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/demosdemon/cpanic"
+)
+
+const crashLog = `2021/04/01 12:00:00 starting server
+panic: assignment to entry in nil map
+
+goroutine 1 [running]:
+main.main()
+	/src/main.go:6 +0x34
+
+goroutine 18 [select (no cases)]:
+main.main.func1()
+	/src/main.go:5 +0x1d
+created by main.main in goroutine 1
+	/src/main.go:5 +0x1e
+exit status 2
+`
+
+func TestParse(t *testing.T) {
+	p, err := cpanic.Parse(crashLog)
+	require.NoError(t, err)
+	assert.Equal(t, "assignment to entry in nil map", p.Value)
+	assert.True(t, p.Time.IsZero())
+	assert.Equal(t, `goroutine 1 [running]:
+main.main()
+	/src/main.go:6 +0x34
+
+goroutine 18 [select (no cases)]:
+main.main.func1()
+	/src/main.go:5 +0x1d
+created by main.main in goroutine 1
+	/src/main.go:5 +0x1e
+`, p.Trace)
+
+	goroutines := p.Goroutines()
+	require.Len(t, goroutines, 2)
+	assert.Equal(t, "select (no cases)", goroutines[1].State)
+	assert.Equal(t, []cpanic.Frame{{Function: "main.main", File: "/src/main.go", Line: 6}}, p.Frames())
+}
+
+func TestParseMessages(t *testing.T) {
+	cases := []struct {
+		name string
+		dump string
+		want string
+	}{
+		{
+			name: "multi-line",
+			dump: "panic: line one\nline two\n\ngoroutine 1 [running]:\nmain.main()\n",
+			want: "line one\nline two",
+		},
+		{
+			name: "recovered",
+			dump: "panic: first [recovered]\n\tpanic: second\n\ngoroutine 1 [running]:\nmain.main()\n",
+			want: "first [recovered]\n\tpanic: second",
+		},
+		{
+			name: "signal",
+			dump: "panic: runtime error: invalid memory address or nil pointer dereference\n" +
+				"[signal SIGSEGV: segmentation violation code=0x1 addr=0x0 pc=0x1]\n\n" +
+				"goroutine 1 [running]:\nmain.main()\n",
+			want: "runtime error: invalid memory address or nil pointer dereference",
+		},
+		{
+			name: "fatal error",
+			dump: "fatal error: all goroutines are asleep - deadlock!\n\ngoroutine 1 [chan receive]:\nmain.main()\n",
+			want: "all goroutines are asleep - deadlock!",
+		},
+		{
+			name: "trace only",
+			dump: "goroutine 1 [running]:\nmain.main()\n",
+			want: "",
+		},
+		{
+			name: "windows",
+			dump: "panic: boom\r\n\r\ngoroutine 1 [running]:\r\nmain.main()\r\n",
+			want: "boom",
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			p, err := cpanic.Parse(tc.dump)
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, p.Value)
+			assert.Equal(t, "goroutine 1", p.Trace[:len("goroutine 1")])
+		})
+	}
+}
+
+func TestParseNoTrace(t *testing.T) {
+	_, err := cpanic.Parse("panic: boom\n")
+	assert.Equal(t, cpanic.ErrNoTrace, err)
+}
+
+func TestParseRoundTrip(t *testing.T) {
+	p := cpanic.New("not at a disco")
+	parsed, err := cpanic.Parse(p.String())
+	require.NoError(t, err)
+	assert.Equal(t, p.Value, parsed.Value)
+	assert.Equal(t, p.Fingerprint(), parsed.Fingerprint())
+}
+
+func TestMustParseLenient(t *testing.T) {
+	interleaved := `panic: assignment to entry in nil map
+
+goroutine 1 [running]:
+main.main()
+2021/04/01 12:00:01 request served
+	/src/main.go:6 +0x34
+2021/04/01 12:00:02 request served
+
+goroutine 18 [select (no cases)]:
+main.main.func1()
+	/src/main.go:5 +0x1d
+created by main.main in goroutine 1
+	/src/ma`
+
+	p := cpanic.MustParseLenient(interleaved)
+	assert.Equal(t, "assignment to entry in nil map", p.Value)
+	assert.Equal(t, `goroutine 1 [running]:
+main.main()
+	/src/main.go:6 +0x34
+
+goroutine 18 [select (no cases)]:
+main.main.func1()
+	/src/main.go:5 +0x1d
+created by main.main in goroutine 1
+	/src/ma
+`, p.Trace)
+	assert.Equal(t, "main.main", p.Frames()[0].Function)
+
+	p = cpanic.MustParseLenient("panic: boom\n")
+	assert.Equal(t, "boom", p.Value)
+	assert.Empty(t, p.Trace)
+}
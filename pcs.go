@@ -0,0 +1,46 @@
+package cpanic
+
+import (
+	"reflect"
+	"runtime"
+	"sync/atomic"
+)
+
+// maxProgramCounters is the deepest stack recorded by `SetProgramCounters`.
+const maxProgramCounters = 64
+
+// anchorFunc is the fully qualified name of the function whose address program counters
+// are recorded relative to.
+const anchorFunc = "github.com/demosdemon/cpanic.New"
+
+var recordPCs int32
+
+// SetProgramCounters makes `New` record the raw program counters of the stack that
+// created the panic in `PCs`, a compact form of the trace that can be shipped from
+// production and symbolized offline with `Symbolize`.
+func SetProgramCounters(enabled bool) {
+	var v int32
+	if enabled {
+		v = 1
+	}
+	atomic.StoreInt32(&recordPCs, v)
+}
+
+// programCounters returns the program counters of the caller of `New` and up, relative
+// to the address of `New`, so they can be symbolized against position-independent
+// executables whose load address differs from run to run. skip is the number of frames
+// between `New`, or `NewEvent`, and programCounters.
+func programCounters(skip int) []uintptr {
+	if atomic.LoadInt32(&recordPCs) == 0 {
+		return nil
+	}
+
+	var pcs [maxProgramCounters]uintptr
+	n := runtime.Callers(3+skip, pcs[:])
+	anchor := reflect.ValueOf(New).Pointer()
+	out := make([]uintptr, n)
+	for i, pc := range pcs[:n] {
+		out[i] = pc - anchor
+	}
+	return out
+}
@@ -0,0 +1,50 @@
+//go:build !tinygo && !cpanic_minimal
+// +build !tinygo,!cpanic_minimal
+
+package cpanic_test
+
+import (
+	"os"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/demosdemon/cpanic"
+)
+
+func TestSymbolize(t *testing.T) {
+	if runtime.GOOS != "linux" && runtime.GOOS != "darwin" {
+		t.Skip("symbolization requires an ELF or Mach-O binary")
+	}
+
+	assert.Empty(t, cpanic.New("not at a disco").PCs)
+
+	defer cpanic.SetProgramCounters(false)
+	cpanic.SetProgramCounters(true)
+	p := recovered(nilDeref)
+	require.NotEmpty(t, p.PCs)
+
+	exe, err := os.Open(os.Args[0])
+	require.NoError(t, err)
+	defer exe.Close()
+
+	frames, err := cpanic.Symbolize(p.PCs, exe)
+	require.NoError(t, err)
+	require.Len(t, frames, len(p.PCs))
+
+	var functions []string
+	for _, f := range frames {
+		functions = append(functions, f.Function)
+	}
+	assert.Contains(t, functions, "github.com/demosdemon/cpanic_test.nilDeref")
+	assert.Contains(t, functions, "github.com/demosdemon/cpanic_test.TestSymbolize")
+	culprit, _ := p.Culprit()
+	for _, f := range frames {
+		if f.Function == culprit.Function {
+			assert.Equal(t, culprit.File, f.File)
+			assert.Equal(t, culprit.Line, f.Line)
+		}
+	}
+}
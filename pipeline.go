@@ -0,0 +1,60 @@
+package cpanic
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+var pipeline struct {
+	handlersInvoked uint64
+	handlerFailures uint64
+	dropped         uint64
+	flushes         uint64
+	flushNanos      uint64
+	queueDepth      int64
+}
+
+// Stats are counters about the reporting pipeline itself, so operators can check that
+// panic reporting is healthy.
+type Stats struct {
+	// HandlersInvoked is the number of panics delivered to a handler where they were
+	// recovered. A panic passed through middleware, such as `MinSeverity`, is counted
+	// once.
+	HandlersInvoked uint64
+	// HandlerFailures is the number of errors returned by handlers that the pipeline
+	// absorbed, such as a failed attempt of `WithRetry` or `Failover`, or an error
+	// discarded by `HandlerFunc.Handler`.
+	HandlerFailures uint64
+	// Dropped is the number of panics that were never delivered, because they were
+	// `Suppressed` or every handler of a `Failover` failed.
+	Dropped uint64
+	// QueueDepth is the number of panics waiting in a `Batcher`.
+	QueueDepth int64
+	// Flushes is the number of batches delivered by a `Batcher`.
+	Flushes uint64
+	// FlushDuration is the total time spent delivering batches.
+	FlushDuration time.Duration
+}
+
+// PipelineStats returns the counters of the reporting pipeline since the process
+// started.
+func PipelineStats() Stats {
+	return Stats{
+		HandlersInvoked: atomic.LoadUint64(&pipeline.handlersInvoked),
+		HandlerFailures: atomic.LoadUint64(&pipeline.handlerFailures),
+		Dropped:         atomic.LoadUint64(&pipeline.dropped),
+		QueueDepth:      atomic.LoadInt64(&pipeline.queueDepth),
+		Flushes:         atomic.LoadUint64(&pipeline.flushes),
+		FlushDuration:   time.Duration(atomic.LoadUint64(&pipeline.flushNanos)),
+	}
+}
+
+func countInvoked()     { atomic.AddUint64(&pipeline.handlersInvoked, 1) }
+func countFailure()     { atomic.AddUint64(&pipeline.handlerFailures, 1) }
+func countDropped()     { atomic.AddUint64(&pipeline.dropped, 1) }
+func countQueued(n int) { atomic.AddInt64(&pipeline.queueDepth, int64(n)) }
+
+func countFlush(d time.Duration) {
+	atomic.AddUint64(&pipeline.flushes, 1)
+	atomic.AddUint64(&pipeline.flushNanos, uint64(d))
+}
@@ -0,0 +1,34 @@
+package cpanic_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/demosdemon/cpanic"
+)
+
+func TestPipelineStats(t *testing.T) {
+	before := cpanic.PipelineStats()
+
+	errDown := errors.New("down")
+	failing := cpanic.TryHandlerFunc(func(*cpanic.Panic) error { return errDown })
+	func() {
+		defer cpanic.Recover(cpanic.MinSeverity(cpanic.Failover(failing, failing), cpanic.SeverityError))
+		panic("not at a disco")
+	}()
+
+	b := cpanic.Batch(func([]*cpanic.Panic) {}, 0, 0)
+	b.Handle(cpanic.New("not at a disco"))
+	queued := cpanic.PipelineStats()
+	b.Flush()
+
+	after := cpanic.PipelineStats()
+	assert.Equal(t, uint64(1), after.HandlersInvoked-before.HandlersInvoked)
+	assert.Equal(t, uint64(2), after.HandlerFailures-before.HandlerFailures)
+	assert.Equal(t, uint64(1), after.Dropped-before.Dropped)
+	assert.Equal(t, int64(1), queued.QueueDepth-before.QueueDepth)
+	assert.Equal(t, before.QueueDepth, after.QueueDepth)
+	assert.Equal(t, uint64(1), after.Flushes-before.Flushes)
+}
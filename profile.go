@@ -0,0 +1,89 @@
+package cpanic
+
+import (
+	"sync"
+	"time"
+)
+
+// CPUProfileAttr is the attribute holding the CPU profile, in the pprof format, attached
+// by `ProfileRepeated` to a panic whose fingerprint is repeating.
+const CPUProfileAttr = "profile.cpu"
+
+// maxProfileKeys is the number of fingerprints above which `Profiler` stops tracking new
+// ones.
+const maxProfileKeys = 1024
+
+// Profiler is a handler that captures a short CPU profile when a fingerprint repeats,
+// since crash loops often correlate with pathological code paths worth profiling.
+// Create one with `ProfileRepeated`.
+type Profiler struct {
+	h         Handler
+	threshold int
+	duration  time.Duration
+
+	mu       sync.Mutex
+	counts   map[string]int
+	profiles map[string][]byte
+}
+
+// ProfileRepeated returns a `Profiler` passing panics to h. When a fingerprint occurs
+// more than threshold times, a CPU profile of the process is captured in the background
+// for d, and attached as `CPUProfileAttr` to the next panic with that fingerprint. Each
+// fingerprint is profiled at most once. Only one CPU profile can run in a process at a
+// time, so no profile is captured while another is running, such as one started by
+// "net/http/pprof", nor in minimal builds.
+func ProfileRepeated(h Handler, threshold int, d time.Duration) *Profiler {
+	return &Profiler{
+		h:         h,
+		threshold: threshold,
+		duration:  d,
+		counts:    make(map[string]int),
+		profiles:  make(map[string][]byte),
+	}
+}
+
+// Handle counts the panic against its fingerprint, starts a profile if the fingerprint
+// exceeds the threshold, and passes the panic to the handler with the profile of the
+// fingerprint, if one has been captured.
+func (r *Profiler) Handle(p *Panic) {
+	fingerprint := p.Fingerprint()
+	profile, start := r.count(fingerprint)
+	if profile != nil {
+		p.SetAttr(CPUProfileAttr, profile)
+	}
+	if start {
+		go r.capture(fingerprint)
+	}
+	r.h.Handle(p)
+}
+
+// count counts the occurrence of the fingerprint, returning its captured profile, if
+// any, and whether a profile should be started.
+func (r *Profiler) count(fingerprint string) ([]byte, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	n, ok := r.counts[fingerprint]
+	if !ok && len(r.counts) >= maxProfileKeys {
+		return nil, false
+	}
+	n++
+	r.counts[fingerprint] = n
+
+	if profile, ok := r.profiles[fingerprint]; ok && profile != nil {
+		r.profiles[fingerprint] = nil
+		return profile, false
+	}
+	return nil, n == r.threshold+1
+}
+
+func (r *Profiler) capture(fingerprint string) {
+	profile, ok := cpuProfile(r.duration)
+	if !ok {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.profiles[fingerprint] = profile
+}
@@ -0,0 +1,38 @@
+//go:build !tinygo && !cpanic_minimal
+// +build !tinygo,!cpanic_minimal
+
+package cpanic_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/demosdemon/cpanic"
+)
+
+func TestProfileRepeated(t *testing.T) {
+	var got []*cpanic.Panic
+	r := cpanic.ProfileRepeated(func(p *cpanic.Panic) {
+		got = append(got, p)
+	}, 2, 10*time.Millisecond)
+
+	boom := func() {
+		r.Handle(cpanic.New("boom"))
+	}
+	for i := 0; i < 3; i++ {
+		boom()
+	}
+	for _, p := range got {
+		assert.NotContains(t, p.Attrs, cpanic.CPUProfileAttr)
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	boom()
+	profile, _ := got[len(got)-1].Attrs[cpanic.CPUProfileAttr].([]byte)
+	assert.NotEmpty(t, profile)
+
+	boom()
+	assert.NotContains(t, got[len(got)-1].Attrs, cpanic.CPUProfileAttr)
+}
@@ -0,0 +1,58 @@
+package cpanic
+
+import "errors"
+
+// WithPublicMessage wraps err with a message that is safe to show to clients, such as
+// "the uploaded file is not a valid image". When a panic with the returned error is
+// recovered, `New` copies the message to `Panic.PublicMessage`. The error message and
+// chain of err are unchanged.
+func WithPublicMessage(err error, msg string) error {
+	return &publicError{err: err, msg: msg}
+}
+
+type publicError struct {
+	err error
+	msg string
+}
+
+func (e *publicError) Error() string {
+	return e.err.Error()
+}
+
+func (e *publicError) Unwrap() error {
+	return e.err
+}
+
+func (e *publicError) PublicMessage() string {
+	return e.msg
+}
+
+// publicMessager is implemented by panic values carrying a client-facing message.
+type publicMessager interface {
+	PublicMessage() string
+}
+
+// publicMessage returns the client-facing message carried by the panic value, or by
+// any error in its chain.
+func publicMessage(v interface{}) string {
+	if pm, ok := v.(publicMessager); ok {
+		return pm.PublicMessage()
+	}
+	if err, ok := v.(error); ok {
+		var pm publicMessager
+		if errors.As(err, &pm) {
+			return pm.PublicMessage()
+		}
+	}
+	return ""
+}
+
+// PublicMessageOr returns the panic's `PublicMessage`, or fallback if it has none.
+// Integrations use it when rendering errors for clients, so internal details never
+// leak while the full panic still reaches the handler.
+func (p *Panic) PublicMessageOr(fallback string) string {
+	if p.PublicMessage != "" {
+		return p.PublicMessage
+	}
+	return fallback
+}
@@ -0,0 +1,46 @@
+package cpanic_test
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/demosdemon/cpanic"
+)
+
+type publicValue struct{}
+
+func (publicValue) PublicMessage() string { return "try again later" }
+
+func TestWithPublicMessage(t *testing.T) {
+	err := cpanic.WithPublicMessage(io.ErrUnexpectedEOF, "the upload was cut short")
+	assert.EqualError(t, err, io.ErrUnexpectedEOF.Error())
+	assert.True(t, errors.Is(err, io.ErrUnexpectedEOF))
+
+	cases := []struct {
+		name  string
+		value interface{}
+		want  string
+	}{
+		{"none", "not at a disco", ""},
+		{"error", err, "the upload was cut short"},
+		{"wrapped", fmt.Errorf("reading: %w", err), "the upload was cut short"},
+		{"value", publicValue{}, "try again later"},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			p := cpanic.New(tc.value)
+			assert.Equal(t, tc.want, p.PublicMessage)
+			if tc.want == "" {
+				assert.Equal(t, "fallback", p.PublicMessageOr("fallback"))
+			} else {
+				assert.Equal(t, tc.want, p.PublicMessageOr("fallback"))
+			}
+		})
+	}
+}
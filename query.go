@@ -0,0 +1,120 @@
+package cpanic
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Order is the order of the panics returned by `Query`.
+type Order int
+
+// The orders of `Query`.
+const (
+	// OldestFirst returns the panics in the order they occurred.
+	OldestFirst Order = iota
+	// NewestFirst returns the most recent panics first.
+	NewestFirst
+)
+
+// TimeRange is the range of times from Start, inclusive, to End, exclusive. A zero
+// bound leaves the range open on that side.
+type TimeRange struct {
+	Start time.Time
+	End   time.Time
+}
+
+// Contains reports whether t is in the range.
+func (r TimeRange) Contains(t time.Time) bool {
+	return (r.Start.IsZero() || !t.Before(r.Start)) && (r.End.IsZero() || t.Before(r.End))
+}
+
+// Filter selects panics from a store. The zero value selects every panic.
+type Filter struct {
+	// Fingerprint selects the panics with the fingerprint, if set.
+	Fingerprint string
+	// Kind selects the panics whose value has the type, as returned by `Panic.Type`,
+	// such as "runtime.boundsError", if set.
+	Kind string
+	// TimeRange selects the panics that occurred in the range.
+	TimeRange TimeRange
+	// AttrEquals selects the panics having every attribute with a value formatted by
+	// `fmt.Sprint` as the given string, so that values decoded from JSON, such as
+	// float64 numbers, match their original.
+	AttrEquals map[string]string
+	// Limit is the maximum number of panics returned, if positive. The first panics in
+	// the order are kept.
+	Limit int
+	// Order is the order of the panics returned.
+	Order Order
+}
+
+// Match reports whether the panic is selected by the filter, ignoring `Limit` and
+// `Order`.
+func (f Filter) Match(p *Panic) bool {
+	if f.Kind != "" && p.Type() != f.Kind {
+		return false
+	}
+	if !f.TimeRange.Contains(p.Time) {
+		return false
+	}
+	for k, want := range f.AttrEquals {
+		v, ok := p.Attrs[k]
+		if !ok || fmt.Sprint(v) != want {
+			return false
+		}
+	}
+	// The fingerprint hashes the trace, so it is checked last.
+	return f.Fingerprint == "" || p.Fingerprint() == f.Fingerprint
+}
+
+// Querier is a `Store` that selects panics itself, such as with an index, instead of
+// loading every panic.
+type Querier interface {
+	Store
+	// Query returns the panics selected by the filter, as `Query` does.
+	Query(f Filter) ([]*Panic, error)
+}
+
+// Query returns the panics of the store selected by the filter, so crash history can
+// be sliced by fingerprint, kind, time, or attributes. If the store is a `Querier`,
+// the query is delegated to it; otherwise every panic is loaded and matched with
+// `Filter.Match`.
+func Query(store Store, f Filter) ([]*Panic, error) {
+	if q, ok := store.(Querier); ok {
+		return q.Query(f)
+	}
+
+	panics, err := store.Load()
+	if err != nil {
+		return nil, err
+	}
+	return f.apply(panics), nil
+}
+
+// apply filters, orders, and limits the panics. Panics that occurred at the same time,
+// such as parsed panics, which have no time, keep the order of the store.
+func (f Filter) apply(panics []*Panic) []*Panic {
+	selected := panics[:0:0]
+	for _, p := range panics {
+		if f.Match(p) {
+			selected = append(selected, p)
+		}
+	}
+	if f.Order == NewestFirst {
+		for i, j := 0, len(selected)-1; i < j; i, j = i+1, j-1 {
+			selected[i], selected[j] = selected[j], selected[i]
+		}
+		sort.SliceStable(selected, func(i, j int) bool {
+			return selected[i].Time.After(selected[j].Time)
+		})
+	} else {
+		sort.SliceStable(selected, func(i, j int) bool {
+			return selected[i].Time.Before(selected[j].Time)
+		})
+	}
+	if f.Limit > 0 && len(selected) > f.Limit {
+		selected = selected[:f.Limit]
+	}
+	return selected
+}
@@ -0,0 +1,76 @@
+package cpanic_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/demosdemon/cpanic"
+)
+
+type querier struct {
+	*cpanic.History
+	filters []cpanic.Filter
+}
+
+func (q *querier) Query(f cpanic.Filter) ([]*cpanic.Panic, error) {
+	q.filters = append(q.filters, f)
+	return nil, nil
+}
+
+func TestQuery(t *testing.T) {
+	base := time.Date(2021, 4, 1, 12, 0, 0, 0, time.UTC)
+	at := func(p *cpanic.Panic, minutes int, attrs ...interface{}) *cpanic.Panic {
+		p.Time = base.Add(time.Duration(minutes) * time.Minute)
+		for i := 0; i < len(attrs); i += 2 {
+			p.SetAttr(attrs[i].(string), attrs[i+1])
+		}
+		return p
+	}
+	one := at(cpanic.New("one"), 0, "tenant", "acme", "retries", 3)
+	two := at(cpanic.New(errors.New("two")), 10, "tenant", "acme")
+	three := at(cpanic.New("three"), 5, "tenant", "globex", "retries", 3.0)
+
+	store := cpanic.NewHistory(0)
+	for _, p := range []*cpanic.Panic{one, two, three} {
+		store.Handle(p)
+	}
+	values := func(panics []*cpanic.Panic) []interface{} {
+		var out []interface{}
+		for _, p := range panics {
+			out = append(out, p.Message())
+		}
+		return out
+	}
+
+	tests := []struct {
+		name   string
+		filter cpanic.Filter
+		want   []interface{}
+	}{
+		{"everything by time", cpanic.Filter{}, []interface{}{"one", "three", "two"}},
+		{"newest first", cpanic.Filter{Order: cpanic.NewestFirst}, []interface{}{"two", "three", "one"}},
+		{"limit", cpanic.Filter{Order: cpanic.NewestFirst, Limit: 1}, []interface{}{"two"}},
+		{"kind", cpanic.Filter{Kind: "string"}, []interface{}{"one", "three"}},
+		{"fingerprint", cpanic.Filter{Fingerprint: two.Fingerprint()}, []interface{}{"two"}},
+		{"time range", cpanic.Filter{TimeRange: cpanic.TimeRange{Start: base.Add(5 * time.Minute), End: base.Add(10 * time.Minute)}}, []interface{}{"three"}},
+		{"attrs", cpanic.Filter{AttrEquals: map[string]string{"tenant": "acme"}}, []interface{}{"one", "two"}},
+		{"attrs decoded from JSON", cpanic.Filter{AttrEquals: map[string]string{"retries": "3"}}, []interface{}{"one", "three"}},
+		{"no match", cpanic.Filter{AttrEquals: map[string]string{"tenant": "initech"}}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			panics, err := cpanic.Query(store, tt.filter)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, values(panics))
+		})
+	}
+
+	q := &querier{History: store}
+	_, err := cpanic.Query(q, cpanic.Filter{Kind: "string"})
+	require.NoError(t, err)
+	assert.Equal(t, []cpanic.Filter{{Kind: "string"}}, q.filters)
+}
@@ -0,0 +1,57 @@
+package cpanic
+
+import (
+	"regexp"
+	"sync"
+)
+
+// corruptedState matches the messages of panics raised when the standard library detects
+// state that can no longer be trusted. Unlocking an unlocked mutex, concurrent map
+// misuse, and data races are fatal errors of the runtime that are never recovered, so
+// they are not matched.
+var corruptedState = regexp.MustCompile(`^sync: (negative WaitGroup counter|WaitGroup is reused before previous Wait has returned)`)
+
+// CorruptedState matches panics indicating that the state of the process is corrupted,
+// such as "sync: negative WaitGroup counter", after which the process cannot be trusted
+// to continue. It is the default matcher of
+// `SetRepanic`.
+var CorruptedState Matcher = MatchMessage(corruptedState)
+
+var repanics = struct {
+	sync.RWMutex
+	matchers []Matcher
+}{matchers: []Matcher{CorruptedState}}
+
+// SetRepanic replaces the matchers for panics that must never be recovered. Panics
+// matching any of them are still reported by the recovery functions of this package,
+// such as `Recover`, `RecoverCtx`, and `RecoverWith`, but are raised again with the
+// same value once the handler returns, so the process crashes instead of continuing in
+// a corrupted state. Functions that return the panic instead of reporting it, such as
+// `Forward` and `Catch`, raise it again immediately. It defaults to `CorruptedState`;
+// call it without matchers to recover every panic.
+func SetRepanic(matchers ...Matcher) {
+	repanics.Lock()
+	defer repanics.Unlock()
+	repanics.matchers = append([]Matcher(nil), matchers...)
+}
+
+// Unrecoverable reports whether the panic matches a matcher set with `SetRepanic`.
+func Unrecoverable(p *Panic) bool {
+	repanics.RLock()
+	defer repanics.RUnlock()
+	for _, m := range repanics.matchers {
+		if m(p) {
+			return true
+		}
+	}
+	return false
+}
+
+// repanic panics again with the original value of the panic if it is `Unrecoverable`,
+// even if the value was dropped by `SetValueSnapshot`.
+func repanic(p *Panic) {
+	value := p.takeValue()
+	if Unrecoverable(p) {
+		panic(value)
+	}
+}
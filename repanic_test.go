@@ -0,0 +1,73 @@
+package cpanic_test
+
+import (
+	"errors"
+	"regexp"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/demosdemon/cpanic"
+)
+
+func TestCorruptedState(t *testing.T) {
+	for _, value := range []interface{}{
+		"sync: negative WaitGroup counter",
+		errors.New("sync: WaitGroup is reused before previous Wait has returned"),
+	} {
+		assert.True(t, cpanic.CorruptedState(&cpanic.Panic{Value: value}), value)
+	}
+	for _, value := range []interface{}{
+		"index out of range",
+		"concurrent map writes",
+		"sync: unlock of unlocked mutex",
+		"WARNING: DATA RACE",
+	} {
+		assert.False(t, cpanic.CorruptedState(&cpanic.Panic{Value: value}), value)
+	}
+}
+
+func TestRepanic(t *testing.T) {
+	var got *cpanic.Panic
+	handler := func(p *cpanic.Panic) { got = p }
+
+	assert.PanicsWithValue(t, "sync: negative WaitGroup counter", func() {
+		defer cpanic.Recover(handler)
+		var wg sync.WaitGroup
+		wg.Add(-1)
+	})
+	if assert.NotNil(t, got, "reported before panicking again") {
+		assert.Equal(t, "sync: negative WaitGroup counter", got.Value)
+	}
+
+	assert.Panics(t, func() {
+		_ = cpanic.Go(func() error {
+			panic("sync: negative WaitGroup counter")
+		})
+	})
+
+	func() {
+		defer cpanic.SetValueSnapshot(0, false)
+		cpanic.SetValueSnapshot(1<<10, true)
+		assert.PanicsWithValue(t, "sync: negative WaitGroup counter", func() {
+			defer cpanic.Recover(handler)
+			panic("sync: negative WaitGroup counter")
+		}, "the dropped value is raised again")
+		assert.Nil(t, got.Value)
+	}()
+
+	defer cpanic.SetRepanic(cpanic.CorruptedState)
+	cpanic.SetRepanic(cpanic.MatchMessage(regexp.MustCompile(`^fatal:`)))
+	assert.NotPanics(t, func() {
+		defer cpanic.Recover(handler)
+		panic("sync: negative WaitGroup counter")
+	})
+	assert.Panics(t, func() {
+		defer cpanic.Recover(handler)
+		panic("fatal: disk corrupted")
+	})
+
+	cpanic.SetRepanic()
+	assert.False(t, cpanic.Unrecoverable(&cpanic.Panic{Value: "fatal: disk corrupted"}))
+}
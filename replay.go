@@ -0,0 +1,72 @@
+package cpanic
+
+// ReplayedAttr is the attribute set to true on every panic fed through a handler by
+// `Replay`, so handlers can tell a replay from a live panic.
+const ReplayedAttr = "replayed"
+
+// ReplayOption configures `Replay`.
+type ReplayOption func(*replayConfig)
+
+type replayConfig struct {
+	matcher Matcher
+	limit   int
+}
+
+// WithReplayMatcher only replays the panics matched by m.
+func WithReplayMatcher(m Matcher) ReplayOption {
+	return func(c *replayConfig) {
+		c.matcher = m
+	}
+}
+
+// WithReplayLimit replays at most n panics, the most recent ones.
+func WithReplayLimit(n int) ReplayOption {
+	return func(c *replayConfig) {
+		c.limit = n
+	}
+}
+
+// Replay feeds the panics of the store through the handler, oldest first, so new alert
+// routing or formatting can be tried against real historical crashes. Each panic is
+// copied and tagged with `ReplayedAttr`, leaving the store unchanged, and passed to the
+// handler unless it is suppressed, as a live panic would be. Suppressed panics are
+// skipped without counting toward their escalation, and replays are not counted in
+// `PipelineStats`, so a replay leaves the live pipeline unchanged. It returns the number
+// of panics passed to the handler.
+func Replay(store Store, chain Handler, opts ...ReplayOption) (int, error) {
+	var cfg replayConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	panics, err := store.Load()
+	if err != nil {
+		return 0, err
+	}
+
+	selected := panics[:0:0]
+	for _, p := range panics {
+		if cfg.matcher == nil || cfg.matcher(p) {
+			selected = append(selected, p)
+		}
+	}
+	if cfg.limit > 0 && len(selected) > cfg.limit {
+		selected = selected[len(selected)-cfg.limit:]
+	}
+
+	n := 0
+	for _, p := range selected {
+		if Suppressed(p) {
+			continue
+		}
+		cp := *p
+		cp.Attrs = make(map[string]interface{}, len(p.Attrs)+1)
+		for k, v := range p.Attrs {
+			cp.Attrs[k] = v
+		}
+		cp.Attrs[ReplayedAttr] = true
+		chain.Handle(&cp)
+		n++
+	}
+	return n, nil
+}
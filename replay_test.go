@@ -0,0 +1,74 @@
+package cpanic_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/demosdemon/cpanic"
+)
+
+func TestReplay(t *testing.T) {
+	store := cpanic.NewHistory(0)
+	for _, v := range []interface{}{"a", 1, "b", "c"} {
+		store.Handle(cpanic.New(v))
+	}
+
+	tests := []struct {
+		name string
+		opts []cpanic.ReplayOption
+		want []interface{}
+	}{
+		{"all", nil, []interface{}{"a", 1, "b", "c"}},
+		{"matcher", []cpanic.ReplayOption{cpanic.WithReplayMatcher(cpanic.MatchType(""))}, []interface{}{"a", "b", "c"}},
+		{"limit", []cpanic.ReplayOption{cpanic.WithReplayMatcher(cpanic.MatchType("")), cpanic.WithReplayLimit(2)}, []interface{}{"b", "c"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []interface{}
+			n, err := cpanic.Replay(store, func(p *cpanic.Panic) {
+				assert.Equal(t, true, p.Attrs[cpanic.ReplayedAttr])
+				got = append(got, p.Value)
+			}, tt.opts...)
+			require.NoError(t, err)
+			assert.Equal(t, len(tt.want), n)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	for _, p := range store.Panics() {
+		assert.NotContains(t, p.Attrs, cpanic.ReplayedAttr)
+	}
+}
+
+func TestReplaySuppressed(t *testing.T) {
+	raise := func(h cpanic.Handler) {
+		for i := 0; i < 3; i++ {
+			raiseTo(h, escalated{})
+		}
+	}
+	store := cpanic.NewHistory(0)
+	raise(store.Handle)
+	store.Handle(cpanic.New("a"))
+
+	defer cpanic.Suppress(cpanic.MatchType(escalated{}))()
+	cpanic.EscalateSuppressed(2, time.Hour)
+	defer cpanic.EscalateSuppressed(0, 0)
+
+	stats := cpanic.PipelineStats()
+	var got []interface{}
+	n, err := cpanic.Replay(store, func(p *cpanic.Panic) { got = append(got, p.Value) })
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.Equal(t, []interface{}{"a"}, got)
+	assert.Equal(t, stats, cpanic.PipelineStats(), "replays are not counted")
+
+	// The replayed panics did not count toward escalation.
+	var handled []*cpanic.Panic
+	raise(func(p *cpanic.Panic) { handled = append(handled, p) })
+	if assert.Len(t, handled, 1) {
+		assert.Equal(t, 3, handled[0].Attrs[cpanic.SuppressedAttr])
+	}
+}
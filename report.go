@@ -0,0 +1,180 @@
+package cpanic
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// SchemaVersion is the version of the report schema written by `NDJSON` and `JSON`. It
+// is increased whenever the schema changes, so reports kept in long-lived stores and
+// archives can be migrated by `ParseReport` when they are read back. The versions are:
+//
+//   - 1: reports written before the schema was versioned, without "schema_version"
+//   - 2: reports with "schema_version", and the "stack" of the panicking goroutine,
+//     "public_message", and "pcs" of the panic
+const SchemaVersion = 2
+
+var (
+	// ErrInvalidReport is returned by `ParseReport` for a JSON object that is not a
+	// report.
+	ErrInvalidReport = errors.New("cpanic: invalid report")
+	// ErrSchemaVersion is returned by `ParseReport` for a report written with a newer
+	// version of the schema.
+	ErrSchemaVersion = errors.New("cpanic: unsupported report schema version")
+)
+
+// reportMigrations upgrade the members of a report of a version, the key, to the next
+// version. Versions without changes to existing members have no migration.
+var reportMigrations = map[int]func(r map[string]json.RawMessage) error{
+	1: migrateReportV1,
+}
+
+// migrateReportV1 derives the "stack" of a version 1 report from its "trace".
+func migrateReportV1(r map[string]json.RawMessage) error {
+	if _, ok := r["stack"]; ok {
+		return nil
+	}
+	var trace string
+	if raw, ok := r["trace"]; ok {
+		if err := json.Unmarshal(raw, &trace); err != nil {
+			return err
+		}
+	}
+	frames := (&Panic{Trace: trace}).Frames()
+	if len(frames) == 0 {
+		return nil
+	}
+	b, err := json.Marshal(frames)
+	if err != nil {
+		return err
+	}
+	r["stack"] = b
+	return nil
+}
+
+// report is the serialized form of a panic used by the handlers that write panics as
+// JSON. Unlike a `*Panic`, every field is guaranteed to be serializable: the value is
+// rendered as a string and attributes that cannot be encoded are rendered with `fmt`.
+type report struct {
+	ID          string                 `json:"id,omitempty"`
+	Time        time.Time              `json:"time"`
+	Value       string                 `json:"value"`
+	Type        string                 `json:"type"`
+	Fingerprint string                 `json:"fingerprint"`
+	BuildID     string                 `json:"build_id,omitempty"`
+	Severity    Severity               `json:"severity"`
+	Culprit     string                 `json:"culprit,omitempty"`
+	Attrs       map[string]interface{} `json:"attrs,omitempty"`
+	// Stack holds the result of `Frames`, so the frames and fingerprint of a panic
+	// whose trace was discarded are kept.
+	Stack         []Frame   `json:"stack,omitempty"`
+	PublicMessage string    `json:"public_message,omitempty"`
+	PCs           []uintptr `json:"pcs,omitempty"`
+	Trace         string    `json:"trace,omitempty"`
+	// SchemaVersion is last so that reports of every version start with the same
+	// members.
+	SchemaVersion int `json:"schema_version"`
+}
+
+func newReport(p *Panic) report {
+	r := report{
+		ID:            p.ID,
+		Time:          p.Time,
+		Value:         p.Message(),
+		Type:          p.Type(),
+		Fingerprint:   p.Fingerprint(),
+		BuildID:       p.BuildID,
+		Severity:      p.Severity,
+		Stack:         p.Frames(),
+		PublicMessage: p.PublicMessage,
+		PCs:           p.PCs,
+		Trace:         p.Trace,
+		SchemaVersion: SchemaVersion,
+	}
+	if f, ok := p.Culprit(); ok {
+		r.Culprit = f.String()
+	}
+	if len(p.Attrs) > 0 {
+		r.Attrs = make(map[string]interface{}, len(p.Attrs))
+		for k, v := range p.Attrs {
+			if _, err := json.Marshal(v); err != nil {
+				v = fmt.Sprint(v)
+			}
+			r.Attrs[k] = v
+		}
+	}
+	return r
+}
+
+// ParseReport decodes a report written by `NDJSON` or `JSON`, such as one received from
+// another process, back into a `*Panic`. As with `Parse`, the value of the panic is its
+// message, a `string`, but the type of the original value is kept in its
+// `ValueSnapshot`, so the panic has the fingerprint of the original. Attributes hold
+// the values decoded by `encoding/json`. Reports of older versions of the schema are
+// migrated to `SchemaVersion`, and reports of newer versions are rejected with
+// `ErrSchemaVersion`.
+func ParseReport(data []byte) (*Panic, error) {
+	var r report
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, err
+	}
+	if r.SchemaVersion == 0 {
+		r.SchemaVersion = 1
+	}
+	if r.SchemaVersion < 0 || r.SchemaVersion > SchemaVersion {
+		return nil, fmt.Errorf("%w %d", ErrSchemaVersion, r.SchemaVersion)
+	}
+	if r.SchemaVersion < SchemaVersion {
+		if err := migrateReport(data, &r); err != nil {
+			return nil, err
+		}
+	}
+	if r.Type == "" {
+		return nil, ErrInvalidReport
+	}
+
+	return &Panic{
+		ID:            r.ID,
+		Time:          r.Time,
+		Value:         r.Value,
+		ValueSnapshot: &ValueSnapshot{Type: r.Type, Text: r.Value},
+		Trace:         r.Trace,
+		Attrs:         r.Attrs,
+		Stack:         r.Stack,
+		Severity:      r.Severity,
+		PublicMessage: r.PublicMessage,
+		PCs:           r.PCs,
+		BuildID:       r.BuildID,
+	}, nil
+}
+
+// migrateReport applies the migrations from the version of the report to
+// `SchemaVersion` to the members of data, and decodes the result into r.
+func migrateReport(data []byte, r *report) error {
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(data, &members); err != nil {
+		return err
+	}
+	migrated := false
+	for v := r.SchemaVersion; v < SchemaVersion; v++ {
+		if migrate, ok := reportMigrations[v]; ok {
+			if err := migrate(members); err != nil {
+				return fmt.Errorf("cpanic: migrating report from version %d: %w", v, err)
+			}
+			migrated = true
+		}
+	}
+	r.SchemaVersion = SchemaVersion
+	if !migrated {
+		return nil
+	}
+
+	b, err := json.Marshal(members)
+	if err != nil {
+		return err
+	}
+	*r = report{}
+	return json.Unmarshal(b, r)
+}
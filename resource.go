@@ -0,0 +1,32 @@
+package cpanic
+
+// RollbackAttr is the attribute holding the `*Panic` raised by the rollback function of
+// `RecoverWith`, if it panicked as well.
+const RollbackAttr = "rollback"
+
+// RecoverWith is a defer function that recovers from a panic, calls rollback, such as
+// the `Rollback` method of a `*sql.Tx`, and then calls the handler, so a resource left
+// half-modified by the panic is restored before the panic is reported. A panic raised
+// by rollback is recovered and attached to the reported panic as `RollbackAttr`. If no
+// handler is provided, the panic continues once rollback has returned. When no panic
+// occurs, rollback is not called.
+func RecoverWith(rollback func(), handler Handler) {
+	if value := recover(); value != nil {
+		handleWith(rollback, handler, value)
+	}
+}
+
+//go:noinline
+func handleWith(rollback func(), handler Handler, value interface{}) {
+	p := New(value)
+	if rollback != nil {
+		if err := Try(rollback); err != nil {
+			p.SetAttr(RollbackAttr, err)
+		}
+	}
+	if handler == nil {
+		panic(value)
+	}
+	reportTo(p, handler)
+	settle(p)
+}
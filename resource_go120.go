@@ -0,0 +1,23 @@
+//go:build go1.20
+// +build go1.20
+
+package cpanic
+
+import "errors"
+
+// WithResource acquires a resource, calls fn with it, and releases it, even if fn
+// panics. A panic in fn or release is recovered as a `*Panic`. The error returned is an
+// `errors.Join` of the error or `*Panic` from fn and the error or `*Panic` from
+// release, so a failure to release the resource is not hidden by the panic that
+// preceded it. If acquire fails, its error is returned and neither fn nor release is
+// called.
+func WithResource[R any](acquire func() (R, error), release func(R) error, fn func(R) error) error {
+	r, err := acquire()
+	if err != nil {
+		return err
+	}
+
+	err = Go(func() error { return fn(r) })
+	releaseErr := Go(func() error { return release(r) })
+	return errors.Join(err, releaseErr)
+}
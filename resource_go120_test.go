@@ -0,0 +1,75 @@
+//go:build go1.20
+// +build go1.20
+
+package cpanic_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/demosdemon/cpanic"
+)
+
+func TestWithResource(t *testing.T) {
+	errAcquire := errors.New("acquire")
+	errRelease := errors.New("release")
+	errFn := errors.New("fn")
+
+	tests := []struct {
+		name       string
+		acquireErr error
+		fn         func(int) error
+		releaseErr error
+		released   bool
+		wantErrs   []error
+		wantPanic  bool
+	}{
+		{
+			name:     "ok",
+			fn:       func(int) error { return nil },
+			released: true,
+		},
+		{
+			name:       "acquire error",
+			acquireErr: errAcquire,
+			wantErrs:   []error{errAcquire},
+		},
+		{
+			name:     "fn error",
+			fn:       func(int) error { return errFn },
+			released: true,
+			wantErrs: []error{errFn},
+		},
+		{
+			name:       "panic and release error",
+			fn:         func(int) error { panic("not at a disco") },
+			releaseErr: errRelease,
+			released:   true,
+			wantErrs:   []error{errRelease},
+			wantPanic:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			released := false
+			err := cpanic.WithResource(func() (int, error) {
+				return 42, tt.acquireErr
+			}, func(r int) error {
+				assert.Equal(t, 42, r)
+				released = true
+				return tt.releaseErr
+			}, tt.fn)
+
+			assert.Equal(t, tt.released, released)
+			if len(tt.wantErrs) == 0 && !tt.wantPanic {
+				assert.NoError(t, err)
+			}
+			for _, want := range tt.wantErrs {
+				assert.ErrorIs(t, err, want)
+			}
+			assert.Equal(t, tt.wantPanic, errors.Is(err, cpanic.ErrPanic))
+		})
+	}
+}
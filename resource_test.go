@@ -0,0 +1,57 @@
+package cpanic_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/demosdemon/cpanic"
+)
+
+func TestRecoverWith(t *testing.T) {
+	var steps []string
+	func() {
+		defer cpanic.RecoverWith(func() {
+			steps = append(steps, "rollback")
+		}, func(p *cpanic.Panic) {
+			steps = append(steps, "handle")
+			assert.Nil(t, p.Attrs[cpanic.RollbackAttr])
+		})
+		panic("not at a disco")
+	}()
+	assert.Equal(t, []string{"rollback", "handle"}, steps)
+}
+
+func TestRecoverWithNoPanic(t *testing.T) {
+	called := false
+	func() {
+		defer cpanic.RecoverWith(func() { called = true }, func(*cpanic.Panic) { called = true })
+	}()
+	assert.False(t, called)
+}
+
+func TestRecoverWithRollbackPanic(t *testing.T) {
+	var got *cpanic.Panic
+	func() {
+		defer cpanic.RecoverWith(func() {
+			panic("rollback failed")
+		}, func(p *cpanic.Panic) { got = p })
+		panic("not at a disco")
+	}()
+
+	require.NotNil(t, got)
+	assert.Equal(t, "not at a disco", got.Value)
+	rollback, ok := got.Attrs[cpanic.RollbackAttr].(*cpanic.Panic)
+	require.True(t, ok)
+	assert.Equal(t, "rollback failed", rollback.Value)
+}
+
+func TestRecoverWithNilHandler(t *testing.T) {
+	rolledBack := false
+	assert.PanicsWithValue(t, "not at a disco", func() {
+		defer cpanic.RecoverWith(func() { rolledBack = true }, nil)
+		panic("not at a disco")
+	})
+	assert.True(t, rolledBack)
+}
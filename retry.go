@@ -0,0 +1,36 @@
+package cpanic
+
+import "time"
+
+// RetryErrorAttr is the attribute holding the error of the last attempt of a panic
+// passed to the dead-letter handler of `WithRetry`.
+const RetryErrorAttr = "retry.error"
+
+// WithRetry returns a handler that calls h with each panic until it succeeds, up to
+// attempts times, or once if attempts is less than one, so a panic report is not dropped during a network blip. The delay
+// before each retry starts at backoff and doubles every attempt. If every attempt
+// fails, the panic is tagged with the last error as `RetryErrorAttr` and passed to
+// deadLetter, if provided, such as a handler writing it to disk with `(*Panic).Bundle`.
+// Retries run on the goroutine that recovered the panic, which is blocked until they
+// are done.
+func WithRetry(h func(p *Panic) error, attempts int, backoff time.Duration, deadLetter Handler) Handler {
+	if attempts < 1 {
+		attempts = 1
+	}
+	return func(p *Panic) {
+		var err error
+		delay := backoff
+		for i := 0; i < attempts; i++ {
+			if i > 0 {
+				time.Sleep(delay)
+				delay *= 2
+			}
+			if err = h(p); err == nil {
+				return
+			}
+			countFailure()
+		}
+		p.SetAttr(RetryErrorAttr, err.Error())
+		deadLetter.Handle(p)
+	}
+}
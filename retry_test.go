@@ -0,0 +1,52 @@
+package cpanic_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/demosdemon/cpanic"
+)
+
+func TestWithRetry(t *testing.T) {
+	errDown := errors.New("endpoint down")
+
+	tests := []struct {
+		name       string
+		attempts   int
+		failures   int
+		wantCalls  int
+		wantLetter bool
+	}{
+		{"first attempt", 3, 0, 1, false},
+		{"after retries", 3, 2, 3, false},
+		{"dead letter", 3, 5, 3, true},
+		{"no attempts", 0, 0, 1, false},
+		{"no attempts dead letter", 0, 5, 1, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			var letter *cpanic.Panic
+			h := cpanic.WithRetry(func(*cpanic.Panic) error {
+				calls++
+				if calls <= tt.failures {
+					return errDown
+				}
+				return nil
+			}, tt.attempts, time.Millisecond, func(p *cpanic.Panic) { letter = p })
+
+			p := cpanic.New("not at a disco")
+			h.Handle(p)
+			assert.Equal(t, tt.wantCalls, calls)
+			if tt.wantLetter {
+				assert.Same(t, p, letter)
+				assert.Equal(t, errDown.Error(), p.Attrs[cpanic.RetryErrorAttr])
+			} else {
+				assert.Nil(t, letter)
+			}
+		})
+	}
+}
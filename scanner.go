@@ -0,0 +1,148 @@
+package cpanic
+
+import (
+	"bufio"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// maxMessageLines is the number of lines after a panic message within which the
+// goroutine trace must start for the block to be treated as a crash.
+const maxMessageLines = 64
+
+// maxLineSize is the longest line the scanner accepts.
+const maxLineSize = 1 << 20
+
+// Scanner detects and parses the crash dumps of Go programs interleaved with other
+// output, such as the stderr of a legacy binary tailed from a log file, so its panics
+// can be reported like recovered ones. Like `bufio.Scanner`, successive calls to `Scan`
+// step through the panics found, which are returned by `Panic`.
+type Scanner struct {
+	lines   lineReader
+	pending *string
+	panic   *Panic
+	err     error
+}
+
+// NewScanner returns a scanner reading from r.
+func NewScanner(r io.Reader) *Scanner {
+	return &Scanner{lines: newLineReader(r)}
+}
+
+// SetPrefix sets a pattern stripped from the start of every line before it is
+// inspected, such as the timestamp and host added by a log collector. It must be
+// called before the first call to `Scan`.
+func (s *Scanner) SetPrefix(re *regexp.Regexp) {
+	s.lines.prefix = re
+}
+
+// Scan advances to the next panic, which is then available through `Panic`. It
+// returns false when the input ends or fails, after which `Err` returns the error, if
+// any. A dump still being written when the input ends is parsed as is.
+func (s *Scanner) Scan() bool {
+	s.panic = nil
+
+	var (
+		block   []string
+		inTrace bool
+	)
+	flush := func() bool {
+		p, err := Parse(strings.Join(block, "\n"))
+		if err != nil {
+			return false
+		}
+		s.panic = p
+		return true
+	}
+
+	for {
+		line, ok := s.next()
+		if !ok {
+			if inTrace && flush() {
+				return true
+			}
+			s.err = s.lines.err()
+			return false
+		}
+
+		switch {
+		case inTrace:
+			if isTraceLine(line) {
+				block = append(block, line)
+				continue
+			}
+			s.pending = &line
+			if flush() {
+				return true
+			}
+			block, inTrace = nil, false
+
+		case block != nil:
+			block = append(block, line)
+			if isGoroutineHeader(line) {
+				inTrace = true
+			} else if len(block) > maxMessageLines {
+				block = nil
+			}
+
+		case isCrashStart(line):
+			block = []string{line}
+		}
+	}
+}
+
+// Panic returns the panic found by the last call to `Scan`.
+func (s *Scanner) Panic() *Panic {
+	return s.panic
+}
+
+// Err returns the first error reading the input, if any.
+func (s *Scanner) Err() error {
+	return s.err
+}
+
+// next returns the next line with the prefix stripped.
+func (s *Scanner) next() (string, bool) {
+	if s.pending != nil {
+		line := *s.pending
+		s.pending = nil
+		return line, true
+	}
+	return s.lines.next()
+}
+
+// lineReader reads lines, stripping carriage returns and an optional prefix.
+type lineReader struct {
+	lines  *bufio.Scanner
+	prefix *regexp.Regexp
+}
+
+func newLineReader(r io.Reader) lineReader {
+	lines := bufio.NewScanner(r)
+	lines.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+	return lineReader{lines: lines}
+}
+
+// next returns the next line with the prefix stripped.
+func (r lineReader) next() (string, bool) {
+	if !r.lines.Scan() {
+		return "", false
+	}
+	line := strings.TrimRight(r.lines.Text(), "\r")
+	if r.prefix != nil {
+		if loc := r.prefix.FindStringIndex(line); loc != nil && loc[0] == 0 {
+			line = line[loc[1]:]
+		}
+	}
+	return line, true
+}
+
+func (r lineReader) err() error {
+	return r.lines.Err()
+}
+
+// isCrashStart reports whether the line starts the crash output of a Go program.
+func isCrashStart(line string) bool {
+	return strings.HasPrefix(line, "panic: ") || strings.HasPrefix(line, "fatal error: ")
+}
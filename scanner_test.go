@@ -0,0 +1,88 @@
+package cpanic_test
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/demosdemon/cpanic"
+)
+
+const mixedLog = `starting server
+panic: first
+
+goroutine 1 [running]:
+main.main()
+	/src/main.go:6 +0x34
+exit status 2
+restarting server
+panic: not followed by a trace
+serving requests
+fatal error: all goroutines are asleep - deadlock!
+
+goroutine 1 [chan receive]:
+main.main()
+	/src/main.go:9 +0x34
+
+goroutine 2 [select]:
+main.idle()
+	/src/main.go:20 +0x1d
+created by main.main in goroutine 1
+	/src/main.go:7 +0x2e
+panic: second
+
+goroutine 1 [running]:
+main.handler()
+	/src/main.go:30 +0x34`
+
+func scanAll(s *cpanic.Scanner) []*cpanic.Panic {
+	var panics []*cpanic.Panic
+	for s.Scan() {
+		panics = append(panics, s.Panic())
+	}
+	return panics
+}
+
+func TestScanner(t *testing.T) {
+	s := cpanic.NewScanner(strings.NewReader(mixedLog))
+	panics := scanAll(s)
+	assert.NoError(t, s.Err())
+
+	if assert.Len(t, panics, 3) {
+		assert.Equal(t, "first", panics[0].Value)
+		assert.Equal(t, "goroutine 1 [running]:\nmain.main()\n\t/src/main.go:6 +0x34\n", panics[0].Trace)
+
+		assert.Equal(t, "all goroutines are asleep - deadlock!", panics[1].Value)
+		assert.Len(t, panics[1].Goroutines(), 2)
+
+		assert.Equal(t, "second", panics[2].Value)
+		assert.Equal(t, []cpanic.Frame{{Function: "main.handler", File: "/src/main.go", Line: 30}}, panics[2].Frames())
+	}
+	assert.Nil(t, s.Panic())
+}
+
+func TestScannerPrefix(t *testing.T) {
+	log := "Apr 01 12:00:00 host app[42]: panic: boom\n" +
+		"Apr 01 12:00:00 host app[42]: \n" +
+		"Apr 01 12:00:00 host app[42]: goroutine 1 [running]:\n" +
+		"Apr 01 12:00:00 host app[42]: main.main()\n" +
+		"Apr 01 12:00:00 host app[42]: \t/src/main.go:6 +0x34\n" +
+		"Apr 01 12:00:01 host systemd[1]: app.service: Main process exited\n"
+
+	s := cpanic.NewScanner(strings.NewReader(log))
+	s.SetPrefix(regexp.MustCompile(`^\w{3} \d{2} [\d:]{8} \S+ \S+: `))
+	panics := scanAll(s)
+	if assert.Len(t, panics, 1) {
+		assert.Equal(t, "boom", panics[0].Value)
+		assert.Equal(t, []cpanic.Frame{{Function: "main.main", File: "/src/main.go", Line: 6}}, panics[0].Frames())
+	}
+}
+
+func TestScannerError(t *testing.T) {
+	s := cpanic.NewScanner(iotest.TimeoutReader(strings.NewReader("panic: x\n")))
+	assert.Empty(t, scanAll(s))
+	assert.Equal(t, iotest.ErrTimeout, s.Err())
+}
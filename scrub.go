@@ -0,0 +1,168 @@
+package cpanic
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DefaultMask replaces the text masked by a `ScrubRule` without a mask of its own.
+const DefaultMask = "[SCRUBBED]"
+
+// minScrubArg is the smallest argument value masked by the argument heuristic of
+// `Scrubber`; smaller values, such as lengths and flags, would mask unrelated numbers.
+const minScrubArg = 256
+
+// ScrubRule masks the parts of panic messages built by the frames it selects.
+type ScrubRule struct {
+	// Function selects the frames of the panicking goroutine the rule applies to, by
+	// fully qualified function name, such as "main.(*Store).Lookup", or by package
+	// import path, matching the functions of the package and its subpackages. An empty
+	// function matches every frame.
+	Function string
+	// Pattern matches the text of the message to mask when the rule applies. If nil,
+	// the arguments of the selected frames that appear in the message are masked, as
+	// when a value built with `fmt.Errorf` includes the identifier being looked up.
+	Pattern *regexp.Regexp
+	// Mask replaces the masked text. It defaults to `DefaultMask`.
+	Mask string
+}
+
+func (r ScrubRule) matches(fn string) bool {
+	if r.Function == "" || r.Function == fn {
+		return true
+	}
+	pkg := packageName(fn)
+	return pkg == r.Function || strings.HasPrefix(pkg, r.Function+"/")
+}
+
+func (r ScrubRule) mask() string {
+	if r.Mask == "" {
+		return DefaultMask
+	}
+	return r.Mask
+}
+
+// Scrubber removes personal data from panics using the structure of the stack rather
+// than the text alone: rules apply only to panics raised through the functions they
+// select, and the arguments of those functions are elided from the trace, since the
+// runtime prints their raw values. Create one with `NewScrubber`.
+type Scrubber struct {
+	rules []ScrubRule
+}
+
+// NewScrubber returns a scrubber applying the rules.
+func NewScrubber(rules ...ScrubRule) *Scrubber {
+	return &Scrubber{rules: rules}
+}
+
+// Handler returns a handler that passes a scrubbed copy of each panic to h.
+func (s *Scrubber) Handler(h Handler) Handler {
+	return func(p *Panic) {
+		cp := *p
+		s.Scrub(&cp)
+		h.Handle(&cp)
+	}
+}
+
+// Scrub applies the rules to the panic in place. The arguments of the frames selected
+// by any rule are replaced with "..." in the trace. If the message changes, the panic
+// is given a `ValueSnapshot` holding the scrubbed message and its value is dropped, as
+// the value would still render the personal data.
+func (s *Scrubber) Scrub(p *Panic) {
+	applied := make([]bool, len(s.rules))
+	args := make([][]uint64, len(s.rules))
+
+	lines := strings.Split(p.Trace, "\n")
+	if len(lines) > 0 && strings.HasPrefix(lines[0], "goroutine ") {
+		for i := 1; i < len(lines) && lines[i] != ""; i++ {
+			line := lines[i]
+			if strings.HasPrefix(line, "\t") || strings.HasPrefix(line, "...") || strings.HasPrefix(line, "created by ") {
+				continue
+			}
+
+			fn := functionName(line)
+			hit := false
+			for j, r := range s.rules {
+				if r.matches(fn) {
+					applied[j], hit = true, true
+					args[j] = append(args[j], frameArgs(line)...)
+				}
+			}
+			if hit {
+				lines[i] = fn + "(...)"
+			}
+		}
+		p.Trace = strings.Join(lines, "\n")
+	}
+	for _, f := range p.Stack {
+		for j, r := range s.rules {
+			applied[j] = applied[j] || r.matches(f.Function)
+		}
+	}
+
+	msg := p.Message()
+	scrubbed := msg
+	for j, r := range s.rules {
+		switch {
+		case !applied[j]:
+		case r.Pattern != nil:
+			scrubbed = r.Pattern.ReplaceAllString(scrubbed, r.mask())
+		default:
+			if re := argsPattern(args[j]); re != nil {
+				scrubbed = re.ReplaceAllString(scrubbed, r.mask())
+			}
+		}
+	}
+	if scrubbed == msg {
+		return
+	}
+
+	p.ValueSnapshot = &ValueSnapshot{
+		Type:      p.Type(),
+		Text:      scrubbed,
+		Truncated: p.ValueSnapshot != nil && p.ValueSnapshot.Truncated,
+	}
+	p.Value = nil
+}
+
+// frameArgs returns the argument words of a frame line of the form
+// "main.f(0x2a, {0xc000012345, 0x5}, 0x7?)".
+func frameArgs(line string) []uint64 {
+	start := strings.LastIndexByte(line, '(')
+	end := strings.LastIndexByte(line, ')')
+	if start < 0 || end < start {
+		return nil
+	}
+
+	var words []uint64
+	for _, field := range strings.FieldsFunc(line[start+1:end], func(r rune) bool {
+		return r == ',' || r == ' ' || r == '{' || r == '}'
+	}) {
+		field = strings.TrimSuffix(field, "?")
+		if !strings.HasPrefix(field, "0x") {
+			continue
+		}
+		if n, err := strconv.ParseUint(field[2:], 16, 64); err == nil && n >= minScrubArg {
+			words = append(words, n)
+		}
+	}
+	return words
+}
+
+// argsPattern returns a pattern matching the argument words in decimal or hexadecimal
+// as whole words, or nil if there are none.
+func argsPattern(words []uint64) *regexp.Regexp {
+	if len(words) == 0 {
+		return nil
+	}
+
+	alts := make([]string, 0, 2*len(words))
+	for _, n := range words {
+		alts = append(alts, strconv.FormatUint(n, 10), "0x"+strconv.FormatUint(n, 16))
+	}
+	// Longer alternatives first, so a number is not masked only in part.
+	sort.Slice(alts, func(i, j int) bool { return len(alts[i]) > len(alts[j]) })
+	return regexp.MustCompile(`\b(?:` + strings.Join(alts, "|") + `)\b`)
+}
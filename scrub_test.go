@@ -0,0 +1,79 @@
+package cpanic_test
+
+import (
+	"errors"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/demosdemon/cpanic"
+)
+
+const scrubTrace = `goroutine 1 [running]:
+example.com/app/store.(*Users).Lookup(0xc000010000, 0x1e240, {0xc000020000?, 0x5})
+	/src/store/users.go:42 +0x1d
+example.com/app/api.handle(0x3)
+	/src/api/api.go:10 +0x2a
+main.main()
+	/src/main.go:3 +0x1d
+`
+
+func TestScrubber(t *testing.T) {
+	email := regexp.MustCompile(`[^@\s]+@[^@\s]+`)
+	tests := []struct {
+		name  string
+		rules []cpanic.ScrubRule
+		value interface{}
+		msg   string
+		trace string
+	}{
+		{
+			name:  "arguments",
+			rules: []cpanic.ScrubRule{{Function: "example.com/app/store"}},
+			value: errors.New("user 123456 (0x1e240) not found; 1234567 is fine"),
+			msg:   "user [SCRUBBED] ([SCRUBBED]) not found; 1234567 is fine",
+			trace: "example.com/app/store.(*Users).Lookup(...)",
+		},
+		{
+			name:  "pattern",
+			rules: []cpanic.ScrubRule{{Function: "example.com/app/api.handle", Pattern: email, Mask: "<email>"}},
+			value: "no account for jane@example.com",
+			msg:   "no account for <email>",
+			trace: "example.com/app/api.handle(...)",
+		},
+		{
+			name:  "unmatched",
+			rules: []cpanic.ScrubRule{{Function: "example.com/other", Pattern: email}},
+			value: "no account for jane@example.com",
+			msg:   "no account for jane@example.com",
+			trace: "example.com/app/store.(*Users).Lookup(0xc000010000, 0x1e240, {0xc000020000?, 0x5})",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var c collector
+			h := cpanic.NewScrubber(tt.rules...).Handler(c.handle)
+			p := &cpanic.Panic{Value: tt.value, Trace: scrubTrace}
+			h.Handle(p)
+
+			assert.Equal(t, tt.value, p.Value, "the original panic is not modified")
+			panics := c.get()
+			require.Len(t, panics, 1)
+			assert.Equal(t, tt.msg, panics[0].Message())
+			assert.Contains(t, panics[0].Trace, tt.trace+"\n")
+		})
+	}
+}
+
+func TestScrubberDropsValue(t *testing.T) {
+	p := &cpanic.Panic{Value: errors.New("user 123456 not found"), Trace: scrubTrace}
+	cpanic.NewScrubber(cpanic.ScrubRule{}).Scrub(p)
+
+	assert.Nil(t, p.Value)
+	assert.Equal(t, "*errors.errorString", p.Type())
+	assert.Equal(t, "user [SCRUBBED] not found", p.Message())
+	assert.NotContains(t, p.Trace, "0x1e240")
+}
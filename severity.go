@@ -0,0 +1,130 @@
+package cpanic
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// Severity is how serious a panic is, used to route panics to different handlers. The
+// zero value is `SeverityError`.
+type Severity int
+
+// The severities, in increasing order.
+const (
+	// SeverityWarning is for panics that are expected from time to time and recovered
+	// from safely, such as a parser bailing out of malformed input.
+	SeverityWarning Severity = iota - 1
+	// SeverityError is the default severity, for bugs such as nil pointer dereferences.
+	SeverityError
+	// SeverityFatal is for panics after which the process cannot be trusted to continue.
+	SeverityFatal
+)
+
+// String returns the lowercase name of the severity, such as "warning".
+func (s Severity) String() string {
+	switch s {
+	case SeverityWarning:
+		return "warning"
+	case SeverityError:
+		return "error"
+	case SeverityFatal:
+		return "fatal"
+	default:
+		return fmt.Sprintf("severity(%d)", int(s))
+	}
+}
+
+// MarshalText implements `encoding.TextMarshaler`.
+func (s Severity) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// UnmarshalText implements `encoding.TextUnmarshaler`.
+func (s *Severity) UnmarshalText(text []byte) error {
+	for _, v := range []Severity{SeverityWarning, SeverityError, SeverityFatal} {
+		if v.String() == string(text) {
+			*s = v
+			return nil
+		}
+	}
+	return fmt.Errorf("cpanic: unknown severity %q", text)
+}
+
+var classifications struct {
+	sync.RWMutex
+	rules []*classification
+}
+
+type classification struct {
+	matcher  Matcher
+	severity Severity
+}
+
+// Classify registers a rule assigning the severity to new panics that match. Rules are
+// consulted by `New` in the order they were registered; the first match wins. It
+// returns a function that unregisters the rule.
+func Classify(m Matcher, severity Severity) (unregister func()) {
+	rule := &classification{m, severity}
+	classifications.Lock()
+	defer classifications.Unlock()
+	classifications.rules = append(classifications.rules, rule)
+	return func() {
+		classifications.Lock()
+		defer classifications.Unlock()
+		rules := make([]*classification, 0, len(classifications.rules))
+		for _, r := range classifications.rules {
+			if r != rule {
+				rules = append(rules, r)
+			}
+		}
+		classifications.rules = rules
+	}
+}
+
+// classify returns the severity of the first matching rule, if any.
+func classify(p *Panic) (Severity, bool) {
+	classifications.RLock()
+	defer classifications.RUnlock()
+	for _, rule := range classifications.rules {
+		if rule.matcher(p) {
+			return rule.severity, true
+		}
+	}
+	return SeverityError, false
+}
+
+type severityKey struct{}
+
+// WithSeverity returns a copy of ctx carrying the severity of panics recovered while
+// serving it, overriding any `Classify` rule. It is applied by `GoLabeled`,
+// `GoNContext`, and the integrations that recover panics for a request.
+func WithSeverity(ctx context.Context, severity Severity) context.Context {
+	return context.WithValue(ctx, severityKey{}, severity)
+}
+
+// SeverityFromContext returns the severity set by `WithSeverity`, if any.
+func SeverityFromContext(ctx context.Context) (Severity, bool) {
+	s, ok := ctx.Value(severityKey{}).(Severity)
+	return s, ok
+}
+
+// withContextSeverity applies the severity carried by ctx to err if it is a `*Panic`.
+func withContextSeverity(ctx context.Context, err error) error {
+	if p, ok := err.(*Panic); ok {
+		if s, ok := SeverityFromContext(ctx); ok {
+			p.Severity = s
+		}
+	}
+	return err
+}
+
+// MinSeverity returns a handler that calls h only for panics of at least the given
+// severity.
+func MinSeverity(h Handler, level Severity) Handler {
+	return func(p *Panic) {
+		if p.Severity >= level {
+			h.Handle(p)
+		}
+	}
+}
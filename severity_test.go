@@ -0,0 +1,83 @@
+package cpanic_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/demosdemon/cpanic"
+)
+
+type parserBailout struct{}
+
+func TestSeverityString(t *testing.T) {
+	cases := []struct {
+		severity cpanic.Severity
+		want     string
+	}{
+		{cpanic.SeverityWarning, "warning"},
+		{cpanic.SeverityError, "error"},
+		{cpanic.SeverityFatal, "fatal"},
+		{cpanic.Severity(7), "severity(7)"},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.want, func(t *testing.T) {
+			assert.Equal(t, tc.want, tc.severity.String())
+		})
+	}
+}
+
+func TestSeverityJSON(t *testing.T) {
+	data, err := json.Marshal(&cpanic.Panic{Value: "boom", Severity: cpanic.SeverityWarning})
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"severity":"warning"`)
+
+	var p cpanic.Panic
+	require.NoError(t, json.Unmarshal([]byte(`{"severity":"fatal"}`), &p))
+	assert.Equal(t, cpanic.SeverityFatal, p.Severity)
+
+	assert.EqualError(t, json.Unmarshal([]byte(`{"severity":"meh"}`), &p), `cpanic: unknown severity "meh"`)
+}
+
+func TestClassify(t *testing.T) {
+	defer cpanic.Classify(cpanic.MatchType(parserBailout{}), cpanic.SeverityWarning)()
+	defer cpanic.Classify(cpanic.MatchType(parserBailout{}), cpanic.SeverityFatal)()
+
+	assert.Equal(t, cpanic.SeverityWarning, cpanic.New(parserBailout{}).Severity)
+	assert.Equal(t, cpanic.SeverityError, cpanic.New("not at a disco").Severity)
+}
+
+func TestWithSeverity(t *testing.T) {
+	ctx := cpanic.WithSeverity(context.Background(), cpanic.SeverityFatal)
+	s, ok := cpanic.SeverityFromContext(ctx)
+	assert.True(t, ok)
+	assert.Equal(t, cpanic.SeverityFatal, s)
+
+	_, ok = cpanic.SeverityFromContext(context.Background())
+	assert.False(t, ok)
+
+	var err error
+	func() {
+		defer cpanic.ForwardCtx(ctx, &err)
+		panic(parserBailout{})
+	}()
+	var p *cpanic.Panic
+	require.True(t, errors.As(err, &p))
+	assert.Equal(t, cpanic.SeverityFatal, p.Severity)
+}
+
+func TestMinSeverity(t *testing.T) {
+	var handled []cpanic.Severity
+	h := cpanic.MinSeverity(func(p *cpanic.Panic) { handled = append(handled, p.Severity) }, cpanic.SeverityError)
+
+	for _, s := range []cpanic.Severity{cpanic.SeverityWarning, cpanic.SeverityError, cpanic.SeverityFatal} {
+		h(&cpanic.Panic{Value: "boom", Severity: s})
+	}
+	assert.Equal(t, []cpanic.Severity{cpanic.SeverityError, cpanic.SeverityFatal}, handled)
+}
@@ -0,0 +1,85 @@
+package cpanic
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"regexp"
+)
+
+var (
+	// ErrUnsigned is returned by `Verify` for a report without a signature.
+	ErrUnsigned = errors.New("cpanic: report is not signed")
+	// ErrInvalidSignature is returned by `Verify` for a report whose signature does not
+	// match any of the keys, because it was forged or modified after it was signed.
+	ErrInvalidSignature = errors.New("cpanic: invalid report signature")
+)
+
+// signatureMember matches the signature added by `WithSigningKey` as the last member of
+// a compacted JSON object.
+var signatureMember = regexp.MustCompile(`,"signature":"([A-Za-z0-9+/=]*)"}$`)
+
+// WithSigningKey returns a formatter that signs the JSON object rendered by f, such as
+// `NDJSON` or `JSON`, with the Ed25519 key, so a central collector can reject forged or
+// modified reports from untrusted edge nodes with `Verify`. The object is compacted and
+// the signature of the compacted object is added as its last member, "signature", so
+// signed reports can still be read by `ParseReport`. The output ends with a newline.
+func WithSigningKey(f Formatter, key ed25519.PrivateKey) Formatter {
+	sign := func(p *Panic) ([]byte, error) {
+		b, err := f.Format(p)
+		if err != nil {
+			return nil, err
+		}
+		var buf bytes.Buffer
+		if err := json.Compact(&buf, b); err != nil {
+			return nil, err
+		}
+		msg := buf.Bytes()
+		if len(msg) < 2 || msg[0] != '{' || msg[len(msg)-1] != '}' {
+			return nil, ErrInvalidReport
+		}
+
+		sig := base64.StdEncoding.EncodeToString(ed25519.Sign(key, msg))
+		out := make([]byte, 0, len(msg)+len(sig)+16)
+		out = append(out, msg[:len(msg)-1]...)
+		out = append(out, `,"signature":"`...)
+		out = append(out, sig...)
+		return append(out, "\"}\n"...), nil
+	}
+
+	contentType := "application/json"
+	if ct, ok := f.(interface{ ContentType() string }); ok {
+		contentType = ct.ContentType()
+	}
+	return formatter{sign, contentType}
+}
+
+// Verify checks that the report was signed by `WithSigningKey` with the private key of
+// any of the public keys, and has not been modified since, except for whitespace. It
+// returns `ErrUnsigned` if the report has no signature and `ErrInvalidSignature` if the
+// signature does not match.
+func Verify(data []byte, keys ...ed25519.PublicKey) error {
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, data); err != nil {
+		return err
+	}
+	compact := buf.Bytes()
+	m := signatureMember.FindSubmatchIndex(compact)
+	if m == nil {
+		return ErrUnsigned
+	}
+	sig, err := base64.StdEncoding.DecodeString(string(compact[m[2]:m[3]]))
+	if err != nil || len(sig) != ed25519.SignatureSize {
+		return ErrInvalidSignature
+	}
+
+	msg := append(compact[:m[0]:m[0]], '}')
+	for _, key := range keys {
+		if len(key) == ed25519.PublicKeySize && ed25519.Verify(key, msg, sig) {
+			return nil
+		}
+	}
+	return ErrInvalidSignature
+}
@@ -0,0 +1,46 @@
+package cpanic_test
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/demosdemon/cpanic"
+)
+
+func TestWithSigningKey(t *testing.T) {
+	key := ed25519.NewKeyFromSeed(bytes.Repeat([]byte{1}, ed25519.SeedSize))
+	other := ed25519.NewKeyFromSeed(bytes.Repeat([]byte{2}, ed25519.SeedSize))
+	pub := key.Public().(ed25519.PublicKey)
+
+	for _, f := range []cpanic.Formatter{cpanic.NDJSON, cpanic.JSON} {
+		b, err := cpanic.WithSigningKey(f, key).Format(cpanic.New("boom"))
+		require.NoError(t, err)
+		assert.Contains(t, string(b), `"signature":`)
+		assert.True(t, bytes.HasSuffix(b, []byte("}\n")))
+
+		assert.NoError(t, cpanic.Verify(b, other.Public().(ed25519.PublicKey), pub))
+		assert.Equal(t, cpanic.ErrInvalidSignature, cpanic.Verify(b, other.Public().(ed25519.PublicKey)))
+
+		p, err := cpanic.ParseReport(b)
+		require.NoError(t, err)
+		assert.Equal(t, "boom", p.Value)
+
+		forged := bytes.Replace(b, []byte(`"boom"`), []byte(`"bang"`), 1)
+		assert.Equal(t, cpanic.ErrInvalidSignature, cpanic.Verify(forged, pub))
+	}
+}
+
+func TestVerifyUnsigned(t *testing.T) {
+	key := ed25519.NewKeyFromSeed(bytes.Repeat([]byte{1}, ed25519.SeedSize))
+	b, err := cpanic.NDJSON.Format(cpanic.New("boom"))
+	require.NoError(t, err)
+	assert.Equal(t, cpanic.ErrUnsigned, cpanic.Verify(b, key.Public().(ed25519.PublicKey)))
+	assert.Error(t, cpanic.Verify([]byte("not json"), key.Public().(ed25519.PublicKey)))
+
+	_, err = cpanic.WithSigningKey(cpanic.Text, key).Format(cpanic.New("boom"))
+	assert.Error(t, err)
+}
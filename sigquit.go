@@ -0,0 +1,38 @@
+//go:build !plan9 && !tinygo && !cpanic_minimal
+// +build !plan9,!tinygo,!cpanic_minimal
+
+package cpanic
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// dumpSignals are the signals that request a goroutine dump for `WithDumpOnSIGQUIT`.
+var dumpSignals = []os.Signal{syscall.SIGQUIT}
+
+// notifyDump reports goroutine dumps to h on SIGQUIT until the returned function is
+// called.
+func notifyDump(h Handler) (stop func()) {
+	signals := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(signals, dumpSignals...)
+	go func() {
+		for {
+			select {
+			case sig := <-signals:
+				p := NewEvent(ErrGoroutineDump)
+				p.Severity = SeverityWarning
+				p.SetAttr(SignalAttr, sig.String())
+				h.Handle(p)
+			case <-done:
+				return
+			}
+		}
+	}()
+	return func() {
+		signal.Stop(signals)
+		close(done)
+	}
+}
@@ -0,0 +1,49 @@
+//go:build !plan9 && !tinygo && !cpanic_minimal
+// +build !plan9,!tinygo,!cpanic_minimal
+
+package cpanic_test
+
+import (
+	"errors"
+	"os"
+	"runtime"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/demosdemon/cpanic"
+)
+
+func TestRunDumpOnSIGQUIT(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("SIGQUIT cannot be sent on windows")
+	}
+
+	before := cpanic.PanicCount()
+	dumps := make(chan *cpanic.Panic, 1)
+	code := cpanic.Run(func() error {
+		proc, err := os.FindProcess(os.Getpid())
+		if err != nil {
+			return err
+		}
+		if err := proc.Signal(syscall.SIGQUIT); err != nil {
+			return err
+		}
+		select {
+		case <-dumps:
+			return nil
+		case <-time.After(5 * time.Second):
+			return errors.New("timed out waiting for dump")
+		}
+	}, cpanic.WithDumpOnSIGQUIT(func(p *cpanic.Panic) {
+		assert.True(t, errors.Is(p, cpanic.ErrGoroutineDump))
+		assert.Equal(t, cpanic.SeverityWarning, p.Severity)
+		assert.Equal(t, "quit", p.Attrs[cpanic.SignalAttr])
+		assert.Contains(t, p.Trace, "TestRunDumpOnSIGQUIT")
+		dumps <- p
+	}))
+	assert.Equal(t, 0, code)
+	assert.Equal(t, before, cpanic.PanicCount(), "dumps are not panics")
+}
@@ -0,0 +1,74 @@
+//go:build go1.21
+// +build go1.21
+
+package cpanic
+
+import (
+	"context"
+	"log/slog"
+)
+
+// SlogHandler returns a `slog.Handler` that passes records to h and remembers the
+// attributes added to it with `WithAttrs` and `WithGroup`, such as by `slog.Logger.With`,
+// so they can be attached to panics with `SlogAttrs`. Attributes in groups are keyed by
+// the group names and the attribute key joined with dots, as in "request.id".
+func SlogHandler(h slog.Handler) slog.Handler {
+	return &slogHandler{Handler: h}
+}
+
+type slogHandler struct {
+	slog.Handler
+	prefix  string
+	keyvals []interface{}
+}
+
+func (h *slogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	keyvals := h.keyvals[:len(h.keyvals):len(h.keyvals)]
+	for _, a := range attrs {
+		keyvals = appendSlogAttr(keyvals, h.prefix, a)
+	}
+	return &slogHandler{Handler: h.Handler.WithAttrs(attrs), prefix: h.prefix, keyvals: keyvals}
+}
+
+func (h *slogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return &slogHandler{Handler: h.Handler.WithGroup(name), prefix: h.prefix + name + ".", keyvals: h.keyvals}
+}
+
+// appendSlogAttr appends the resolved attribute to keyvals, flattening groups.
+func appendSlogAttr(keyvals []interface{}, prefix string, a slog.Attr) []interface{} {
+	a.Value = a.Value.Resolve()
+	if a.Value.Kind() != slog.KindGroup {
+		if a.Key == "" {
+			return keyvals
+		}
+		return append(keyvals, prefix+a.Key, a.Value.Any())
+	}
+	if a.Key != "" {
+		prefix += a.Key + "."
+	}
+	for _, ga := range a.Value.Group() {
+		keyvals = appendSlogAttr(keyvals, prefix, ga)
+	}
+	return keyvals
+}
+
+// SlogAttrs returns the attributes attached to the logger, as alternating key/value
+// pairs, if its handler was created with `SlogHandler`, or nil otherwise.
+func SlogAttrs(l *slog.Logger) []interface{} {
+	if h, ok := l.Handler().(*slogHandler); ok {
+		return h.keyvals
+	}
+	return nil
+}
+
+// SlogDefaultAttrs returns the attributes attached to the default `slog` logger, as
+// `SlogAttrs` does. Register it with `RegisterAttrExtractor` to attach them to panics:
+//
+//	slog.SetDefault(slog.New(cpanic.SlogHandler(handler)).With("service", "api"))
+//	cpanic.RegisterAttrExtractor(cpanic.SlogDefaultAttrs)
+func SlogDefaultAttrs(ctx context.Context) []interface{} {
+	return SlogAttrs(slog.Default())
+}
@@ -0,0 +1,35 @@
+//go:build go1.21
+// +build go1.21
+
+package cpanic_test
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/demosdemon/cpanic"
+)
+
+func TestSlogAttrs(t *testing.T) {
+	var buf bytes.Buffer
+	base := slog.New(cpanic.SlogHandler(slog.NewTextHandler(&buf, nil)))
+	l := base.With("service", "api").WithGroup("request").With("id", 7, slog.Group("user", "name", "ada"))
+
+	assert.Nil(t, cpanic.SlogAttrs(base))
+	assert.Equal(t, []interface{}{"service", "api", "request.id", int64(7), "request.user.name", "ada"}, cpanic.SlogAttrs(l))
+	assert.Nil(t, cpanic.SlogAttrs(slog.New(slog.NewTextHandler(&buf, nil))))
+
+	l.Info("hello")
+	assert.Contains(t, buf.String(), "request.user.name=ada")
+}
+
+func TestSlogDefaultAttrs(t *testing.T) {
+	defer slog.SetDefault(slog.Default())
+	var buf bytes.Buffer
+	slog.SetDefault(slog.New(cpanic.SlogHandler(slog.NewTextHandler(&buf, nil))).With("service", "api"))
+	assert.Equal(t, []interface{}{"service", "api"}, cpanic.SlogDefaultAttrs(context.Background()))
+}
@@ -0,0 +1,87 @@
+package cpanic
+
+import (
+	"fmt"
+	"sync/atomic"
+)
+
+// ValueSnapshot is the rendered form of a panic value, captured by `New` when enabled
+// by `SetValueSnapshot`.
+type ValueSnapshot struct {
+	// Type is the type of the value, as formatted by the `%T` verb.
+	Type string `json:"type" yaml:"type"`
+	// Text is the value as rendered by `Message` when the panic was created, truncated
+	// to the maximum size.
+	Text string `json:"text" yaml:"text"`
+	// Truncated reports whether Text was truncated.
+	Truncated bool `json:"truncated,omitempty" yaml:"truncated,omitempty"`
+}
+
+type snapshotConfig struct {
+	maxBytes  int
+	dropValue bool
+}
+
+var snapshots atomic.Value // snapshotConfig
+
+// SetValueSnapshot makes `New` capture a `ValueSnapshot` of the panic value of at most
+// maxBytes, so reports are unaffected by changes to the value after the panic is
+// recovered; `Message` then returns the snapshot. If dropValue is true, the panic's
+// `Value` is also set to nil, so a huge value, such as a buffer, is not kept alive by
+// the panic; `Type` and the fingerprint still use the type of the dropped value, but
+// `Unwrap`, `As`, and `MatchType` no longer see it. A maxBytes of zero or less turns
+// snapshots off. Use `WithValueSnapshot` to enable snapshots while `Run` calls its
+// function.
+//
+// The text of strings, errors, and `fmt.Stringer` values is copied only up to maxBytes,
+// and only the first maxBytes of a byte slice are formatted; other values are formatted
+// in full by `fmt` before being cut.
+func SetValueSnapshot(maxBytes int, dropValue bool) {
+	snapshots.Store(snapshotConfig{maxBytes: maxBytes, dropValue: dropValue})
+}
+
+// snapshotValue applies the `SetValueSnapshot` configuration to a new panic.
+func snapshotValue(p *Panic) {
+	c, _ := snapshots.Load().(snapshotConfig)
+	if c.maxBytes <= 0 {
+		return
+	}
+
+	// One byte more than the maximum tells a value that fits from a truncated one.
+	text := renderValueLimit(p.Value, c.maxBytes+1)
+	p.ValueSnapshot = &ValueSnapshot{
+		Type:      fmt.Sprintf("%T", p.Value),
+		Text:      truncate(text, c.maxBytes),
+		Truncated: len(text) > c.maxBytes,
+	}
+	if c.dropValue {
+		p.dropped = &droppedValue{value: p.Value}
+		p.Value = nil
+	}
+}
+
+// droppedValue is a panic value dropped by `SetValueSnapshot`.
+type droppedValue struct {
+	value interface{}
+}
+
+// takeValue returns the original value of the panic, releasing it if it was dropped by
+// `SetValueSnapshot`.
+func (p *Panic) takeValue() interface{} {
+	if p.dropped == nil {
+		return p.Value
+	}
+	value := p.dropped.value
+	p.dropped.value = nil
+	return value
+}
+
+// Type returns the type of the panic value, as formatted by the `%T` verb, such as
+// "runtime.boundsError". If the value was dropped by `SetValueSnapshot`, it is the type
+// of the dropped value.
+func (p *Panic) Type() string {
+	if p.ValueSnapshot != nil {
+		return p.ValueSnapshot.Type
+	}
+	return fmt.Sprintf("%T", p.Value)
+}
@@ -0,0 +1,67 @@
+package cpanic_test
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/demosdemon/cpanic"
+)
+
+type buffer struct {
+	data []byte
+}
+
+func (b *buffer) String() string { return string(b.data) }
+
+func TestSetValueSnapshot(t *testing.T) {
+	defer cpanic.SetValueSnapshot(0, false)
+	cpanic.SetValueSnapshot(16, false)
+
+	buf := &buffer{data: []byte("not at a disco")}
+	p := cpanic.New(buf)
+	buf.data = bytes.Repeat([]byte("x"), 1<<10)
+
+	assert.Same(t, buf, p.Value)
+	assert.Equal(t, &cpanic.ValueSnapshot{Type: "*cpanic_test.buffer", Text: "not at a disco"}, p.ValueSnapshot)
+	assert.Equal(t, "panic: not at a disco", p.Error())
+
+	p = cpanic.New(buf)
+	assert.Equal(t, &cpanic.ValueSnapshot{Type: "*cpanic_test.buffer", Text: "xxxxxxxxxxxxx...", Truncated: true}, p.ValueSnapshot)
+
+	p = cpanic.New(strings.Repeat("y", 1<<20))
+	assert.Equal(t, &cpanic.ValueSnapshot{Type: "string", Text: "yyyyyyyyyyyyy...", Truncated: true}, p.ValueSnapshot)
+	p = cpanic.New("sixteen bytes!!!")
+	assert.Equal(t, &cpanic.ValueSnapshot{Type: "string", Text: "sixteen bytes!!!"}, p.ValueSnapshot)
+
+	p = cpanic.New(bytes.Repeat([]byte{1}, 1<<20))
+	assert.Equal(t, &cpanic.ValueSnapshot{Type: "[]uint8", Text: "[1 1 1 1 1 1 ...", Truncated: true}, p.ValueSnapshot)
+	p = cpanic.New(errors.New(strings.Repeat("z", 1<<20)))
+	assert.Equal(t, &cpanic.ValueSnapshot{Type: "*errors.errorString", Text: "zzzzzzzzzzzzz...", Truncated: true}, p.ValueSnapshot)
+	p = cpanic.New((*nilError)(nil))
+	assert.Equal(t, &cpanic.ValueSnapshot{Type: "*cpanic_test.nilError", Text: "<nil>"}, p.ValueSnapshot)
+}
+
+// nilError is an error whose Error method panics on a nil receiver.
+type nilError struct{ msg string }
+
+func (e *nilError) Error() string { return e.msg }
+
+func TestSetValueSnapshotDrop(t *testing.T) {
+	err := errors.New("not at a disco")
+	fn := func() { panic(err) }
+	kept := recovered(fn)
+
+	defer cpanic.SetValueSnapshot(0, false)
+	cpanic.SetValueSnapshot(1<<10, true)
+
+	p := recovered(fn)
+	assert.Nil(t, p.Value)
+	assert.Equal(t, "*errors.errorString", p.Type())
+	assert.Equal(t, "panic: not at a disco", p.Error())
+	assert.False(t, errors.Is(p, err))
+	assert.Equal(t, kept.Fingerprint(), p.Fingerprint())
+}
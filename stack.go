@@ -0,0 +1,98 @@
+package cpanic
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Frame is a single function call parsed from a goroutine stack trace.
+type Frame struct {
+	// Function is the fully qualified name of the function, such as
+	// "github.com/demosdemon/cpanic.New" or "main.(*T).Method.func1".
+	Function string `json:"function" yaml:"function"`
+	// File is the path of the source file containing the call.
+	File string `json:"file" yaml:"file"`
+	// Line is the line number of the call in the file.
+	Line int `json:"line" yaml:"line"`
+}
+
+// String returns the frame in the form "function (file:line)".
+func (f Frame) String() string {
+	return f.Function + " (" + f.File + ":" + strconv.Itoa(f.Line) + ")"
+}
+
+// Frames parses the stack of the first goroutine in the trace, which is the goroutine
+// that created the panic, innermost call first. When the panic was recovered, the
+// frames above the call to `panic`, such as the deferred function that recovered it,
+// are omitted. Otherwise, the frames of this package that created the panic are
+// omitted. If the panic has retained frames in `Stack`, they are returned instead.
+func (p *Panic) Frames() []Frame {
+	if p.Stack != nil {
+		return p.Stack
+	}
+
+	lines := strings.Split(p.Trace, "\n")
+	if len(lines) == 0 || !strings.HasPrefix(lines[0], "goroutine ") {
+		return nil
+	}
+
+	frames, _ := parseFrames(lines[1:])
+
+	for i := len(frames) - 1; i >= 0; i-- {
+		if frames[i].Function == "panic" {
+			return frames[i+1:]
+		}
+	}
+
+	for len(frames) > 0 && isOwnFunction(frames[0].Function) {
+		frames = frames[1:]
+	}
+	return frames
+}
+
+// Culprit returns the innermost frame of the panicking goroutine that belongs to the
+// application: frames of the runtime, of this package, and of functions registered with
+// `RegisterHelper` are skipped. If no such frame exists, false is returned.
+func (p *Panic) Culprit() (Frame, bool) {
+	for _, f := range p.Frames() {
+		if strings.HasPrefix(f.Function, "runtime.") || isOwnFunction(f.Function) || isHelper(f.Function) {
+			continue
+		}
+		return f, true
+	}
+	return Frame{}, false
+}
+
+// functionName returns the function name from a line of the form
+// "main.(*T).Method(0xc000012345, ...)".
+func functionName(line string) string {
+	if idx := strings.LastIndexByte(line, '('); idx > 0 {
+		return line[:idx]
+	}
+	return line
+}
+
+// parseFileLine parses a line of the form "\t/path/to/file.go:42 +0x1d".
+func parseFileLine(line string) (string, int) {
+	line = strings.TrimPrefix(line, "\t")
+	if idx := strings.LastIndex(line, " +0x"); idx >= 0 {
+		line = line[:idx]
+	}
+
+	idx := strings.LastIndexByte(line, ':')
+	if idx < 0 {
+		return line, 0
+	}
+
+	n, err := strconv.Atoi(line[idx+1:])
+	if err != nil {
+		return line, 0
+	}
+	return line[:idx], n
+}
+
+// isOwnFunction reports whether the function belongs to this package.
+func isOwnFunction(fn string) bool {
+	const pkg = "github.com/demosdemon/cpanic."
+	return strings.HasPrefix(fn, pkg) && !strings.Contains(fn[len(pkg):], "/")
+}
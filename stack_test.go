@@ -0,0 +1,53 @@
+package cpanic_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/demosdemon/cpanic"
+)
+
+func recovered(fn func()) *cpanic.Panic {
+	var p *cpanic.Panic
+	_ = errors.As(cpanic.Try(fn), &p)
+	return p
+}
+
+func nilDeref() {
+	var m map[string]*int
+	_ = *m["missing"]
+}
+
+func TestFrames(t *testing.T) {
+	p := recovered(nilDeref)
+	frames := p.Frames()
+	if assert.NotEmpty(t, frames) {
+		assert.Equal(t, "github.com/demosdemon/cpanic_test.nilDeref", frames[0].Function)
+		assert.True(t, strings.HasSuffix(frames[0].File, "stack_test.go"), frames[0].File)
+		assert.NotZero(t, frames[0].Line)
+	}
+
+	frames = cpanic.New("not at a disco").Frames()
+	if assert.NotEmpty(t, frames) {
+		assert.Equal(t, "github.com/demosdemon/cpanic_test.TestFrames", frames[0].Function)
+	}
+
+	assert.Empty(t, (&cpanic.Panic{Trace: "garbage"}).Frames())
+}
+
+func TestCulprit(t *testing.T) {
+	f, ok := recovered(func() { panic("not at a disco") }).Culprit()
+	assert.True(t, ok)
+	assert.True(t, strings.HasPrefix(f.Function, "github.com/demosdemon/cpanic_test.TestCulprit."), f.Function)
+
+	f, ok = recovered(nilDeref).Culprit()
+	assert.True(t, ok)
+	assert.Equal(t, "github.com/demosdemon/cpanic_test.nilDeref", f.Function)
+	assert.Contains(t, f.String(), "github.com/demosdemon/cpanic_test.nilDeref (")
+
+	_, ok = (&cpanic.Panic{}).Culprit()
+	assert.False(t, ok)
+}
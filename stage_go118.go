@@ -0,0 +1,47 @@
+//go:build go1.18
+// +build go1.18
+
+package cpanic
+
+// Stage returns a channel-based pipeline stage that applies fn to every item received
+// from its input channel. Results are sent on the output channel and errors on the
+// error channel. If fn panics on an item, the panic is recovered, reported to the
+// handler, if provided, and sent on the error channel as a `*Panic` while the stage
+// keeps consuming, so a single poison message cannot stall the pipeline. Both
+// returned channels are closed once the input channel is closed and drained; callers
+// must receive from both to avoid blocking the stage.
+func Stage[In, Out any](fn func(In) (Out, error), h Handler) func(in <-chan In) (<-chan Out, <-chan error) {
+	return func(in <-chan In) (<-chan Out, <-chan error) {
+		out := make(chan Out)
+		errs := make(chan error)
+
+		go func() {
+			defer close(out)
+			defer close(errs)
+
+			for item := range in {
+				result, err := stageItem(fn, item, h)
+				if err == nil {
+					out <- result
+					continue
+				}
+				errs <- err
+			}
+		}()
+
+		return out, errs
+	}
+}
+
+// stageItem applies fn to the item, reporting and returning any panic it raises.
+func stageItem[In, Out any](fn func(In) (Out, error), item In, h Handler) (result Out, err error) {
+	defer func() {
+		if value := recover(); value != nil {
+			p := New(value)
+			Deliver(p, h)
+			err = p
+		}
+	}()
+
+	return fn(item)
+}
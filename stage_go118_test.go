@@ -0,0 +1,63 @@
+//go:build go1.18
+// +build go1.18
+
+package cpanic_test
+
+import (
+	"errors"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/demosdemon/cpanic"
+)
+
+func TestStage(t *testing.T) {
+	var handled []*cpanic.Panic
+	stage := cpanic.Stage(func(s string) (int, error) {
+		switch s {
+		case "poison":
+			panic("not at a disco")
+		case "bad":
+			return 0, errors.New("test")
+		default:
+			return strconv.Atoi(s)
+		}
+	}, func(p *cpanic.Panic) { handled = append(handled, p) })
+
+	in := make(chan string)
+	go func() {
+		defer close(in)
+		for _, s := range []string{"1", "poison", "2", "bad", "3"} {
+			in <- s
+		}
+	}()
+
+	out, errs := stage(in)
+
+	var (
+		wg      sync.WaitGroup
+		results []int
+		errMsgs []string
+	)
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for v := range out {
+			results = append(results, v)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for err := range errs {
+			errMsgs = append(errMsgs, err.Error())
+		}
+	}()
+	wg.Wait()
+
+	assert.Equal(t, []int{1, 2, 3}, results)
+	assert.Equal(t, []string{"panic: not at a disco", "test"}, errMsgs)
+	assert.Len(t, handled, 1)
+}
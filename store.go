@@ -0,0 +1,71 @@
+package cpanic
+
+import (
+	"fmt"
+	"io/ioutil"
+)
+
+// Store is a collection of previously captured panics, such as a `*History`, or the
+// crash dumps read by `DumpFiles`.
+type Store interface {
+	// Load returns the stored panics, oldest first.
+	Load() ([]*Panic, error)
+}
+
+// Appender is a `Store` that panics can be added to, such as the store of a crash
+// collector fed by remote processes.
+type Appender interface {
+	Store
+	// Append adds the panic to the store.
+	Append(p *Panic) error
+}
+
+// Load returns the recorded panics, oldest first. It implements `Store`.
+func (h *History) Load() ([]*Panic, error) {
+	return h.Panics(), nil
+}
+
+// Append records the panic as `Handle` does. It implements `Appender`.
+func (h *History) Append(p *Panic) error {
+	h.Handle(p)
+	return nil
+}
+
+// DumpFiles returns a store of the crash output, such as the stderr of a crashed
+// process, in each of the files, parsed with `Parse`, in the order given.
+func DumpFiles(paths ...string) Store {
+	return dumpFiles{paths: paths}
+}
+
+// SealedDumpFiles is like `DumpFiles` but opens each file with the keyring first, for
+// crash output that was encrypted before it was written to disk, such as through
+// `(*Keyring).NewWriter`.
+func SealedDumpFiles(k *Keyring, paths ...string) Store {
+	return dumpFiles{paths: paths, keyring: k}
+}
+
+type dumpFiles struct {
+	paths   []string
+	keyring *Keyring
+}
+
+func (d dumpFiles) Load() ([]*Panic, error) {
+	panics := make([]*Panic, 0, len(d.paths))
+	for _, path := range d.paths {
+		b, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if d.keyring != nil {
+			if b, err = d.keyring.Open(b); err != nil {
+				return nil, fmt.Errorf("%s: %w", path, err)
+			}
+		}
+		p, err := Parse(string(b))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		panics = append(panics, p)
+	}
+	return panics, nil
+}
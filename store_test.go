@@ -0,0 +1,70 @@
+package cpanic_test
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/demosdemon/cpanic"
+)
+
+func TestDumpFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cpanic")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	crash := filepath.Join(dir, "crash.log")
+	require.NoError(t, ioutil.WriteFile(crash, []byte(crashLog), 0o644))
+	empty := filepath.Join(dir, "empty.log")
+	require.NoError(t, ioutil.WriteFile(empty, []byte("starting server\n"), 0o644))
+
+	panics, err := cpanic.DumpFiles(crash, crash).Load()
+	require.NoError(t, err)
+	require.Len(t, panics, 2)
+	assert.Equal(t, "assignment to entry in nil map", panics[0].Value)
+
+	_, err = cpanic.DumpFiles(crash, empty).Load()
+	assert.True(t, errors.Is(err, cpanic.ErrNoTrace))
+	assert.Contains(t, err.Error(), empty)
+}
+
+func TestSealedDumpFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cpanic")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	k, err := cpanic.NewKeyring(bytes.Repeat([]byte{1}, 32))
+	require.NoError(t, err)
+	sealed, err := k.Seal([]byte(crashLog))
+	require.NoError(t, err)
+	crash := filepath.Join(dir, "crash.log.sealed")
+	require.NoError(t, ioutil.WriteFile(crash, sealed, 0o644))
+	plain := filepath.Join(dir, "crash.log")
+	require.NoError(t, ioutil.WriteFile(plain, []byte(crashLog), 0o644))
+
+	panics, err := cpanic.SealedDumpFiles(k, crash).Load()
+	require.NoError(t, err)
+	require.Len(t, panics, 1)
+	assert.Equal(t, "assignment to entry in nil map", panics[0].Value)
+
+	_, err = cpanic.SealedDumpFiles(k, plain).Load()
+	assert.True(t, errors.Is(err, cpanic.ErrNotEncrypted), err)
+	assert.Contains(t, err.Error(), plain)
+}
+
+func TestHistoryAppend(t *testing.T) {
+	var store cpanic.Appender = cpanic.NewHistory(1)
+	require.NoError(t, store.Append(cpanic.New("one")))
+	require.NoError(t, store.Append(cpanic.New("two")))
+
+	panics, err := store.Load()
+	require.NoError(t, err)
+	require.Len(t, panics, 1)
+	assert.Equal(t, "two", panics[0].Value)
+}
@@ -0,0 +1,110 @@
+package cpanic
+
+import (
+	"errors"
+	"os"
+	"sync"
+	"time"
+)
+
+// StormCountAttr is the attribute of the panic reported by a `Storm` when it trips,
+// holding the number of panics handled within the window.
+const StormCountAttr = "storm.count"
+
+// ErrPanicStorm is the value of the panic reported by a `Storm` when it trips.
+var ErrPanicStorm = errors.New("cpanic: panic storm")
+
+// Storm is a handler that tracks the rate of recovered panics and, past a threshold,
+// escalates from recovering and continuing to reporting, flushing, and exiting, so a
+// half-broken server does not limp along returning garbage indefinitely. Share one
+// `Storm` between every recovery point of the process, so it sees the global rate.
+// Create one with `DetectStorm`.
+type Storm struct {
+	h         Handler
+	threshold int
+	window    time.Duration
+	flushers  []Flusher
+
+	mu      sync.Mutex
+	times   []time.Time
+	next    int
+	tripped bool
+	exit    func(code int)
+}
+
+// DetectStorm returns a `Storm` passing panics to h. When more than threshold panics
+// are handled within window, it trips: it reports a panic with the value
+// `ErrPanicStorm`, `SeverityFatal`, and the `StormCountAttr` attribute to h, flushes
+// each of the flushers, such as a `*Batcher`, and exits the process with
+// `ExitCodePanic`. A threshold below zero is treated as zero.
+func DetectStorm(h Handler, threshold int, window time.Duration, flushers ...Flusher) *Storm {
+	if threshold < 0 {
+		threshold = 0
+	}
+	return &Storm{
+		h:         h,
+		threshold: threshold,
+		window:    window,
+		flushers:  flushers,
+		times:     make([]time.Time, 0, threshold+1),
+		exit:      os.Exit,
+	}
+}
+
+// SetExit replaces the function called with the exit code when the storm trips, such as
+// to drain a server before exiting. It defaults to `os.Exit`.
+func (s *Storm) SetExit(exit func(code int)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.exit = exit
+}
+
+// Handle passes the panic to the handler and trips the storm if the panic exceeds the
+// threshold.
+func (s *Storm) Handle(p *Panic) {
+	s.h.Handle(p)
+	if exit, ok := s.count(time.Now()); ok {
+		s.trip(exit)
+	}
+}
+
+// Tripped reports whether the storm has tripped.
+func (s *Storm) Tripped() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.tripped
+}
+
+// count records a panic at now and, if it is the first to exceed the threshold within
+// the window, returns the exit function.
+func (s *Storm) count(now time.Time) (func(code int), bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.tripped {
+		return nil, false
+	}
+
+	// times is a ring of the last threshold+1 panics; next is the oldest once full.
+	if len(s.times) < cap(s.times) {
+		s.times = append(s.times, now)
+	} else {
+		s.times[s.next] = now
+		s.next = (s.next + 1) % len(s.times)
+	}
+	if len(s.times) <= s.threshold || now.Sub(s.times[s.next]) >= s.window {
+		return nil, false
+	}
+	s.tripped = true
+	return s.exit, true
+}
+
+func (s *Storm) trip(exit func(code int)) {
+	p := NewEvent(ErrPanicStorm)
+	p.Severity = SeverityFatal
+	p.SetAttr(StormCountAttr, s.threshold+1)
+	Deliver(p, s.h)
+	for _, f := range s.flushers {
+		f.Flush()
+	}
+	exit(ExitCodePanic)
+}
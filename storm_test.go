@@ -0,0 +1,63 @@
+package cpanic_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/demosdemon/cpanic"
+)
+
+type flushCounter int
+
+func (f *flushCounter) Flush() { *f++ }
+
+func TestDetectStorm(t *testing.T) {
+	var got []*cpanic.Panic
+	var flushed flushCounter
+	s := cpanic.DetectStorm(func(p *cpanic.Panic) {
+		got = append(got, p)
+	}, 3, time.Minute, &flushed)
+	var codes []int
+	s.SetExit(func(code int) { codes = append(codes, code) })
+
+	for i := 0; i < 3; i++ {
+		s.Handle(cpanic.New("boom"))
+	}
+	assert.False(t, s.Tripped())
+	assert.Empty(t, codes)
+
+	p := cpanic.New("boom")
+	count := cpanic.PanicCount()
+	s.Handle(p)
+	assert.Equal(t, count, cpanic.PanicCount(), "the storm report is not counted as a panic")
+	assert.True(t, s.Tripped())
+	assert.Equal(t, []int{cpanic.ExitCodePanic}, codes)
+	assert.Equal(t, flushCounter(1), flushed)
+	if assert.Len(t, got, 5) {
+		storm := got[4]
+		assert.Equal(t, cpanic.ErrPanicStorm, storm.Value)
+		assert.Equal(t, cpanic.SeverityFatal, storm.Severity)
+		assert.Equal(t, 4, storm.Attrs[cpanic.StormCountAttr])
+	}
+
+	s.Handle(cpanic.New("boom"))
+	assert.Len(t, codes, 1, "trips once")
+}
+
+func TestDetectStormWindow(t *testing.T) {
+	s := cpanic.DetectStorm(func(p *cpanic.Panic) {}, 1, 20*time.Millisecond)
+	tripped := false
+	s.SetExit(func(int) { tripped = true })
+
+	for i := 0; i < 3; i++ {
+		s.Handle(cpanic.New("boom"))
+		time.Sleep(30 * time.Millisecond)
+	}
+	assert.False(t, tripped)
+
+	s.Handle(cpanic.New("boom"))
+	s.Handle(cpanic.New("boom"))
+	assert.True(t, tripped)
+}
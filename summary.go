@@ -0,0 +1,46 @@
+package cpanic
+
+import "time"
+
+// SummaryCountsAttr is the attribute of a summary panic sent by `Summary` holding the
+// number of panics of each fingerprint, as a `map[string]int`.
+const SummaryCountsAttr = "summary.counts"
+
+// Summary returns a batcher that, instead of forwarding every panic to h, forwards a
+// digest of the panics reported during each period, for teams who want a periodic
+// digest rather than an alert per panic. The period starts with the first panic
+// reported after the previous digest, so nothing is sent while no panic occurs.
+//
+// The digest is a panic whose value is the `Report` of `Aggregate`, so it is rendered
+// by every handler, with the highest severity of the summarized panics and the counts
+// by fingerprint in `SummaryCountsAttr`. Use the batcher's `Handle` method as the
+// handler, and flush it before the process exits, such as with `WithFlush`.
+func Summary(h Handler, every time.Duration) *Batcher {
+	return Batch(func(ps []*Panic) {
+		h.Handle(summarize(ps))
+	}, 0, every)
+}
+
+func summarize(ps []*Panic) *Panic {
+	r := Aggregate(ps)
+	now := time.Now()
+	p := &Panic{
+		ID:       newID(now),
+		Time:     now,
+		Value:    r,
+		Severity: SeverityWarning,
+		BuildID:  BuildID(),
+	}
+	for _, q := range ps {
+		if q.Severity > p.Severity {
+			p.Severity = q.Severity
+		}
+	}
+
+	counts := make(map[string]int, len(r.Crashers))
+	for _, c := range r.Crashers {
+		counts[c.Fingerprint] = c.Count
+	}
+	p.SetAttr(SummaryCountsAttr, counts)
+	return p
+}
@@ -0,0 +1,47 @@
+package cpanic_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/demosdemon/cpanic"
+)
+
+func TestSummary(t *testing.T) {
+	var c collector
+	s := cpanic.Summary(c.handle, time.Hour)
+
+	one := func() *cpanic.Panic { return cpanic.New("one") }()
+	two := func() *cpanic.Panic { return cpanic.New("two") }()
+	two.Severity = cpanic.SeverityFatal
+	s.Handle(one)
+	s.Handle(one)
+	s.Handle(two)
+	assert.Empty(t, c.get(), "nothing is forwarded before the period ends")
+
+	s.Flush()
+	panics := c.get()
+	require.Len(t, panics, 1)
+	p := panics[0]
+	assert.Equal(t, cpanic.SeverityFatal, p.Severity)
+	assert.Equal(t, "cpanic.Report", p.Type())
+	assert.Contains(t, p.Message(), "3 panics, 2 distinct")
+	assert.Equal(t, map[string]int{one.Fingerprint(): 2, two.Fingerprint(): 1}, p.Attrs[cpanic.SummaryCountsAttr])
+
+	r, ok := p.Value.(cpanic.Report)
+	require.True(t, ok)
+	assert.Equal(t, 3, r.Total)
+
+	s.Flush()
+	assert.Len(t, c.get(), 1, "empty periods are not forwarded")
+}
+
+func TestSummaryPeriod(t *testing.T) {
+	var c collector
+	s := cpanic.Summary(c.handle, time.Millisecond)
+	s.Handle(cpanic.New("one"))
+	assert.Eventually(t, func() bool { return len(c.get()) == 1 }, time.Second, time.Millisecond)
+}
@@ -0,0 +1,175 @@
+package cpanic
+
+import (
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SuppressedAttr is the attribute holding the number of suppressed occurrences of the
+// fingerprint in the current window when a suppressed panic is escalated.
+const SuppressedAttr = "suppressed"
+
+// maxEscalationKeys is the number of tracked fingerprints above which expired windows
+// are pruned.
+const maxEscalationKeys = 1024
+
+// Matcher reports whether a panic matches some criteria.
+type Matcher func(p *Panic) bool
+
+var suppressions struct {
+	sync.RWMutex
+	matchers []*Matcher
+
+	threshold int
+	window    time.Duration
+	counts    map[string]*escalationWindow
+}
+
+type escalationWindow struct {
+	start time.Time
+	count int
+}
+
+// Suppress registers matchers for known, benign panics, such as those from third-party
+// libraries. Panics matching any registered matcher are still recovered, but the
+// handler is not called for them where they are recovered, or by `Deliver`, so they do
+// not page anyone. It returns a function that unregisters the matchers.
+func Suppress(matchers ...Matcher) (unregister func()) {
+	registered := make(map[*Matcher]bool, len(matchers))
+	suppressions.Lock()
+	defer suppressions.Unlock()
+	for i := range matchers {
+		m := &matchers[i]
+		registered[m] = true
+		suppressions.matchers = append(suppressions.matchers, m)
+	}
+	return func() {
+		suppressions.Lock()
+		defer suppressions.Unlock()
+		kept := make([]*Matcher, 0, len(suppressions.matchers))
+		for _, m := range suppressions.matchers {
+			if !registered[m] {
+				kept = append(kept, m)
+			}
+		}
+		suppressions.matchers = kept
+	}
+}
+
+// EscalateSuppressed escalates suppressed panics whose fingerprint occurs more than
+// threshold times within window, so that known, benign panics cannot silently become a
+// real problem. Escalated panics are passed to the handler with the `SuppressedAttr`
+// attribute set to the number of occurrences in the window. A window of zero never
+// expires. A threshold of zero or less disables escalation, which is the default.
+func EscalateSuppressed(threshold int, window time.Duration) {
+	suppressions.Lock()
+	defer suppressions.Unlock()
+	suppressions.threshold = threshold
+	suppressions.window = window
+	suppressions.counts = make(map[string]*escalationWindow)
+}
+
+// escalate counts the occurrence of a suppressed panic and reports whether it exceeds
+// the escalation threshold, along with the count.
+func escalate(p *Panic) (int, bool) {
+	suppressions.Lock()
+	defer suppressions.Unlock()
+	if suppressions.threshold <= 0 {
+		return 0, false
+	}
+
+	now := time.Now()
+	expired := func(w *escalationWindow) bool {
+		return suppressions.window > 0 && now.Sub(w.start) >= suppressions.window
+	}
+
+	fingerprint := p.Fingerprint()
+	w, ok := suppressions.counts[fingerprint]
+	if !ok || expired(w) {
+		if len(suppressions.counts) >= maxEscalationKeys {
+			for k, w := range suppressions.counts {
+				if expired(w) {
+					delete(suppressions.counts, k)
+				}
+			}
+		}
+		w = &escalationWindow{start: now}
+		suppressions.counts[fingerprint] = w
+	}
+	w.count++
+	return w.count, w.count > suppressions.threshold
+}
+
+// Suppressed reports whether the panic matches a matcher registered with `Suppress`.
+func Suppressed(p *Panic) bool {
+	suppressions.RLock()
+	defer suppressions.RUnlock()
+	for _, m := range suppressions.matchers {
+		if (*m)(p) {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchType matches panics whose value has the same type as v. If v is a nil pointer to
+// an interface, such as `(*runtime.Error)(nil)`, panics whose value implements the
+// interface are matched instead.
+func MatchType(v interface{}) Matcher {
+	t := reflect.TypeOf(v)
+	if t != nil && t.Kind() == reflect.Ptr && t.Elem().Kind() == reflect.Interface {
+		iface := t.Elem()
+		return func(p *Panic) bool {
+			vt := reflect.TypeOf(p.Value)
+			return vt != nil && vt.Implements(iface)
+		}
+	}
+	return func(p *Panic) bool {
+		return reflect.TypeOf(p.Value) == t
+	}
+}
+
+// MatchMessage matches panics whose value, as rendered by `Message`, matches re.
+func MatchMessage(re *regexp.Regexp) Matcher {
+	return func(p *Panic) bool {
+		return re.MatchString(p.Message())
+	}
+}
+
+// MatchFingerprint matches panics with any of the fingerprints.
+func MatchFingerprint(fingerprints ...string) Matcher {
+	set := make(map[string]struct{}, len(fingerprints))
+	for _, fp := range fingerprints {
+		set[fp] = struct{}{}
+	}
+	return func(p *Panic) bool {
+		_, ok := set[p.Fingerprint()]
+		return ok
+	}
+}
+
+// MatchCulpritPackage matches panics whose `Culprit` is in the package, such as
+// "github.com/some/lib", or one of its subpackages.
+func MatchCulpritPackage(pkg string) Matcher {
+	return func(p *Panic) bool {
+		f, ok := p.Culprit()
+		if !ok {
+			return false
+		}
+		got := packageName(f.Function)
+		return got == pkg || strings.HasPrefix(got, pkg+"/")
+	}
+}
+
+// packageName returns the import path of the package of the fully qualified function
+// name, such as "github.com/some/lib" for "github.com/some/lib.(*T).Method".
+func packageName(fn string) string {
+	slash := strings.LastIndex(fn, "/")
+	if dot := strings.Index(fn[slash+1:], "."); dot >= 0 {
+		return fn[:slash+1+dot]
+	}
+	return fn
+}
@@ -0,0 +1,146 @@
+package cpanic_test
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/demosdemon/cpanic"
+)
+
+type benign struct{}
+
+func TestMatchers(t *testing.T) {
+	var nilMap map[string]int
+	boundsErr := recovered(func() { _ = []int{}[len(nilMap)] })
+	stringPanic := cpanic.New("not at a disco")
+
+	cases := []struct {
+		name    string
+		matcher cpanic.Matcher
+		p       *cpanic.Panic
+		want    bool
+	}{
+		{"type", cpanic.MatchType(""), stringPanic, true},
+		{"other type", cpanic.MatchType(0), stringPanic, false},
+		{"interface", cpanic.MatchType((*runtime.Error)(nil)), boundsErr, true},
+		{"other interface", cpanic.MatchType((*runtime.Error)(nil)), stringPanic, false},
+		{"message", cpanic.MatchMessage(regexp.MustCompile(`^not at`)), stringPanic, true},
+		{"other message", cpanic.MatchMessage(regexp.MustCompile(`^disco`)), stringPanic, false},
+		{"fingerprint", cpanic.MatchFingerprint("x", stringPanic.Fingerprint()), stringPanic, true},
+		{"other fingerprint", cpanic.MatchFingerprint(boundsErr.Fingerprint()), stringPanic, false},
+		{"culprit package", cpanic.MatchCulpritPackage("github.com/demosdemon/cpanic_test"), boundsErr, true},
+		{"culprit parent package", cpanic.MatchCulpritPackage("github.com/demosdemon"), boundsErr, true},
+		{"culprit package prefix", cpanic.MatchCulpritPackage("github.com/demosdemon/cpan"), boundsErr, false},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, tc.matcher(tc.p))
+		})
+	}
+}
+
+func TestSuppress(t *testing.T) {
+	defer cpanic.Suppress(cpanic.MatchType(benign{}))()
+
+	var handled []*cpanic.Panic
+	h := cpanic.Handler(func(p *cpanic.Panic) { handled = append(handled, p) })
+
+	func() {
+		defer cpanic.Recover(h)
+		panic(benign{})
+	}()
+	assert.Empty(t, handled)
+
+	func() {
+		defer cpanic.Recover(h)
+		panic(errors.New("boom"))
+	}()
+	assert.Len(t, handled, 1)
+
+	p := cpanic.New(benign{})
+	assert.True(t, cpanic.Suppressed(p))
+	h.Handle(p)
+	assert.Len(t, handled, 2, "suppressions apply where panics are recovered, not in Handle")
+
+	// Suppressed panics are still recovered.
+	err := cpanic.Go(func() error { panic(benign{}) })
+	assert.True(t, errors.Is(err, cpanic.ErrPanic), fmt.Sprint(err))
+}
+
+func TestHandleNil(t *testing.T) {
+	assert.NotPanics(t, func() { cpanic.Handler(nil).Handle(cpanic.New("boom")) })
+}
+
+type escalated struct{}
+
+// raiseTo panics with the value and recovers it with the handler, so every call has
+// the same fingerprint.
+func raiseTo(h cpanic.Handler, value interface{}) {
+	defer cpanic.Recover(h)
+	panic(value)
+}
+
+func TestEscalateSuppressed(t *testing.T) {
+	defer cpanic.Suppress(cpanic.MatchType(escalated{}))()
+	cpanic.EscalateSuppressed(2, time.Hour)
+	defer cpanic.EscalateSuppressed(0, 0)
+
+	var handled []*cpanic.Panic
+	h := cpanic.Handler(func(p *cpanic.Panic) { handled = append(handled, p) })
+
+	for i := 0; i < 4; i++ {
+		raiseTo(h, escalated{})
+	}
+	if assert.Len(t, handled, 2) {
+		assert.Equal(t, 4, handled[1].Attrs[cpanic.SuppressedAttr])
+	}
+
+	// Other fingerprints are counted separately.
+	func() {
+		defer cpanic.Recover(h)
+		panic(escalated{})
+	}()
+	assert.Len(t, handled, 2)
+}
+
+func TestEscalateSuppressedOncePerPanic(t *testing.T) {
+	defer cpanic.Suppress(cpanic.MatchType(escalated{}))()
+	cpanic.EscalateSuppressed(3, time.Hour)
+	defer cpanic.EscalateSuppressed(0, 0)
+
+	var handled []*cpanic.Panic
+	h := cpanic.MinSeverity(func(p *cpanic.Panic) { handled = append(handled, p) }, cpanic.SeverityError)
+	for i := 0; i < 4; i++ {
+		raiseTo(h, escalated{})
+	}
+	if assert.Len(t, handled, 1) {
+		assert.Equal(t, 4, handled[0].Attrs[cpanic.SuppressedAttr])
+	}
+}
+
+func TestEscalateSuppressedWindow(t *testing.T) {
+	defer cpanic.Suppress(cpanic.MatchType(escalated{}))()
+	cpanic.EscalateSuppressed(1, time.Millisecond)
+	defer cpanic.EscalateSuppressed(0, 0)
+
+	var handled []*cpanic.Panic
+	h := cpanic.Handler(func(p *cpanic.Panic) { handled = append(handled, p) })
+
+	raiseTo(h, escalated{})
+	time.Sleep(5 * time.Millisecond)
+	raiseTo(h, escalated{})
+	assert.Empty(t, handled)
+
+	raiseTo(h, escalated{})
+	if assert.Len(t, handled, 1) {
+		assert.Equal(t, 2, handled[0].Attrs[cpanic.SuppressedAttr])
+	}
+}
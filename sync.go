@@ -0,0 +1,32 @@
+package cpanic
+
+import "sync"
+
+// OnceFunc returns a function that calls fn only once, like `sync.OnceFunc`. If fn
+// panics, the panic is recovered and the returned function returns the same `*Panic`
+// on every call, instead of re-panicking with the original stack trace lost.
+func OnceFunc(fn func()) func() error {
+	var (
+		once sync.Once
+		err  error
+	)
+	return func() error {
+		once.Do(func() {
+			err = Go(func() error {
+				fn()
+				return nil
+			})
+		})
+		return err
+	}
+}
+
+// LockedDo calls fn while holding the lock. The lock is released even if fn panics, in
+// which case the error returned will be a `*Panic`. Otherwise, the error returned, if
+// any, will be from the function.
+func LockedDo(mu sync.Locker, fn func() error) (err error) {
+	mu.Lock()
+	defer mu.Unlock()
+	defer Forward(&err)
+	return fn()
+}
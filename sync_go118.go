@@ -0,0 +1,45 @@
+//go:build go1.18
+// +build go1.18
+
+package cpanic
+
+import "sync"
+
+// OnceValue returns a function that calls fn only once and returns its value, like
+// `sync.OnceValue`. If fn panics, the panic is recovered and the returned function
+// returns the zero value and the same `*Panic` on every call.
+func OnceValue[T any](fn func() T) func() (T, error) {
+	var (
+		once  sync.Once
+		value T
+		err   error
+	)
+	return func() (T, error) {
+		once.Do(func() {
+			err = Go(func() error {
+				value = fn()
+				return nil
+			})
+		})
+		return value, err
+	}
+}
+
+// OnceValues is like `OnceValue` but for functions that also return an error, like
+// `sync.OnceValues`. The error returned by fn is returned on every call.
+func OnceValues[T any](fn func() (T, error)) func() (T, error) {
+	var (
+		once  sync.Once
+		value T
+		err   error
+	)
+	return func() (T, error) {
+		once.Do(func() {
+			err = Go(func() (err error) {
+				value, err = fn()
+				return err
+			})
+		})
+		return value, err
+	}
+}
@@ -0,0 +1,57 @@
+//go:build go1.18
+// +build go1.18
+
+package cpanic_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/demosdemon/cpanic"
+)
+
+func TestOnceValue(t *testing.T) {
+	calls := 0
+	fn := cpanic.OnceValue(func() int {
+		calls++
+		if calls == 1 {
+			panic("not at a disco")
+		}
+		return calls
+	})
+
+	v, first := fn()
+	assert.Zero(t, v)
+	assert.EqualError(t, first, "panic: not at a disco")
+
+	v, err := fn()
+	assert.Zero(t, v)
+	assert.Same(t, first, err)
+	assert.Equal(t, 1, calls)
+
+	fn = cpanic.OnceValue(func() int { return 42 })
+	v, err = fn()
+	assert.NoError(t, err)
+	assert.Equal(t, 42, v)
+}
+
+func TestOnceValues(t *testing.T) {
+	calls := 0
+	fn := cpanic.OnceValues(func() (int, error) {
+		calls++
+		return 42, errors.New("test")
+	})
+
+	for i := 0; i < 2; i++ {
+		v, err := fn()
+		assert.Equal(t, 42, v)
+		assert.EqualError(t, err, "test")
+	}
+	assert.Equal(t, 1, calls)
+
+	fn = cpanic.OnceValues(func() (int, error) { panic("not at a disco") })
+	_, err := fn()
+	assert.EqualError(t, err, "panic: not at a disco")
+}
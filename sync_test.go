@@ -0,0 +1,70 @@
+package cpanic_test
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/demosdemon/cpanic"
+)
+
+func TestOnceFunc(t *testing.T) {
+	calls := 0
+	fn := cpanic.OnceFunc(func() {
+		calls++
+		panic("not at a disco")
+	})
+
+	first := fn()
+	assert.EqualError(t, first, "panic: not at a disco")
+	assert.Same(t, first, fn())
+	assert.Equal(t, 1, calls)
+
+	calls = 0
+	fn = cpanic.OnceFunc(func() { calls++ })
+	assert.NoError(t, fn())
+	assert.NoError(t, fn())
+	assert.Equal(t, 1, calls)
+}
+
+func TestLockedDo(t *testing.T) {
+	tests := []struct {
+		name   string
+		fn     func() error
+		errMsg string
+	}{
+		{
+			name: "does nothing",
+			fn:   func() error { return nil },
+		},
+		{
+			name:   "returns error",
+			fn:     func() error { return errors.New("test") },
+			errMsg: "test",
+		},
+		{
+			name:   "returns panic",
+			fn:     func() error { panic("not at a disco") },
+			errMsg: "panic: not at a disco",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			var mu sync.Mutex
+			err := cpanic.LockedDo(&mu, tt.fn)
+			if tt.errMsg == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tt.errMsg)
+			}
+
+			// The lock must have been released.
+			mu.Lock()
+			mu.Unlock()
+		})
+	}
+}
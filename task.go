@@ -0,0 +1,200 @@
+package cpanic
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+)
+
+// StateAttr is the attribute holding the state captured by the function set with
+// `WithStateCapture` when a panic is reported.
+const StateAttr = "state"
+
+// Task carries the handlers and attributes for reporting panics in a unit of work, such
+// as a request, and in every goroutine it fans out to with `Spawn` or a `Group`, so
+// reporting is configured once at the top. Tasks are immutable: `WithHandler` and
+// `WithAttr` return a child task, leaving the parent unchanged. A nil `*Task` has no
+// handlers, so its panics are recovered and dropped.
+type Task struct {
+	handlers []Handler
+	attrs    map[string]interface{}
+	labels   []string
+	state    func() interface{}
+}
+
+// NewTask returns a task that reports panics to the handler.
+func NewTask(h Handler) *Task {
+	return (*Task)(nil).WithHandler(h)
+}
+
+// WithHandler returns a child task that also reports panics to the handler, after the
+// handlers of t.
+func (t *Task) WithHandler(h Handler) *Task {
+	child := t.clone()
+	if h != nil {
+		child.handlers = append(child.handlers, h)
+	}
+	return child
+}
+
+// WithAttr returns a child task that attaches the key/value pair to its panics,
+// replacing any value for the key inherited from t.
+func (t *Task) WithAttr(key string, value interface{}) *Task {
+	child := t.clone()
+	attrs := make(map[string]interface{}, len(child.attrs)+1)
+	for k, v := range child.attrs {
+		attrs[k] = v
+	}
+	attrs[key] = value
+	child.attrs = attrs
+	return child
+}
+
+// WithLabels returns a child task whose goroutines started with `Spawn` or a `Group`
+// carry the `runtime/pprof` labels, given as key/value pairs, after those of t, so that
+// they are identifiable in the all-goroutine trace of any later panic (see
+// `Goroutines`) as well as in CPU and goroutine profiles. The labels replace those the
+// goroutines would inherit from the goroutine calling `Spawn`.
+func (t *Task) WithLabels(keyvals ...string) *Task {
+	child := t.clone()
+	child.labels = append(child.labels, keyvals...)
+	return child
+}
+
+// WithStateCapture returns a child task that calls fn when one of its panics is
+// reported and attaches the result as `StateAttr`, such as the current job ID, queue
+// offsets, or the state of a state machine, to help reproduce crashes in stateful
+// workers. The result is converted to plain values through its JSON encoding, so it
+// is a snapshot that later changes to the state do not affect, or rendered with `fmt`
+// if it cannot be encoded. The function replaces any inherited from t.
+func (t *Task) WithStateCapture(fn func() interface{}) *Task {
+	child := t.clone()
+	child.state = fn
+	return child
+}
+
+// ContextWithStateCapture returns a copy of ctx whose `Task` captures the state with fn
+// for the panics reported with `RecoverCtx` or `ForwardCtx`, as with
+// `(*Task).WithStateCapture`.
+func ContextWithStateCapture(ctx context.Context, fn func() interface{}) context.Context {
+	return ContextWithTask(ctx, TaskFromContext(ctx).WithStateCapture(fn))
+}
+
+func (t *Task) clone() *Task {
+	if t == nil {
+		return &Task{}
+	}
+	return &Task{
+		handlers: t.handlers[:len(t.handlers):len(t.handlers)],
+		attrs:    t.attrs,
+		labels:   t.labels[:len(t.labels):len(t.labels)],
+		state:    t.state,
+	}
+}
+
+// captureState calls the state capture function, converting its result to plain
+// values. A panic in the function is rendered as the state.
+func captureState(fn func() interface{}) (state interface{}) {
+	defer func() {
+		if value := recover(); value != nil {
+			state = fmt.Sprintf("state capture panicked: %v", value)
+		}
+	}()
+
+	v := fn()
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	if err := json.Unmarshal(b, &state); err != nil {
+		return fmt.Sprint(v)
+	}
+	return state
+}
+
+// Handle attaches the task's attributes and captured state to the panic, without
+// replacing attributes it already has, and calls each of the task's handlers with it.
+// It can be passed to `Recover` as a `Handler`.
+func (t *Task) Handle(p *Panic) {
+	if t == nil {
+		return
+	}
+	for k, v := range t.attrs {
+		if _, ok := p.Attrs[k]; !ok {
+			p.SetAttr(k, v)
+		}
+	}
+	if _, ok := p.Attrs[StateAttr]; !ok && t.state != nil {
+		p.SetAttr(StateAttr, captureState(t.state))
+	}
+	for _, h := range t.handlers {
+		h.Handle(p)
+	}
+}
+
+// Spawn calls fn with the task in a new goroutine, recovering any panic it raises and
+// reporting it with `Handle`. The goroutine carries the labels of the task. Goroutines
+// spawned from fn with the task it is given inherit the same handlers, attributes, and
+// labels.
+func (t *Task) Spawn(fn func(t *Task)) {
+	go doLabeled(t.labelPairs(), func() {
+		defer Recover(t.Handle)
+		fn(t)
+	})
+}
+
+// labelPairs returns the labels of the task.
+func (t *Task) labelPairs() []string {
+	if t == nil {
+		return nil
+	}
+	return t.labels
+}
+
+// Group is a collection of goroutines working on subtasks of the same task, like
+// `golang.org/x/sync/errgroup.Group`. A panic in any goroutine is reported by the task
+// and returned by `Wait` as a `*Panic`.
+type Group struct {
+	task *Task
+	wg   sync.WaitGroup
+	once sync.Once
+	err  error
+}
+
+// NewGroup returns a group whose goroutines report panics with the task.
+func NewGroup(t *Task) *Group {
+	return &Group{task: t}
+}
+
+// Spawn calls fn with the group's task in a new goroutine carrying the labels of the
+// task. A panic is recovered and reported by the task; the first panic or error
+// returned is kept for `Wait`.
+func (g *Group) Spawn(fn func(t *Task) error) {
+	g.wg.Add(1)
+	go doLabeled(g.task.labelPairs(), func() {
+		defer g.wg.Done()
+		if err := g.run(fn); err != nil {
+			g.once.Do(func() { g.err = err })
+		}
+	})
+}
+
+func (g *Group) run(fn func(t *Task) error) (err error) {
+	defer func() {
+		if value := recover(); value != nil {
+			p := New(value)
+			reportTo(p, g.task.Handle)
+			settle(p)
+			err = p
+		}
+	}()
+	return fn(g.task)
+}
+
+// Wait blocks until every goroutine started with `Spawn` has returned, and returns the
+// first panic or error, if any.
+func (g *Group) Wait() error {
+	g.wg.Wait()
+	return g.err
+}
@@ -0,0 +1,116 @@
+package cpanic_test
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/demosdemon/cpanic"
+)
+
+// collector is a handler that records the panics it is called with.
+type collector struct {
+	mu     sync.Mutex
+	panics []*cpanic.Panic
+}
+
+func (c *collector) handle(p *cpanic.Panic) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.panics = append(c.panics, p)
+}
+
+func (c *collector) get() []*cpanic.Panic {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.panics
+}
+
+func TestTaskSpawn(t *testing.T) {
+	var outer, inner collector
+	task := cpanic.NewTask(outer.handle).WithAttr("request_id", "abc")
+
+	done := make(chan struct{})
+	task.Spawn(func(t *cpanic.Task) {
+		t.WithHandler(inner.handle).WithAttr("worker", 1).Spawn(func(*cpanic.Task) {
+			defer close(done)
+			panic("not at a disco")
+		})
+	})
+	<-done
+
+	// The handlers run after fn returns, in the deferred `Recover`; inner runs last.
+	assert.Eventually(t, func() bool { return len(inner.get()) == 1 }, time.Second, time.Millisecond)
+	if assert.Len(t, outer.get(), 1) {
+		p := inner.get()[0]
+		assert.Same(t, outer.get()[0], p)
+		assert.Equal(t, map[string]interface{}{"request_id": "abc", "worker": 1}, p.Attrs)
+	}
+}
+
+func TestTaskImmutable(t *testing.T) {
+	var c collector
+	parent := cpanic.NewTask(c.handle).WithAttr("a", 1)
+	child := parent.WithAttr("a", 2).WithAttr("b", 3)
+	_ = parent.WithHandler(func(*cpanic.Panic) { t.Error("sibling handler called") })
+
+	p := cpanic.New("not at a disco")
+	p.SetAttr("b", "own")
+	child.Handle(p)
+	assert.Equal(t, map[string]interface{}{"a": 2, "b": "own"}, p.Attrs)
+
+	p = cpanic.New("not at a disco")
+	parent.Handle(p)
+	assert.Equal(t, map[string]interface{}{"a": 1}, p.Attrs)
+	assert.Len(t, c.panics, 2)
+
+	var nilTask *cpanic.Task
+	assert.NotPanics(t, func() { nilTask.Handle(p) })
+}
+
+func TestGroup(t *testing.T) {
+	var c collector
+	g := cpanic.NewGroup(cpanic.NewTask(c.handle).WithAttr("request_id", "abc"))
+	g.Spawn(func(*cpanic.Task) error { return nil })
+	g.Spawn(func(*cpanic.Task) error { panic("not at a disco") })
+
+	err := g.Wait()
+	var p *cpanic.Panic
+	if assert.True(t, errors.As(err, &p)) {
+		assert.Equal(t, "abc", p.Attrs["request_id"])
+	}
+	assert.Len(t, c.panics, 1)
+
+	g = cpanic.NewGroup(nil)
+	g.Spawn(func(*cpanic.Task) error { return errors.New("boom") })
+	assert.EqualError(t, g.Wait(), "boom")
+}
+
+func TestTaskWithStateCapture(t *testing.T) {
+	type jobState struct {
+		Job    string `json:"job"`
+		Offset int    `json:"offset"`
+	}
+	var c collector
+	state := &jobState{Job: "import", Offset: 41}
+	task := cpanic.NewTask(c.handle).WithStateCapture(func() interface{} { return state })
+
+	done := make(chan struct{})
+	task.Spawn(func(*cpanic.Task) {
+		defer close(done)
+		state.Offset++
+		panic("not at a disco")
+	})
+	<-done
+
+	assert.Eventually(t, func() bool { return len(c.get()) == 1 }, time.Second, time.Millisecond)
+	state.Offset++
+	assert.Equal(t, map[string]interface{}{"job": "import", "offset": float64(42)}, c.get()[0].Attrs[cpanic.StateAttr])
+
+	p := cpanic.New("not at a disco")
+	cpanic.NewTask(c.handle).WithStateCapture(func() interface{} { panic("no state") }).Handle(p)
+	assert.Equal(t, "state capture panicked: no state", p.Attrs[cpanic.StateAttr])
+}
@@ -0,0 +1,32 @@
+package cpanic
+
+import "io"
+
+// TemplateAttr is the `Panic` attribute key holding the name of the template that was
+// executing when the panic occurred.
+const TemplateAttr = "template"
+
+// Template is the subset of `*text/template.Template` and `*html/template.Template` used
+// by `SafeExecute`.
+type Template interface {
+	Name() string
+	Execute(w io.Writer, data interface{}) error
+}
+
+// SafeExecute executes the template with the provided data and recovers any panic
+// raised during execution, such as from template functions, methods called on data,
+// or the writer. If the template panics, the error returned will be a `*Panic` with the
+// template name attached, otherwise the error returned, if any, will be from the
+// template.
+func SafeExecute(t Template, w io.Writer, data interface{}) (err error) {
+	defer func() {
+		if value := recover(); value != nil {
+			p := New(value)
+			p.SetAttr(TemplateAttr, t.Name())
+			settle(p)
+			err = p
+		}
+	}()
+
+	return t.Execute(w, data)
+}
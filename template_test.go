@@ -0,0 +1,75 @@
+package cpanic_test
+
+import (
+	"bytes"
+	htmltemplate "html/template"
+	"io"
+	"testing"
+	"text/template"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/demosdemon/cpanic"
+)
+
+type panicWriter struct{}
+
+func (panicWriter) Write([]byte) (int, error) {
+	panic("not at a disco")
+}
+
+func TestSafeExecute(t *testing.T) {
+	tests := []struct {
+		name   string
+		tmpl   cpanic.Template
+		w      io.Writer
+		out    string
+		errMsg string
+		panics bool
+	}{
+		{
+			name: "text template",
+			tmpl: template.Must(template.New("text").Parse("hello {{.}}")),
+			w:    &bytes.Buffer{},
+			out:  "hello world",
+		},
+		{
+			name: "html template",
+			tmpl: htmltemplate.Must(htmltemplate.New("html").Parse("<b>{{.}}</b>")),
+			w:    &bytes.Buffer{},
+			out:  "<b>world</b>",
+		},
+		{
+			name:   "returns template error",
+			tmpl:   template.Must(template.New("text").Parse("{{.Missing}}")),
+			w:      &bytes.Buffer{},
+			errMsg: `template: text:1:2: executing "text" at <.Missing>: can't evaluate field Missing in type string`,
+		},
+		{
+			name:   "returns panic",
+			tmpl:   template.Must(template.New("writer").Parse("hello {{.}}")),
+			w:      panicWriter{},
+			errMsg: "panic: not at a disco",
+			panics: true,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			err := cpanic.SafeExecute(tt.tmpl, tt.w, "world")
+			if tt.errMsg == "" {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.out, tt.w.(*bytes.Buffer).String())
+			} else {
+				assert.EqualError(t, err, tt.errMsg)
+			}
+
+			if tt.panics {
+				if p, ok := err.(*cpanic.Panic); assert.True(t, ok) {
+					assert.Equal(t, tt.tmpl.Name(), p.Attrs[cpanic.TemplateAttr])
+				}
+			}
+		})
+	}
+}
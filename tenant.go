@@ -0,0 +1,157 @@
+package cpanic
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// TenantAttr is the attribute identifying the tenant, or customer, whose request caused a
+// panic. It is read by `Tenants` and set by `ContextWithTenant`.
+const TenantAttr = "tenant"
+
+// ContextWithTenant returns a copy of ctx that attaches the tenant to the panics reported
+// with `RecoverCtx` or `ForwardCtx` as the `TenantAttr` attribute.
+func ContextWithTenant(ctx context.Context, tenant string) context.Context {
+	return NewContext(ctx, nil, TenantAttr, tenant)
+}
+
+// OtherTenant is the tenant that `Tenants` attributes panics to once it counts 1024
+// tenants, so tenant IDs taken from request data cannot grow its memory without bound.
+const OtherTenant = "(other)"
+
+// maxTenantKeys is the number of tenants above which `Tenants` attributes the panics of
+// new tenants without a limit of their own to `OtherTenant`.
+const maxTenantKeys = 1024
+
+// TenantCount is the number of panics attributed to a tenant by `Tenants`.
+type TenantCount struct {
+	// Reported is the number of panics passed to the handler.
+	Reported int `json:"reported"`
+	// Dropped is the number of panics that exceeded the tenant's limit.
+	Dropped int `json:"dropped"`
+}
+
+// Tenants is a handler that attributes panics to the tenant named by an attribute, counts
+// them per tenant and optionally limits how many panics of each tenant are reported, so
+// multi-tenant platforms can tell which customer's payload triggers crashes without one
+// customer flooding the reports. Panics without the attribute are attributed to the
+// empty tenant. Past 1024 tenants, the panics of new tenants without a limit of their
+// own are attributed to `OtherTenant`, which shares the default limit. Create one with
+// `PerTenant`.
+type Tenants struct {
+	h   Handler
+	key string
+
+	mu       sync.Mutex
+	limits   map[string]tenantLimit
+	counts   map[string]*tenantCounter
+	defLimit tenantLimit
+}
+
+type tenantLimit struct {
+	n      int
+	window time.Duration
+}
+
+type tenantCounter struct {
+	TenantCount
+	start    time.Time
+	inWindow int
+}
+
+// PerTenant returns a `Tenants` handler passing panics to h, attributing them to the
+// tenant named by the key attribute, such as `TenantAttr`. Attribute values that are not
+// strings are formatted with `fmt.Sprint`.
+func PerTenant(h Handler, key string) *Tenants {
+	return &Tenants{
+		h:      h,
+		key:    key,
+		limits: make(map[string]tenantLimit),
+		counts: make(map[string]*tenantCounter),
+	}
+}
+
+// Limit reports at most n panics of the tenant within each window, dropping the rest. A
+// window of zero never expires, and n of zero or less removes the limit. Limits set for
+// the empty tenant apply to every tenant without a limit of its own.
+func (t *Tenants) Limit(tenant string, n int, window time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	l := tenantLimit{n: n, window: window}
+	switch {
+	case tenant == "":
+		t.defLimit = l
+	case n <= 0:
+		delete(t.limits, tenant)
+	default:
+		t.limits[tenant] = l
+	}
+}
+
+// Handle counts the panic against its tenant and passes it to the handler unless the
+// tenant's limit is exceeded.
+func (t *Tenants) Handle(p *Panic) {
+	if t.allow(Tenant(p, t.key), time.Now()) {
+		t.h.Handle(p)
+	} else {
+		countDropped()
+	}
+}
+
+func (t *Tenants) allow(tenant string, now time.Time) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	c, ok := t.counts[tenant]
+	if !ok && len(t.counts) >= maxTenantKeys {
+		if _, limited := t.limits[tenant]; !limited {
+			tenant = OtherTenant
+			c, ok = t.counts[tenant]
+		}
+	}
+	if !ok {
+		c = &tenantCounter{start: now}
+		t.counts[tenant] = c
+	}
+
+	l, ok := t.limits[tenant]
+	if !ok {
+		l = t.defLimit
+	}
+	if l.window > 0 && now.Sub(c.start) >= l.window {
+		c.start, c.inWindow = now, 0
+	}
+	if l.n > 0 && c.inWindow >= l.n {
+		c.Dropped++
+		return false
+	}
+	c.inWindow++
+	c.Reported++
+	return true
+}
+
+// Counts returns the number of panics attributed to each tenant so far.
+func (t *Tenants) Counts() map[string]TenantCount {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	out := make(map[string]TenantCount, len(t.counts))
+	for tenant, c := range t.counts {
+		out[tenant] = c.TenantCount
+	}
+	return out
+}
+
+// Tenant returns the tenant named by the key attribute of the panic, or the empty string
+// if it has none.
+func Tenant(p *Panic, key string) string {
+	v, ok := p.Attrs[key]
+	if !ok || v == nil {
+		return ""
+	}
+	if s, ok := v.(string); ok {
+		return s
+	}
+	return fmt.Sprint(v)
+}
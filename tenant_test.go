@@ -0,0 +1,103 @@
+package cpanic_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/demosdemon/cpanic"
+)
+
+func TestTenants(t *testing.T) {
+	var c collector
+	tenants := cpanic.PerTenant(c.handle, cpanic.TenantAttr)
+	tenants.Limit("", 2, 0)
+	tenants.Limit("acme", 3, 0)
+
+	report := func(tenant interface{}, n int) {
+		for i := 0; i < n; i++ {
+			p := cpanic.New("boom")
+			if tenant != nil {
+				p.SetAttr(cpanic.TenantAttr, tenant)
+			}
+			tenants.Handle(p)
+		}
+	}
+	report("acme", 5)
+	report("globex", 1)
+	report(42, 4)
+	report(nil, 1)
+
+	assert.Len(t, c.get(), 3+1+2+1)
+	assert.Equal(t, map[string]cpanic.TenantCount{
+		"acme":   {Reported: 3, Dropped: 2},
+		"globex": {Reported: 1},
+		"42":     {Reported: 2, Dropped: 2},
+		"":       {Reported: 1},
+	}, tenants.Counts())
+}
+
+func TestTenantsWindow(t *testing.T) {
+	var c collector
+	tenants := cpanic.PerTenant(c.handle, cpanic.TenantAttr)
+	tenants.Limit("acme", 1, 10*time.Millisecond)
+
+	p := cpanic.New("boom")
+	p.SetAttr(cpanic.TenantAttr, "acme")
+	tenants.Handle(p)
+	tenants.Handle(p)
+	require.Len(t, c.get(), 1)
+
+	time.Sleep(20 * time.Millisecond)
+	tenants.Handle(p)
+	assert.Len(t, c.get(), 2)
+	assert.Equal(t, cpanic.TenantCount{Reported: 2, Dropped: 1}, tenants.Counts()["acme"])
+
+	tenants.Limit("acme", 0, 0)
+	tenants.Handle(p)
+	tenants.Handle(p)
+	assert.Len(t, c.get(), 4)
+}
+
+func TestContextWithTenant(t *testing.T) {
+	var c collector
+	ctx := cpanic.NewContext(context.Background(), c.handle)
+	ctx = cpanic.ContextWithTenant(ctx, "acme")
+
+	func() {
+		defer cpanic.RecoverCtx(ctx)
+		panic("boom")
+	}()
+
+	panics := c.get()
+	require.Len(t, panics, 1)
+	assert.Equal(t, "acme", cpanic.Tenant(panics[0], cpanic.TenantAttr))
+}
+
+func TestTenantsOverflow(t *testing.T) {
+	var c collector
+	tenants := cpanic.PerTenant(c.handle, cpanic.TenantAttr)
+	tenants.Limit("", 50, 0)
+	tenants.Limit("acme", 1, 0)
+
+	report := func(tenant string) {
+		p := cpanic.New("boom")
+		p.SetAttr(cpanic.TenantAttr, tenant)
+		tenants.Handle(p)
+	}
+	for i := 0; i < 1100; i++ {
+		report(fmt.Sprintf("tenant-%d", i))
+	}
+	report("acme")
+	report("acme")
+
+	counts := tenants.Counts()
+	assert.Len(t, counts, 1024+2)
+	assert.Equal(t, cpanic.TenantCount{Reported: 50, Dropped: 26}, counts[cpanic.OtherTenant])
+	assert.Equal(t, cpanic.TenantCount{Reported: 1, Dropped: 1}, counts["acme"], "tenants with a limit are counted")
+	assert.NotContains(t, counts, "tenant-1024")
+}
@@ -0,0 +1,48 @@
+package cpanic
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// TimeoutPanicIDAttr is the attribute holding the ID of the panic whose handler timed
+// out, set on the meta-event reported by `WithTimeout`.
+const TimeoutPanicIDAttr = "timeout.panic_id"
+
+// ErrHandlerTimeout is returned by a handler wrapped with `WithTimeout` that did not
+// return in time, and is the value of the meta-event it reports.
+var ErrHandlerTimeout = errors.New("cpanic: handler timed out")
+
+// WithTimeout returns a handler that calls h with a context canceled after d, and
+// abandons it if it has not returned by then, so a recovering goroutine is not blocked
+// by a reporter whose endpoint is down. An abandoned handler keeps running in the
+// background until it returns. On timeout, `ErrHandlerTimeout` is returned and, if
+// onTimeout is provided, it is called with a meta-event: a `*Panic` with the value
+// `ErrHandlerTimeout`, `SeverityWarning`, and the `TimeoutPanicIDAttr` attribute. A
+// panic in h is recovered and returned as a `*Panic`.
+func WithTimeout(h HandlerFunc, d time.Duration, onTimeout Handler) HandlerFunc {
+	return func(ctx context.Context, p *Panic) error {
+		ctx, cancel := context.WithTimeout(ctx, d)
+		defer cancel()
+
+		errc := make(chan error, 1)
+		go func() {
+			errc <- Go(func() error { return h(ctx, p) })
+		}()
+
+		select {
+		case err := <-errc:
+			return err
+		case <-ctx.Done():
+		}
+
+		if onTimeout != nil {
+			meta := NewEvent(ErrHandlerTimeout)
+			meta.Severity = SeverityWarning
+			meta.SetAttr(TimeoutPanicIDAttr, p.ID)
+			onTimeout.Handle(meta)
+		}
+		return ErrHandlerTimeout
+	}
+}
@@ -0,0 +1,45 @@
+package cpanic_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/demosdemon/cpanic"
+)
+
+func TestWithTimeout(t *testing.T) {
+	errDown := errors.New("down")
+	fast := cpanic.HandlerFunc(func(context.Context, *cpanic.Panic) error { return errDown })
+	slow := cpanic.HandlerFunc(func(ctx context.Context, _ *cpanic.Panic) error {
+		<-ctx.Done()
+		time.Sleep(10 * time.Millisecond)
+		return nil
+	})
+
+	var meta *cpanic.Panic
+	onTimeout := func(p *cpanic.Panic) { meta = p }
+
+	p := cpanic.New("not at a disco")
+	assert.Equal(t, errDown, cpanic.WithTimeout(fast, time.Second, onTimeout)(context.Background(), p))
+	assert.Nil(t, meta)
+
+	count := cpanic.PanicCount()
+	err := cpanic.WithTimeout(slow, time.Millisecond, onTimeout)(context.Background(), p)
+	assert.Equal(t, cpanic.ErrHandlerTimeout, err)
+	require.NotNil(t, meta)
+	assert.Equal(t, count, cpanic.PanicCount(), "the meta-event is not counted as a panic")
+	assert.Equal(t, cpanic.ErrHandlerTimeout, meta.Value)
+	assert.Equal(t, cpanic.SeverityWarning, meta.Severity)
+	assert.Equal(t, p.ID, meta.Attrs[cpanic.TimeoutPanicIDAttr])
+}
+
+func TestWithTimeoutPanic(t *testing.T) {
+	h := cpanic.HandlerFunc(func(context.Context, *cpanic.Panic) error { panic("reporter bug") })
+	err := cpanic.WithTimeout(h, time.Second, nil)(context.Background(), cpanic.New("not at a disco"))
+	assert.True(t, errors.Is(err, cpanic.ErrPanic))
+}
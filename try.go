@@ -0,0 +1,49 @@
+package cpanic
+
+// Try calls the function and recovers any panics. It is the inverse of a `Must` helper:
+// code written in a panic-on-error style can be called at a boundary and converted back
+// to an idiomatic error return. If the function panics, the error returned will be a
+// `*Panic`.
+func Try(fn func()) error {
+	return Go(func() error {
+		fn()
+		return nil
+	})
+}
+
+// Catch calls the function and returns the `*Panic` it raised, or nil if it returned
+// normally, so the panic can be inspected, such as its `Value` or `Frames`, before
+// deciding what to do with it.
+func Catch(fn func()) (p *Panic) {
+	defer RecoverTo(&p)
+	fn()
+	return nil
+}
+
+// RecoverTo is a defer function that recovers from a panic and sets the provided
+// pointer to the `*Panic`, unless it is already set, as `Forward` does for an error
+// pointer. If the pointer is nil, `recover` is never called and the panic is allowed to
+// continue.
+//
+//	func parse(b []byte) (v Value, p *cpanic.Panic) {
+//		defer cpanic.RecoverTo(&p)
+//		return decode(b), nil
+//	}
+func RecoverTo(pp **Panic) {
+	if pp == nil {
+		return
+	}
+
+	if value := recover(); value != nil {
+		recoverTo(pp, value)
+	}
+}
+
+//go:noinline
+func recoverTo(pp **Panic, value interface{}) {
+	p := New(value)
+	settle(p)
+	if *pp == nil {
+		*pp = p
+	}
+}
@@ -0,0 +1,27 @@
+//go:build go1.18
+// +build go1.18
+
+package cpanic
+
+// Try1 is like `Try` for functions returning a single value. If the function panics,
+// the zero value is returned along with the `*Panic`.
+func Try1[T any](fn func() T) (v T, err error) {
+	defer Forward(&err)
+	return fn(), nil
+}
+
+// Try2 is like `Try` for functions returning two values. If the function panics, the
+// zero values are returned along with the `*Panic`.
+func Try2[T1, T2 any](fn func() (T1, T2)) (v1 T1, v2 T2, err error) {
+	defer Forward(&err)
+	v1, v2 = fn()
+	return v1, v2, nil
+}
+
+// TryE is like `Try1` for functions that already return an error. If the function
+// panics, the zero value is returned along with the `*Panic`; otherwise, the results of
+// the function are returned unchanged.
+func TryE[T any](fn func() (T, error)) (v T, err error) {
+	defer Forward(&err)
+	return fn()
+}
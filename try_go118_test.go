@@ -0,0 +1,46 @@
+//go:build go1.18
+// +build go1.18
+
+package cpanic_test
+
+import (
+	"errors"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/demosdemon/cpanic"
+)
+
+func TestTry1(t *testing.T) {
+	re, err := cpanic.Try1(func() *regexp.Regexp { return regexp.MustCompile("a+") })
+	assert.NoError(t, err)
+	assert.True(t, re.MatchString("aaa"))
+
+	re, err = cpanic.Try1(func() *regexp.Regexp { return regexp.MustCompile("a(") })
+	assert.Nil(t, re)
+	assert.EqualError(t, err, "panic: regexp: Compile(`a(`): error parsing regexp: missing closing ): `a(`")
+}
+
+func TestTry2(t *testing.T) {
+	a, b, err := cpanic.Try2(func() (int, string) { return 42, "ok" })
+	assert.NoError(t, err)
+	assert.Equal(t, 42, a)
+	assert.Equal(t, "ok", b)
+
+	a, b, err = cpanic.Try2(func() (int, string) { panic("not at a disco") })
+	assert.Zero(t, a)
+	assert.Zero(t, b)
+	assert.EqualError(t, err, "panic: not at a disco")
+}
+
+func TestTryE(t *testing.T) {
+	v, err := cpanic.TryE(func() (int, error) { return 42, errors.New("test") })
+	assert.Equal(t, 42, v)
+	assert.EqualError(t, err, "test")
+
+	v, err = cpanic.TryE(func() (int, error) { panic("not at a disco") })
+	assert.Zero(t, v)
+	assert.EqualError(t, err, "panic: not at a disco")
+}
@@ -0,0 +1,48 @@
+package cpanic_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/demosdemon/cpanic"
+)
+
+func TestTry(t *testing.T) {
+	assert.NoError(t, cpanic.Try(func() {}))
+	assert.EqualError(t, cpanic.Try(func() { panic("not at a disco") }), "panic: not at a disco")
+}
+
+func TestCatch(t *testing.T) {
+	assert.Nil(t, cpanic.Catch(func() {}))
+
+	p := cpanic.Catch(func() { panic(42) })
+	if assert.NotNil(t, p) {
+		assert.Equal(t, 42, p.Value)
+		assert.NotEmpty(t, p.Frames())
+	}
+}
+
+func TestRecoverTo(t *testing.T) {
+	parse := func(s string) (n int, p *cpanic.Panic) {
+		defer cpanic.RecoverTo(&p)
+		if s == "" {
+			panic("empty input")
+		}
+		return len(s), nil
+	}
+
+	n, p := parse("abc")
+	assert.Equal(t, 3, n)
+	assert.Nil(t, p)
+
+	_, p = parse("")
+	if assert.NotNil(t, p) {
+		assert.Equal(t, "empty input", p.Value)
+	}
+
+	assert.Panics(t, func() {
+		defer cpanic.RecoverTo(nil)
+		panic("boom")
+	})
+}
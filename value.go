@@ -0,0 +1,184 @@
+package cpanic
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+var valueFormatters struct {
+	sync.RWMutex
+	funcs []*func(v interface{}) (string, bool)
+}
+
+// RegisterValueFormatter adds a function that renders panic values for `Message`, and
+// so for `Error`, `String`, and every formatter and integration, in place of the `%v`
+// verb of `fmt`. It can redact fields of custom payload types or include error codes.
+// The function reports whether it rendered the value; formatters are tried in the
+// order they were registered, falling back to `%v` if none renders the value. It
+// returns a function that unregisters the formatter.
+//
+//	cpanic.RegisterValueFormatter(func(v interface{}) (string, bool) {
+//		if err, ok := v.(*QueryError); ok {
+//			return fmt.Sprintf("query %s failed: %s", err.Name, err.Code), true
+//		}
+//		return "", false
+//	})
+func RegisterValueFormatter(fn func(v interface{}) (string, bool)) (unregister func()) {
+	valueFormatters.Lock()
+	defer valueFormatters.Unlock()
+	valueFormatters.funcs = append(valueFormatters.funcs, &fn)
+	return func() {
+		valueFormatters.Lock()
+		defer valueFormatters.Unlock()
+		funcs := make([]*func(v interface{}) (string, bool), 0, len(valueFormatters.funcs))
+		for _, f := range valueFormatters.funcs {
+			if f != &fn {
+				funcs = append(funcs, f)
+			}
+		}
+		valueFormatters.funcs = funcs
+	}
+}
+
+// Message renders the panic value, as customized by `RegisterValueFormatter`, or
+// returns the text of its `ValueSnapshot`, if it has one.
+func (p *Panic) Message() string {
+	if p.ValueSnapshot != nil {
+		return p.ValueSnapshot.Text
+	}
+	return renderValue(p.Value)
+}
+
+// renderValue renders v with the first value formatter that accepts it, or `%v`.
+func renderValue(v interface{}) string {
+	var b strings.Builder
+	renderValueTo(&b, v, -1)
+	return b.String()
+}
+
+// renderValueLimit renders v like `renderValue` but keeps at most n bytes, so the text
+// of a huge value is not copied in full. Strings, and the text of errors and
+// `fmt.Stringer` values, are copied only up to the limit, and only the first n bytes of
+// a `[]byte` are formatted; other values are formatted in full by `fmt` before being
+// cut.
+func renderValueLimit(v interface{}, n int) string {
+	w := &limitWriter{n: n}
+	renderValueTo(w, v, n)
+	return string(w.buf)
+}
+
+// renderValueTo writes v to w. If n is not negative, at most the first n bytes of a
+// `[]byte` are formatted, which renders to at least as many bytes.
+func renderValueTo(w io.Writer, v interface{}, n int) {
+	valueFormatters.RLock()
+	funcs := valueFormatters.funcs
+	valueFormatters.RUnlock()
+
+	for _, fn := range funcs {
+		if s, ok := (*fn)(v); ok {
+			_, _ = io.WriteString(w, s)
+			return
+		}
+	}
+	switch v := v.(type) {
+	case string:
+		_, _ = io.WriteString(w, v)
+		return
+	case fmt.Formatter:
+		// Formatters take precedence over Error and String in fmt.
+	case error:
+		if s, ok := callText(v.Error); ok {
+			_, _ = io.WriteString(w, s)
+			return
+		}
+	case fmt.Stringer:
+		if s, ok := callText(v.String); ok {
+			_, _ = io.WriteString(w, s)
+			return
+		}
+	case []byte:
+		if n >= 0 && len(v) > n {
+			_, _ = fmt.Fprint(w, v[:n])
+			return
+		}
+	}
+	_, _ = fmt.Fprint(w, v)
+}
+
+// callText calls the `Error` or `String` method of a value, reporting false if it
+// panics, such as for a nil receiver, so `fmt` can render the value instead.
+func callText(fn func() string) (s string, ok bool) {
+	defer func() {
+		if recover() != nil {
+			ok = false
+		}
+	}()
+	return fn(), true
+}
+
+// errLimit is returned by a `limitWriter` once it is full.
+var errLimit = errors.New("cpanic: write limit reached")
+
+// limitWriter keeps the first n bytes written to it and discards the rest.
+type limitWriter struct {
+	buf []byte
+	n   int
+}
+
+func (w *limitWriter) Write(p []byte) (int, error) {
+	room := w.n - len(w.buf)
+	if len(p) > room {
+		w.buf = append(w.buf, p[:room]...)
+		return room, errLimit
+	}
+	w.buf = append(w.buf, p...)
+	return len(p), nil
+}
+
+// DefaultErrorPrefix is the prefix of `Error` unless changed by `SetErrorPrefix`.
+const DefaultErrorPrefix = "panic: "
+
+// errorMessage holds the customizations of `Error`.
+type errorMessage struct {
+	prefix string
+	fn     func(p *Panic) string
+}
+
+var (
+	errorMessages   atomic.Value // errorMessage
+	errorMessagesMu sync.Mutex   // serializes updates
+)
+
+// SetErrorPrefix replaces `DefaultErrorPrefix` as the text `Error` puts before the
+// panic's `Message`, so embedded products can brand their crash messages. The panic
+// itself is unchanged.
+func SetErrorPrefix(prefix string) {
+	errorMessagesMu.Lock()
+	defer errorMessagesMu.Unlock()
+	m := loadErrorMessage()
+	m.prefix = prefix
+	errorMessages.Store(m)
+}
+
+// SetMessageFunc replaces the message returned by `Error`, and so rendered by `String`,
+// the formatters, and the integrations, with the result of fn, such as a localized
+// message. If fn is nil, the message is the error prefix followed by `Message`. The
+// panic's structured data, such as its value and fingerprint, is unchanged.
+func SetMessageFunc(fn func(p *Panic) string) {
+	errorMessagesMu.Lock()
+	defer errorMessagesMu.Unlock()
+	m := loadErrorMessage()
+	m.fn = fn
+	errorMessages.Store(m)
+}
+
+func loadErrorMessage() errorMessage {
+	if m, ok := errorMessages.Load().(errorMessage); ok {
+		return m
+	}
+	return errorMessage{prefix: DefaultErrorPrefix}
+}
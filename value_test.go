@@ -0,0 +1,68 @@
+package cpanic_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/demosdemon/cpanic"
+)
+
+type credentials struct {
+	User     string
+	Password string
+}
+
+func init() {
+	cpanic.RegisterValueFormatter(func(v interface{}) (string, bool) {
+		if c, ok := v.(credentials); ok {
+			return "bad credentials for " + c.User, true
+		}
+		return "", false
+	})
+}
+
+func TestRegisterValueFormatter(t *testing.T) {
+	p := cpanic.New(credentials{User: "gopher", Password: "hunter2"})
+	assert.Equal(t, "bad credentials for gopher", p.Message())
+	assert.Equal(t, "panic: bad credentials for gopher", p.Error())
+
+	b, err := cpanic.NDJSON.Format(p)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(b), "hunter2")
+	var record map[string]interface{}
+	assert.NoError(t, json.Unmarshal(b, &record))
+	assert.Equal(t, "bad credentials for gopher", record["value"])
+
+	b, err = cpanic.Logfmt.Format(p)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(b), "hunter2")
+
+	assert.Equal(t, "not at a disco", cpanic.New("not at a disco").Message())
+}
+
+func TestSetErrorPrefix(t *testing.T) {
+	defer cpanic.SetErrorPrefix(cpanic.DefaultErrorPrefix)
+	cpanic.SetErrorPrefix("acme crashed: ")
+
+	p := cpanic.New("not at a disco")
+	assert.Equal(t, "acme crashed: not at a disco", p.Error())
+	assert.True(t, strings.HasPrefix(p.String(), "acme crashed: not at a disco\n\n"))
+	assert.Equal(t, "not at a disco", p.Message())
+}
+
+func TestSetMessageFunc(t *testing.T) {
+	defer cpanic.SetMessageFunc(nil)
+	cpanic.SetMessageFunc(func(p *cpanic.Panic) string {
+		return "Programmfehler " + p.Fingerprint() + ": " + p.Message()
+	})
+
+	p := cpanic.New("not at a disco")
+	assert.Equal(t, "Programmfehler "+p.Fingerprint()+": not at a disco", p.Error())
+	assert.Equal(t, "not at a disco", p.Value)
+
+	cpanic.SetMessageFunc(nil)
+	assert.Equal(t, "panic: not at a disco", p.Error())
+}
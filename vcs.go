@@ -0,0 +1,9 @@
+//go:build !go1.18
+// +build !go1.18
+
+package cpanic
+
+// vcsRevision is unavailable before Go 1.18, which added VCS stamping.
+func vcsRevision() string {
+	return ""
+}
@@ -0,0 +1,42 @@
+//go:build go1.20
+// +build go1.20
+
+package cpanic
+
+import (
+	"errors"
+	"sync"
+)
+
+// WaitGroup is a replacement for `sync.WaitGroup` whose goroutines are each recovered
+// independently, so a panic in one goroutine is collected instead of killing the
+// process. The zero value is ready to use.
+type WaitGroup struct {
+	wg   sync.WaitGroup
+	mu   sync.Mutex
+	errs []error
+}
+
+// Go calls the function in a new goroutine, recovering any panic it raises.
+func (g *WaitGroup) Go(fn func()) {
+	g.wg.Add(1)
+	go func() {
+		defer g.wg.Done()
+		if err := Go(func() error { fn(); return nil }); err != nil {
+			g.mu.Lock()
+			g.errs = append(g.errs, err)
+			g.mu.Unlock()
+		}
+	}()
+}
+
+// Wait blocks until all goroutines started with `Go` have returned. The error returned
+// is an `errors.Join` of the `*Panic` errors of every goroutine that panicked, or nil if
+// none did.
+func (g *WaitGroup) Wait() error {
+	g.wg.Wait()
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return errors.Join(g.errs...)
+}
@@ -0,0 +1,37 @@
+//go:build go1.20
+// +build go1.20
+
+package cpanic_test
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/demosdemon/cpanic"
+)
+
+func TestWaitGroup(t *testing.T) {
+	var wg cpanic.WaitGroup
+	assert.NoError(t, wg.Wait())
+
+	var calls int32
+	for i := 0; i < 4; i++ {
+		i := i
+		wg.Go(func() {
+			atomic.AddInt32(&calls, 1)
+			if i%2 == 0 {
+				panic("not at a disco")
+			}
+		})
+	}
+
+	err := wg.Wait()
+	assert.Equal(t, int32(4), atomic.LoadInt32(&calls))
+	assert.EqualError(t, err, "panic: not at a disco\npanic: not at a disco")
+
+	var p *cpanic.Panic
+	assert.True(t, errors.As(err, &p))
+}
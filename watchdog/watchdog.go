@@ -0,0 +1,189 @@
+// watchdog detects a process, or a part of it, that has stopped making progress and
+// reports it through the usual cpanic pipeline. A stall is reported as a synthetic
+// `*cpanic.Panic` whose value is a `*Stall` and whose trace is a dump of every
+// goroutine at the time the stall was detected, which usually shows what it is stuck
+// on.
+//
+//	w := watchdog.New(time.Second, 30*time.Second)
+//	w.OnStall(handler)
+//	defer w.Stop()
+//
+//	hb := w.Heartbeat("queue consumer")
+//	for msg := range queue {
+//		hb.Beat()
+//		process(msg)
+//	}
+package watchdog
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/demosdemon/cpanic"
+)
+
+// Self is the name of the heartbeat the watchdog bumps from its own goroutine, which
+// stalls when the whole process stops scheduling goroutines.
+const Self = "watchdog"
+
+// LeakedAttr is the `cpanic.Panic` attribute key holding the goroutines, compared by
+// `cpanic.CompareSnapshots`, that were started since the watchdog was created and are
+// still running, which are suspected of causing the stall.
+const LeakedAttr = "watchdog.leaked_goroutines"
+
+// Stall is the value of the `*cpanic.Panic` reported for a stalled heartbeat.
+type Stall struct {
+	// Name is the name of the heartbeat that stalled.
+	Name string
+	// Since is how long it had been since the heartbeat was last bumped.
+	Since time.Duration
+}
+
+// Error implements the `error` interface.
+func (s *Stall) Error() string {
+	return fmt.Sprintf("watchdog: %s stalled for %v", s.Name, s.Since)
+}
+
+// Heartbeat is bumped by a goroutine to show that it is making progress.
+type Heartbeat struct {
+	last  int64
+	fired int32
+}
+
+// Beat records progress. It is safe to call concurrently and does not allocate.
+func (hb *Heartbeat) Beat() {
+	atomic.StoreInt64(&hb.last, time.Now().UnixNano())
+	atomic.StoreInt32(&hb.fired, 0)
+}
+
+// Watchdog checks its heartbeats on an interval and reports those that have not been
+// bumped within the stall threshold. Each stall is reported once, until the heartbeat
+// is bumped again.
+type Watchdog struct {
+	interval  time.Duration
+	threshold time.Duration
+
+	mu       sync.Mutex
+	handlers []cpanic.Handler
+	beats    map[string]*Heartbeat
+	baseline []cpanic.Goroutine
+
+	stop chan struct{}
+	done sync.WaitGroup
+}
+
+// New starts a watchdog that checks its heartbeats every interval and reports those
+// that have not been bumped for stallThreshold. The watchdog bumps the `Self`
+// heartbeat from its own goroutine, so a stall of the whole process is reported once
+// it recovers. The goroutines running when the watchdog is created are the baseline
+// for `LeakedAttr`.
+func New(interval, stallThreshold time.Duration) *Watchdog {
+	w := &Watchdog{
+		interval:  interval,
+		threshold: stallThreshold,
+		beats:     make(map[string]*Heartbeat),
+		stop:      make(chan struct{}),
+	}
+	self := w.Heartbeat(Self)
+
+	w.done.Add(2)
+	go w.ping(self)
+	go w.monitor()
+
+	baseline := cpanic.SnapshotGoroutines()
+	w.mu.Lock()
+	w.baseline = baseline
+	w.mu.Unlock()
+	return w
+}
+
+// OnStall adds a handler that is called with the report of every stall.
+func (w *Watchdog) OnStall(h cpanic.Handler) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.handlers = append(w.handlers, h)
+}
+
+// Heartbeat returns the heartbeat registered with the name, registering a new one if
+// there is none. A new heartbeat starts out bumped.
+func (w *Watchdog) Heartbeat(name string) *Heartbeat {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	hb, ok := w.beats[name]
+	if !ok {
+		hb = &Heartbeat{}
+		hb.Beat()
+		w.beats[name] = hb
+	}
+	return hb
+}
+
+// Remove unregisters the heartbeat with the name, such as when the goroutine bumping
+// it exits.
+func (w *Watchdog) Remove(name string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	delete(w.beats, name)
+}
+
+// Stop stops the watchdog and waits for its goroutines to exit.
+func (w *Watchdog) Stop() {
+	close(w.stop)
+	w.done.Wait()
+}
+
+func (w *Watchdog) ping(self *Heartbeat) {
+	defer w.done.Done()
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			self.Beat()
+		case <-w.stop:
+			return
+		}
+	}
+}
+
+func (w *Watchdog) monitor() {
+	defer w.done.Done()
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			w.check(time.Now())
+		case <-w.stop:
+			return
+		}
+	}
+}
+
+// check reports every heartbeat that has stalled as of now and has not been reported.
+func (w *Watchdog) check(now time.Time) {
+	w.mu.Lock()
+	var stalls []*Stall
+	for name, hb := range w.beats {
+		since := now.Sub(time.Unix(0, atomic.LoadInt64(&hb.last)))
+		if since > w.threshold && atomic.CompareAndSwapInt32(&hb.fired, 0, 1) {
+			stalls = append(stalls, &Stall{Name: name, Since: since})
+		}
+	}
+	handlers, baseline := w.handlers, w.baseline
+	w.mu.Unlock()
+
+	for _, stall := range stalls {
+		p := cpanic.NewEvent(stall)
+		if leaked := cpanic.CompareSnapshots(baseline, cpanic.SnapshotGoroutines()); len(leaked) > 0 {
+			p.SetAttr(LeakedAttr, leaked)
+		}
+		cpanic.Deliver(p, func(p *cpanic.Panic) {
+			for _, h := range handlers {
+				h.Handle(p)
+			}
+		})
+	}
+}
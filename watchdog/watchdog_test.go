@@ -0,0 +1,80 @@
+package watchdog_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/demosdemon/cpanic"
+	"github.com/demosdemon/cpanic/watchdog"
+)
+
+func TestWatchdogStall(t *testing.T) {
+	w := watchdog.New(5*time.Millisecond, 20*time.Millisecond)
+	defer w.Stop()
+
+	reports := make(chan *cpanic.Panic, 10)
+	w.OnStall(func(p *cpanic.Panic) { reports <- p })
+
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() { <-stop }()
+
+	count := cpanic.PanicCount()
+	hb := w.Heartbeat("worker")
+	assert.Same(t, hb, w.Heartbeat("worker"))
+
+	var p *cpanic.Panic
+	select {
+	case p = <-reports:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for stall")
+	}
+
+	var stall *watchdog.Stall
+	require.True(t, errors.As(p, &stall))
+	assert.Equal(t, "worker", stall.Name)
+	assert.True(t, stall.Since > 20*time.Millisecond, stall.Since)
+	assert.Contains(t, p.Error(), "watchdog: worker stalled for ")
+	assert.Contains(t, p.Trace, "TestWatchdogStall")
+	assert.Equal(t, count, cpanic.PanicCount(), "a stall is not counted as a panic")
+	if leaked, ok := p.Attrs[watchdog.LeakedAttr].([]cpanic.Goroutine); assert.True(t, ok) {
+		assert.Len(t, leaked, 1)
+		assert.Equal(t, "github.com/demosdemon/cpanic/watchdog_test.TestWatchdogStall", leaked[0].CreatedBy.Function)
+	}
+
+	// a stall is reported once until the heartbeat is bumped again
+	select {
+	case p = <-reports:
+		t.Fatalf("unexpected report: %v", p)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	hb.Beat()
+	select {
+	case p = <-reports:
+		assert.True(t, errors.As(p, &stall))
+		assert.Equal(t, "worker", stall.Name)
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for second stall")
+	}
+}
+
+func TestWatchdogHealthy(t *testing.T) {
+	w := watchdog.New(5*time.Millisecond, time.Second)
+	reports := make(chan *cpanic.Panic, 10)
+	w.OnStall(func(p *cpanic.Panic) { reports <- p })
+
+	hb := w.Heartbeat("worker")
+	for i := 0; i < 20; i++ {
+		hb.Beat()
+		time.Sleep(5 * time.Millisecond)
+	}
+	w.Remove("worker")
+	w.Stop()
+
+	assert.Empty(t, reports)
+}
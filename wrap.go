@@ -0,0 +1,36 @@
+package cpanic
+
+import "fmt"
+
+// Wrap annotates the panic with call-site context as it is returned up through the
+// layers of an application. The error message is msg followed by the panic's message.
+// The returned error unwraps to the panic, which in turn unwraps to the panic value if
+// it is an error, so `errors.Is` and `errors.As` see the whole chain. If p is nil, nil
+// is returned.
+func Wrap(p *Panic, msg string) error {
+	if p == nil {
+		return nil
+	}
+	return &wrappedPanic{msg: msg, panic: p}
+}
+
+// Wrapf is like `Wrap` but formats the message according to a format specifier.
+func Wrapf(p *Panic, format string, args ...interface{}) error {
+	if p == nil {
+		return nil
+	}
+	return &wrappedPanic{msg: fmt.Sprintf(format, args...), panic: p}
+}
+
+type wrappedPanic struct {
+	msg   string
+	panic *Panic
+}
+
+func (w *wrappedPanic) Error() string {
+	return w.msg + ": " + w.panic.Error()
+}
+
+func (w *wrappedPanic) Unwrap() error {
+	return w.panic
+}
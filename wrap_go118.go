@@ -0,0 +1,25 @@
+//go:build go1.18
+// +build go1.18
+
+package cpanic
+
+// Wrap1 returns a panic-safe version of a function taking one argument and returning a
+// value and an error, such as a method value taken from an interface. If the function
+// panics, the wrapper returns the zero value along with the `*Panic`; otherwise, the
+// results are returned unchanged. It makes it practical to populate plugin registries
+// and callback tables with guarded entries.
+func Wrap1[A, R any](fn func(A) (R, error)) func(A) (R, error) {
+	return func(a A) (r R, err error) {
+		defer Forward(&err)
+		return fn(a)
+	}
+}
+
+// Wrap2 is like `Wrap1` for functions taking two arguments, such as the common
+// `func(context.Context, *Request) (*Response, error)`.
+func Wrap2[A1, A2, R any](fn func(A1, A2) (R, error)) func(A1, A2) (R, error) {
+	return func(a1 A1, a2 A2) (r R, err error) {
+		defer Forward(&err)
+		return fn(a1, a2)
+	}
+}
@@ -0,0 +1,62 @@
+//go:build go1.18
+// +build go1.18
+
+package cpanic_test
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/demosdemon/cpanic"
+)
+
+func TestWrap1(t *testing.T) {
+	registry := map[string]func(string) (int, error){
+		"atoi": cpanic.Wrap1(strconv.Atoi),
+		"index": cpanic.Wrap1(func(s string) (int, error) {
+			return []int{1, 2, 3}[len(s)], nil
+		}),
+	}
+
+	v, err := registry["atoi"]("42")
+	assert.NoError(t, err)
+	assert.Equal(t, 42, v)
+
+	_, err = registry["atoi"]("x")
+	assert.Error(t, err)
+	assert.False(t, errors.Is(err, cpanic.ErrPanic))
+
+	v, err = registry["index"]("long")
+	assert.Equal(t, 0, v)
+	assert.True(t, errors.Is(err, cpanic.ErrPanic))
+}
+
+type greeter interface {
+	Greet(ctx context.Context, name string) (string, error)
+}
+
+type panickyGreeter struct{}
+
+func (panickyGreeter) Greet(_ context.Context, name string) (string, error) {
+	if name == "" {
+		panic("no name")
+	}
+	return "hello, " + name, nil
+}
+
+func TestWrap2(t *testing.T) {
+	var g greeter = panickyGreeter{}
+	greet := cpanic.Wrap2(g.Greet)
+
+	v, err := greet(context.Background(), "brendon")
+	assert.NoError(t, err)
+	assert.Equal(t, "hello, brendon", v)
+
+	v, err = greet(context.Background(), "")
+	assert.Empty(t, v)
+	assert.EqualError(t, err, "panic: no name")
+}
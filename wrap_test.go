@@ -0,0 +1,32 @@
+package cpanic_test
+
+import (
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/demosdemon/cpanic"
+)
+
+func TestWrapf(t *testing.T) {
+	p := cpanic.New(io.ErrUnexpectedEOF)
+	err := cpanic.Wrapf(p, "loading %s", "config")
+	assert.EqualError(t, err, "loading config: panic: unexpected EOF")
+
+	var got *cpanic.Panic
+	assert.True(t, errors.As(err, &got))
+	assert.Same(t, p, got)
+	assert.True(t, errors.Is(err, cpanic.ErrPanic))
+	assert.True(t, errors.Is(err, io.ErrUnexpectedEOF))
+
+	outer := cpanic.Wrap(got, "serving request")
+	assert.EqualError(t, outer, "serving request: panic: unexpected EOF")
+	assert.Equal(t, p, errors.Unwrap(outer))
+}
+
+func TestWrapNil(t *testing.T) {
+	assert.NoError(t, cpanic.Wrap(nil, "loading"))
+	assert.NoError(t, cpanic.Wrapf(nil, "loading %s", "config"))
+}